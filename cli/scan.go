@@ -14,8 +14,10 @@ import (
 )
 
 var (
-	format string
-	debug  bool
+	format     string
+	debug      bool
+	helmValues []string
+	osvDB      string
 )
 
 var scanCmd = &cobra.Command{
@@ -48,8 +50,10 @@ var scanCmd = &cobra.Command{
 		}
 
 		signals, err := scanner.ScanRepoWithOptions(absPath, scanner.ScanOptions{
-			Debug:  debug,
-			Logger: logger,
+			Debug:           debug,
+			Logger:          logger,
+			HelmValuesFiles: helmValues,
+			OSVDatabase:     osvDB,
 		})
 		if err != nil {
 			fmt.Println("Error scanning:", err)
@@ -84,4 +88,6 @@ func init() {
 	rootCmd.AddCommand(scanCmd)
 	scanCmd.Flags().StringVarP(&format, "format", "f", "md", "output format: md or json")
 	scanCmd.Flags().BoolVarP(&debug, "debug", "d", false, "enable debug logging")
+	scanCmd.Flags().StringSliceVar(&helmValues, "helm-values", nil, "extra Helm values files, relative to each chart, merged over values.yaml")
+	scanCmd.Flags().StringVar(&osvDB, "osv-db", "", "directory of an OSV JSON export to match dependencies against offline")
 }
@@ -82,6 +82,45 @@ func init() {
 		}
 		return false
 	}
+
+	ConditionRegistry["signal_greater_than"] = func(value interface{}, signals *scanner.RepoSignals) bool {
+		return compareIntSignal(value, signals, func(actual, limit float64) bool { return actual > limit })
+	}
+
+	ConditionRegistry["signal_less_than"] = func(value interface{}, signals *scanner.RepoSignals) bool {
+		return compareIntSignal(value, signals, func(actual, limit float64) bool { return actual < limit })
+	}
+}
+
+// compareIntSignal compares an integer signal with a number from a rule.
+// A signal that was never set does not match, so that a missing count is
+// not mistaken for zero.
+func compareIntSignal(value interface{}, signals *scanner.RepoSignals, cmp func(actual, limit float64) bool) bool {
+	params, ok := value.(map[string]interface{})
+	if !ok {
+		return false
+	}
+	for key, raw := range params {
+		actual, ok := signals.GetIntSignal(key)
+		if !ok {
+			return false
+		}
+		var limit float64
+		switch v := raw.(type) {
+		case int:
+			limit = float64(v)
+		case int64:
+			limit = float64(v)
+		case float64:
+			limit = v
+		default:
+			return false
+		}
+		if !cmp(float64(actual), limit) {
+			return false
+		}
+	}
+	return len(params) > 0
 }
 
 func RegisterCondition(name string, fn ConditionFunc) {
@@ -110,9 +149,20 @@ func Evaluate(ruleSet []rules.Rule, signals *scanner.RepoSignals) []Finding {
 	// Use 'i' to avoid copying the 200-byte Rule struct into a local variable
 	for i := range ruleSet {
 		triggered := evaluateRule(&ruleSet[i], signals)
+
+		// for_each rules report one instance per evidence entry
+		var instances []scanner.Evidence
+		if ruleSet[i].ForEach != "" {
+			if triggered {
+				instances = signals.GetEvidence(ruleSet[i].ForEach)
+			}
+			triggered = len(instances) > 0
+		}
+
 		findings = append(findings, Finding{
 			Rule:      ruleSet[i], // This still copies into the new Finding
 			Triggered: triggered,
+			Instances: instances,
 		})
 	}
 	return findings
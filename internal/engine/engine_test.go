@@ -163,6 +163,58 @@ func TestEvaluateCondition(t *testing.T) {
 			},
 			expected: true,
 		},
+		{
+			name: "signal_greater_than - above",
+			condition: map[string]interface{}{
+				"signal_greater_than": map[string]interface{}{
+					"slo_valid_count": 0,
+				},
+			},
+			signals: &scanner.RepoSignals{
+				IntSignals: map[string]int{
+					"slo_valid_count": 2,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "signal_greater_than - equal",
+			condition: map[string]interface{}{
+				"signal_greater_than": map[string]interface{}{
+					"slo_valid_count": 2,
+				},
+			},
+			signals: &scanner.RepoSignals{
+				IntSignals: map[string]int{
+					"slo_valid_count": 2,
+				},
+			},
+			expected: false,
+		},
+		{
+			name: "signal_less_than - float limit",
+			condition: map[string]interface{}{
+				"signal_less_than": map[string]interface{}{
+					"slo_min_error_budget_minutes": 4.5,
+				},
+			},
+			signals: &scanner.RepoSignals{
+				IntSignals: map[string]int{
+					"slo_min_error_budget_minutes": 4,
+				},
+			},
+			expected: true,
+		},
+		{
+			name: "signal_less_than - missing signal",
+			condition: map[string]interface{}{
+				"signal_less_than": map[string]interface{}{
+					"slo_min_error_budget_minutes": 5,
+				},
+			},
+			signals:  &scanner.RepoSignals{},
+			expected: false,
+		},
 	}
 
 	for _, tt := range tests {
@@ -470,3 +522,53 @@ func TestEvaluate(t *testing.T) {
 		t.Errorf("Expected rule ID 'health-check', got %q", findings[1].Rule.ID)
 	}
 }
+
+func TestEvaluateForEach(t *testing.T) {
+	rule := rules.Rule{
+		ID:       "resource-limits",
+		Severity: "medium",
+		ForEach:  "k8s_containers_without_limits",
+		Detect: rules.Detect{
+			NoneOf: []map[string]interface{}{
+				{
+					"signal_equals": map[string]interface{}{
+						"k8s_resource_limits_detected": true,
+					},
+				},
+			},
+		},
+	}
+
+	t.Run("One instance per evidence entry", func(t *testing.T) {
+		signals := &scanner.RepoSignals{BoolSignals: map[string]bool{}}
+		signals.AddEvidence("k8s_containers_without_limits", scanner.Evidence{Subject: "default/Deployment/api/app"})
+		signals.AddEvidence("k8s_containers_without_limits", scanner.Evidence{Subject: "default/Deployment/web/nginx"})
+
+		findings := Evaluate([]rules.Rule{rule}, signals)
+		if !findings[0].Triggered {
+			t.Fatal("expected for_each rule to trigger")
+		}
+		if len(findings[0].Instances) != 2 {
+			t.Errorf("expected 2 instances, got %d", len(findings[0].Instances))
+		}
+	})
+
+	t.Run("No evidence does not trigger", func(t *testing.T) {
+		signals := &scanner.RepoSignals{BoolSignals: map[string]bool{}}
+
+		findings := Evaluate([]rules.Rule{rule}, signals)
+		if findings[0].Triggered {
+			t.Error("expected for_each rule without evidence to pass")
+		}
+	})
+
+	t.Run("Conditions still apply", func(t *testing.T) {
+		signals := &scanner.RepoSignals{BoolSignals: map[string]bool{"k8s_resource_limits_detected": true}}
+		signals.AddEvidence("k8s_containers_without_limits", scanner.Evidence{Subject: "default/Pod/debug/shell"})
+
+		findings := Evaluate([]rules.Rule{rule}, signals)
+		if findings[0].Triggered || findings[0].Instances != nil {
+			t.Error("expected for_each rule to pass when detect conditions fail")
+		}
+	})
+}
@@ -1,11 +1,15 @@
 package engine
 
-import "github.com/chuanjin/production-readiness/internal/rules"
+import (
+	"github.com/chuanjin/production-readiness/internal/rules"
+	"github.com/chuanjin/production-readiness/internal/scanner"
+)
 
 // Finding represents the result of a single rule evaluation
 type Finding struct {
 	Rule      rules.Rule
 	Triggered bool
+	Instances []scanner.Evidence // offending occurrences for for_each rules
 }
 
 // Summary aggregates findings and computes the score
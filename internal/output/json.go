@@ -43,22 +43,24 @@ type FindingsGroup struct {
 
 // FindingDetail represents a single finding in the JSON output
 type FindingDetail struct {
-	ID          string   `json:"id"`
-	Title       string   `json:"title"`
-	Description string   `json:"description,omitempty"`
-	Category    string   `json:"category,omitempty"`
-	Severity    string   `json:"severity,omitempty"`
-	Why         []string `json:"why_it_matters,omitempty"`
-	Confidence  string   `json:"confidence,omitempty"`
+	ID          string             `json:"id"`
+	Title       string             `json:"title"`
+	Description string             `json:"description,omitempty"`
+	Category    string             `json:"category,omitempty"`
+	Severity    string             `json:"severity,omitempty"`
+	Why         []string           `json:"why_it_matters,omitempty"`
+	Confidence  string             `json:"confidence,omitempty"`
+	Instances   []scanner.Evidence `json:"instances,omitempty"`
 }
 
 // SignalsInfo contains detected signals from the repository scan
 type SignalsInfo struct {
-	BoolSignals      map[string]bool   `json:"bool_signals,omitempty"`
-	StringSignals    map[string]string `json:"string_signals,omitempty"`
-	IntSignals       map[string]int    `json:"int_signals,omitempty"`
-	FilesScanned     int               `json:"files_scanned"`
-	FilesWithContent int               `json:"files_with_content"`
+	BoolSignals      map[string]bool               `json:"bool_signals,omitempty"`
+	StringSignals    map[string]string             `json:"string_signals,omitempty"`
+	IntSignals       map[string]int                `json:"int_signals,omitempty"`
+	Evidence         map[string][]scanner.Evidence `json:"evidence,omitempty"`
+	FilesScanned     int                           `json:"files_scanned"`
+	FilesWithContent int                           `json:"files_with_content"`
 }
 
 // JSON generates a JSON-formatted report
@@ -82,6 +84,7 @@ func JSON(summary engine.Summary, findings []engine.Finding, signals *scanner.Re
 			BoolSignals:      signals.BoolSignals,
 			StringSignals:    signals.StringSignals,
 			IntSignals:       signals.IntSignals,
+			Evidence:         signals.GetEvidenceMap(),
 			FilesScanned:     len(signals.Files),
 			FilesWithContent: len(signals.FileContent),
 		}
@@ -99,6 +102,7 @@ func JSON(summary engine.Summary, findings []engine.Finding, signals *scanner.Re
 			Category:    f.Rule.Category,
 			Why:         f.Rule.Why,
 			Confidence:  f.Rule.Confidence,
+			Instances:   f.Instances,
 		}
 
 		if f.Triggered {
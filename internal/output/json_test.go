@@ -27,6 +27,18 @@ func TestJSON(t *testing.T) {
 				Severity: rules.High,
 			},
 		},
+		{
+			Triggered: true,
+			Rule: rules.Rule{
+				ID:       "TEST-003",
+				Title:    "Per-workload Issue",
+				Severity: rules.Medium,
+				ForEach:  "k8s_containers_without_limits",
+			},
+			Instances: []scanner.Evidence{
+				{Subject: "default/Deployment/api/app", File: "deploy.yaml", Line: 1},
+			},
+		},
 		{
 			Triggered: false,
 			Rule: rules.Rule{
@@ -44,6 +56,7 @@ func TestJSON(t *testing.T) {
 		Files:       map[string]bool{"main.go": true},
 		FileContent: map[string]string{"main.go": "package main"},
 	}
+	signals.AddEvidence("k8s_containers_without_limits", scanner.Evidence{Subject: "default/Deployment/api/app"})
 
 	t.Run("Full Report", func(t *testing.T) {
 		output, err := JSON(summary, findings, signals)
@@ -72,6 +85,17 @@ func TestJSON(t *testing.T) {
 		if report.Signals == nil || !report.Signals.BoolSignals["test_signal"] {
 			t.Error("Signals not correctly included")
 		}
+
+		if len(report.Findings.Medium) != 1 || len(report.Findings.Medium[0].Instances) != 1 {
+			t.Fatalf("Expected 1 medium finding with 1 instance, got %+v", report.Findings.Medium)
+		}
+		if report.Findings.Medium[0].Instances[0].Subject != "default/Deployment/api/app" {
+			t.Errorf("Unexpected instance %+v", report.Findings.Medium[0].Instances[0])
+		}
+
+		if len(report.Signals.Evidence["k8s_containers_without_limits"]) != 1 {
+			t.Error("Evidence not correctly included")
+		}
 	})
 
 	t.Run("Compact Report", func(t *testing.T) {
@@ -25,26 +25,6 @@ func Markdown(summary engine.Summary, findings []engine.Finding, signals *scanne
 	fmt.Fprintf(&b, "- ❌ Triggered: %d rules\n", summary.Triggered)
 	fmt.Fprintf(&b, "- 📊 Total: %d rules\n\n", summary.Total)
 
-	writeSection := func(title string, emoji string, findings []engine.Finding) {
-		if len(findings) == 0 {
-			return
-		}
-		fmt.Fprintf(&b, "## %s %s\n\n", emoji, title)
-		for i := range findings {
-			f := &findings[i]
-			fmt.Fprintf(&b, "### %s\n\n", f.Rule.Title)
-			b.WriteString(f.Rule.Description + "\n\n")
-
-			if len(f.Rule.Why) > 0 {
-				b.WriteString("**Why it matters:**\n")
-				for _, w := range f.Rule.Why {
-					b.WriteString("- " + w + "\n")
-				}
-				b.WriteString("\n")
-			}
-		}
-	}
-
 	// Group findings by severity
 	var high, medium, low []engine.Finding
 	for i := range findings {
@@ -62,9 +42,9 @@ func Markdown(summary engine.Summary, findings []engine.Finding, signals *scanne
 		}
 	}
 
-	writeSection("High Risk", "🔴", high)
-	writeSection("Medium Risk", "🟠", medium)
-	writeSection("Low Risk", "🟡", low)
+	writeFindingsSection(&b, "High Risk", "🔴", high)
+	writeFindingsSection(&b, "Medium Risk", "🟠", medium)
+	writeFindingsSection(&b, "Low Risk", "🟡", low)
 
 	// Add signals status section
 	b.WriteString("---\n\n")
@@ -133,14 +113,93 @@ func Markdown(summary engine.Summary, findings []engine.Finding, signals *scanne
 		b.WriteString("\n")
 	}
 
+	// Evidence behind signals
+	writeEvidenceTable(&b, signals.GetEvidenceMap())
+
 	// File statistics
 	b.WriteString("### Repository Statistics\n\n")
 	fmt.Fprintf(&b, "- **Files scanned:** %d\n", len(signals.Files))
-	fmt.Fprintf(&b, "- **Files with content:** %d\n\n", len(signals.FileContent))
+	fmt.Fprintf(&b, "- **Files with content:** %d\n", len(signals.FileContent))
+	if date, ok := signals.StringSignals["osv_snapshot_date"]; ok {
+		fmt.Fprintf(&b, "- **Vulnerability database:** %d OSV advisories, snapshot of %s (%d days old)\n",
+			signals.IntSignals["osv_advisory_count"], date, signals.IntSignals["osv_snapshot_age_days"])
+	}
+	b.WriteString("\n")
 
 	return b.String()
 }
 
+// writeFindingsSection renders the triggered rules of one severity
+func writeFindingsSection(b *strings.Builder, title, emoji string, findings []engine.Finding) {
+	if len(findings) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "## %s %s\n\n", emoji, title)
+	for i := range findings {
+		f := &findings[i]
+		fmt.Fprintf(b, "### %s\n\n", f.Rule.Title)
+		b.WriteString(f.Rule.Description + "\n\n")
+
+		writeInstances(b, f.Instances)
+
+		if len(f.Rule.Why) > 0 {
+			b.WriteString("**Why it matters:**\n")
+			for _, w := range f.Rule.Why {
+				b.WriteString("- " + w + "\n")
+			}
+			b.WriteString("\n")
+		}
+	}
+}
+
+// writeInstances lists the evidence a finding was triggered by
+func writeInstances(b *strings.Builder, instances []scanner.Evidence) {
+	if len(instances) == 0 {
+		return
+	}
+	fmt.Fprintf(b, "**Affected (%d):**\n", len(instances))
+	for _, ev := range instances {
+		b.WriteString("- " + formatEvidence(ev) + "\n")
+	}
+	b.WriteString("\n")
+}
+
+// writeEvidenceTable counts the evidence recorded behind each signal
+func writeEvidenceTable(b *strings.Builder, evidence map[string][]scanner.Evidence) {
+	if len(evidence) == 0 {
+		return
+	}
+	b.WriteString("### Evidence\n\n")
+	b.WriteString("| Signal | Instances |\n")
+	b.WriteString("|--------|-----------|\n")
+
+	var keys []string
+	for k := range evidence {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		fmt.Fprintf(b, "| `%s` | %d |\n", key, len(evidence[key]))
+	}
+	b.WriteString("\n")
+}
+
+// formatEvidence renders one evidence entry as a list item body
+func formatEvidence(ev scanner.Evidence) string {
+	line := "`" + ev.Subject + "`"
+	if loc := ev.Location(); loc != "" {
+		line += " in `" + loc + "`"
+	}
+	if ev.Context != "" {
+		line += " (" + ev.Context + ")"
+	}
+	if ev.Detail != "" {
+		line += " — " + ev.Detail
+	}
+	return line
+}
+
 // MarkdownSummary generates a short summary report (without signals)
 func MarkdownSummary(summary engine.Summary, findings []engine.Finding) string {
 	var b strings.Builder
@@ -28,6 +28,10 @@ func TestMarkdown(t *testing.T) {
 				Severity:    rules.High,
 				Why:         []string{"It is dangerous."},
 			},
+			Instances: []scanner.Evidence{
+				{Subject: "prod/Deployment/api/app", File: "k8s/api.yaml", Line: 4, Detail: "no cpu or memory limits"},
+				{Subject: "staging/Deployment/api/app", File: "k8s/base/api.yaml", Line: 1, Detail: "no cpu or memory limits", Context: "overlay k8s/overlays/staging"},
+			},
 		},
 		{
 			Triggered: false,
@@ -52,6 +56,7 @@ func TestMarkdown(t *testing.T) {
 		Files:       map[string]bool{"main.go": true},
 		FileContent: map[string]string{"main.go": "package main"},
 	}
+	signals.AddEvidence("k8s_containers_without_limits", scanner.Evidence{Subject: "prod/Deployment/api/app"})
 
 	output := Markdown(summary, findings, signals)
 
@@ -67,6 +72,10 @@ func TestMarkdown(t *testing.T) {
 		"`version` | `1.2.3`",
 		"`count` | 42",
 		"Files scanned:** 1",
+		"**Affected (2):**",
+		"- `prod/Deployment/api/app` in `k8s/api.yaml:4` — no cpu or memory limits",
+		"- `staging/Deployment/api/app` in `k8s/base/api.yaml:1` (overlay k8s/overlays/staging) — no cpu or memory limits",
+		"`k8s_containers_without_limits` | 1",
 	}
 
 	for _, check := range checks {
@@ -78,6 +87,17 @@ func TestMarkdown(t *testing.T) {
 	if strings.Contains(output, "Passed Rule") {
 		t.Error("Markdown output should not contain passed rules titles in sections")
 	}
+	if strings.Contains(output, "Vulnerability database") {
+		t.Error("Markdown output should not describe a vulnerability database that was not loaded")
+	}
+
+	signals.StringSignals["osv_snapshot_date"] = "2026-09-30"
+	signals.IntSignals["osv_advisory_count"] = 1200
+	signals.IntSignals["osv_snapshot_age_days"] = 18
+	output = Markdown(summary, findings, signals)
+	if !strings.Contains(output, "- **Vulnerability database:** 1200 OSV advisories, snapshot of 2026-09-30 (18 days old)") {
+		t.Error("Markdown output missing the OSV snapshot age")
+	}
 }
 
 func TestMarkdownSummary(t *testing.T) {
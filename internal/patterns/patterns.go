@@ -3,8 +3,10 @@ package patterns
 // APIGatewayRateLimitPatterns checks for rate limiting in API Gateway configurations
 var APIGatewayRateLimitPatterns = []string{
 	// AWS API Gateway
+	// (a bare AWS::ApiGateway resource is not evidence of throttling; see
+	// the CloudFormation analyzer)
 	"throttlesettings", "throttle", "ratelimit", "burstlimit",
-	"aws::apigateway", "usage plan", "usageplan",
+	"usage plan", "usageplan",
 
 	// Kong
 	"rate-limiting", "rate_limiting", "kong-plugin-rate-limiting",
@@ -120,16 +122,14 @@ var SecretsProviderPatterns = []string{
 	"sealed-secrets", "external-secrets", "secrets-store-csi",
 }
 
-// InfraPatterns checks if IaC (Infrastructure as Code) is present
+// InfraPatterns checks if IaC (Infrastructure as Code) is present.
+// Terraform is detected by parsing .tf files instead.
 var InfraPatterns = []string{
-	// Terraform
-	"terraform", "provider \"", "resource \"", "module \"",
-
 	// CloudFormation
 	"aws::cloudformation", "awscloudformation", "resources:",
 
-	// Pulumi
-	"pulumi", "@pulumi/",
+	// Pulumi (projects are detected from Pulumi.yaml)
+	"@pulumi/",
 
 	// CDK
 	"aws-cdk", "@aws-cdk/",
@@ -220,35 +220,6 @@ var MigrationToolPatterns = []string{
 	"create_table", "alter_table", "add_column", "drop_column",
 }
 
-// BackwardCompatPatterns checks for backward compatibility hints
-var BackwardCompatPatterns = []string{
-	// Explicit backward compatibility
-	"backward compatible", "backwards compatible",
-	"backward-compatible", "backwards-compatible",
-	"zero-downtime", "zero downtime",
-
-	// Expand-contract pattern
-	"expand and contract", "expand-contract",
-	"dual-write", "dual write", "shadow write",
-
-	// Safe migration practices
-	"nullable", "null: true", "default:", "default value",
-	// We treat these as weaker indicators that must appear in combination
-	// (e.g. ADD COLUMN + NULL/DEFAULT) to avoid false positives.
-	"add column", "null", "default",
-
-	// Incremental changes
-	"incremental migration", "phased migration",
-	"blue-green", "canary",
-
-	// Documentation about compatibility
-	"safe to deploy", "rollback safe", "reversible",
-	"no breaking change", "non-breaking",
-
-	// Feature flags for migrations
-	"feature flag", "feature toggle", "flag:",
-}
-
 // MigrationValidationPatterns checks for migration validation steps
 var MigrationValidationPatterns = []string{
 	// Explicit validation
@@ -285,18 +256,15 @@ var MigrationValidationPatterns = []string{
 	"backup before", "snapshot before", "dump before",
 }
 
-// UnsafeMigrationPatterns checks for destructive or risky migrations
-var UnsafeMigrationPatterns = []string{
-	"drop table", "drop column", "truncate table",
-	"rename column", "rename table",
-	"alter column", "alter type",
-	"set not null", "drop not null",
-	"remove column", "remove field",
-}
-
 // MutableTags (anti-pattern)
 var MutableTags = []string{":latest", ":main", ":master", ":dev", ":develop"}
 
+// FloatingImageTags are image tags that are moved to new builds
+var FloatingImageTags = []string{
+	"latest", "main", "master", "dev", "develop", "development", "edge", "nightly", "stable",
+	"head", "trunk", "canary", "beta", "alpha", "lts", "current", "next", "snapshot",
+}
+
 // VersioningPatterns checks for versioned artifact patterns
 var VersioningPatterns = []string{
 	// Semantic versioning
@@ -598,7 +566,76 @@ var GracefulShutdownPatterns = []string{
 	"graceful shutdown", "graceful_shutdown", "termination signal",
 }
 
-// NonRootUserPatterns checks for non-root user configuration in Dockerfiles
-var NonRootUserPatterns = []string{
-	"user ", "user\t",
+// CIDeployCommands identify CI steps that deploy (matched lower-cased)
+var CIDeployCommands = []string{
+	// Kubernetes
+	"kubectl apply", "kubectl set image", "kubectl rollout", "kubectl create",
+	"helm upgrade", "helm install", "helmfile apply", "helmfile sync",
+	"argocd app sync", "flux reconcile", "skaffold run", "skaffold deploy",
+
+	// Infrastructure as code
+	"terraform apply", "tofu apply", "pulumi up", "cdk deploy",
+	"sam deploy", "serverless deploy", "sls deploy",
+
+	// Cloud platforms
+	"aws ecs update-service", "aws deploy", "ecs-deploy", "copilot deploy",
+	"gcloud run deploy", "gcloud app deploy", "gcloud deploy",
+	"az webapp deploy", "az containerapp update",
+	"fly deploy", "flyctl deploy", "heroku container:release", "vercel --prod",
+	"cap production deploy", "ansible-playbook",
+}
+
+// CITestCommands identify CI steps that run tests (matched lower-cased)
+var CITestCommands = []string{
+	"go test", "gotestsum", "npm test", "npm run test", "yarn test", "pnpm test",
+	"npx jest", "jest", "vitest", "mocha", "pytest", "tox", "nox",
+	"python -m unittest", "mvn test", "mvn verify", "./mvnw verify", "./mvnw test",
+	"gradle test", "./gradlew test", "./gradlew check", "gradle check",
+	"cargo test", "make test", "make check", "rspec", "rake test", "bundle exec rspec",
+	"dotnet test", "phpunit", "mix test",
+}
+
+// ProgressiveDeliveryPatterns identify canary and blue/green rollouts
+var ProgressiveDeliveryPatterns = []string{
+	"canary", "blue-green", "blue/green", "bluegreen", "blue_green",
+	"kubectl argo rollouts", "argo-rollouts", "flagger",
+	"traffic-split", "trafficsplit", "--traffic", "set-traffic", "update-traffic",
+	"codedeploy", "linear10percent", "canary10percent",
+}
+
+// PostgresMarkers identify repositories whose database is PostgreSQL
+// (connection strings, drivers and framework settings, matched lower-cased)
+var PostgresMarkers = []string{
+	"postgres://", "postgresql://", "jdbc:postgresql", "postgresql+",
+	"adapter: postgresql", "provider = \"postgresql\"", "engine: postgres",
+	"github.com/jackc/pgx", "github.com/lib/pq", "psycopg", "\"pg\":", "npgsql",
+	"image: postgres",
+}
+
+// InfraMetricPrefixes identify series from exporters and Prometheus itself
+// rather than from the service's own instrumentation (entries ending in "_"
+// or ":" are prefixes, the others exact names)
+var InfraMetricPrefixes = []string{
+	"up", "ALERTS", "ALERTS_FOR_STATE",
+	"kube_", "kubelet_", "apiserver_", "container_", "node_", "machine_",
+	"process_", "go_", "jvm_", "python_", "nodejs_",
+	"prometheus_", "alertmanager_", "scrape_", "probe_",
+	"nginx_ingress_", "envoy_", "istio_", "traefik_", "haproxy_",
+	"slo:", "sloth_", "pyrra_",
+}
+
+// SecretFixturePaths mark test fixtures and examples whose credentials are
+// not real (directory segments end in "/", file name prefixes in "_", the
+// others are parts of file names)
+var SecretFixturePaths = []string{
+	"test/", "tests/", "testdata/", "__tests__/", "spec/", "fixtures/", "__fixtures__/", "mocks/", "__mocks__/",
+	"_test.", ".test.", ".spec.", "_spec.", "test_", "conftest.py",
+	".example", ".sample", ".template", ".dist",
+}
+
+// SecretPlaceholderMarkers identify documented example and placeholder
+// credentials, compared case-insensitively
+var SecretPlaceholderMarkers = []string{
+	"example", "dummy", "fake", "sample", "placeholder", "changeme", "change_me",
+	"redacted", "xxxx", "****", "your_", "your-", "<", "${", "{{", "%s", "localhost",
 }
@@ -19,9 +19,9 @@ func TestPatterns(t *testing.T) {
 		{"AzureRegions", AzureRegions},
 		{"ManualStepPatterns", ManualStepPatterns},
 		{"MigrationToolPatterns", MigrationToolPatterns},
-		{"BackwardCompatPatterns", BackwardCompatPatterns},
 		{"MigrationValidationPatterns", MigrationValidationPatterns},
 		{"MutableTags", MutableTags},
+		{"FloatingImageTags", FloatingImageTags},
 		{"VersioningPatterns", VersioningPatterns},
 		{"HealthPatterns", HealthPatterns},
 		{"ReadyPatterns", ReadyPatterns},
@@ -34,6 +34,13 @@ func TestPatterns(t *testing.T) {
 		{"ErrorBudgetYAMLKeys", ErrorBudgetYAMLKeys},
 		{"DocFileKeywords", DocFileKeywords},
 		{"DocFileExtensions", DocFileExtensions},
+		{"CIDeployCommands", CIDeployCommands},
+		{"CITestCommands", CITestCommands},
+		{"ProgressiveDeliveryPatterns", ProgressiveDeliveryPatterns},
+		{"PostgresMarkers", PostgresMarkers},
+		{"InfraMetricPrefixes", InfraMetricPrefixes},
+		{"SecretFixturePaths", SecretFixturePaths},
+		{"SecretPlaceholderMarkers", SecretPlaceholderMarkers},
 	}
 
 	for _, tt := range tests {
@@ -17,6 +17,9 @@ type Rule struct {
 	Why         []string `yaml:"why_it_matters"`
 	Confidence  string   `yaml:"confidence"`
 	Detect      Detect   `yaml:"detect"`
+	// ForEach names an evidence signal; the rule then reports one instance
+	// per evidence entry and only triggers when there is at least one
+	ForEach string `yaml:"for_each"`
 }
 
 type Detect struct {
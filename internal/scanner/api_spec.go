@@ -0,0 +1,365 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// API description formats
+const (
+	apiFormatOpenAPI  = "openapi"
+	apiFormatSwagger  = "swagger"
+	apiFormatAsyncAPI = "asyncapi"
+)
+
+// apiSpec is an OpenAPI 2/3 or AsyncAPI document
+type apiSpec struct {
+	File            string
+	Format          string
+	Version         string
+	Title           string
+	Line            int
+	Servers         []string
+	Operations      []apiOperation
+	SecuritySchemes []apiSecurityScheme
+	Channels        []apiChannel
+	AsyncServers    []apiServer
+}
+
+// apiOperation is an HTTP operation of an OpenAPI document
+type apiOperation struct {
+	Method      string
+	Path        string
+	OperationID string
+	Line        int
+	Parameters  []apiParameter
+	Responses   []apiResponse
+	Secured     bool // a security requirement applies and none is anonymous
+}
+
+// apiParameter is an operation parameter after $ref resolution
+type apiParameter struct {
+	Name string
+	In   string
+}
+
+// apiResponse is an operation response with its header names
+type apiResponse struct {
+	Status  string
+	Headers []string
+	Array   bool // the body is a list or wraps one
+}
+
+// apiSecurityScheme is a declared authentication scheme
+type apiSecurityScheme struct {
+	Name string
+	Type string
+}
+
+// apiChannel is an AsyncAPI channel
+type apiChannel struct {
+	Name string
+	Line int
+}
+
+// apiServer is an AsyncAPI server
+type apiServer struct {
+	Name    string
+	URL     string
+	Line    int
+	Secured bool
+}
+
+// apiHTTPMethods are the operation keys of an OpenAPI path item
+var apiHTTPMethods = []string{"get", "put", "post", "delete", "options", "head", "patch", "trace"}
+
+// apiSpecPattern matches the top-level version field of an API document in
+// YAML or JSON
+var apiSpecPattern = regexp.MustCompile(`(?m)^\s*["']?(openapi|swagger|asyncapi)["']?\s*:\s*["']?\d`)
+
+// isAPISpecFile reports whether a file is an OpenAPI, Swagger or AsyncAPI
+// document
+func isAPISpecFile(relPath, content string) bool {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ExtYAML, ExtYML, ".json":
+		return apiSpecPattern.MatchString(content)
+	}
+	return false
+}
+
+// parseAPISpec parses an API document. It returns nil when the file is not
+// one.
+func parseAPISpec(content, relPath string) (*apiSpec, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	doc := root.Content[0]
+
+	spec := &apiSpec{File: relPath}
+	for _, format := range []string{apiFormatOpenAPI, apiFormatSwagger, apiFormatAsyncAPI} {
+		for i := 0; i+1 < len(doc.Content); i += 2 {
+			if doc.Content[i].Value == format {
+				spec.Format, spec.Version, spec.Line = format, doc.Content[i+1].Value, doc.Content[i].Line
+			}
+		}
+		if spec.Format != "" {
+			break
+		}
+	}
+	if spec.Format == "" {
+		return nil, nil
+	}
+	if title := yamlMappingValue(yamlMappingValue(doc, "info"), "title"); title != nil {
+		spec.Title = title.Value
+	}
+
+	schemes := yamlMappingValue(yamlMappingValue(doc, "components"), "securitySchemes")
+	if spec.Format == apiFormatSwagger {
+		schemes = yamlMappingValue(doc, "securityDefinitions")
+	}
+	if schemes != nil && schemes.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(schemes.Content); i += 2 {
+			scheme := resolveAPIRef(doc, schemes.Content[i+1])
+			spec.SecuritySchemes = append(spec.SecuritySchemes, apiSecurityScheme{
+				Name: schemes.Content[i].Value,
+				Type: yamlNodeString(yamlMappingValue(scheme, "type")),
+			})
+		}
+	}
+
+	if spec.Format == apiFormatAsyncAPI {
+		parseAsyncAPI(doc, spec)
+	} else {
+		parseOpenAPI(doc, spec)
+	}
+	return spec, nil
+}
+
+// parseOpenAPI reads the servers and operations of an OpenAPI or Swagger
+// document
+func parseOpenAPI(doc *yaml.Node, spec *apiSpec) {
+	if spec.Format == apiFormatSwagger {
+		if base := yamlMappingValue(doc, "basePath"); base != nil {
+			spec.Servers = append(spec.Servers, yamlNodeString(yamlMappingValue(doc, "host"))+base.Value)
+		}
+	}
+	if servers := yamlMappingValue(doc, "servers"); servers != nil && servers.Kind == yaml.SequenceNode {
+		for _, server := range servers.Content {
+			if url := yamlMappingValue(server, "url"); url != nil {
+				spec.Servers = append(spec.Servers, url.Value)
+			}
+		}
+	}
+
+	globalSecurity := yamlMappingValue(doc, "security")
+	paths := yamlMappingValue(doc, "paths")
+	if paths == nil || paths.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(paths.Content); i += 2 {
+		path := paths.Content[i].Value
+		item := resolveAPIRef(doc, paths.Content[i+1])
+		shared := apiParameters(doc, yamlMappingValue(item, "parameters"))
+		for _, method := range apiHTTPMethods {
+			opNode := yamlMappingValue(item, method)
+			if opNode == nil || opNode.Kind != yaml.MappingNode {
+				continue
+			}
+			op := apiOperation{
+				Method:      strings.ToUpper(method),
+				Path:        path,
+				OperationID: yamlNodeString(yamlMappingValue(opNode, "operationId")),
+				Line:        apiKeyLine(item, method),
+				Parameters:  mergeAPIParameters(shared, apiParameters(doc, yamlMappingValue(opNode, "parameters"))),
+			}
+			security := globalSecurity
+			if own := yamlMappingValue(opNode, "security"); own != nil {
+				security = own
+			}
+			op.Secured = apiSecurityRequired(security)
+
+			if responses := yamlMappingValue(opNode, "responses"); responses != nil && responses.Kind == yaml.MappingNode {
+				for j := 0; j+1 < len(responses.Content); j += 2 {
+					response := resolveAPIRef(doc, responses.Content[j+1])
+					op.Responses = append(op.Responses, apiResponse{
+						Status:  responses.Content[j].Value,
+						Headers: apiMappingKeys(yamlMappingValue(response, "headers")),
+						Array:   apiListSchema(doc, apiResponseSchema(response)),
+					})
+				}
+			}
+			spec.Operations = append(spec.Operations, op)
+		}
+	}
+}
+
+// parseAsyncAPI reads the servers and channels of an AsyncAPI 2 or 3
+// document
+func parseAsyncAPI(doc *yaml.Node, spec *apiSpec) {
+	if servers := yamlMappingValue(doc, "servers"); servers != nil && servers.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(servers.Content); i += 2 {
+			server := resolveAPIRef(doc, servers.Content[i+1])
+			url := yamlNodeString(yamlMappingValue(server, "url"))
+			if url == "" {
+				url = yamlNodeString(yamlMappingValue(server, "host")) + yamlNodeString(yamlMappingValue(server, "pathname"))
+			}
+			spec.Servers = append(spec.Servers, url)
+			spec.AsyncServers = append(spec.AsyncServers, apiServer{
+				Name:    servers.Content[i].Value,
+				URL:     url,
+				Line:    servers.Content[i].Line,
+				Secured: apiSecurityRequired(yamlMappingValue(server, "security")),
+			})
+		}
+	}
+	if channels := yamlMappingValue(doc, "channels"); channels != nil && channels.Kind == yaml.MappingNode {
+		for i := 0; i+1 < len(channels.Content); i += 2 {
+			name := channels.Content[i].Value
+			// AsyncAPI 3 keys channels by ID and gives the address separately
+			if address := yamlMappingValue(resolveAPIRef(doc, channels.Content[i+1]), "address"); address != nil && address.Value != "" {
+				name = address.Value
+			}
+			spec.Channels = append(spec.Channels, apiChannel{Name: name, Line: channels.Content[i].Line})
+		}
+	}
+}
+
+// resolveAPIRef follows local $ref pointers such as
+// #/components/parameters/Limit. External references are left as they are.
+func resolveAPIRef(doc, node *yaml.Node) *yaml.Node {
+	for depth := 0; depth < 16; depth++ {
+		ref := yamlMappingValue(node, "$ref")
+		if ref == nil || !strings.HasPrefix(ref.Value, "#/") {
+			return node
+		}
+		target := doc
+		for _, part := range strings.Split(strings.TrimPrefix(ref.Value, "#/"), "/") {
+			part = strings.ReplaceAll(strings.ReplaceAll(part, "~1", "/"), "~0", "~")
+			target = yamlMappingValue(target, part)
+		}
+		if target == nil {
+			return node
+		}
+		node = target
+	}
+	return node
+}
+
+// apiParameters resolves a parameter list
+func apiParameters(doc, list *yaml.Node) []apiParameter {
+	if list == nil || list.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var params []apiParameter
+	for _, node := range list.Content {
+		param := resolveAPIRef(doc, node)
+		params = append(params, apiParameter{
+			Name: yamlNodeString(yamlMappingValue(param, "name")),
+			In:   yamlNodeString(yamlMappingValue(param, "in")),
+		})
+	}
+	return params
+}
+
+// mergeAPIParameters applies operation parameters over path-level ones
+func mergeAPIParameters(shared, own []apiParameter) []apiParameter {
+	merged := append([]apiParameter(nil), own...)
+	for _, p := range shared {
+		overridden := false
+		for _, o := range own {
+			if strings.EqualFold(o.Name, p.Name) && o.In == p.In {
+				overridden = true
+			}
+		}
+		if !overridden {
+			merged = append(merged, p)
+		}
+	}
+	return merged
+}
+
+// apiSecurityRequired reports whether a security requirement list demands
+// credentials. An empty list or an empty requirement allows anonymous calls.
+func apiSecurityRequired(security *yaml.Node) bool {
+	if security == nil || security.Kind != yaml.SequenceNode || len(security.Content) == 0 {
+		return false
+	}
+	for _, requirement := range security.Content {
+		if requirement.Kind == yaml.MappingNode && len(requirement.Content) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// apiResponseSchema returns the body schema of a response: the schema of
+// its first media type in OpenAPI 3, its schema in Swagger 2
+func apiResponseSchema(response *yaml.Node) *yaml.Node {
+	if schema := yamlMappingValue(response, "schema"); schema != nil {
+		return schema
+	}
+	content := yamlMappingValue(response, "content")
+	if content == nil || content.Kind != yaml.MappingNode || len(content.Content) < 2 {
+		return nil
+	}
+	return yamlMappingValue(content.Content[1], "schema")
+}
+
+// apiListSchema reports whether a schema is an array or an object wrapping
+// one, as paginated envelopes such as {"items": [...]} do
+func apiListSchema(doc, schema *yaml.Node) bool {
+	schema = resolveAPIRef(doc, schema)
+	if schema == nil {
+		return false
+	}
+	if yamlNodeString(yamlMappingValue(schema, "type")) == "array" {
+		return true
+	}
+	properties := yamlMappingValue(schema, "properties")
+	if properties == nil || properties.Kind != yaml.MappingNode {
+		return false
+	}
+	for i := 1; i < len(properties.Content); i += 2 {
+		if yamlNodeString(yamlMappingValue(resolveAPIRef(doc, properties.Content[i]), "type")) == "array" {
+			return true
+		}
+	}
+	return false
+}
+
+// apiMappingKeys returns the keys of a mapping node
+func apiMappingKeys(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	keys := make([]string, 0, len(node.Content)/2)
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		keys = append(keys, node.Content[i].Value)
+	}
+	return keys
+}
+
+// apiKeyLine returns the line of a key in a mapping node
+func apiKeyLine(node *yaml.Node, key string) int {
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i].Line
+		}
+	}
+	return node.Line
+}
+
+// yamlNodeString returns the value of a scalar node, or "" for anything else
+func yamlNodeString(node *yaml.Node) string {
+	if node == nil || node.Kind != yaml.ScalarNode {
+		return ""
+	}
+	return node.Value
+}
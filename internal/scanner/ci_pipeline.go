@@ -0,0 +1,462 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// CI platforms
+const (
+	ciGitHubActions = "github-actions"
+	ciGitLab        = "gitlab-ci"
+	ciJenkins       = "jenkins"
+)
+
+// ciPipeline is a CI/CD pipeline definition: a GitHub Actions workflow, a
+// .gitlab-ci.yml or a declarative Jenkinsfile
+type ciPipeline struct {
+	File     string
+	Platform string
+	Jobs     []ciJob
+}
+
+// ciJob is a GitHub Actions job, a GitLab CI job or a Jenkins stage
+type ciJob struct {
+	Name         string
+	Line         int
+	Stage        string   // GitLab stage
+	Order        int      // GitLab stage index or Jenkins stage position
+	Needs        []string // jobs that must finish first
+	HasNeeds     bool     // GitLab jobs with needs do not wait for earlier stages
+	Environment  string
+	Manual       bool // waits for a manual approval
+	Dispatched   bool // the workflow only runs when started by hand
+	AllowFailure bool // failures do not fail the pipeline
+	Steps        []ciStep
+}
+
+// ciStep is a step of a job: an action or a script
+type ciStep struct {
+	Name            string
+	Uses            string
+	Run             string
+	Line            int
+	ContinueOnError bool
+}
+
+// gitlabReservedKeys are top-level .gitlab-ci.yml keys that are not jobs
+var gitlabReservedKeys = map[string]bool{
+	"stages": true, "variables": true, "default": true, "include": true, "workflow": true,
+	"image": true, "services": true, "before_script": true, "after_script": true, "cache": true,
+}
+
+// gitlabDefaultStages are the stages of a pipeline that does not declare any
+var gitlabDefaultStages = []string{".pre", "build", "test", "deploy", ".post"}
+
+var (
+	// stage('Build') { in declarative Jenkinsfiles
+	jenkinsStagePattern = regexp.MustCompile(`\bstage\s*\(\s*['"]([^'"]+)['"]\s*\)\s*\{`)
+
+	// input message: '...' or input { ... } approval steps
+	jenkinsInputPattern = regexp.MustCompile(`\binput\s*(?:\(|\{|message\s*:)`)
+
+	// catchError, unstable and || true let a failing step pass
+	jenkinsIgnoreFailurePattern = regexp.MustCompile(`\bcatchError\b|\bwarnError\b|\bunstable\s*\(|returnStatus\s*:\s*true|\|\|\s*true\b`)
+)
+
+// ciPlatform returns the CI platform a file configures, if any
+func ciPlatform(relPath string) string {
+	slashed := filepath.ToSlash(relPath)
+	base := filepath.Base(slashed)
+	ext := strings.ToLower(filepath.Ext(base))
+	switch {
+	case strings.Contains("/"+slashed, "/.github/workflows/") && (ext == ExtYAML || ext == ExtYML):
+		return ciGitHubActions
+	case base == ".gitlab-ci.yml" || base == ".gitlab-ci.yaml":
+		return ciGitLab
+	case base == "Jenkinsfile" || strings.HasPrefix(base, "Jenkinsfile.") || strings.HasSuffix(base, ".jenkinsfile"):
+		return ciJenkins
+	}
+	return ""
+}
+
+// parseCIPipeline parses a pipeline definition of any supported platform
+func parseCIPipeline(content, relPath string) (*ciPipeline, error) {
+	p := &ciPipeline{File: relPath, Platform: ciPlatform(relPath)}
+	var err error
+	switch p.Platform {
+	case ciGitHubActions:
+		err = parseGitHubWorkflow(content, p)
+	case ciGitLab:
+		err = parseGitLabCI(content, p)
+	case ciJenkins:
+		err = parseJenkinsfile(content, p)
+	default:
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return p, nil
+}
+
+// parseGitHubWorkflow reads the jobs of a GitHub Actions workflow
+func parseGitHubWorkflow(content string, p *ciPipeline) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	doc := root.Content[0]
+	// A workflow_dispatch trigger is not an approval: anyone allowed to run
+	// the workflow deploys without a second person
+	dispatched := githubManualTrigger(yamlMappingValue(doc, "on"))
+
+	jobs := yamlMappingValue(doc, "jobs")
+	if jobs == nil || jobs.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(jobs.Content); i += 2 {
+		node := jobs.Content[i+1]
+		var spec map[string]interface{}
+		if err := node.Decode(&spec); err != nil {
+			return err
+		}
+		job := ciJob{
+			Name:         jobs.Content[i].Value,
+			Line:         jobs.Content[i].Line,
+			Needs:        stringList(spec["needs"]),
+			Environment:  ciEnvironmentName(spec["environment"]),
+			Dispatched:   dispatched,
+			AllowFailure: spec["continue-on-error"] == true,
+		}
+		// Reusable workflows are called with a job-level uses
+		if uses, ok := spec["uses"].(string); ok {
+			job.Steps = append(job.Steps, ciStep{Uses: uses, Line: node.Line})
+		}
+		if steps := yamlMappingValue(node, "steps"); steps != nil {
+			for _, stepNode := range steps.Content {
+				var step map[string]interface{}
+				if err := stepNode.Decode(&step); err != nil {
+					return err
+				}
+				s := ciStep{Line: stepNode.Line, ContinueOnError: step["continue-on-error"] == true}
+				s.Name, _ = step["name"].(string)
+				s.Uses, _ = step["uses"].(string)
+				s.Run, _ = step["run"].(string)
+				job.Steps = append(job.Steps, s)
+			}
+		}
+		p.Jobs = append(p.Jobs, job)
+	}
+	return nil
+}
+
+// githubManualTrigger reports whether a workflow only runs when dispatched by hand
+func githubManualTrigger(on *yaml.Node) bool {
+	if on == nil {
+		return false
+	}
+	var events []string
+	switch on.Kind {
+	case yaml.ScalarNode:
+		events = []string{on.Value}
+	case yaml.SequenceNode:
+		for _, child := range on.Content {
+			events = append(events, child.Value)
+		}
+	case yaml.MappingNode:
+		for i := 0; i < len(on.Content); i += 2 {
+			events = append(events, on.Content[i].Value)
+		}
+	}
+	for _, event := range events {
+		if event != "workflow_dispatch" {
+			return false
+		}
+	}
+	return len(events) > 0
+}
+
+// parseGitLabCI reads the jobs of a .gitlab-ci.yml, resolving extends
+func parseGitLabCI(content string, p *ciPipeline) error {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil
+	}
+	doc := root.Content[0]
+
+	var config map[string]interface{}
+	if err := doc.Decode(&config); err != nil {
+		return err
+	}
+	stages := gitlabDefaultStages
+	if declared := stringList(config["stages"]); len(declared) > 0 {
+		stages = append(append([]string{".pre"}, declared...), ".post")
+	}
+	stageIndex := make(map[string]int, len(stages))
+	for i, stage := range stages {
+		stageIndex[stage] = i
+	}
+
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		name := doc.Content[i].Value
+		if gitlabReservedKeys[name] || strings.HasPrefix(name, ".") {
+			continue
+		}
+		spec := gitlabResolveExtends(config, name, 0)
+		if spec == nil {
+			continue
+		}
+		if _, isJob := spec["script"]; !isJob {
+			if _, isTrigger := spec["trigger"]; !isTrigger {
+				continue
+			}
+		}
+
+		job := ciJob{
+			Name:        name,
+			Line:        doc.Content[i].Line,
+			Stage:       "test",
+			Environment: ciEnvironmentName(spec["environment"]),
+			Manual:      gitlabManual(spec),
+		}
+		if stage, ok := spec["stage"].(string); ok {
+			job.Stage = stage
+		}
+		job.Order = stageIndex[job.Stage]
+		if needs, ok := spec["needs"]; ok {
+			job.HasNeeds = true
+			job.Needs = gitlabNeeds(needs)
+		}
+		switch allow := spec["allow_failure"].(type) {
+		case bool:
+			job.AllowFailure = allow
+		case map[string]interface{}:
+			job.AllowFailure = true // allowed to fail with specific exit codes
+		}
+		var script []string
+		for _, key := range []string{"before_script", "script"} {
+			script = append(script, stringList(spec[key])...)
+		}
+		for _, line := range script {
+			job.Steps = append(job.Steps, ciStep{Run: line, Line: job.Line})
+		}
+		p.Jobs = append(p.Jobs, job)
+	}
+	return nil
+}
+
+// gitlabResolveExtends returns a job with the keys of the templates it
+// extends filled in. Job keys take precedence, as in GitLab.
+func gitlabResolveExtends(config map[string]interface{}, name string, depth int) map[string]interface{} {
+	spec, ok := config[name].(map[string]interface{})
+	if !ok || depth > 10 {
+		return nil
+	}
+	resolved := make(map[string]interface{}, len(spec))
+	for _, parent := range stringList(spec["extends"]) {
+		for key, value := range gitlabResolveExtends(config, parent, depth+1) {
+			resolved[key] = value
+		}
+	}
+	for key, value := range spec {
+		resolved[key] = value
+	}
+	return resolved
+}
+
+// gitlabManual reports whether a job only runs when started by hand
+func gitlabManual(spec map[string]interface{}) bool {
+	if spec["when"] == "manual" {
+		return true
+	}
+	rules, _ := spec["rules"].([]interface{})
+	for _, raw := range rules {
+		if rule, ok := raw.(map[string]interface{}); ok && rule["when"] == "manual" {
+			return true
+		}
+	}
+	return false
+}
+
+// gitlabNeeds returns the job names of a needs list, which holds names or
+// {job: name} entries
+func gitlabNeeds(raw interface{}) []string {
+	list, _ := raw.([]interface{})
+	var needs []string
+	for _, item := range list {
+		switch v := item.(type) {
+		case string:
+			needs = append(needs, v)
+		case map[string]interface{}:
+			if job, ok := v["job"].(string); ok {
+				needs = append(needs, job)
+			}
+		}
+	}
+	return needs
+}
+
+// parseJenkinsfile reads the leaf stages of a declarative Jenkinsfile in order
+func parseJenkinsfile(content string, p *ciPipeline) error {
+	for _, m := range jenkinsStagePattern.FindAllStringSubmatchIndex(content, -1) {
+		line := strings.Count(content[:m[0]], "\n") + 1
+		body, err := jenkinsBlock(content, m[1]-1)
+		if err != nil {
+			return fmt.Errorf("stage %q at line %d: %w", content[m[2]:m[3]], line, err)
+		}
+		// Stages that group parallel or sequential stages are not jobs themselves
+		if jenkinsStagePattern.MatchString(body) {
+			continue
+		}
+		p.Jobs = append(p.Jobs, ciJob{
+			Name:   content[m[2]:m[3]],
+			Line:   line,
+			Order:  len(p.Jobs),
+			Manual: jenkinsInputPattern.MatchString(body),
+			Steps: []ciStep{{
+				Name:            content[m[2]:m[3]],
+				Run:             body,
+				Line:            line,
+				ContinueOnError: jenkinsIgnoreFailurePattern.MatchString(body),
+			}},
+		})
+	}
+	return nil
+}
+
+// jenkinsMaxBlock bounds the size of a stage body read from a Jenkinsfile
+const jenkinsMaxBlock = 64 << 10
+
+// jenkinsBlock returns the text between the brace at open and its matching
+// close. Quotes and braces inside Groovy strings and comments do not count,
+// so an apostrophe in a // comment does not hide the rest of the stage.
+func jenkinsBlock(content string, open int) (string, error) {
+	end := min(len(content), open+jenkinsMaxBlock)
+	depth := 0
+	var quote byte
+	for i := open; i < end; i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case strings.HasPrefix(content[i:], "//"):
+			if n := strings.IndexByte(content[i:], '\n'); n >= 0 {
+				i += n
+			} else {
+				i = len(content)
+			}
+		case strings.HasPrefix(content[i:], "/*"):
+			if n := strings.Index(content[i+2:], "*/"); n >= 0 {
+				i += n + 3
+			} else {
+				i = len(content)
+			}
+		case c == '"' || c == '\'':
+			quote = c
+		case c == '{':
+			depth++
+		case c == '}':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i], nil
+			}
+		}
+	}
+	if end < len(content) {
+		return "", fmt.Errorf("block exceeds %d bytes", jenkinsMaxBlock)
+	}
+	return content[open+1:], nil
+}
+
+// ciEnvironmentName returns the name of a deployment environment, given
+// as a string or as {name: ..., url: ...}
+func ciEnvironmentName(raw interface{}) string {
+	switch v := raw.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		name, _ := v["name"].(string)
+		return name
+	}
+	return ""
+}
+
+// stringList returns a string or a list of strings as a list
+func stringList(raw interface{}) []string {
+	switch v := raw.(type) {
+	case string:
+		return []string{v}
+	case []interface{}:
+		var list []string
+		for _, item := range v {
+			if s, ok := item.(string); ok {
+				list = append(list, s)
+			}
+		}
+		return list
+	}
+	return nil
+}
+
+// yamlMappingValue returns the value node of a key in a mapping node
+func yamlMappingValue(node *yaml.Node, key string) *yaml.Node {
+	if node == nil || node.Kind != yaml.MappingNode {
+		return nil
+	}
+	for i := 0; i+1 < len(node.Content); i += 2 {
+		if node.Content[i].Value == key {
+			return node.Content[i+1]
+		}
+	}
+	return nil
+}
+
+// job returns the job with the given name or ID
+func (p *ciPipeline) job(name string) *ciJob {
+	for i := range p.Jobs {
+		if p.Jobs[i].Name == name {
+			return &p.Jobs[i]
+		}
+	}
+	return nil
+}
+
+// runsBefore reports whether job a always finishes before job b starts
+func (p *ciPipeline) runsBefore(a, b *ciJob) bool {
+	if p.Platform == ciJenkins || (p.Platform == ciGitLab && !b.HasNeeds) {
+		return a.Order < b.Order
+	}
+	return p.dependsOn(b, a.Name, make(map[string]bool))
+}
+
+// dependsOn reports whether a job needs the named job, directly or transitively
+func (p *ciPipeline) dependsOn(job *ciJob, name string, seen map[string]bool) bool {
+	for _, need := range job.Needs {
+		if need == name {
+			return true
+		}
+		if seen[need] {
+			continue
+		}
+		seen[need] = true
+		if dep := p.job(need); dep != nil && p.dependsOn(dep, name, seen) {
+			return true
+		}
+	}
+	return false
+}
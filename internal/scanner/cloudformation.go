@@ -0,0 +1,310 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// cfnResource is a resource of a CloudFormation (or SAM) template
+type cfnResource struct {
+	LogicalID      string
+	Type           string
+	Properties     map[string]interface{}
+	DeletionPolicy string
+	Line           int
+}
+
+// cfnTemplate is a parsed CloudFormation or SAM template
+type cfnTemplate struct {
+	File       string
+	Serverless bool                   // uses the AWS::Serverless transform
+	Parameters map[string]interface{} // defaults of parameters that have one
+	Globals    map[string]interface{} // SAM Globals section
+	Resources  []cfnResource
+}
+
+// cfnIntrinsicTags maps short-form intrinsic tags to their full names
+var cfnIntrinsicTags = map[string]string{
+	"!Ref":         "Ref",
+	"!Condition":   "Condition",
+	"!GetAtt":      "Fn::GetAtt",
+	"!Sub":         "Fn::Sub",
+	"!Join":        "Fn::Join",
+	"!Select":      "Fn::Select",
+	"!Split":       "Fn::Split",
+	"!If":          "Fn::If",
+	"!Equals":      "Fn::Equals",
+	"!And":         "Fn::And",
+	"!Or":          "Fn::Or",
+	"!Not":         "Fn::Not",
+	"!FindInMap":   "Fn::FindInMap",
+	"!ImportValue": "Fn::ImportValue",
+	"!GetAZs":      "Fn::GetAZs",
+	"!Base64":      "Fn::Base64",
+	"!Cidr":        "Fn::Cidr",
+	"!Transform":   "Fn::Transform",
+}
+
+// isCFNCandidate reports whether a file may hold a CloudFormation template
+func isCFNCandidate(relPath string) bool {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ExtYAML, ExtYML, ".json", ".template":
+		return true
+	}
+	return false
+}
+
+// parseCFNTemplate parses a YAML or JSON CloudFormation/SAM template. It
+// returns false for files that are not templates.
+func parseCFNTemplate(content, relPath string) (*cfnTemplate, bool) {
+	if !isCFNCandidate(relPath) {
+		return nil, false
+	}
+	// Cheap pre-check before decoding every YAML/JSON file
+	if !strings.Contains(content, "AWS::") {
+		return nil, false
+	}
+
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return nil, false
+	}
+	doc := root.Content[0]
+	if doc.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	tmpl := &cfnTemplate{File: relPath, Parameters: map[string]interface{}{}}
+	var resources *yaml.Node
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		key, value := doc.Content[i].Value, doc.Content[i+1]
+		switch key {
+		case "Resources":
+			resources = value
+		case "Transform":
+			tmpl.Serverless = strings.Contains(cfnNodeString(value), "AWS::Serverless")
+		case "Globals":
+			tmpl.Globals, _ = cfnNodeValue(value).(map[string]interface{})
+		case "Parameters":
+			params, _ := cfnNodeValue(value).(map[string]interface{})
+			for name := range params {
+				if def, ok := nestedMap(params, name)["Default"]; ok {
+					tmpl.Parameters[name] = def
+				}
+			}
+		}
+	}
+	if resources == nil || resources.Kind != yaml.MappingNode {
+		return nil, false
+	}
+
+	for i := 0; i+1 < len(resources.Content); i += 2 {
+		res, ok := cfnNodeValue(resources.Content[i+1]).(map[string]interface{})
+		if !ok {
+			continue
+		}
+		resourceType, _ := res["Type"].(string)
+		if resourceType == "" {
+			continue
+		}
+		r := cfnResource{
+			LogicalID: resources.Content[i].Value,
+			Type:      resourceType,
+			Line:      resources.Content[i].Line,
+		}
+		r.Properties, _ = res["Properties"].(map[string]interface{})
+		r.DeletionPolicy, _ = res["DeletionPolicy"].(string)
+		if strings.HasPrefix(resourceType, "AWS::Serverless::") {
+			tmpl.Serverless = true
+		}
+		tmpl.Resources = append(tmpl.Resources, r)
+	}
+	if len(tmpl.Resources) == 0 {
+		return nil, false
+	}
+	sort.SliceStable(tmpl.Resources, func(i, j int) bool { return tmpl.Resources[i].Line < tmpl.Resources[j].Line })
+	return tmpl, true
+}
+
+// cfnNodeString returns the scalar values of a node joined by commas
+func cfnNodeString(node *yaml.Node) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	var parts []string
+	for _, child := range node.Content {
+		parts = append(parts, cfnNodeString(child))
+	}
+	return strings.Join(parts, ",")
+}
+
+// cfnNodeValue decodes a node into plain values, expanding short-form
+// intrinsic tags (!Ref, !GetAtt, ...) into their long form
+func cfnNodeValue(node *yaml.Node) interface{} {
+	var value interface{}
+	switch node.Kind {
+	case yaml.DocumentNode:
+		if len(node.Content) > 0 {
+			return cfnNodeValue(node.Content[0])
+		}
+		return nil
+	case yaml.AliasNode:
+		return cfnNodeValue(node.Alias)
+	case yaml.MappingNode:
+		m := make(map[string]interface{}, len(node.Content)/2)
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			m[node.Content[i].Value] = cfnNodeValue(node.Content[i+1])
+		}
+		value = m
+	case yaml.SequenceNode:
+		list := make([]interface{}, 0, len(node.Content))
+		for _, child := range node.Content {
+			list = append(list, cfnNodeValue(child))
+		}
+		value = list
+	case yaml.ScalarNode:
+		value = cfnScalar(node)
+	}
+
+	name, ok := cfnIntrinsicTags[node.Tag]
+	if !ok {
+		return value
+	}
+	// !GetAtt Resource.Attribute is short for [Resource, Attribute]
+	if s, isString := value.(string); isString && name == "Fn::GetAtt" {
+		resource, attr, _ := strings.Cut(s, ".")
+		value = []interface{}{resource, attr}
+	}
+	return map[string]interface{}{name: value}
+}
+
+// cfnScalar decodes a scalar by its resolved YAML type
+func cfnScalar(node *yaml.Node) interface{} {
+	switch node.ShortTag() {
+	case "!!bool":
+		b, _ := strconv.ParseBool(strings.ToLower(node.Value))
+		return b
+	case "!!int", "!!float":
+		if f, err := strconv.ParseFloat(node.Value, 64); err == nil {
+			return f
+		}
+	case "!!null":
+		return nil
+	}
+	return node.Value
+}
+
+// property returns a (possibly nested) property of a resource. For SAM
+// resources, unset properties fall back to the Globals section.
+func (t *cfnTemplate) property(r *cfnResource, path ...string) (interface{}, bool) {
+	if v, ok := cfnLookup(r.Properties, path); ok {
+		return v, true
+	}
+	if section := strings.TrimPrefix(r.Type, "AWS::Serverless::"); section != r.Type {
+		return cfnLookup(nestedMap(t.Globals, section), path)
+	}
+	return nil, false
+}
+
+// cfnLookup walks a path of keys through nested maps
+func cfnLookup(m map[string]interface{}, path []string) (interface{}, bool) {
+	var cur interface{} = m
+	for _, key := range path {
+		obj, ok := cur.(map[string]interface{})
+		if !ok {
+			return nil, false
+		}
+		if cur, ok = obj[key]; !ok {
+			return nil, false
+		}
+	}
+	return cur, true
+}
+
+// resolve returns the literal value of a property, following Ref to
+// parameter defaults. Other intrinsic functions cannot be resolved offline.
+func (t *cfnTemplate) resolve(value interface{}) (interface{}, bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return value, true
+	}
+	if ref, ok := m["Ref"].(string); ok && len(m) == 1 {
+		if def, ok := t.Parameters[ref]; ok {
+			return t.resolve(def)
+		}
+		return nil, false
+	}
+	if isCFNIntrinsic(m) {
+		return nil, false
+	}
+	return value, true
+}
+
+// isCFNIntrinsic reports whether a map encodes an intrinsic function call
+func isCFNIntrinsic(m map[string]interface{}) bool {
+	if len(m) != 1 {
+		return false
+	}
+	for key := range m {
+		return key == "Ref" || key == "Condition" || strings.HasPrefix(key, "Fn::")
+	}
+	return false
+}
+
+// boolProperty returns a boolean property; CloudFormation accepts "true"
+// strings as well as booleans
+func (t *cfnTemplate) boolProperty(r *cfnResource, def bool, path ...string) (val, known bool) {
+	raw, ok := t.property(r, path...)
+	if !ok {
+		return def, true
+	}
+	resolved, ok := t.resolve(raw)
+	if !ok {
+		return false, false
+	}
+	switch v := resolved.(type) {
+	case bool:
+		return v, true
+	case string:
+		b, err := strconv.ParseBool(strings.ToLower(v))
+		return b, err == nil
+	}
+	return false, false
+}
+
+// numberProperty returns a numeric property; numbers may be quoted
+func (t *cfnTemplate) numberProperty(r *cfnResource, path ...string) (val float64, set, known bool) {
+	raw, ok := t.property(r, path...)
+	if !ok {
+		return 0, false, true
+	}
+	resolved, ok := t.resolve(raw)
+	if !ok {
+		return 0, true, false
+	}
+	switch v := resolved.(type) {
+	case float64:
+		return v, true, true
+	case string:
+		f, err := strconv.ParseFloat(v, 64)
+		return f, true, err == nil
+	}
+	return 0, true, false
+}
+
+// resources returns the resources of the given types
+func (t *cfnTemplate) resources(types ...string) []cfnResource {
+	var res []cfnResource
+	for _, r := range t.Resources {
+		for _, resourceType := range types {
+			if r.Type == resourceType {
+				res = append(res, r)
+			}
+		}
+	}
+	return res
+}
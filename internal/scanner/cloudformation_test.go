@@ -0,0 +1,97 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseCFNTemplate(t *testing.T) {
+	content := `AWSTemplateFormatVersion: "2010-09-09"
+Transform: AWS::Serverless-2016-10-31
+Parameters:
+  Env:
+    Type: String
+    Default: prod
+  Retention:
+    Type: Number
+Globals:
+  Function:
+    Timeout: 10
+Resources:
+  Queue:
+    Type: AWS::SQS::Queue
+  Handler:
+    Type: AWS::Serverless::Function
+    Properties:
+      Environment:
+        Variables:
+          QUEUE_URL: !Ref Queue
+          QUEUE_ARN: !GetAtt Queue.Arn
+          NAME: !Sub "${Env}-handler"
+      MemorySize: !If [IsProd, 1024, 512]
+`
+	tmpl, ok := parseCFNTemplate(content, "template.yaml")
+	if !ok {
+		t.Fatal("expected a template")
+	}
+	if !tmpl.Serverless || len(tmpl.Resources) != 2 {
+		t.Fatalf("unexpected template %+v", tmpl)
+	}
+	fn := &tmpl.Resources[1]
+	if fn.LogicalID != "Handler" || fn.Line != 15 {
+		t.Errorf("expected Handler on line 15, got %s on %d", fn.LogicalID, fn.Line)
+	}
+	vars := nestedMap(fn.Properties, "Environment", "Variables")
+	if !reflect.DeepEqual(vars["QUEUE_URL"], map[string]interface{}{"Ref": "Queue"}) {
+		t.Errorf("unexpected !Ref expansion %#v", vars["QUEUE_URL"])
+	}
+	if !reflect.DeepEqual(vars["QUEUE_ARN"], map[string]interface{}{"Fn::GetAtt": []interface{}{"Queue", "Arn"}}) {
+		t.Errorf("unexpected !GetAtt expansion %#v", vars["QUEUE_ARN"])
+	}
+
+	// Globals apply to SAM functions; Ref resolves to parameter defaults
+	if timeout, set, known := tmpl.numberProperty(fn, "Timeout"); !set || !known || timeout != 10 {
+		t.Errorf("expected Timeout 10 from Globals, got %v (set=%v known=%v)", timeout, set, known)
+	}
+	if v, ok := tmpl.resolve(map[string]interface{}{"Ref": "Env"}); !ok || v != "prod" {
+		t.Errorf("expected Env to resolve to prod, got %v", v)
+	}
+	if _, ok := tmpl.resolve(map[string]interface{}{"Ref": "Retention"}); ok {
+		t.Error("expected a parameter without default to be unresolved")
+	}
+	if _, set, known := tmpl.numberProperty(fn, "MemorySize"); !set || known {
+		t.Error("expected Fn::If to be unresolved")
+	}
+}
+
+func TestParseCFNTemplateJSON(t *testing.T) {
+	content := `{
+  "Resources": {
+    "Table": {
+      "Type": "AWS::DynamoDB::Table",
+      "DeletionPolicy": "Retain",
+      "Properties": {"PointInTimeRecoverySpecification": {"PointInTimeRecoveryEnabled": "true"}}
+    }
+  }
+}`
+	tmpl, ok := parseCFNTemplate(content, "cdk.out/Stack.template.json")
+	if !ok || len(tmpl.Resources) != 1 {
+		t.Fatalf("expected a JSON template, got %+v", tmpl)
+	}
+	table := &tmpl.Resources[0]
+	if table.DeletionPolicy != "Retain" || table.Line != 3 {
+		t.Errorf("unexpected resource %+v", table)
+	}
+	if enabled, known := tmpl.boolProperty(table, false, "PointInTimeRecoverySpecification", "PointInTimeRecoveryEnabled"); !enabled || !known {
+		t.Error("expected string booleans to be accepted")
+	}
+}
+
+func TestParseCFNTemplateRejects(t *testing.T) {
+	if _, ok := parseCFNTemplate("resources:\n  limits:\n    cpu: 1\n", "deploy.yaml"); ok {
+		t.Error("expected Kubernetes YAML not to be a template")
+	}
+	if _, ok := parseCFNTemplate(`Type: "AWS::S3::Bucket"`, "main.go"); ok {
+		t.Error("expected non-template extensions to be skipped")
+	}
+}
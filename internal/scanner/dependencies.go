@@ -0,0 +1,747 @@
+package scanner
+
+import (
+	"encoding/json"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Dependency ecosystems
+const (
+	depGo     = "go"
+	depNPM    = "npm"
+	depPython = "python"
+	depCargo  = "cargo"
+	depRuby   = "ruby"
+	depMaven  = "maven"
+	depGradle = "gradle"
+)
+
+// How a dependency version is specified
+const (
+	depPinned    = "pinned"    // an exact version
+	depRange     = "range"     // a bounded range, such as ^1.2 or ~> 7.1
+	depUnbounded = "unbounded" // any version, such as *, latest or >=1.0
+	depVCS       = "vcs"       // a git repository or URL
+	depLocal     = "local"     // a path on disk
+)
+
+// dependency is a dependency declared in a manifest
+type dependency struct {
+	Name    string
+	Version string // the version, range or source as written
+	Kind    string
+	Line    int
+}
+
+// depReplace is a Go replace directive or Cargo patch pointing at a local path
+type depReplace struct {
+	Module string
+	Path   string
+	Line   int
+}
+
+// depManifest is a dependency manifest and the lockfile that pins it
+type depManifest struct {
+	Ecosystem string
+	File      string
+	Lockfile  string // "" when none was found
+	NeedsLock bool   // whether resolving the manifest can pick different versions
+	Deps      []dependency
+	Replaces  []depReplace
+}
+
+// depLockfiles are the lockfiles of each ecosystem. npm and Cargo
+// workspaces share the lockfile at their root, so those are also looked
+// for in parent directories.
+var depLockfiles = map[string][]string{
+	depGo:     {"go.sum"},
+	depNPM:    {"package-lock.json", "npm-shrinkwrap.json", "yarn.lock", "pnpm-lock.yaml", "bun.lockb", "bun.lock"},
+	depPython: {"poetry.lock", "Pipfile.lock", "uv.lock", "pdm.lock"},
+	depCargo:  {"Cargo.lock"},
+	depRuby:   {"Gemfile.lock"},
+	depMaven:  {"lockfile.json"},
+	depGradle: {"gradle.lockfile", "settings-gradle.lockfile"},
+}
+
+// depManifestEcosystem returns the ecosystem of a dependency manifest, or ""
+// when the file is not one. Vendored manifests are skipped.
+func depManifestEcosystem(relPath string) string {
+	slashed := "/" + filepath.ToSlash(relPath)
+	if strings.Contains(slashed, "/vendor/") || strings.Contains(slashed, "/third_party/") {
+		return ""
+	}
+	base := path.Base(slashed)
+	switch {
+	case base == "go.mod":
+		return depGo
+	case base == "package.json":
+		return depNPM
+	case base == "Pipfile" || base == "pyproject.toml" || requirementsFile(slashed):
+		return depPython
+	case base == "Cargo.toml":
+		return depCargo
+	case base == "Gemfile":
+		return depRuby
+	case base == "pom.xml":
+		return depMaven
+	case base == "build.gradle" || base == "build.gradle.kts":
+		return depGradle
+	}
+	return ""
+}
+
+// requirementsFile reports whether a path is a pip requirements file, such
+// as requirements.txt, requirements-dev.txt or requirements/base.txt
+func requirementsFile(slashed string) bool {
+	base := path.Base(slashed)
+	if path.Ext(base) != ".txt" {
+		return false
+	}
+	return strings.HasPrefix(base, "requirements") || strings.HasSuffix(path.Dir(slashed), "/requirements")
+}
+
+// parseDepManifest parses a dependency manifest of any supported ecosystem.
+// files are the paths in the repository, used to find the lockfile.
+func parseDepManifest(content, relPath string, files map[string]bool) *depManifest {
+	m := &depManifest{Ecosystem: depManifestEcosystem(relPath), File: relPath}
+	base := filepath.Base(relPath)
+	switch {
+	case m.Ecosystem == depGo:
+		parseGoMod(content, m)
+	case m.Ecosystem == depNPM:
+		if !parsePackageJSON(content, m) {
+			return nil
+		}
+	case base == "Pipfile":
+		parseTOMLDeps(content, m, pipfileTables, classifyPipfileVersion)
+	case base == "pyproject.toml":
+		parsePyproject(content, m)
+	case m.Ecosystem == depPython:
+		parseRequirements(content, m)
+	case m.Ecosystem == depCargo:
+		parseTOMLDeps(content, m, cargoTables, classifyCargoVersion)
+	case m.Ecosystem == depRuby:
+		parseGemfile(content, m)
+	case m.Ecosystem == depMaven:
+		parsePom(content, m)
+	case m.Ecosystem == depGradle:
+		parseGradle(content, m)
+	default:
+		return nil
+	}
+	if len(m.Deps) == 0 && len(m.Replaces) == 0 {
+		return nil
+	}
+
+	// Maven, Gradle and fully pinned requirements files resolve the same
+	// versions without a lockfile unless they use ranges
+	needsLockAlways := m.Ecosystem != depMaven && m.Ecosystem != depGradle && !requirementsFile("/"+filepath.ToSlash(relPath))
+	for _, dep := range m.Deps {
+		if dep.Kind != depLocal && (needsLockAlways || dep.Kind == depRange || dep.Kind == depUnbounded) {
+			m.NeedsLock = true
+		}
+	}
+	m.Lockfile = findLockfile(relPath, m.Ecosystem, files)
+	return m
+}
+
+// findLockfile looks for a lockfile of the ecosystem next to the manifest,
+// then in parent directories for npm, Python and Cargo workspaces
+func findLockfile(relPath, ecosystem string, files map[string]bool) string {
+	dir := filepath.Dir(relPath)
+	for {
+		for _, name := range depLockfiles[ecosystem] {
+			if lock := filepath.Join(dir, name); files[lock] {
+				return lock
+			}
+		}
+		if ecosystem == depGradle {
+			if lock := filepath.Join(dir, "gradle", "dependency-locks"); hasFileUnder(files, lock) {
+				return lock
+			}
+		}
+		if dir == "." || ecosystem == depGo || ecosystem == depRuby || ecosystem == depMaven || ecosystem == depGradle {
+			return ""
+		}
+		dir = filepath.Dir(dir)
+	}
+}
+
+// hasFileUnder reports whether any file is inside dir
+func hasFileUnder(files map[string]bool, dir string) bool {
+	prefix := dir + string(filepath.Separator)
+	for f := range files {
+		if strings.HasPrefix(f, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// depLine returns the line of the first occurrence of s in content, or 1
+func depLine(content, s string) int {
+	if i := strings.Index(content, s); i >= 0 {
+		return strings.Count(content[:i], "\n") + 1
+	}
+	return 1
+}
+
+// localPath reports whether a dependency source is a filesystem path
+func localPath(source string) bool {
+	return source == "." || strings.HasPrefix(source, "./") || strings.HasPrefix(source, "../") ||
+		strings.HasPrefix(source, "/") || strings.HasPrefix(source, "file:") ||
+		len(source) > 2 && source[1] == ':' && (source[2] == '\\' || source[2] == '/')
+}
+
+// parseGoMod reads the require and replace directives of a go.mod file
+func parseGoMod(content string, m *depManifest) {
+	block := ""
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if comment := strings.Index(line, "//"); comment >= 0 {
+			line = strings.TrimSpace(line[:comment])
+		}
+		switch {
+		case line == "":
+			continue
+		case line == ")":
+			block = ""
+			continue
+		case strings.HasSuffix(line, "("):
+			block = strings.TrimSpace(strings.TrimSuffix(line, "("))
+			continue
+		}
+		directive, args := block, line
+		if directive == "" {
+			directive, args, _ = strings.Cut(line, " ")
+			args = strings.TrimSpace(args)
+		}
+		switch directive {
+		case "require":
+			if fields := strings.Fields(args); len(fields) >= 2 {
+				m.Deps = append(m.Deps, dependency{Name: fields[0], Version: fields[1], Kind: depPinned, Line: i + 1})
+			}
+		case "replace":
+			old, target, ok := strings.Cut(args, "=>")
+			if !ok {
+				continue
+			}
+			fields := strings.Fields(target)
+			if len(fields) > 0 && localPath(fields[0]) {
+				m.Replaces = append(m.Replaces, depReplace{Module: strings.Fields(old)[0], Path: fields[0], Line: i + 1})
+			}
+		}
+	}
+}
+
+var (
+	// an exact npm version: 1.2.3, =1.2.3, v1.2.3-beta.1
+	npmExactPattern = regexp.MustCompile(`^=?v?\d+\.\d+\.\d+(?:[-+][\w.-]+)?$`)
+
+	// user/repo GitHub shorthand, optionally with #ref
+	npmGitHubShorthandPattern = regexp.MustCompile(`^[\w-]+/[\w.-]+(?:#.*)?$`)
+)
+
+// parsePackageJSON reads the dependencies, devDependencies and
+// optionalDependencies of a package.json. Peer dependencies are ranges by
+// design and are not installed from the manifest.
+func parsePackageJSON(content string, m *depManifest) bool {
+	var pkg map[string]json.RawMessage
+	if err := json.Unmarshal([]byte(content), &pkg); err != nil {
+		return false
+	}
+	for _, key := range []string{"dependencies", "devDependencies", "optionalDependencies"} {
+		var deps map[string]string
+		if err := json.Unmarshal(pkg[key], &deps); err != nil {
+			continue
+		}
+		for name, version := range deps {
+			m.Deps = append(m.Deps, dependency{
+				Name:    name,
+				Version: version,
+				Kind:    classifyNPMVersion(version),
+				Line:    depLine(content, `"`+name+`"`),
+			})
+		}
+	}
+	sortDeps(m.Deps)
+	return true
+}
+
+// classifyNPMVersion classifies an npm version specifier
+func classifyNPMVersion(version string) string {
+	v := strings.TrimSpace(version)
+	if alias, ok := strings.CutPrefix(v, "npm:"); ok {
+		// npm:package@version
+		if at := strings.LastIndex(alias, "@"); at > 0 {
+			return classifyNPMVersion(alias[at+1:])
+		}
+		return depUnbounded
+	}
+	switch {
+	case strings.HasPrefix(v, "file:") || strings.HasPrefix(v, "link:") || strings.HasPrefix(v, "workspace:") || strings.HasPrefix(v, "portal:") || localPath(v):
+		return depLocal
+	case strings.Contains(v, "://") || strings.HasPrefix(v, "git+") || strings.HasPrefix(v, "github:") ||
+		strings.HasPrefix(v, "gitlab:") || strings.HasPrefix(v, "bitbucket:") || npmGitHubShorthandPattern.MatchString(v):
+		return depVCS
+	case npmExactPattern.MatchString(v):
+		return depPinned
+	}
+	return classifyVersionRange(v)
+}
+
+// classifyVersionRange classifies a range in the npm, Cargo and Poetry
+// syntax: ranges with an upper bound, carets, tildes and wildcards in a
+// minor or patch position are bounded; *, dist-tags such as latest and
+// ranges without an upper bound are not
+func classifyVersionRange(v string) string {
+	v = strings.TrimSpace(v)
+	if v == "" || v == "*" || strings.EqualFold(v, "x") || !strings.ContainsAny(v, "0123456789") {
+		return depUnbounded
+	}
+	for _, alternative := range strings.Split(v, "||") {
+		alternative = strings.TrimSpace(alternative)
+		if (strings.Contains(alternative, ">") && !strings.Contains(alternative, "<") && !strings.Contains(alternative, " - ")) ||
+			strings.HasPrefix(alternative, "*") || strings.HasPrefix(alternative, "x") {
+			return depUnbounded
+		}
+	}
+	return depRange
+}
+
+// pep508Pattern splits a requirement into name, extras, specifier and marker
+var pep508Pattern = regexp.MustCompile(`^([A-Za-z0-9][A-Za-z0-9._-]*)\s*(\[[^\]]*\])?\s*([^;]*?)\s*(;.*)?$`)
+
+// parseRequirements reads a pip requirements file. Options, includes and
+// hashes are skipped.
+func parseRequirements(content string, m *depManifest) {
+	for i, raw := range strings.Split(content, "\n") {
+		line := strings.TrimSpace(raw)
+		if comment := strings.Index(line, " #"); comment >= 0 {
+			line = strings.TrimSpace(line[:comment])
+		}
+		if editable, ok := strings.CutPrefix(line, "-e "); ok {
+			line = strings.TrimSpace(editable)
+		} else if editable, ok := strings.CutPrefix(line, "--editable "); ok {
+			line = strings.TrimSpace(editable)
+		}
+		line = strings.TrimSpace(strings.TrimSuffix(line, "\\"))
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, "-") {
+			continue
+		}
+		if dep, ok := parsePEP508(line); ok {
+			dep.Line = i + 1
+			m.Deps = append(m.Deps, dep)
+		}
+	}
+}
+
+// parsePEP508 parses a requirement such as requests>=2.31,<3 or a direct
+// reference such as pkg @ git+https://... or a bare URL or path
+func parsePEP508(requirement string) (dependency, bool) {
+	requirement = strings.TrimSpace(requirement)
+	if strings.Contains(requirement, "://") && !strings.Contains(requirement, " @ ") || strings.HasPrefix(requirement, "git+") {
+		return dependency{Name: requirement, Version: requirement, Kind: depVCS}, true
+	}
+	if localPath(requirement) {
+		return dependency{Name: requirement, Version: requirement, Kind: depLocal}, true
+	}
+	if name, ref, ok := strings.Cut(requirement, " @ "); ok {
+		kind := depVCS
+		if localPath(strings.TrimSpace(ref)) {
+			kind = depLocal
+		}
+		return dependency{Name: strings.TrimSpace(name), Version: strings.TrimSpace(ref), Kind: kind}, true
+	}
+	match := pep508Pattern.FindStringSubmatch(requirement)
+	if match == nil {
+		return dependency{}, false
+	}
+	return dependency{Name: match[1], Version: match[3], Kind: classifyPEP440(match[3])}, true
+}
+
+// classifyPEP440 classifies a PEP 440 version specifier
+func classifyPEP440(spec string) string {
+	spec = strings.ReplaceAll(spec, " ", "")
+	switch {
+	case spec == "":
+		return depUnbounded
+	case strings.HasPrefix(spec, "===") || strings.HasPrefix(spec, "==") && !strings.Contains(spec, ",") && !strings.Contains(spec, "*"):
+		return depPinned
+	case strings.Contains(spec, "<") || strings.Contains(spec, "~=") || strings.HasPrefix(spec, "=="):
+		return depRange
+	}
+	return depUnbounded
+}
+
+// tomlEntry is a key = value line of a TOML file, with multi-line arrays
+// joined, under the table it belongs to
+type tomlEntry struct {
+	Table string
+	Key   string
+	Value string
+	Line  int
+}
+
+// tomlTablePattern matches [table] and [[array.of.tables]] headers
+var tomlTablePattern = regexp.MustCompile(`^\[\[?\s*([^\]]+?)\s*\]\]?$`)
+
+// tomlEntries reads the key/value pairs of a TOML file. It understands the
+// subset dependency manifests use: tables, strings, inline tables and
+// arrays, including arrays spanning lines.
+func tomlEntries(content string) []tomlEntry {
+	var entries []tomlEntry
+	table := ""
+	lines := strings.Split(content, "\n")
+	for i := 0; i < len(lines); i++ {
+		line := strings.TrimSpace(lines[i])
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		if m := tomlTablePattern.FindStringSubmatch(line); m != nil {
+			table = strings.ReplaceAll(m[1], `"`, "")
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		entry := tomlEntry{Table: table, Key: strings.Trim(strings.TrimSpace(key), `"'`), Value: strings.TrimSpace(value), Line: i + 1}
+		for strings.Count(entry.Value, "[")+strings.Count(entry.Value, "{") > strings.Count(entry.Value, "]")+strings.Count(entry.Value, "}") && i+1 < len(lines) {
+			i++
+			entry.Value += " " + strings.TrimSpace(lines[i])
+		}
+		entries = append(entries, entry)
+	}
+	return entries
+}
+
+var (
+	tomlQuotedPattern      = regexp.MustCompile(`"((?:[^"\\]|\\.)*)"|'([^']*)'`)
+	tomlInlineFieldPattern = regexp.MustCompile(`([\w-]+)\s*=\s*(?:"([^"]*)"|'([^']*)'|(true|false))`)
+)
+
+// tomlString returns the string a TOML value holds, if it is one
+func tomlString(value string) (string, bool) {
+	m := tomlQuotedPattern.FindStringSubmatch(value)
+	if m == nil || strings.HasPrefix(value, "{") || strings.HasPrefix(value, "[") {
+		return "", false
+	}
+	return m[1] + m[2], true
+}
+
+// tomlStrings returns the strings of a TOML array
+func tomlStrings(value string) []string {
+	var values []string
+	for _, m := range tomlQuotedPattern.FindAllStringSubmatch(value, -1) {
+		values = append(values, m[1]+m[2])
+	}
+	return values
+}
+
+// tomlInlineTable returns the string and boolean fields of an inline table
+func tomlInlineTable(value string) map[string]string {
+	fields := make(map[string]string)
+	for _, m := range tomlInlineFieldPattern.FindAllStringSubmatch(value, -1) {
+		fields[m[1]] = m[2] + m[3] + m[4]
+	}
+	return fields
+}
+
+// pipfileTables and cargoTables report whether a TOML table lists
+// dependencies, and whether it patches them
+var (
+	pipfileTables = func(table string) (deps, patch bool) {
+		return table == "packages" || table == "dev-packages", false
+	}
+	cargoTables = func(table string) (deps, patch bool) {
+		if strings.HasPrefix(table, "patch.") {
+			return true, true
+		}
+		last := table[strings.LastIndex(table, ".")+1:]
+		return last == "dependencies" || last == "dev-dependencies" || last == "build-dependencies", false
+	}
+)
+
+// parseTOMLDeps reads the dependency tables of a Pipfile or Cargo.toml.
+// Dependencies are strings, inline tables or [dependencies.name] tables.
+func parseTOMLDeps(content string, m *depManifest, tables func(string) (bool, bool), classify func(string) string) {
+	add := func(dep dependency, patch bool) {
+		switch {
+		case !patch:
+			m.Deps = append(m.Deps, dep)
+		case dep.Kind == depLocal:
+			m.Replaces = append(m.Replaces, depReplace{Module: dep.Name, Path: dep.Version, Line: dep.Line})
+		}
+	}
+
+	// the fields of [dependencies.name] tables, in order
+	var subtables []string
+	subtableFields := make(map[string]map[string]string)
+	subtableLines := make(map[string]int)
+	for _, entry := range tomlEntries(content) {
+		if deps, patch := tables(entry.Table); deps {
+			fields := tomlInlineTable(entry.Value)
+			if version, ok := tomlString(entry.Value); ok {
+				fields = map[string]string{"version": version}
+			}
+			if dep, ok := tomlDependency(entry.Key, fields, classify); ok {
+				dep.Line = entry.Line
+				add(dep, patch)
+			}
+			continue
+		}
+		if parent, _, ok := cutLast(entry.Table, "."); ok {
+			if deps, _ := tables(parent); deps {
+				if _, seen := subtableFields[entry.Table]; !seen {
+					subtables = append(subtables, entry.Table)
+					subtableFields[entry.Table] = make(map[string]string)
+					subtableLines[entry.Table] = entry.Line - 1
+				}
+				value, ok := tomlString(entry.Value)
+				if !ok {
+					value = entry.Value
+				}
+				subtableFields[entry.Table][entry.Key] = value
+			}
+		}
+	}
+	for _, table := range subtables {
+		parent, name, _ := cutLast(table, ".")
+		if dep, ok := tomlDependency(name, subtableFields[table], classify); ok {
+			dep.Line = subtableLines[table]
+			_, patch := tables(parent)
+			add(dep, patch)
+		}
+	}
+	sortDeps(m.Deps)
+}
+
+// tomlDependency builds a dependency from the fields of a Pipfile or Cargo
+// entry. Workspace-inherited dependencies are versioned by the workspace.
+func tomlDependency(name string, fields map[string]string, classify func(string) string) (dependency, bool) {
+	dep := dependency{Name: name}
+	switch {
+	case fields["workspace"] == "true":
+		return dependency{}, false
+	case fields["path"] != "":
+		dep.Version, dep.Kind = fields["path"], depLocal
+	case fields["git"] != "":
+		dep.Version, dep.Kind = fields["git"], depVCS
+	case fields["url"] != "":
+		dep.Version, dep.Kind = fields["url"], depVCS
+	case fields["file"] != "":
+		dep.Version, dep.Kind = fields["file"], depLocal
+	default:
+		version, ok := fields["version"]
+		if !ok {
+			return dependency{}, false
+		}
+		dep.Version, dep.Kind = version, classify(version)
+	}
+	return dep, true
+}
+
+// sortDeps orders dependencies by line, then name
+func sortDeps(deps []dependency) {
+	sort.SliceStable(deps, func(i, j int) bool {
+		if deps[i].Line != deps[j].Line {
+			return deps[i].Line < deps[j].Line
+		}
+		return deps[i].Name < deps[j].Name
+	})
+}
+
+// cutLast splits s around the last sep
+func cutLast(s, sep string) (before, after string, found bool) {
+	if i := strings.LastIndex(s, sep); i >= 0 {
+		return s[:i], s[i+len(sep):], true
+	}
+	return s, "", false
+}
+
+// classifyPipfileVersion classifies a Pipfile version, which uses PEP 440
+// specifiers or * for any version
+func classifyPipfileVersion(version string) string {
+	if strings.TrimSpace(version) == "*" {
+		return depUnbounded
+	}
+	return classifyPEP440(version)
+}
+
+// classifyCargoVersion classifies a Cargo version requirement, where a bare
+// version is a caret range
+func classifyCargoVersion(version string) string {
+	v := strings.TrimSpace(version)
+	if strings.HasPrefix(v, "=") && !strings.ContainsAny(v, ",*") {
+		return depPinned
+	}
+	return classifyVersionRange(v)
+}
+
+// classifyPoetryVersion classifies a Poetry constraint, where a bare version
+// is exact
+func classifyPoetryVersion(version string) string {
+	v := strings.TrimSpace(version)
+	if npmExactPattern.MatchString(v) || strings.HasPrefix(v, "==") && !strings.ContainsAny(v, ",*") {
+		return depPinned
+	}
+	return classifyVersionRange(strings.ReplaceAll(v, ",", " <"))
+}
+
+// parsePyproject reads PEP 621 project dependencies and optional
+// dependencies, and Poetry dependency tables
+func parsePyproject(content string, m *depManifest) {
+	for _, entry := range tomlEntries(content) {
+		switch {
+		case entry.Table == "project" && entry.Key == "dependencies",
+			entry.Table == "project.optional-dependencies",
+			entry.Table == "dependency-groups":
+			for _, requirement := range tomlStrings(entry.Value) {
+				if dep, ok := parsePEP508(requirement); ok {
+					dep.Line = entry.Line
+					m.Deps = append(m.Deps, dep)
+				}
+			}
+		case entry.Table == "tool.poetry.dependencies" || entry.Table == "tool.poetry.dev-dependencies" ||
+			strings.HasPrefix(entry.Table, "tool.poetry.group.") && strings.HasSuffix(entry.Table, ".dependencies"):
+			if entry.Key == "python" {
+				continue
+			}
+			fields := tomlInlineTable(entry.Value)
+			if version, ok := tomlString(entry.Value); ok {
+				fields = map[string]string{"version": version}
+			}
+			if dep, ok := tomlDependency(entry.Key, fields, classifyPoetryVersion); ok {
+				dep.Line = entry.Line
+				m.Deps = append(m.Deps, dep)
+			}
+		}
+	}
+}
+
+var (
+	// gem 'name', '~> 1.2', git: '...'
+	gemPattern = regexp.MustCompile(`(?m)^\s*gem\s+["']([^"']+)["']([^\n#]*)`)
+
+	// the version requirements of a gem line
+	gemVersionPattern = regexp.MustCompile(`^\s*,\s*["']([^"']+)["']`)
+
+	// git, github and path sources of a gem line
+	gemSourcePattern = regexp.MustCompile(`\b(git|github|gist|bitbucket|path):\s*["']([^"']+)["']|:(git|github|gist|bitbucket|path)\s*=>\s*["']([^"']+)["']`)
+)
+
+// parseGemfile reads the gems of a Gemfile
+func parseGemfile(content string, m *depManifest) {
+	for _, match := range gemPattern.FindAllStringSubmatchIndex(content, -1) {
+		dep := dependency{Name: content[match[2]:match[3]], Line: strings.Count(content[:match[0]], "\n") + 1}
+		rest := content[match[4]:match[5]]
+		var versions []string
+		for v := gemVersionPattern.FindStringSubmatch(rest); v != nil; v = gemVersionPattern.FindStringSubmatch(rest) {
+			versions = append(versions, v[1])
+			rest = rest[len(v[0]):]
+		}
+		if source := gemSourcePattern.FindStringSubmatch(content[match[4]:match[5]]); source != nil {
+			kind, value := source[1]+source[3], source[2]+source[4]
+			dep.Version, dep.Kind = kind+": "+value, depVCS
+			if kind == "path" {
+				dep.Kind = depLocal
+			}
+		} else {
+			dep.Version, dep.Kind = strings.Join(versions, ", "), classifyGemVersion(versions)
+		}
+		m.Deps = append(m.Deps, dep)
+	}
+}
+
+// classifyGemVersion classifies the version requirements of a gem
+func classifyGemVersion(requirements []string) string {
+	if len(requirements) == 0 {
+		return depUnbounded
+	}
+	bounded := false
+	for _, r := range requirements {
+		r = strings.TrimSpace(r)
+		switch {
+		case strings.HasPrefix(r, "~>") || strings.HasPrefix(r, "<"):
+			bounded = true
+		case strings.HasPrefix(r, "=") && !strings.HasPrefix(r, "=>") || r != "" && r[0] >= '0' && r[0] <= '9':
+			return depPinned
+		}
+	}
+	if bounded {
+		return depRange
+	}
+	return depUnbounded
+}
+
+var (
+	pomDependencyPattern = regexp.MustCompile(`(?s)<dependency>(.*?)</dependency>`)
+	pomTagPattern        = regexp.MustCompile(`<(groupId|artifactId|version)>\s*([^<]*?)\s*</`)
+)
+
+// parsePom reads the dependencies of a Maven pom.xml. Versions managed by a
+// parent or BOM, or set by properties, are taken as pinned.
+func parsePom(content string, m *depManifest) {
+	for _, match := range pomDependencyPattern.FindAllStringSubmatchIndex(content, -1) {
+		fields := make(map[string]string)
+		for _, tag := range pomTagPattern.FindAllStringSubmatch(content[match[2]:match[3]], -1) {
+			fields[tag[1]] = tag[2]
+		}
+		if fields["artifactId"] == "" {
+			continue
+		}
+		m.Deps = append(m.Deps, dependency{
+			Name:    fields["groupId"] + ":" + fields["artifactId"],
+			Version: fields["version"],
+			Kind:    classifyMavenVersion(fields["version"]),
+			Line:    strings.Count(content[:match[0]], "\n") + 1,
+		})
+	}
+}
+
+// gradleDependencyPattern matches "group:name:version" coordinates in a
+// dependency declaration such as implementation("g:n:1.0")
+var gradleDependencyPattern = regexp.MustCompile(`\b(?:implementation|api|compileOnly|runtimeOnly|annotationProcessor|kapt|ksp|classpath|(?:test|androidTest|debug|release)\w*(?:Implementation|CompileOnly|RuntimeOnly))\s*\(?\s*(?:platform\s*\(\s*)?["']([\w.-]+:[\w.-]+)(?::([^"'@]+))?[^"']*["']`)
+
+// parseGradle reads the dependency coordinates of a Gradle build script
+func parseGradle(content string, m *depManifest) {
+	for _, match := range gradleDependencyPattern.FindAllStringSubmatchIndex(content, -1) {
+		version := ""
+		if match[4] >= 0 {
+			version = content[match[4]:match[5]]
+		}
+		m.Deps = append(m.Deps, dependency{
+			Name:    content[match[2]:match[3]],
+			Version: version,
+			Kind:    classifyMavenVersion(version),
+			Line:    strings.Count(content[:match[0]], "\n") + 1,
+		})
+	}
+}
+
+// classifyMavenVersion classifies a Maven or Gradle version. Missing and
+// property versions are managed elsewhere; ranges, + suffixes, LATEST,
+// RELEASE and snapshots move.
+func classifyMavenVersion(version string) string {
+	v := strings.TrimSpace(version)
+	switch {
+	case v == "" || strings.HasPrefix(v, "${") || strings.HasPrefix(v, "$"):
+		return depPinned
+	case strings.HasPrefix(v, "[") || strings.HasPrefix(v, "("):
+		if strings.HasSuffix(v, ",)") || strings.HasSuffix(v, ",]") {
+			return depUnbounded
+		}
+		return depRange
+	case v == "+" || strings.EqualFold(v, "LATEST") || strings.EqualFold(v, "RELEASE") ||
+		strings.HasPrefix(v, "latest.") || strings.HasSuffix(v, "-SNAPSHOT"):
+		return depUnbounded
+	case strings.HasSuffix(v, "+"):
+		return depRange
+	}
+	return depPinned
+}
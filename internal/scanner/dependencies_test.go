@@ -0,0 +1,295 @@
+package scanner
+
+import "testing"
+
+func TestClassifyNPMVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", depPinned},
+		{"=1.2.3-beta.1", depPinned},
+		{"^1.2.3", depRange},
+		{"~1.2", depRange},
+		{"1.x", depRange},
+		{">=1.0.0 <2.0.0", depRange},
+		{"*", depUnbounded},
+		{"latest", depUnbounded},
+		{">=1.0.0", depUnbounded},
+		{"", depUnbounded},
+		{"npm:lodash@^4.17.0", depRange},
+		{"github:acme/lib#v1", depVCS},
+		{"acme/lib", depVCS},
+		{"git+https://github.com/acme/lib.git", depVCS},
+		{"https://example.com/lib.tgz", depVCS},
+		{"file:../lib", depLocal},
+		{"workspace:*", depLocal},
+	}
+	for _, tt := range tests {
+		if got := classifyNPMVersion(tt.version); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.version, tt.expected, got)
+		}
+	}
+}
+
+func TestParsePEP508(t *testing.T) {
+	tests := []struct {
+		requirement string
+		name        string
+		expected    string
+	}{
+		{"requests==2.31.0", "requests", depPinned},
+		{"requests[socks]==2.31.0; python_version >= '3.8'", "requests", depPinned},
+		{"django>=4.2,<5", "django", depRange},
+		{"flask~=3.0", "flask", depRange},
+		{"numpy>=1.26", "numpy", depUnbounded},
+		{"boto3", "boto3", depUnbounded},
+		{"lib @ git+https://github.com/acme/lib@v1", "lib", depVCS},
+		{"git+https://github.com/acme/other.git#egg=other", "git+https://github.com/acme/other.git#egg=other", depVCS},
+		{"./libs/shared", "./libs/shared", depLocal},
+	}
+	for _, tt := range tests {
+		dep, ok := parsePEP508(tt.requirement)
+		if !ok || dep.Name != tt.name || dep.Kind != tt.expected {
+			t.Errorf("%q: expected %s %s, got %+v", tt.requirement, tt.name, tt.expected, dep)
+		}
+	}
+}
+
+func TestClassifyMavenVersion(t *testing.T) {
+	tests := []struct {
+		version  string
+		expected string
+	}{
+		{"1.2.3", depPinned},
+		{"${jackson.version}", depPinned},
+		{"", depPinned},
+		{"[1.0,2.0)", depRange},
+		{"[1.0,)", depUnbounded},
+		{"1.+", depRange},
+		{"+", depUnbounded},
+		{"latest.release", depUnbounded},
+		{"LATEST", depUnbounded},
+		{"1.0-SNAPSHOT", depUnbounded},
+	}
+	for _, tt := range tests {
+		if got := classifyMavenVersion(tt.version); got != tt.expected {
+			t.Errorf("%q: expected %s, got %s", tt.version, tt.expected, got)
+		}
+	}
+}
+
+func TestParseGoMod(t *testing.T) {
+	content := `module example.com/app
+
+go 1.22
+
+require github.com/spf13/cobra v1.8.0
+
+require (
+	golang.org/x/sync v0.6.0 // indirect
+	example.com/shared v0.0.0
+)
+
+replace example.com/shared => ../shared
+
+replace (
+	example.com/fork v1.0.0 => github.com/acme/fork v1.0.1
+	example.com/tools => /opt/tools
+)
+`
+	m := parseDepManifest(content, "app/go.mod", map[string]bool{"app/go.sum": true})
+	if m == nil || len(m.Deps) != 3 || m.Lockfile != "app/go.sum" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	if m.Deps[1].Name != "golang.org/x/sync" || m.Deps[1].Version != "v0.6.0" || m.Deps[1].Line != 8 {
+		t.Errorf("unexpected dependency %+v", m.Deps[1])
+	}
+	if len(m.Replaces) != 2 || m.Replaces[0].Path != "../shared" || m.Replaces[0].Line != 12 || m.Replaces[1].Module != "example.com/tools" {
+		t.Errorf("unexpected replaces %+v", m.Replaces)
+	}
+}
+
+func TestParsePackageJSONLockfile(t *testing.T) {
+	content := `{
+  "name": "web",
+  "dependencies": {"react": "^18.2.0", "lib": "file:../lib"},
+  "devDependencies": {"jest": "29.7.0"},
+  "peerDependencies": {"react-dom": "*"}
+}`
+	files := map[string]bool{"yarn.lock": true, "packages/web/package.json": true}
+	m := parseDepManifest(content, "packages/web/package.json", files)
+	if m == nil || len(m.Deps) != 3 {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	// workspaces share the lockfile at the root
+	if m.Lockfile != "yarn.lock" || !m.NeedsLock {
+		t.Errorf("expected the root yarn.lock, got %+v", m)
+	}
+	if m.Deps[1].Name != "react" || m.Deps[1].Line != 3 || m.Deps[1].Kind != depRange {
+		t.Errorf("unexpected dependency %+v", m.Deps[1])
+	}
+}
+
+func TestParseCargoToml(t *testing.T) {
+	content := `[package]
+name = "app"
+version = "0.1.0"
+
+[dependencies]
+serde = { version = "1.0", features = ["derive"] }
+tokio = "=1.36.0"
+shared = { path = "../shared" }
+fork = { git = "https://github.com/acme/fork", rev = "abc123" }
+common = { workspace = true }
+
+[dependencies.rand]
+version = "*"
+
+[target.'cfg(unix)'.dev-dependencies]
+nix = "0.27"
+
+[patch.crates-io]
+serde = { path = "../serde" }
+`
+	m := parseDepManifest(content, "Cargo.toml", map[string]bool{})
+	if m == nil {
+		t.Fatal("expected a manifest")
+	}
+	kinds := map[string]string{}
+	for _, dep := range m.Deps {
+		kinds[dep.Name] = dep.Kind
+	}
+	expected := map[string]string{
+		"serde": depRange, "tokio": depPinned, "shared": depLocal, "fork": depVCS, "rand": depUnbounded, "nix": depRange,
+	}
+	if len(kinds) != len(expected) {
+		t.Errorf("unexpected dependencies %+v", m.Deps)
+	}
+	for name, kind := range expected {
+		if kinds[name] != kind {
+			t.Errorf("%s: expected %s, got %s", name, kind, kinds[name])
+		}
+	}
+	if len(m.Replaces) != 1 || m.Replaces[0].Module != "serde" || m.Replaces[0].Path != "../serde" {
+		t.Errorf("unexpected patches %+v", m.Replaces)
+	}
+	if m.Lockfile != "" || !m.NeedsLock {
+		t.Errorf("expected a missing lockfile, got %q", m.Lockfile)
+	}
+}
+
+func TestParsePyproject(t *testing.T) {
+	content := `[project]
+name = "app"
+dependencies = [
+  "requests>=2.31,<3",
+  "click",
+]
+
+[tool.poetry.dependencies]
+python = "^3.11"
+fastapi = "0.110.0"
+httpx = "^0.27"
+lib = { git = "https://github.com/acme/lib.git" }
+`
+	m := parseDepManifest(content, "pyproject.toml", map[string]bool{"poetry.lock": true})
+	if m == nil || len(m.Deps) != 5 || m.Lockfile != "poetry.lock" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	expected := []string{depRange, depUnbounded, depPinned, depRange, depVCS}
+	for i, dep := range m.Deps {
+		if dep.Kind != expected[i] {
+			t.Errorf("%s: expected %s, got %s", dep.Name, expected[i], dep.Kind)
+		}
+	}
+}
+
+func TestParseRequirementsPinned(t *testing.T) {
+	content := "# pinned\n-r base.txt\nflask==3.0.2 \\\n    --hash=sha256:abc\ngunicorn==21.2.0  # server\n"
+	m := parseDepManifest(content, "requirements.txt", map[string]bool{})
+	if m == nil || len(m.Deps) != 2 || m.Deps[1].Line != 5 {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	// a fully pinned requirements file is its own lock
+	if m.NeedsLock {
+		t.Error("expected a pinned requirements file not to need a lockfile")
+	}
+}
+
+func TestParseGemfile(t *testing.T) {
+	content := `source "https://rubygems.org"
+
+gem "rails", "~> 7.1"
+gem 'pg', '1.5.4'
+gem "puma"
+gem "internal", git: "https://github.com/acme/internal.git", branch: "main"
+gem "local", path: "../local"
+`
+	m := parseDepManifest(content, "Gemfile", map[string]bool{"Gemfile.lock": true})
+	if m == nil || len(m.Deps) != 5 || m.Lockfile != "Gemfile.lock" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	expected := []string{depRange, depPinned, depUnbounded, depVCS, depLocal}
+	for i, dep := range m.Deps {
+		if dep.Kind != expected[i] {
+			t.Errorf("%s: expected %s, got %s", dep.Name, expected[i], dep.Kind)
+		}
+	}
+}
+
+func TestParseMavenAndGradle(t *testing.T) {
+	pom := `<project>
+  <dependencies>
+    <dependency>
+      <groupId>com.fasterxml.jackson.core</groupId>
+      <artifactId>jackson-databind</artifactId>
+      <version>2.16.1</version>
+    </dependency>
+  </dependencies>
+</project>
+`
+	m := parseDepManifest(pom, "pom.xml", map[string]bool{})
+	if m == nil || len(m.Deps) != 1 || m.Deps[0].Name != "com.fasterxml.jackson.core:jackson-databind" || m.Deps[0].Line != 3 {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	// exact versions resolve the same way without a lockfile
+	if m.NeedsLock {
+		t.Error("expected pinned Maven versions not to need a lockfile")
+	}
+
+	gradle := `dependencies {
+    implementation("org.springframework.boot:spring-boot-starter-web:3.2.2")
+    implementation 'com.google.guava:guava:32.+'
+    testImplementation(platform("org.junit:junit-bom:5.10.1"))
+}
+`
+	m = parseDepManifest(gradle, "build.gradle.kts", map[string]bool{"gradle.lockfile": true})
+	if m == nil || len(m.Deps) != 3 || m.Deps[1].Kind != depRange || m.Deps[1].Version != "32.+" {
+		t.Fatalf("unexpected manifest %+v", m)
+	}
+	if !m.NeedsLock || m.Lockfile != "gradle.lockfile" {
+		t.Errorf("expected a dynamic version locked by gradle.lockfile, got %+v", m)
+	}
+}
+
+func TestDepManifestEcosystem(t *testing.T) {
+	tests := map[string]string{
+		"go.mod":                      depGo,
+		"web/package.json":            depNPM,
+		"requirements-dev.txt":        depPython,
+		"requirements/base.txt":       depPython,
+		"notes.txt":                   "",
+		"Pipfile":                     depPython,
+		"Cargo.toml":                  depCargo,
+		"Gemfile":                     depRuby,
+		"pom.xml":                     depMaven,
+		"app/build.gradle":            depGradle,
+		"vendor/example.com/x/go.mod": "",
+	}
+	for path, expected := range tests {
+		if got := depManifestEcosystem(path); got != expected {
+			t.Errorf("%s: expected %q, got %q", path, expected, got)
+		}
+	}
+}
@@ -21,3 +21,41 @@ func runAllDetectors(content, relPath string, signals *RepoSignals) {
 		detector(content, relPath, signals)
 	}
 }
+
+// RepoAnalyzerFunc is the signature for analyzers that run once after every
+// file has been scanned and need a view across files
+type RepoAnalyzerFunc func(signals *RepoSignals, opts ScanOptions)
+
+// repoAnalyzerRegistry holds all registered repository analyzers
+var repoAnalyzerRegistry []RepoAnalyzerFunc
+
+// registerRepoAnalyzer adds an analyzer to the registry
+// Call this from init() in detectors.go for each analyzer
+func registerRepoAnalyzer(fn RepoAnalyzerFunc) {
+	repoAnalyzerRegistry = append(repoAnalyzerRegistry, fn)
+}
+
+// runAllRepoAnalyzers executes all registered analyzers in order
+func runAllRepoAnalyzers(signals *RepoSignals, opts ScanOptions) {
+	for _, analyzer := range repoAnalyzerRegistry {
+		analyzer(signals, opts)
+	}
+}
+
+// k8sCheckFunc is the signature for checks over parsed Kubernetes manifests
+type k8sCheckFunc func(sets []k8sManifestSet, signals *RepoSignals)
+
+// k8sCheckRegistry holds all registered Kubernetes checks
+var k8sCheckRegistry []k8sCheckFunc
+
+// registerK8sCheck adds a Kubernetes check to the registry
+func registerK8sCheck(fn k8sCheckFunc) {
+	k8sCheckRegistry = append(k8sCheckRegistry, fn)
+}
+
+// runAllK8sChecks executes all registered Kubernetes checks
+func runAllK8sChecks(sets []k8sManifestSet, signals *RepoSignals) {
+	for _, check := range k8sCheckRegistry {
+		check(sets, signals)
+	}
+}
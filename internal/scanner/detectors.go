@@ -4,14 +4,11 @@ func init() {
 	// Auto-register all detectors
 	// Add new detectors here - just one line per detector!
 	registerDetector(detectSecretsProvider)
+	registerDetector(detectHardcodedSecrets)
 	registerDetector(detectInfrastructure)
 	registerDetector(detectRegions)
-	registerDetector(detectNonRootUser)
 
-	registerDetector(detectK8sDeploymentStrategy)
-	registerDetector(detectK8sProbes)
-	registerDetector(detectIngressRateLimit)
-	registerDetector(detectResourceLimits)
+	registerDetector(detectK8sManifests)
 
 	registerDetector(detectHealthEndpoints)
 	registerDetector(detectCorrelationID)
@@ -27,10 +24,42 @@ func init() {
 
 	registerDetector(detectManualSteps)
 	registerDetector(detectMigrationTool)
-	registerDetector(detectBackwardCompatibleMigration)
 	registerDetector(detectMigrationValidation)
-	registerDetector(detectUnsafeMigration)
 	registerDetector(detectGracefulShutdown)
+
+	// Analyzers that need the whole repository run once after the scan
+	// (Helm and kustomize builds must run before the Kubernetes checks)
+	registerRepoAnalyzer(analyzeHelmCharts)
+	registerRepoAnalyzer(analyzeKustomizations)
+	registerRepoAnalyzer(analyzeK8sManifests)
+	registerRepoAnalyzer(analyzeTerraform)
+	registerRepoAnalyzer(analyzeCloudFormation)
+	registerRepoAnalyzer(analyzePulumi)
+	registerRepoAnalyzer(analyzeCDK)
+	registerRepoAnalyzer(analyzeCompose)
+	registerRepoAnalyzer(analyzeDockerfiles)
+	registerRepoAnalyzer(analyzeCIPipelines)
+	registerRepoAnalyzer(analyzeImageReferences)
+	registerRepoAnalyzer(analyzeDependencies)
+	registerRepoAnalyzer(analyzeVulnerabilities)
+	registerRepoAnalyzer(analyzeMigrations)
+	registerRepoAnalyzer(analyzeAPISpecs)
+	registerRepoAnalyzer(analyzePrometheusRules)
+	registerRepoAnalyzer(analyzeSLOSpecs)
+	registerRepoAnalyzer(analyzeOpenTelemetry)
+	registerRepoAnalyzer(analyzeMetrics)
+
+	// Kubernetes checks run over manifests parsed by detectK8sManifests
+	// and rendered from Helm charts and kustomize overlays
+	registerK8sCheck(checkK8sWorkloads)
+	registerK8sCheck(checkK8sDeploymentStrategy)
+	registerK8sCheck(checkK8sProbes)
+	registerK8sCheck(checkIngressRateLimit)
+	registerK8sCheck(checkResourceLimits)
+	registerK8sCheck(checkPodSecurity)
+	registerK8sCheck(checkK8sAvailability)
+	registerK8sCheck(checkK8sTopologySpread)
+	registerK8sCheck(checkNetworkPolicies)
 }
 
 const (
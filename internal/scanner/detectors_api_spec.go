@@ -0,0 +1,347 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	// /v1/orders, https://api.example.com/v2, orders.v1.created
+	apiVersionPattern = regexp.MustCompile(`(?i)(?:^|[/.:_-])v\d+(?:[/.:_-]|$)`)
+
+	// the static segments of a health or readiness path
+	apiHealthSegments = map[string]bool{"health": true, "healthz": true, "healthcheck": true, "health-check": true, "livez": true, "liveness": true}
+	apiReadySegments  = map[string]bool{"ready": true, "readyz": true, "readiness": true}
+
+	// query parameters that page through a collection, lowercased without
+	// separators
+	apiPaginationParams = map[string]bool{
+		"limit": true, "offset": true, "page": true, "pagesize": true, "perpage": true,
+		"cursor": true, "after": true, "before": true, "pagetoken": true, "nextpagetoken": true,
+		"startingafter": true, "endingbefore": true, "continuationtoken": true,
+		"skip": true, "top": true, "first": true, "last": true, "maxresults": true, "size": true,
+	}
+)
+
+// analyzeAPISpecs parses OpenAPI 2/3 and AsyncAPI documents and checks the
+// contracts they declare: health endpoints, rate-limit responses, versioning,
+// idempotency keys, pagination and authentication. A health endpoint
+// declared by a spec replaces the keyword result of detectHealthEndpoints.
+func analyzeAPISpecs(signals *RepoSignals, opts ScanOptions) {
+	specs := parseAPISpecs(signals, opts)
+	if len(specs) == 0 {
+		return
+	}
+
+	summary := apiSpecSummary{formats: make(map[string]bool), schemeTypes: make(map[string]bool)}
+	for _, spec := range specs {
+		summary.formats[spec.Format] = true
+		for _, scheme := range spec.SecuritySchemes {
+			summary.schemeTypes[scheme.Type] = true
+		}
+		checkAPISpecVersioning(signals, spec, &summary)
+		if spec.Format == apiFormatAsyncAPI {
+			checkAsyncAPIServers(signals, spec)
+			continue
+		}
+		checkAPISpecRateLimits(signals, spec, &summary)
+		checkAPIOperations(signals, spec, &summary)
+	}
+
+	signals.SetBool("api_spec_detected", true)
+	signals.SetInt("api_spec_count", len(specs))
+	signals.SetString("api_spec_formats", joinSet(summary.formats))
+	signals.SetBool("api_rate_limit_documented", summary.rateLimited > 0)
+	signals.SetBool("api_versioned", summary.versioned == len(specs))
+	signals.SetBool("api_security_schemes_defined", len(summary.schemeTypes) > 0)
+	signals.SetString("api_security_schemes", joinSet(summary.schemeTypes))
+	signals.SetBool("api_idempotency_keys_defined", len(signals.GetEvidence("api_post_operations_without_idempotency_key")) == 0)
+	signals.SetBool("api_pagination_defined", len(signals.GetEvidence("api_list_operations_without_pagination")) == 0)
+	setAPISpecHealth(signals, summary.health, summary.ready)
+}
+
+// apiSpecSummary collects what the specs of a repository declare
+type apiSpecSummary struct {
+	formats     map[string]bool
+	schemeTypes map[string]bool
+	health      string // the first health path
+	ready       string // the first readiness path
+	rateLimited int    // specs documenting rate limits
+	versioned   int    // versioned specs
+}
+
+// parseAPISpecs returns the API specs of a repository in path order
+func parseAPISpecs(signals *RepoSignals, opts ScanOptions) []*apiSpec {
+	contents := signals.GetFileContentMap()
+	var files []string
+	for path, content := range contents {
+		if isAPISpecFile(path, content) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+
+	var specs []*apiSpec
+	for _, path := range files {
+		spec, err := parseAPISpec(contents[path], path)
+		if err != nil {
+			if opts.Debug {
+				opts.Logger.Printf("API spec %s: parse failed: %v", path, err)
+			}
+			continue
+		}
+		if spec != nil {
+			specs = append(specs, spec)
+		}
+	}
+	return specs
+}
+
+// checkAPISpecVersioning records a spec without a version
+func checkAPISpecVersioning(signals *RepoSignals, spec *apiSpec, summary *apiSpecSummary) {
+	if apiSpecVersioned(spec) {
+		summary.versioned++
+		return
+	}
+	signals.AddEvidence("api_specs_unversioned", apiSpecEvidence(spec, "no version in the server URLs or paths"))
+}
+
+// checkAsyncAPIServers records AsyncAPI servers without a security
+// requirement
+func checkAsyncAPIServers(signals *RepoSignals, spec *apiSpec) {
+	for _, server := range spec.AsyncServers {
+		if !server.Secured {
+			signals.AddEvidence("api_operations_without_security", Evidence{
+				Subject: "server " + server.Name,
+				File:    spec.File,
+				Line:    server.Line,
+				Detail:  "no security requirement",
+				Context: apiSpecContext(spec),
+			})
+		}
+	}
+}
+
+// checkAPISpecRateLimits records an OpenAPI spec that documents no rate
+// limits
+func checkAPISpecRateLimits(signals *RepoSignals, spec *apiSpec, summary *apiSpecSummary) {
+	if apiRateLimitDocumented(spec) {
+		summary.rateLimited++
+		return
+	}
+	signals.AddEvidence("api_specs_without_rate_limit_responses", apiSpecEvidence(spec, "no 429 response or rate-limit headers"))
+}
+
+// checkAPIOperations notes the health endpoints of an OpenAPI spec and
+// records the other operations that lack authentication, idempotency keys
+// or pagination
+func checkAPIOperations(signals *RepoSignals, spec *apiSpec, summary *apiSpecSummary) {
+	for i := range spec.Operations {
+		op := &spec.Operations[i]
+		switch apiHealthKind(op) {
+		case "/health":
+			if summary.health == "" {
+				summary.health = op.Path
+			}
+			continue
+		case "/ready":
+			if summary.ready == "" {
+				summary.ready = op.Path
+			}
+			continue
+		}
+		if !op.Secured {
+			signals.AddEvidence("api_operations_without_security", apiOperationEvidence(spec, op, "no security requirement"))
+		}
+		if op.Method == "POST" && !apiHasIdempotencyKey(op) {
+			signals.AddEvidence("api_post_operations_without_idempotency_key", apiOperationEvidence(spec, op, "no Idempotency-Key header"))
+		}
+		if apiListOperation(op) && !apiPaginated(op) {
+			signals.AddEvidence("api_list_operations_without_pagination", apiOperationEvidence(spec, op, "returns a list without pagination parameters"))
+		}
+	}
+}
+
+// setAPISpecHealth sets the health endpoint the specs declare, preferring a
+// health path to a readiness path
+func setAPISpecHealth(signals *RepoSignals, health, ready string) {
+	switch {
+	case health != "":
+		signals.SetBool("api_spec_health_endpoint", true)
+		signals.SetString("api_spec_health_path", health)
+		signals.SetString("http_endpoint", "/health")
+	case ready != "":
+		signals.SetBool("api_spec_health_endpoint", true)
+		signals.SetString("api_spec_health_path", ready)
+		signals.SetString("http_endpoint", "/ready")
+	default:
+		signals.SetBool("api_spec_health_endpoint", false)
+	}
+}
+
+// apiHealthKind classifies a GET or HEAD operation on a health or readiness
+// path as "/health" or "/ready", the values of http_endpoint
+func apiHealthKind(op *apiOperation) string {
+	if op.Method != "GET" && op.Method != "HEAD" {
+		return ""
+	}
+	segments := strings.Split(strings.Trim(strings.ToLower(op.Path), "/"), "/")
+	last := segments[len(segments)-1]
+	switch {
+	case apiHealthSegments[last]:
+		return "/health"
+	case apiReadySegments[last]:
+		return "/ready"
+	}
+	return ""
+}
+
+// apiSpecVersioned reports whether a server URL carries a version, or every
+// path or channel does
+func apiSpecVersioned(spec *apiSpec) bool {
+	for _, server := range spec.Servers {
+		if apiVersionPattern.MatchString(server) {
+			return true
+		}
+	}
+	var names []string
+	for i := range spec.Operations {
+		if apiHealthKind(&spec.Operations[i]) == "" {
+			names = append(names, spec.Operations[i].Path)
+		}
+	}
+	for _, channel := range spec.Channels {
+		names = append(names, channel.Name)
+	}
+	if len(names) == 0 {
+		return false
+	}
+	for _, name := range names {
+		if !apiVersionPattern.MatchString(name) {
+			return false
+		}
+	}
+	return true
+}
+
+// apiRateLimitDocumented reports whether any operation declares a 429
+// response or rate-limit headers such as RateLimit-Remaining or Retry-After
+func apiRateLimitDocumented(spec *apiSpec) bool {
+	for _, op := range spec.Operations {
+		for _, response := range op.Responses {
+			if response.Status == "429" {
+				return true
+			}
+			for _, header := range response.Headers {
+				name := apiNormalizedName(header)
+				if strings.Contains(name, "ratelimit") || name == "retryafter" {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// apiHasIdempotencyKey reports whether an operation accepts an idempotency
+// key header, such as Idempotency-Key or X-Idempotency-Key
+func apiHasIdempotencyKey(op *apiOperation) bool {
+	for _, p := range op.Parameters {
+		if p.In == "header" && strings.Contains(apiNormalizedName(p.Name), "idempotencykey") {
+			return true
+		}
+	}
+	return false
+}
+
+// apiListOperation reports whether an operation lists a collection: a GET
+// on a path that does not end in a parameter, returning a list or named
+// like one
+func apiListOperation(op *apiOperation) bool {
+	if op.Method != "GET" || strings.HasSuffix(strings.TrimRight(op.Path, "/"), "}") {
+		return false
+	}
+	id := strings.ToLower(op.OperationID)
+	if strings.HasPrefix(id, "list") || strings.HasPrefix(id, "search") {
+		return true
+	}
+	for _, response := range op.Responses {
+		if strings.HasPrefix(response.Status, "2") && response.Array {
+			return true
+		}
+	}
+	return false
+}
+
+// apiPaginated reports whether an operation takes paging query parameters
+// or returns a Link header
+func apiPaginated(op *apiOperation) bool {
+	for _, p := range op.Parameters {
+		if p.In == "query" && apiPaginationParams[apiNormalizedName(p.Name)] {
+			return true
+		}
+	}
+	for _, response := range op.Responses {
+		for _, header := range response.Headers {
+			if strings.EqualFold(header, "link") {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// apiNormalizedName lowercases a name and drops separators, so that
+// page_size, page-size and pageSize compare equal
+func apiNormalizedName(name string) string {
+	return strings.Map(func(r rune) rune {
+		if r == '-' || r == '_' || r == '.' || r == '[' || r == ']' {
+			return -1
+		}
+		return r
+	}, strings.ToLower(name))
+}
+
+// joinSet returns the members of a set sorted and joined by commas
+func joinSet(set map[string]bool) string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		if name != "" {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return strings.Join(names, ",")
+}
+
+// apiSpecContext describes a spec's format and version
+func apiSpecContext(spec *apiSpec) string {
+	return spec.Format + " " + spec.Version
+}
+
+// apiSpecEvidence builds evidence for a whole API document
+func apiSpecEvidence(spec *apiSpec, detail string) Evidence {
+	subject := spec.Title
+	if subject == "" {
+		subject = spec.File
+	}
+	return Evidence{
+		Subject: subject,
+		File:    spec.File,
+		Line:    spec.Line,
+		Detail:  detail,
+		Context: apiSpecContext(spec),
+	}
+}
+
+// apiOperationEvidence builds evidence for an API operation
+func apiOperationEvidence(spec *apiSpec, op *apiOperation, detail string) Evidence {
+	return Evidence{
+		Subject: op.Method + " " + op.Path,
+		File:    spec.File,
+		Line:    op.Line,
+		Detail:  detail,
+		Context: apiSpecContext(spec),
+	}
+}
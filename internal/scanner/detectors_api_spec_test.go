@@ -0,0 +1,200 @@
+package scanner
+
+import (
+	"testing"
+)
+
+var testAPISpecFiles = map[string]string{
+	"api/openapi.yaml": `openapi: 3.0.3
+info:
+  title: Orders API
+servers:
+  - url: https://api.example.com/v1
+security:
+  - bearerAuth: []
+components:
+  securitySchemes:
+    bearerAuth:
+      type: http
+      scheme: bearer
+  parameters:
+    Limit:
+      name: limit
+      in: query
+  responses:
+    TooManyRequests:
+      description: slow down
+      headers:
+        Retry-After:
+          schema:
+            type: integer
+  schemas:
+    OrderPage:
+      type: object
+      properties:
+        items:
+          type: array
+paths:
+  /healthz:
+    get:
+      security: []
+      responses:
+        "200":
+          description: ok
+  /orders:
+    get:
+      operationId: listOrders
+      parameters:
+        - $ref: "#/components/parameters/Limit"
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                $ref: "#/components/schemas/OrderPage"
+        "429":
+          $ref: "#/components/responses/TooManyRequests"
+    post:
+      parameters:
+        - name: Idempotency-Key
+          in: header
+      responses:
+        "201":
+          description: created
+  /customers:
+    get:
+      responses:
+        "200":
+          content:
+            application/json:
+              schema:
+                type: array
+    post:
+      security: []
+      responses:
+        "201":
+          description: created
+`,
+	"legacy/swagger.json": `{
+  "swagger": "2.0",
+  "info": {"title": "Legacy API"},
+  "basePath": "/api",
+  "paths": {
+    "/users": {
+      "get": {
+        "operationId": "getUsers",
+        "responses": {"200": {"schema": {"type": "array"}}}
+      }
+    }
+  }
+}
+`,
+	"events/asyncapi.yaml": `asyncapi: 2.6.0
+info:
+  title: Order events
+servers:
+  production:
+    url: kafka.example.com:9092
+    protocol: kafka
+channels:
+  orders.v1.created:
+    subscribe:
+      message:
+        name: OrderCreated
+`,
+	// a health keyword in a package manifest is not an API spec
+	"package.json": `{"name": "svc", "scripts": {"healthcheck": "node health.js"}}`,
+}
+
+func TestParseAPISpec(t *testing.T) {
+	spec, err := parseAPISpec(testAPISpecFiles["api/openapi.yaml"], "api/openapi.yaml")
+	if err != nil || spec == nil {
+		t.Fatalf("expected a spec, got %v, %v", spec, err)
+	}
+	if spec.Format != apiFormatOpenAPI || spec.Version != "3.0.3" || spec.Title != "Orders API" {
+		t.Errorf("unexpected header: %+v", spec)
+	}
+	if len(spec.Operations) != 5 {
+		t.Fatalf("expected 5 operations, got %d", len(spec.Operations))
+	}
+	list := spec.Operations[1]
+	if list.Method != "GET" || list.Path != "/orders" || list.Line != 38 {
+		t.Errorf("unexpected operation: %+v", list)
+	}
+	if len(list.Parameters) != 1 || list.Parameters[0].Name != "limit" {
+		t.Errorf("expected the $ref parameter to resolve, got %+v", list.Parameters)
+	}
+	if !list.Secured || spec.Operations[0].Secured {
+		t.Error("expected global security to apply unless an operation clears it")
+	}
+	if len(list.Responses) != 2 || !list.Responses[0].Array || len(list.Responses[1].Headers) != 1 {
+		t.Errorf("unexpected responses: %+v", list.Responses)
+	}
+
+	if spec, _ := parseAPISpec(testAPISpecFiles["package.json"], "package.json"); spec != nil {
+		t.Errorf("expected package.json not to be a spec, got %+v", spec)
+	}
+}
+
+func TestAnalyzeAPISpecs(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent:   testAPISpecFiles,
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeAPISpecs(signals, ScanOptions{})
+
+	if got := signals.GetInt("api_spec_count"); got != 3 {
+		t.Errorf("expected 3 specs, got %d", got)
+	}
+	if got := signals.GetString("api_spec_formats"); got != "asyncapi,openapi,swagger" {
+		t.Errorf("unexpected formats %q", got)
+	}
+	if got := signals.GetString("api_spec_health_path"); got != "/healthz" {
+		t.Errorf("expected /healthz, got %q", got)
+	}
+	if got := signals.GetString("http_endpoint"); got != "/health" {
+		t.Errorf("expected http_endpoint /health, got %q", got)
+	}
+	if !signals.GetBool("api_rate_limit_documented") || !signals.GetBool("api_security_schemes_defined") {
+		t.Error("expected rate limits and security schemes to be documented")
+	}
+	if got := signals.GetString("api_security_schemes"); got != "http" {
+		t.Errorf("unexpected security schemes %q", got)
+	}
+
+	tests := []struct {
+		key  string
+		want []string
+	}{
+		{"api_specs_unversioned", []string{"Legacy API"}},
+		{"api_specs_without_rate_limit_responses", []string{"Legacy API"}},
+		{"api_post_operations_without_idempotency_key", []string{"POST /customers"}},
+		{"api_list_operations_without_pagination", []string{"GET /customers", "GET /users"}},
+		{"api_operations_without_security", []string{"POST /customers", "server production", "GET /users"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			if len(evidence) != len(tt.want) {
+				t.Fatalf("expected %v, got %+v", tt.want, evidence)
+			}
+			for i, want := range tt.want {
+				if evidence[i].Subject != want {
+					t.Errorf("expected %q, got %+v", want, evidence[i])
+				}
+			}
+		})
+	}
+}
+
+func TestDetectHealthEndpointsSkipsAPISpecs(t *testing.T) {
+	signals := &RepoSignals{
+		StringSignals: make(map[string]string),
+	}
+	detectHealthEndpoints("openapi: 3.0.0\ninfo:\n  description: see /health in the runbook\n", "openapi.yaml", signals)
+	if got := signals.GetString("http_endpoint"); got != "" {
+		t.Errorf("expected no endpoint from a spec, got %q", got)
+	}
+}
@@ -6,7 +6,9 @@ import (
 	"github.com/chuanjin/production-readiness/internal/patterns"
 )
 
-// detectArtifactVersioning checks for versioned artifact patterns
+// detectArtifactVersioning checks for versioned artifact patterns. When the
+// repository references images, analyzeImageReferences decides the signal
+// from their tags instead.
 func detectArtifactVersioning(content, relPath string, signals *RepoSignals) {
 	if signals.GetBool("versioned_artifacts") {
 		return
@@ -34,8 +36,13 @@ func detectArtifactVersioning(content, relPath string, signals *RepoSignals) {
 	}
 }
 
-// detectHealthEndpoints checks for health check HTTP endpoints
+// detectHealthEndpoints checks for health check HTTP endpoints. API specs
+// are skipped: analyzeAPISpecs takes the endpoint from the paths they declare.
 func detectHealthEndpoints(content, relPath string, signals *RepoSignals) {
+	if isAPISpecFile(relPath, content) {
+		return
+	}
+
 	contentLower := strings.ToLower(content)
 
 	// Detect /health endpoint
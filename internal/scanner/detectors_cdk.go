@@ -0,0 +1,117 @@
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// cdkProgramMarkers identify source files that define CDK constructs
+var cdkProgramMarkers = []string{"aws-cdk-lib", "@aws-cdk/", "aws_cdk", "github.com/aws/aws-cdk-go"}
+
+// cdkRegionPattern matches an explicit stack environment region, e.g.
+// env: { region: 'eu-west-1' } or Region: jsii.String("eu-west-1")
+var cdkRegionPattern = regexp.MustCompile(`(?i)\bregion["']?\s*[:=]\s*(?:jsii\.String\(\s*)?["']([a-z0-9-]+)["']`)
+
+// analyzeCDK recognizes AWS CDK apps, extracts the constructs their code
+// defines and the regions of their stacks. Synthesized templates in cdk.out
+// are evaluated by the CloudFormation analyzer.
+func analyzeCDK(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+
+	var appFiles []string
+	for path := range contents {
+		if filepath.Base(path) == "cdk.json" {
+			appFiles = append(appFiles, path)
+		}
+	}
+	if len(appFiles) == 0 {
+		return
+	}
+	sort.Strings(appFiles)
+
+	constructs, synthesized := 0, 0
+	for _, appFile := range appFiles {
+		var app struct {
+			Output string `json:"output"`
+		}
+		_ = json.Unmarshal([]byte(contents[appFile]), &app)
+		dir := filepath.Dir(appFile)
+		outDir := filepath.Join(dir, "cdk.out")
+		if app.Output != "" {
+			outDir = filepath.Join(dir, app.Output)
+		}
+
+		for _, path := range filesUnder(contents, dir) {
+			if strings.HasPrefix(path, outDir+string(filepath.Separator)) {
+				if strings.HasSuffix(path, ".template.json") {
+					if _, ok := parseCFNTemplate(contents[path], path); ok {
+						synthesized++
+					}
+				} else if filepath.Base(path) == "manifest.json" {
+					checkCDKManifestRegions(contents[path], path, signals)
+				}
+				continue
+			}
+			if !containsAny(contents[path], cdkProgramMarkers) {
+				continue
+			}
+
+			decls := extractIaCDeclarations(contents[path], path)
+			for i := range decls {
+				signals.AddEvidence("cdk_constructs", Evidence{Subject: decls[i].Name, File: decls[i].File, Line: decls[i].Line, Detail: decls[i].Type})
+			}
+			constructs += len(decls)
+			checkCDKCodeRegions(contents[path], path, signals)
+		}
+	}
+
+	signals.SetBool("cdk_detected", true)
+	signals.SetBool("infra_as_code_detected", true)
+	signals.SetInt("cdk_construct_count", constructs)
+	signals.SetInt("cdk_synthesized_template_count", synthesized)
+	signals.SetInt("region_count", signals.GetRegionCount())
+}
+
+// checkCDKCodeRegions records regions stacks are explicitly deployed to
+func checkCDKCodeRegions(content, relPath string, signals *RepoSignals) {
+	for _, m := range cdkRegionPattern.FindAllStringSubmatchIndex(content, -1) {
+		region, ok := knownRegion(content[m[2]:m[3]])
+		if !ok {
+			continue
+		}
+		signals.SetRegion(region)
+		signals.AddEvidence("cdk_regions", Evidence{
+			Subject: "stack env",
+			File:    relPath,
+			Line:    strings.Count(content[:m[0]], "\n") + 1,
+			Detail:  region,
+		})
+	}
+}
+
+// checkCDKManifestRegions records the regions of synthesized stacks, whose
+// cloud assembly manifest holds environments like aws://123456789012/eu-west-1
+func checkCDKManifestRegions(content, relPath string, signals *RepoSignals) {
+	var manifest struct {
+		Artifacts map[string]struct {
+			Type        string `json:"type"`
+			Environment string `json:"environment"`
+		} `json:"artifacts"`
+	}
+	if json.Unmarshal([]byte(content), &manifest) != nil {
+		return
+	}
+	for name, artifact := range manifest.Artifacts {
+		if artifact.Type != "aws:cloudformation:stack" {
+			continue
+		}
+		env := artifact.Environment
+		if region, ok := knownRegion(env[strings.LastIndex(env, "/")+1:]); ok {
+			signals.SetRegion(region)
+			signals.AddEvidence("cdk_regions", Evidence{Subject: name, File: relPath, Detail: region})
+		}
+	}
+}
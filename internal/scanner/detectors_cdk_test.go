@@ -0,0 +1,45 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzeCDK(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"cdk.json": `{"app": "npx ts-node bin/app.ts"}`,
+			"bin/app.ts": `import * as cdk from 'aws-cdk-lib';
+new ApiStack(app, 'ApiStack', { env: { region: 'eu-central-1' } });
+`,
+			"lib/api-stack.ts": `import { aws_rds as rds } from 'aws-cdk-lib';
+
+export class ApiStack extends cdk.Stack {
+  constructor(scope: Construct, id: string, props?: cdk.StackProps) {
+    super(scope, id, props);
+    new rds.DatabaseInstance(this, 'Db', { multiAz: true });
+    new lambda.Function(this, 'Handler', {});
+  }
+}
+`,
+			"cdk.out/manifest.json":          `{"artifacts": {"ApiStack": {"type": "aws:cloudformation:stack", "environment": "aws://123456789012/us-west-2"}}}`,
+			"cdk.out/ApiStack.template.json": `{"Resources": {"Db": {"Type": "AWS::RDS::DBInstance", "Properties": {"MultiAZ": true}}}}`,
+		},
+		BoolSignals:     make(map[string]bool),
+		IntSignals:      make(map[string]int),
+		DetectedRegions: make(map[string]bool),
+	}
+	analyzeCDK(signals, ScanOptions{})
+
+	if !signals.GetBool("cdk_detected") || !signals.GetBool("infra_as_code_detected") {
+		t.Error("expected the CDK app to count as IaC")
+	}
+	if got := signals.GetInt("cdk_construct_count"); got != 2 {
+		t.Errorf("expected 2 constructs, got %d: %+v", got, signals.GetEvidence("cdk_constructs"))
+	}
+	if got := signals.GetInt("cdk_synthesized_template_count"); got != 1 {
+		t.Errorf("expected 1 synthesized template, got %d", got)
+	}
+	if got := signals.GetInt("region_count"); got != 2 {
+		t.Errorf("expected regions from the code and the cloud assembly, got %d", got)
+	}
+}
@@ -0,0 +1,343 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"github.com/chuanjin/production-readiness/internal/patterns"
+)
+
+// commitSHAPattern matches a full commit SHA, the only immutable action ref
+var commitSHAPattern = regexp.MustCompile(`^[0-9a-f]{40}$`)
+
+// firstPartyActionOwners publish actions maintained by the CI platform itself
+var firstPartyActionOwners = map[string]bool{"actions": true, "github": true}
+
+// nonProdEnvironmentWords mark deploy jobs that do not reach production
+var nonProdEnvironmentWords = []string{"dev", "development", "staging", "stage", "test", "testing", "qa", "uat", "preview", "review", "sandbox", "demo"}
+
+// analyzeCIPipelines parses GitHub Actions workflows, GitLab CI pipelines and
+// Jenkinsfiles and checks how they test and deploy
+func analyzeCIPipelines(signals *RepoSignals, opts ScanOptions) {
+	contents := signals.GetFileContentMap()
+	var files []string
+	for path := range contents {
+		if ciPlatform(path) != "" {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+
+	var pipelines []*ciPipeline
+	platforms := make(map[string]bool)
+	for _, path := range files {
+		p, err := parseCIPipeline(contents[path], path)
+		if err != nil {
+			signals.AddEvidence("ci_parse_errors", Evidence{Subject: path, File: path, Detail: err.Error()})
+			if opts.Debug {
+				opts.Logger.Printf("CI pipeline %s: parse failed: %v", path, err)
+			}
+			continue
+		}
+		if p == nil || len(p.Jobs) == 0 {
+			continue
+		}
+		pipelines = append(pipelines, p)
+		platforms[p.Platform] = true
+	}
+	if len(pipelines) == 0 {
+		return
+	}
+
+	names := make([]string, 0, len(platforms))
+	for platform := range platforms {
+		names = append(names, platform)
+	}
+	sort.Strings(names)
+	signals.SetBool("ci_detected", true)
+	signals.SetInt("ci_pipeline_count", len(pipelines))
+	signals.SetString("ci_platforms", strings.Join(names, ","))
+
+	deploys := 0
+	tested, approved, environments := true, true, true
+	for _, p := range pipelines {
+		for i := range p.Jobs {
+			job := &p.Jobs[i]
+			checkCIActionPins(p, job, signals)
+			checkCIIgnoredTests(p, job, signals)
+			checkCIProgressiveDelivery(p, job, signals)
+
+			if !job.isDeploy() {
+				continue
+			}
+			deploys++
+			target := "deploys"
+			if job.Environment != "" {
+				target = "deploys to " + job.Environment
+			}
+			signals.AddEvidence("ci_deploy_jobs", ciEvidence(p, job, job.Line, target))
+
+			if !p.testedBefore(job) {
+				tested = false
+				signals.AddEvidence("ci_deploys_without_tests", ciEvidence(p, job, job.Line, "no test job or step runs before the deploy"))
+			}
+			if !checkCIApproval(p, job, signals) {
+				approved = false
+			}
+			// Jenkins has no deployment environments to protect
+			if p.Platform != ciJenkins && job.Environment == "" {
+				environments = false
+				signals.AddEvidence("ci_deploys_without_environment", ciEvidence(p, job, job.Line, "no environment, so environment protection rules cannot apply"))
+			}
+		}
+	}
+
+	signals.SetBool("ci_deploy_job_detected", deploys > 0)
+	signals.SetInt("ci_deploy_job_count", deploys)
+	if deploys > 0 {
+		signals.SetBool("ci_tests_before_deploy", tested)
+		signals.SetBool("ci_deploy_approval_required", approved)
+		signals.SetBool("ci_deploy_environments", environments)
+	}
+	signals.SetInt("ci_deploy_reviewers_unverifiable_count", len(signals.GetEvidence("ci_deploys_reviewers_unverifiable")))
+	signals.SetBool("ci_actions_pinned", len(signals.GetEvidence("ci_unpinned_actions")) == 0)
+	signals.SetBool("ci_test_failures_ignored", len(signals.GetEvidence("ci_tests_allowed_to_fail")) > 0)
+	signals.SetBool("ci_progressive_delivery", len(signals.GetEvidence("ci_progressive_delivery_steps")) > 0)
+}
+
+// checkCIActionPins reports third-party actions and reusable workflows that
+// are referenced by a tag or branch instead of a commit SHA
+func checkCIActionPins(p *ciPipeline, job *ciJob, signals *RepoSignals) {
+	for i := range job.Steps {
+		uses := job.Steps[i].Uses
+		if uses == "" || strings.HasPrefix(uses, "./") {
+			continue
+		}
+		if image, ok := strings.CutPrefix(uses, "docker://"); ok {
+			if _, _, digest := splitImageRef(image); digest == "" {
+				signals.AddEvidence("ci_unpinned_actions", ciEvidence(p, job, job.Steps[i].Line, uses+" is not pinned by digest"))
+			}
+			continue
+		}
+		action, ref, _ := strings.Cut(uses, "@")
+		owner, _, _ := strings.Cut(action, "/")
+		if firstPartyActionOwners[owner] || commitSHAPattern.MatchString(ref) {
+			continue
+		}
+		signals.AddEvidence("ci_unpinned_actions", ciEvidence(p, job, job.Steps[i].Line, uses+" is not pinned to a commit SHA"))
+	}
+}
+
+// checkCIIgnoredTests reports test jobs and steps whose failures do not fail
+// the pipeline
+func checkCIIgnoredTests(p *ciPipeline, job *ciJob, signals *RepoSignals) {
+	if job.AllowFailure && job.isTest() {
+		detail := "continue-on-error on a test job"
+		if p.Platform == ciGitLab {
+			detail = "allow_failure on a test job"
+		}
+		signals.AddEvidence("ci_tests_allowed_to_fail", ciEvidence(p, job, job.Line, detail))
+		return
+	}
+	for i := range job.Steps {
+		step := &job.Steps[i]
+		if !step.ContinueOnError || !step.isTest() {
+			continue
+		}
+		detail := "continue-on-error on a test step"
+		if p.Platform == ciJenkins {
+			detail = "test failures are caught and ignored"
+		}
+		signals.AddEvidence("ci_tests_allowed_to_fail", ciEvidence(p, job, step.Line, detail))
+	}
+}
+
+// checkCIProgressiveDelivery records canary and blue/green rollout steps
+func checkCIProgressiveDelivery(p *ciPipeline, job *ciJob, signals *RepoSignals) {
+	for i := range job.Steps {
+		step := &job.Steps[i]
+		text := strings.ToLower(job.Name + " " + step.Name + " " + step.Uses + " " + step.Run)
+		for _, pattern := range patterns.ProgressiveDeliveryPatterns {
+			if strings.Contains(text, pattern) {
+				signals.AddEvidence("ci_progressive_delivery_steps", ciEvidence(p, job, step.Line, pattern))
+				break
+			}
+		}
+	}
+}
+
+// checkCIApproval records a production deploy job that does not wait for a
+// manual approval, and reports whether it is gated. GitHub approvals are
+// required reviewers on the job's environment, which are configured in the
+// repository settings: an environment is not a gate by itself, and a job
+// that relies on one is also recorded as unverifiable.
+func checkCIApproval(p *ciPipeline, job *ciJob, signals *RepoSignals) bool {
+	if job.nonProduction() || ciApprovalGate(p, job) {
+		return true
+	}
+	detail := "runs without a manual approval"
+	if job.Dispatched {
+		detail = "runs when dispatched by hand, which is not an approval by a second person"
+	}
+	if p.Platform == ciGitHubActions && job.Environment != "" {
+		detail = "runs without a manual approval step; environment " + job.Environment + " may require reviewers"
+		signals.AddEvidence("ci_deploys_reviewers_unverifiable", ciEvidence(p, job, job.Line,
+			"environment "+job.Environment+" set, required reviewers cannot be verified from the repository"))
+	}
+	signals.AddEvidence("ci_deploys_without_approval", ciEvidence(p, job, job.Line, detail))
+	return false
+}
+
+// ciApprovalGate reports whether a deploy job waits for a person: a manual
+// job, an approval action, or a manual job it depends on
+func ciApprovalGate(p *ciPipeline, job *ciJob) bool {
+	if job.Manual {
+		return true
+	}
+	for i := range job.Steps {
+		if strings.Contains(strings.ToLower(job.Steps[i].Uses), "approval") {
+			return true
+		}
+	}
+	// Approval jobs gate the jobs that need them and, in Jenkins, every later stage
+	for i := range p.Jobs {
+		other := &p.Jobs[i]
+		if other == job || !other.Manual || other.isDeploy() {
+			continue
+		}
+		if (p.Platform == ciJenkins && other.Order < job.Order) || p.dependsOn(job, other.Name, make(map[string]bool)) {
+			return true
+		}
+	}
+	return false
+}
+
+// testedBefore reports whether tests run before the deploy job deploys,
+// in an earlier job or in an earlier step of the same job
+func (p *ciPipeline) testedBefore(deploy *ciJob) bool {
+	for i := range p.Jobs {
+		other := &p.Jobs[i]
+		if other != deploy && other.isTest() && p.runsBefore(other, deploy) {
+			return true
+		}
+	}
+	for i := range deploy.Steps {
+		step := &deploy.Steps[i]
+		if step.isDeploy() {
+			return false
+		}
+		if step.isTest() {
+			return true
+		}
+	}
+	return false
+}
+
+// isDeploy reports whether a job deploys: by its name, stage or environment,
+// or because one of its steps deploys
+func (j *ciJob) isDeploy() bool {
+	if j.Environment != "" || hasWordPrefix(j.Name+" "+j.Stage, "deploy", "rollout", "promote") {
+		return true
+	}
+	for i := range j.Steps {
+		if j.Steps[i].isDeploy() {
+			return true
+		}
+	}
+	return false
+}
+
+// isTest reports whether a job runs tests
+func (j *ciJob) isTest() bool {
+	if hasWordPrefix(j.Name+" "+j.Stage, "test") {
+		return true
+	}
+	for i := range j.Steps {
+		if j.Steps[i].isTest() {
+			return true
+		}
+	}
+	return false
+}
+
+// nonProduction reports whether a deploy job targets a development,
+// staging or preview environment
+func (j *ciJob) nonProduction() bool {
+	target := j.Environment
+	if target == "" {
+		target = j.Name
+	}
+	return nonProductionName(target)
+}
+
+// nonProductionName reports whether a name, such as an environment,
+// namespace or path, has a word naming a non-production environment
+func nonProductionName(name string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToLower(name), isNotAlphanumeric) {
+		for _, nonProd := range nonProdEnvironmentWords {
+			if word == nonProd {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isDeploy reports whether a step runs a deploy command or deploy action
+func (s *ciStep) isDeploy() bool {
+	if strings.Contains(strings.ToLower(s.Uses), "deploy") {
+		return true
+	}
+	return containsCommand(strings.ToLower(s.Run), patterns.CIDeployCommands)
+}
+
+// isTest reports whether a step runs tests
+func (s *ciStep) isTest() bool {
+	return hasWordPrefix(s.Name, "test") || containsCommand(strings.ToLower(s.Run), patterns.CITestCommands)
+}
+
+// containsCommand reports whether text contains one of the commands as
+// whole words, so that "jest" does not match "suggest"
+func containsCommand(text string, commands []string) bool {
+	for _, command := range commands {
+		for offset := 0; ; {
+			idx := strings.Index(text[offset:], command)
+			if idx < 0 {
+				break
+			}
+			start, end := offset+idx, offset+idx+len(command)
+			before := start == 0 || isNotAlphanumeric(rune(text[start-1]))
+			after := end == len(text) || isNotAlphanumeric(rune(text[end]))
+			if before && after {
+				return true
+			}
+			offset = end
+		}
+	}
+	return false
+}
+
+// hasWordPrefix reports whether a word of text starts with one of the
+// prefixes, e.g. "unit-tests" has a word starting with "test"
+func hasWordPrefix(text string, prefixes ...string) bool {
+	for _, word := range strings.FieldsFunc(strings.ToLower(text), isNotAlphanumeric) {
+		for _, prefix := range prefixes {
+			if strings.HasPrefix(word, prefix) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isNotAlphanumeric separates words in job names and commands
+func isNotAlphanumeric(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+}
+
+// ciEvidence builds evidence for a CI job
+func ciEvidence(p *ciPipeline, job *ciJob, line int, detail string) Evidence {
+	return Evidence{Subject: job.Name, File: p.File, Line: line, Detail: detail, Context: p.Platform}
+}
@@ -0,0 +1,273 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+const testGitHubWorkflow = `name: release
+on:
+  push:
+    branches: [main]
+jobs:
+  test:
+    runs-on: ubuntu-latest
+    steps:
+      - uses: actions/checkout@v4
+      - uses: golangci/golangci-lint-action@v6
+      - run: go test ./...
+        continue-on-error: true
+  deploy:
+    needs: [test]
+    runs-on: ubuntu-latest
+    environment: production
+    steps:
+      - uses: aws-actions/configure-aws-credentials@e3dd6a429d7300a6a4c196c26e071d42e0343502
+      - run: kubectl argo rollouts set image api api=registry/api:${{ github.sha }}
+  hotfix:
+    runs-on: ubuntu-latest
+    steps:
+      - run: helm upgrade --install api ./chart
+`
+
+const testGitLabCI = `stages: [build, test, deploy]
+
+.deploy:
+  stage: deploy
+  script:
+    - helm upgrade --install api ./chart
+
+unit:
+  stage: test
+  script: make test
+  allow_failure: true
+
+deploy_staging:
+  extends: .deploy
+  environment: staging
+
+deploy_production:
+  extends: .deploy
+  environment:
+    name: production
+  when: manual
+
+migrate:
+  stage: build
+  needs: []
+  script:
+    - terraform apply -auto-approve
+`
+
+const testJenkinsfile = `pipeline {
+  agent any
+  stages {
+    stage('Build') {
+      steps { sh 'make build' }
+    }
+    stage('Deploy') {
+      steps { sh 'kubectl apply -f k8s/' }
+    }
+    stage('Checks') {
+      parallel {
+        stage('Unit Tests') {
+          steps {
+            catchError(buildResult: 'SUCCESS') { sh 'go test ./...' }
+          }
+        }
+      }
+    }
+    stage('Approve') {
+      steps { input message: 'Ship to production?' }
+    }
+    stage('Release') {
+      steps { sh 'helm upgrade api ./chart' }
+    }
+  }
+}
+`
+
+func TestParseCIPipelinesGitHub(t *testing.T) {
+	gh, err := parseCIPipeline(testGitHubWorkflow, ".github/workflows/release.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gh.Jobs) != 3 || gh.Jobs[1].Environment != "production" || gh.Jobs[1].Needs[0] != "test" {
+		t.Fatalf("unexpected GitHub jobs %+v", gh.Jobs)
+	}
+	if step := gh.Jobs[0].Steps[2]; !step.ContinueOnError || step.Line != 11 {
+		t.Errorf("unexpected test step %+v", step)
+	}
+}
+
+func TestParseCIPipelinesGitLab(t *testing.T) {
+	gl, err := parseCIPipeline(testGitLabCI, ".gitlab-ci.yml")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(gl.Jobs) != 4 {
+		t.Fatalf("expected 4 GitLab jobs (templates skipped), got %+v", gl.Jobs)
+	}
+	prod := gl.job("deploy_production")
+	if prod.Stage != "deploy" || prod.Environment != "production" || !prod.Manual || len(prod.Steps) != 1 {
+		t.Errorf("expected extends to be resolved, got %+v", prod)
+	}
+	if !gl.runsBefore(gl.job("unit"), prod) || gl.runsBefore(gl.job("unit"), gl.job("migrate")) {
+		t.Error("unexpected GitLab job ordering")
+	}
+}
+
+func TestParseCIPipelinesJenkins(t *testing.T) {
+	jenkins, err := parseCIPipeline(testJenkinsfile, "Jenkinsfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	var names []string
+	for _, job := range jenkins.Jobs {
+		names = append(names, job.Name)
+	}
+	if len(names) != 5 || names[2] != "Unit Tests" {
+		t.Fatalf("expected the leaf stages in order, got %v", names)
+	}
+	if !jenkins.Jobs[3].Manual || jenkins.Jobs[3].Line != 19 {
+		t.Errorf("unexpected approval stage %+v", jenkins.Jobs[3])
+	}
+}
+
+func TestParseJenkinsfileComments(t *testing.T) {
+	jenkins, err := parseCIPipeline(`pipeline {
+  stages {
+    stage('Build') {
+      // don't skip the linter { here }
+      /* it's slow, but it's worth it */
+      steps { sh 'make lint build' }
+    }
+    stage('Release') {
+      steps { sh 'helm upgrade api ./chart' }
+    }
+  }
+}
+`, "Jenkinsfile")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(jenkins.Jobs) != 2 || jenkins.Jobs[1].Name != "Release" || strings.Contains(jenkins.Jobs[0].Steps[0].Run, "helm") {
+		t.Errorf("expected comment quotes and braces to be ignored, got %+v", jenkins.Jobs)
+	}
+
+	huge := "pipeline {\n  stages {\n    stage('Build') {\n" + strings.Repeat("      sh 'make'\n", jenkinsMaxBlock/10) + "    }\n  }\n}\n"
+	if _, err := parseCIPipeline(huge, "Jenkinsfile"); err == nil || !strings.Contains(err.Error(), `stage "Build" at line 3`) {
+		t.Errorf("expected an error for a stage over the size limit, got %v", err)
+	}
+}
+
+func TestAnalyzeCIPipelinesDispatch(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			".github/workflows/deploy.yml": `on: workflow_dispatch
+jobs:
+  deploy:
+    runs-on: ubuntu-latest
+    steps:
+      - run: make test
+      - run: helm upgrade --install api ./chart
+`,
+		},
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeCIPipelines(signals, ScanOptions{})
+
+	// starting a workflow by hand is not an approval by a second person
+	if signals.GetBool("ci_deploy_approval_required") {
+		t.Error("expected a workflow_dispatch trigger not to count as an approval gate")
+	}
+	if ev := signals.GetEvidence("ci_deploys_without_approval"); len(ev) != 1 || !strings.Contains(ev[0].Detail, "dispatched by hand") {
+		t.Errorf("unexpected approval evidence %+v", ev)
+	}
+}
+
+func TestAnalyzeCIPipelines(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			".github/workflows/release.yml": testGitHubWorkflow,
+			".gitlab-ci.yml":                testGitLabCI,
+			"Jenkinsfile":                   testJenkinsfile,
+		},
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeCIPipelines(signals, ScanOptions{})
+
+	if got := signals.GetString("ci_platforms"); got != "github-actions,gitlab-ci,jenkins" {
+		t.Errorf("unexpected platforms %q", got)
+	}
+	if got := signals.GetInt("ci_deploy_job_count"); got != 7 {
+		t.Errorf("expected 7 deploy jobs, got %d", got)
+	}
+
+	tests := []struct {
+		key      string
+		subjects []string
+	}{
+		{"ci_deploys_without_tests", []string{"hotfix", "migrate", "Deploy"}},
+		{"ci_deploys_without_approval", []string{"deploy", "hotfix", "migrate", "Deploy"}},
+		{"ci_deploys_reviewers_unverifiable", []string{"deploy"}},
+		{"ci_deploys_without_environment", []string{"hotfix", "migrate"}},
+		{"ci_unpinned_actions", []string{"test"}},
+		{"ci_tests_allowed_to_fail", []string{"test", "unit", "Unit Tests"}},
+		{"ci_progressive_delivery_steps", []string{"deploy"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			subjects := make(map[string]bool)
+			for _, ev := range evidence {
+				subjects[ev.Subject] = true
+			}
+			if len(evidence) != len(tt.subjects) {
+				t.Fatalf("expected %v, got %+v", tt.subjects, evidence)
+			}
+			for _, subject := range tt.subjects {
+				if !subjects[subject] {
+					t.Errorf("expected evidence for %s, got %+v", subject, evidence)
+				}
+			}
+		})
+	}
+
+	if got := signals.GetInt("ci_deploy_reviewers_unverifiable_count"); got != 1 {
+		t.Errorf("expected the GitHub production environment to be unverifiable, got %d", got)
+	}
+	for key, want := range map[string]bool{
+		"ci_deploy_job_detected":      true,
+		"ci_tests_before_deploy":      false,
+		"ci_deploy_approval_required": false,
+		"ci_actions_pinned":           false,
+		"ci_test_failures_ignored":    true,
+		"ci_progressive_delivery":     true,
+	} {
+		if got := signals.GetBool(key); got != want {
+			t.Errorf("expected %s = %v, got %v", key, want, got)
+		}
+	}
+}
+
+func TestContainsCommand(t *testing.T) {
+	commands := []string{"jest", "go test"}
+	tests := map[string]bool{
+		"npx jest --ci":               true,
+		"go test ./...":               true,
+		"echo suggest":                false,
+		"cargo test && gotest":        false,
+		"run the tests with jest.":    true,
+		"go testing is not a command": false,
+	}
+	for text, want := range tests {
+		if got := containsCommand(text, commands); got != want {
+			t.Errorf("containsCommand(%q) = %v, want %v", text, got, want)
+		}
+	}
+}
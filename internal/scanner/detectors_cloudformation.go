@@ -0,0 +1,350 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+)
+
+// analyzeCloudFormation parses every CloudFormation/SAM template in the
+// repository and derives signals from the declared resources
+func analyzeCloudFormation(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var templates []*cfnTemplate
+	for _, path := range paths {
+		if tmpl, ok := parseCFNTemplate(contents[path], path); ok {
+			templates = append(templates, tmpl)
+		}
+	}
+	checkCFNTemplates(templates, signals)
+}
+
+// checkCFNTemplates runs the CloudFormation checks over parsed templates
+func checkCFNTemplates(templates []*cfnTemplate, signals *RepoSignals) {
+	if len(templates) == 0 {
+		return
+	}
+	signals.SetBool("infra_as_code_detected", true)
+	signals.SetInt("cfn_template_count", signals.GetInt("cfn_template_count")+len(templates))
+
+	for _, tmpl := range templates {
+		checkCFNAPIThrottling(tmpl, signals)
+		checkCFNLambda(tmpl, signals)
+		checkCFNDynamoDB(tmpl, signals)
+		checkCFNRDS(tmpl, signals)
+		checkCFNDeletionPolicy(tmpl, signals)
+	}
+
+	// Lambda timeouts only count as timeout configuration when every
+	// function sets one
+	if signals.GetBool("lambda_timeout_configured") {
+		signals.SetBool("timeout_configured", true)
+	}
+}
+
+// cfnAPITypes are the resources that define an API Gateway API
+var cfnAPITypes = []string{
+	"AWS::ApiGateway::RestApi",
+	"AWS::ApiGatewayV2::Api",
+	"AWS::Serverless::Api",
+	"AWS::Serverless::HttpApi",
+}
+
+// cfnThrottlingKeys are the properties that configure API Gateway throttling
+var cfnThrottlingKeys = []string{"ThrottlingRateLimit", "ThrottlingBurstLimit", "RateLimit", "BurstLimit"}
+
+// cfnImplicitAPI is the API SAM creates for function Api events without RestApiId
+const cfnImplicitAPI = "ServerlessRestApi"
+
+// checkCFNAPIThrottling checks that every API is throttled by a stage,
+// deployment or usage plan. The existing api_gateway_rate_limit signal is
+// only set when every API in the template is throttled.
+func checkCFNAPIThrottling(t *cfnTemplate, signals *RepoSignals) {
+	apis := t.resources(cfnAPITypes...)
+	if hasCFNImplicitAPI(t) {
+		apis = append(apis, cfnResource{LogicalID: cfnImplicitAPI, Type: "AWS::Serverless::Api"})
+	}
+	if len(apis) == 0 {
+		return
+	}
+
+	throttled := make(map[string]bool)
+	for i := range t.Resources {
+		r := &t.Resources[i]
+		switch r.Type {
+		case "AWS::ApiGateway::Stage", "AWS::ApiGateway::Deployment":
+			if containsAnyKey(r.Properties, cfnThrottlingKeys) {
+				throttled[cfnRefID(r.Properties["RestApiId"])] = true
+			}
+		case "AWS::ApiGatewayV2::Stage":
+			if containsAnyKey(r.Properties, cfnThrottlingKeys) {
+				throttled[cfnRefID(r.Properties["ApiId"])] = true
+			}
+		case "AWS::ApiGateway::UsagePlan":
+			if !containsAnyKey(r.Properties["Throttle"], cfnThrottlingKeys) {
+				continue
+			}
+			stages, _ := r.Properties["ApiStages"].([]interface{})
+			for _, stage := range stages {
+				if m, ok := stage.(map[string]interface{}); ok {
+					throttled[cfnRefID(m["ApiId"])] = true
+				}
+			}
+		}
+	}
+
+	missing := 0
+	for i := range apis {
+		api := &apis[i]
+		if throttled[api.LogicalID] {
+			continue
+		}
+		// SAM APIs configure throttling inline or through Globals
+		if settings, ok := t.property(api, "MethodSettings"); ok && containsAnyKey(settings, cfnThrottlingKeys) {
+			continue
+		}
+		if settings, ok := t.property(api, "DefaultRouteSettings"); ok && containsAnyKey(settings, cfnThrottlingKeys) {
+			continue
+		}
+		missing++
+		signals.AddEvidence("cfn_apis_without_throttling", cfnEvidence(t, api, "no stage, route or usage plan throttling"))
+	}
+	if missing == 0 {
+		signals.SetBool("api_gateway_rate_limit", true)
+	}
+}
+
+// hasCFNImplicitAPI reports whether SAM functions declare Api events without
+// an explicit API, which makes SAM create ServerlessRestApi
+func hasCFNImplicitAPI(t *cfnTemplate) bool {
+	for _, fn := range t.resources("AWS::Serverless::Function") {
+		events, _ := fn.Properties["Events"].(map[string]interface{})
+		for name := range events {
+			event := nestedMap(events, name)
+			if event["Type"] != "Api" {
+				continue
+			}
+			if _, explicit := nestedMap(event, "Properties")["RestApiId"]; !explicit {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// checkCFNLambda checks functions for an explicit timeout and reserved concurrency
+func checkCFNLambda(t *cfnTemplate, signals *RepoSignals) {
+	functions := t.resources("AWS::Lambda::Function", "AWS::Serverless::Function")
+	for i := range functions {
+		fn := &functions[i]
+
+		_, timeout, _ := t.numberProperty(fn, "Timeout")
+		if !timeout {
+			signals.AddEvidence("lambda_functions_without_timeout", cfnEvidence(t, fn, "Timeout unset (3 second default)"))
+		}
+		setAllBool(signals, "lambda_timeout_configured", timeout)
+
+		concurrency, set, known := t.numberProperty(fn, "ReservedConcurrentExecutions")
+		if !known {
+			continue
+		}
+		reserved := set && concurrency > 0
+		if !reserved {
+			signals.AddEvidence("lambda_functions_without_reserved_concurrency", cfnEvidence(t, fn, "ReservedConcurrentExecutions unset"))
+		}
+		setAllBool(signals, "lambda_reserved_concurrency", reserved)
+	}
+}
+
+// checkCFNDynamoDB checks tables for point-in-time recovery
+func checkCFNDynamoDB(t *cfnTemplate, signals *RepoSignals) {
+	for i := range t.Resources {
+		table := &t.Resources[i]
+		var enabled, known bool
+		switch table.Type {
+		case "AWS::DynamoDB::Table":
+			enabled, known = t.boolProperty(table, false, "PointInTimeRecoverySpecification", "PointInTimeRecoveryEnabled")
+		case "AWS::DynamoDB::GlobalTable":
+			enabled, known = cfnGlobalTablePITR(t, table)
+		case "AWS::Serverless::SimpleTable":
+			// SimpleTable cannot enable point-in-time recovery
+			enabled, known = false, true
+		default:
+			continue
+		}
+		if !known {
+			continue
+		}
+		if !enabled {
+			signals.AddEvidence("dynamodb_tables_without_pitr", cfnEvidence(t, table, "point-in-time recovery is not enabled"))
+		}
+		setAllBool(signals, "dynamodb_pitr_enabled", enabled)
+	}
+}
+
+// cfnGlobalTablePITR reports whether every replica of a global table has
+// point-in-time recovery enabled
+func cfnGlobalTablePITR(t *cfnTemplate, table *cfnResource) (enabled, known bool) {
+	replicas, _ := table.Properties["Replicas"].([]interface{})
+	if len(replicas) == 0 {
+		return false, true
+	}
+	for _, raw := range replicas {
+		replica, _ := raw.(map[string]interface{})
+		value, ok := cfnLookup(replica, []string{"PointInTimeRecoverySpecification", "PointInTimeRecoveryEnabled"})
+		if !ok {
+			return false, true
+		}
+		resolved, ok := t.resolve(value)
+		if !ok {
+			return false, false
+		}
+		if b, isBool := resolved.(bool); !isBool || !b {
+			return false, true
+		}
+	}
+	return true, true
+}
+
+// checkCFNRDS checks standalone RDS instances for Multi-AZ, and instances
+// and clusters for an adequate backup retention
+func checkCFNRDS(t *cfnTemplate, signals *RepoSignals) {
+	for i := range t.Resources {
+		db := &t.Resources[i]
+		if db.Type != "AWS::RDS::DBInstance" && db.Type != "AWS::RDS::DBCluster" {
+			continue
+		}
+		// Aurora instances and read replicas inherit from their cluster or source
+		if cfnHasAny(db.Properties, "DBClusterIdentifier", "SourceDBInstanceIdentifier") {
+			continue
+		}
+
+		if db.Type == "AWS::RDS::DBInstance" {
+			if multiAZ, known := t.boolProperty(db, false, "MultiAZ"); known {
+				if !multiAZ {
+					signals.AddEvidence("rds_instances_without_multi_az", cfnEvidence(t, db, "MultiAZ is not enabled"))
+				}
+				setAllBool(signals, "rds_multi_az", multiAZ)
+			}
+		}
+
+		days, set, known := t.numberProperty(db, "BackupRetentionPeriod")
+		if !known {
+			continue
+		}
+		adequate := set && days >= minBackupRetentionDays
+		if !adequate {
+			detail := "BackupRetentionPeriod unset (1 day default)"
+			if set {
+				detail = fmt.Sprintf("BackupRetentionPeriod is %g days", days)
+			}
+			signals.AddEvidence("rds_backup_retention_short", cfnEvidence(t, db, detail))
+		}
+		setAllBool(signals, "rds_backup_retention_configured", adequate)
+	}
+}
+
+// cfnStatefulTypes maps stateful resource types to their deletion protection
+// property and the DeletionPolicy CloudFormation applies when none is set
+var cfnStatefulTypes = map[string]struct {
+	Property      string
+	DefaultPolicy string
+}{
+	"AWS::RDS::DBInstance":       {"DeletionProtection", "Snapshot"},
+	"AWS::RDS::DBCluster":        {"DeletionProtection", "Snapshot"},
+	"AWS::DynamoDB::Table":       {"DeletionProtectionEnabled", "Delete"},
+	"AWS::DynamoDB::GlobalTable": {"", "Delete"},
+	"AWS::DocDB::DBCluster":      {"DeletionProtection", "Delete"},
+	"AWS::Neptune::DBCluster":    {"DeletionProtection", "Delete"},
+}
+
+// checkCFNDeletionPolicy checks that stateful resources survive stack
+// deletion (a Retain or Snapshot DeletionPolicy) or have deletion protection
+func checkCFNDeletionPolicy(t *cfnTemplate, signals *RepoSignals) {
+	for i := range t.Resources {
+		r := &t.Resources[i]
+		setting, ok := cfnStatefulTypes[r.Type]
+		if !ok {
+			continue
+		}
+		// Aurora instances are protected through their cluster
+		if r.Type == "AWS::RDS::DBInstance" && cfnHasAny(r.Properties, "DBClusterIdentifier") {
+			continue
+		}
+
+		policy := r.DeletionPolicy
+		if policy == "" {
+			policy = setting.DefaultPolicy
+		}
+		protected := policy == "Retain" || policy == "RetainExceptOnCreate" || policy == "Snapshot"
+		if !protected && setting.Property != "" {
+			enabled, known := t.boolProperty(r, false, setting.Property)
+			if !known {
+				continue
+			}
+			protected = enabled
+		}
+		if !protected {
+			signals.AddEvidence("resources_without_deletion_protection", cfnEvidence(t, r, fmt.Sprintf("DeletionPolicy %s and no deletion protection", policy)))
+		}
+		setAllBool(signals, "deletion_protection_enabled", protected)
+	}
+}
+
+// cfnEvidence builds evidence for a template resource
+func cfnEvidence(t *cfnTemplate, r *cfnResource, detail string) Evidence {
+	return Evidence{Subject: r.LogicalID + " (" + r.Type + ")", File: t.File, Line: r.Line, Detail: detail}
+}
+
+// cfnRefID returns the logical ID a Ref points to, or a plain string ID
+func cfnRefID(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		return v
+	case map[string]interface{}:
+		if ref, ok := v["Ref"].(string); ok {
+			return ref
+		}
+	}
+	return ""
+}
+
+// cfnHasAny reports whether any of the properties is set
+func cfnHasAny(props map[string]interface{}, names ...string) bool {
+	for _, name := range names {
+		if _, ok := props[name]; ok {
+			return true
+		}
+	}
+	return false
+}
+
+// containsAnyKey reports whether a decoded value contains one of the keys at
+// any depth
+func containsAnyKey(value interface{}, keys []string) bool {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for k, child := range v {
+			for _, key := range keys {
+				if k == key {
+					return true
+				}
+			}
+			if containsAnyKey(child, keys) {
+				return true
+			}
+		}
+	case []interface{}:
+		for _, child := range v {
+			if containsAnyKey(child, keys) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,160 @@
+package scanner
+
+import (
+	"testing"
+)
+
+const testCFNTemplate = `Transform: AWS::Serverless-2016-10-31
+Parameters:
+  MultiAZ:
+    Type: String
+    Default: "false"
+Resources:
+  PublicApi:
+    Type: AWS::Serverless::Api
+    Properties:
+      StageName: prod
+      MethodSettings:
+        - HttpMethod: "*"
+          ResourcePath: "/*"
+          ThrottlingRateLimit: 100
+          ThrottlingBurstLimit: 50
+  AdminApi:
+    Type: AWS::ApiGateway::RestApi
+  Handler:
+    Type: AWS::Serverless::Function
+    Properties:
+      Timeout: 10
+      ReservedConcurrentExecutions: 20
+      Events:
+        Get:
+          Type: Api
+          Properties:
+            RestApiId: !Ref PublicApi
+  Worker:
+    Type: AWS::Lambda::Function
+  Sessions:
+    Type: AWS::DynamoDB::Table
+  Orders:
+    Type: AWS::DynamoDB::Table
+    DeletionPolicy: Retain
+    Properties:
+      PointInTimeRecoverySpecification:
+        PointInTimeRecoveryEnabled: true
+  Database:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      MultiAZ: !Ref MultiAZ
+      BackupRetentionPeriod: 14
+  AuroraWriter:
+    Type: AWS::RDS::DBInstance
+    Properties:
+      DBClusterIdentifier: !Ref Cluster
+`
+
+func TestCheckCFNTemplates(t *testing.T) {
+	tmpl, ok := parseCFNTemplate(testCFNTemplate, "template.yaml")
+	if !ok {
+		t.Fatal("expected a template")
+	}
+	signals := &RepoSignals{
+		BoolSignals: make(map[string]bool),
+		IntSignals:  make(map[string]int),
+	}
+	checkCFNTemplates([]*cfnTemplate{tmpl}, signals)
+
+	if !signals.GetBool("infra_as_code_detected") || signals.GetInt("cfn_template_count") != 1 {
+		t.Error("expected the template to count as IaC")
+	}
+
+	apis := signals.GetEvidence("cfn_apis_without_throttling")
+	if len(apis) != 1 || apis[0].Subject != "AdminApi (AWS::ApiGateway::RestApi)" || apis[0].Line != 16 {
+		t.Errorf("expected only AdminApi to lack throttling, got %+v", apis)
+	}
+	if signals.GetBool("api_gateway_rate_limit") {
+		t.Error("expected api_gateway_rate_limit to stay unset while an API is unthrottled")
+	}
+
+	if val, ok := signals.GetBoolSignal("lambda_timeout_configured"); !ok || val {
+		t.Error("expected lambda_timeout_configured to be false while Worker has no timeout")
+	}
+	if signals.GetBool("timeout_configured") {
+		t.Error("expected one function with a timeout not to count as timeout configuration")
+	}
+	if got := signals.GetEvidence("lambda_functions_without_timeout"); len(got) != 1 || got[0].Subject != "Worker (AWS::Lambda::Function)" {
+		t.Errorf("expected Worker without timeout, got %+v", got)
+	}
+	if got := signals.GetEvidence("lambda_functions_without_reserved_concurrency"); len(got) != 1 {
+		t.Errorf("expected Worker without reserved concurrency, got %+v", got)
+	}
+}
+
+func TestCheckCFNDataStores(t *testing.T) {
+	tmpl, _ := parseCFNTemplate(testCFNTemplate, "template.yaml")
+	signals := &RepoSignals{
+		BoolSignals: make(map[string]bool),
+		IntSignals:  make(map[string]int),
+	}
+	checkCFNTemplates([]*cfnTemplate{tmpl}, signals)
+
+	if got := signals.GetEvidence("dynamodb_tables_without_pitr"); len(got) != 1 || got[0].Subject != "Sessions (AWS::DynamoDB::Table)" {
+		t.Errorf("expected Sessions without PITR, got %+v", got)
+	}
+	if got := signals.GetEvidence("rds_instances_without_multi_az"); len(got) != 1 || got[0].Subject != "Database (AWS::RDS::DBInstance)" {
+		t.Errorf("expected MultiAZ resolved from the parameter default, got %+v", got)
+	}
+	if val, ok := signals.GetBoolSignal("rds_backup_retention_configured"); !ok || !val {
+		t.Error("expected 14 days of backups to be adequate")
+	}
+
+	// Sessions is deleted with the stack; Database defaults to Snapshot
+	protection := signals.GetEvidence("resources_without_deletion_protection")
+	if len(protection) != 1 || protection[0].Subject != "Sessions (AWS::DynamoDB::Table)" {
+		t.Errorf("expected only Sessions without deletion protection, got %+v", protection)
+	}
+}
+
+func TestCheckCFNImplicitAPI(t *testing.T) {
+	content := `Transform: AWS::Serverless-2016-10-31
+Globals:
+  Api:
+    MethodSettings:
+      - ThrottlingRateLimit: 10
+Resources:
+  Handler:
+    Type: AWS::Serverless::Function
+    Properties:
+      Events:
+        Get:
+          Type: Api
+`
+	tmpl, _ := parseCFNTemplate(content, "template.yml")
+	signals := &RepoSignals{BoolSignals: make(map[string]bool), IntSignals: make(map[string]int)}
+	checkCFNTemplates([]*cfnTemplate{tmpl}, signals)
+
+	if !signals.GetBool("api_gateway_rate_limit") {
+		t.Errorf("expected the implicit API to be throttled through Globals, got %+v", signals.GetEvidence("cfn_apis_without_throttling"))
+	}
+}
+
+func TestCheckCFNLambdaTimeouts(t *testing.T) {
+	content := `Transform: AWS::Serverless-2016-10-31
+Globals:
+  Function:
+    Timeout: 10
+Resources:
+  Handler:
+    Type: AWS::Serverless::Function
+  Worker:
+    Type: AWS::Serverless::Function
+    Properties:
+      Timeout: 60
+`
+	tmpl, _ := parseCFNTemplate(content, "template.yml")
+	signals := &RepoSignals{BoolSignals: make(map[string]bool), IntSignals: make(map[string]int)}
+	checkCFNTemplates([]*cfnTemplate{tmpl}, signals)
+
+	if !signals.GetBool("lambda_timeout_configured") || !signals.GetBool("timeout_configured") {
+		t.Errorf("expected timeouts on every function to count, got %+v", signals.GetEvidence("lambda_functions_without_timeout"))
+	}
+}
@@ -0,0 +1,321 @@
+package scanner
+
+import (
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// composeService is a service of a Docker Compose file, merged with the
+// other files of one Compose configuration of the same directory
+type composeService struct {
+	Name      string
+	File      string // where the service is first declared
+	Line      int
+	Spec      map[string]interface{}
+	Overrides []string // override files merged into Spec
+	// Environment is the environment file of the configuration, such as
+	// docker-compose.prod.yml, or "" for the default configuration
+	Environment string
+}
+
+// isComposeFile reports whether a path is a Docker Compose file, including
+// overrides such as docker-compose.prod.yml
+func isComposeFile(relPath string) bool {
+	base := strings.ToLower(filepath.Base(relPath))
+	ext := filepath.Ext(base)
+	if ext != ExtYAML && ext != ExtYML {
+		return false
+	}
+	return strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose.") || strings.HasPrefix(base, "compose-")
+}
+
+// parseComposeServices returns the services of a Compose file in file order
+func parseComposeServices(content, relPath string) ([]composeService, error) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil {
+		return nil, err
+	}
+	if len(root.Content) == 0 || root.Content[0].Kind != yaml.MappingNode {
+		return nil, nil
+	}
+	doc := root.Content[0]
+
+	var services []composeService
+	for i := 0; i+1 < len(doc.Content); i += 2 {
+		if doc.Content[i].Value != "services" || doc.Content[i+1].Kind != yaml.MappingNode {
+			continue
+		}
+		node := doc.Content[i+1]
+		for j := 0; j+1 < len(node.Content); j += 2 {
+			var spec map[string]interface{}
+			if err := node.Content[j+1].Decode(&spec); err != nil {
+				return nil, err
+			}
+			if spec == nil {
+				spec = map[string]interface{}{}
+			}
+			services = append(services, composeService{
+				Name: node.Content[j].Value,
+				File: relPath,
+				Line: node.Content[j].Line,
+				Spec: spec,
+			})
+		}
+	}
+	return services, nil
+}
+
+// composeFileRank orders the Compose files of a directory the way they are
+// applied: the base file, the override file Compose loads by default, then
+// environment overrides such as docker-compose.prod.yml
+func composeFileRank(relPath string) int {
+	base := strings.ToLower(filepath.Base(relPath))
+	switch strings.TrimSuffix(base, filepath.Ext(base)) {
+	case "docker-compose", "compose":
+		return 0
+	case "docker-compose.override", "compose.override":
+		return 1
+	}
+	return 2
+}
+
+// composeConfigurations returns the sets of files Compose is run with in
+// each directory: the base file with docker-compose.override.yml, as
+// `docker compose up` loads them by default, and the base file with each
+// environment file, as `docker compose -f docker-compose.yml -f
+// docker-compose.prod.yml` does. Files are expected in composeFileRank order
+// within their directory.
+func composeConfigurations(files []string) [][]string {
+	var configs [][]string
+	for start := 0; start < len(files); {
+		end := start
+		for end < len(files) && filepath.Dir(files[end]) == filepath.Dir(files[start]) {
+			end++
+		}
+		var base, defaults, environments []string
+		for _, path := range files[start:end] {
+			switch composeFileRank(path) {
+			case 0:
+				base = append(base, path)
+			case 1:
+				defaults = append(defaults, path)
+			default:
+				environments = append(environments, path)
+			}
+		}
+		if len(base)+len(defaults) > 0 {
+			configs = append(configs, append(slices.Clone(base), defaults...))
+		}
+		for _, env := range environments {
+			configs = append(configs, append(slices.Clone(base), env))
+		}
+		start = end
+	}
+	return configs
+}
+
+// mergeComposeServices parses the Compose files and merges services by name
+// within each configuration of composeConfigurations, later files
+// overriding earlier ones: mappings are merged and other values replaced. A
+// merged service keeps the location of its first declaration, so a service
+// of the base file is returned once per configuration.
+func mergeComposeServices(contents map[string]string, opts ScanOptions) []composeService {
+	var files []string
+	for path := range contents {
+		if isComposeFile(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Slice(files, func(i, j int) bool {
+		if di, dj := filepath.Dir(files[i]), filepath.Dir(files[j]); di != dj {
+			return di < dj
+		}
+		if ri, rj := composeFileRank(files[i]), composeFileRank(files[j]); ri != rj {
+			return ri < rj
+		}
+		return files[i] < files[j]
+	})
+
+	parsed := make(map[string][]composeService, len(files))
+	for _, path := range files {
+		services, err := parseComposeServices(contents[path], path)
+		if err != nil {
+			if opts.Debug {
+				opts.Logger.Printf("Compose file %s: parse failed: %v", path, err)
+			}
+			continue
+		}
+		parsed[path] = services
+	}
+
+	var services []composeService
+	for _, config := range composeConfigurations(files) {
+		env := config[len(config)-1]
+		if composeFileRank(env) < 2 {
+			env = ""
+		}
+		index := make(map[string]int) // service name to services index
+		for _, path := range config {
+			for _, svc := range parsed[path] {
+				svc.Environment = env
+				i, ok := index[svc.Name]
+				if !ok {
+					index[svc.Name] = len(services)
+					services = append(services, svc)
+					continue
+				}
+				services[i].Spec = mergeYAMLMaps(services[i].Spec, svc.Spec)
+				services[i].Overrides = append(services[i].Overrides, path)
+			}
+		}
+	}
+	return services
+}
+
+// analyzeCompose checks every Compose service for healthchecks, restart
+// policies, resource limits, mutable images and host-level privileges
+func analyzeCompose(signals *RepoSignals, opts ScanOptions) {
+	services := mergeComposeServices(signals.GetFileContentMap(), opts)
+	if len(services) == 0 {
+		return
+	}
+	signals.SetBool("compose_detected", true)
+	names := make(map[string]bool, len(services))
+	for i := range services {
+		names[filepath.Dir(services[i].File)+"/"+services[i].Name] = true
+	}
+	signals.SetInt("compose_service_count", len(names))
+
+	healthchecks, restarts, limits := 0, 0, 0
+	for i := range services {
+		svc := &services[i]
+		if composeHasHealthcheck(svc) {
+			healthchecks++
+		} else {
+			signals.AddEvidence("compose_services_without_healthcheck", composeEvidence(svc, "no healthcheck (unless the image defines one)"))
+		}
+		if composeHasRestartPolicy(svc) {
+			restarts++
+		} else {
+			signals.AddEvidence("compose_services_without_restart", composeEvidence(svc, "no restart policy"))
+		}
+		if composeHasLimits(svc) {
+			limits++
+		} else {
+			signals.AddEvidence("compose_services_without_limits", composeEvidence(svc, "no deploy.resources.limits"))
+		}
+		if detail, mutable := composeMutableImage(svc); mutable {
+			signals.AddEvidence("compose_services_latest_image", composeEvidence(svc, detail))
+		}
+		if privileged, _ := svc.Spec["privileged"].(bool); privileged {
+			signals.AddEvidence("compose_services_privileged", composeEvidence(svc, "privileged: true"))
+		}
+		for _, key := range []string{"network_mode", "pid"} {
+			if value, _ := svc.Spec[key].(string); value == "host" {
+				signals.AddEvidence("compose_services_host_namespace", composeEvidence(svc, key+": host"))
+			}
+		}
+	}
+
+	signals.SetBool("compose_healthchecks_defined", healthchecks == len(services))
+	signals.SetBool("compose_restart_policy_defined", restarts == len(services))
+	signals.SetBool("compose_resource_limits_defined", limits == len(services))
+}
+
+// composeHasHealthcheck reports whether a service defines an enabled healthcheck
+func composeHasHealthcheck(svc *composeService) bool {
+	healthcheck, ok := svc.Spec["healthcheck"].(map[string]interface{})
+	if !ok {
+		return false
+	}
+	disabled, _ := healthcheck["disable"].(bool)
+	return !disabled
+}
+
+// composeHasRestartPolicy reports whether a service restarts on failure
+func composeHasRestartPolicy(svc *composeService) bool {
+	if restart, ok := svc.Spec["restart"].(string); ok {
+		return restart != "no" && restart != ""
+	}
+	policy := nestedMap(svc.Spec, "deploy", "restart_policy")
+	if policy == nil {
+		return false
+	}
+	condition, _ := policy["condition"].(string)
+	return condition != "none"
+}
+
+// composeHasLimits reports whether a service limits CPU or memory, through
+// deploy.resources.limits or the legacy mem_limit/cpus keys
+func composeHasLimits(svc *composeService) bool {
+	limits := nestedMap(svc.Spec, "deploy", "resources", "limits")
+	if _, ok := limits["cpus"]; ok {
+		return true
+	}
+	if _, ok := limits["memory"]; ok {
+		return true
+	}
+	_, hasMem := svc.Spec["mem_limit"]
+	_, hasCPUs := svc.Spec["cpus"]
+	return hasMem || hasCPUs
+}
+
+// composeMutableImage reports images that use :latest or no tag at all
+func composeMutableImage(svc *composeService) (string, bool) {
+	image, ok := composeImage(svc)
+	if !ok {
+		return "", false
+	}
+
+	_, tag, digest := splitImageRef(image)
+	switch {
+	case digest != "":
+		return "", false
+	case tag == "":
+		return "image " + image + " has no tag (defaults to latest)", true
+	case tag == "latest":
+		return "image " + image + " uses :latest", true
+	}
+	return "", false
+}
+
+// composeImage returns the image of a service with ${TAG:-latest} style
+// variables resolved to their default. Images that depend on variables
+// without a default are not resolved.
+func composeImage(svc *composeService) (string, bool) {
+	image, ok := svc.Spec["image"].(string)
+	if !ok || image == "" {
+		return "", false
+	}
+	if start := strings.Index(image, "${"); start >= 0 {
+		end := strings.Index(image[start:], "}")
+		if end < 0 {
+			return "", false
+		}
+		variable := image[start+2 : start+end]
+		_, def, hasDefault := strings.Cut(variable, ":-")
+		if !hasDefault {
+			return "", false
+		}
+		image = image[:start] + def + image[start+end+1:]
+	}
+	return image, !strings.Contains(image, "$")
+}
+
+// composeEvidence builds evidence for a Compose service, naming the
+// override files it was merged with or the environment file of its
+// configuration that leaves it unchanged
+func composeEvidence(svc *composeService, detail string) Evidence {
+	ev := Evidence{Subject: svc.Name, File: svc.File, Line: svc.Line, Detail: detail}
+	switch {
+	case len(svc.Overrides) > 0:
+		ev.Context = "merged with " + strings.Join(svc.Overrides, ", ")
+	case svc.Environment != "" && svc.Environment != svc.File:
+		ev.Context = "unchanged by " + svc.Environment
+	}
+	return ev
+}
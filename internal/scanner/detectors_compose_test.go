@@ -0,0 +1,171 @@
+package scanner
+
+import (
+	"testing"
+)
+
+const testComposeFile = `services:
+  api:
+    image: registry/api:1.4.2
+    restart: unless-stopped
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost/health"]
+    deploy:
+      resources:
+        limits:
+          memory: 512M
+  worker:
+    image: registry/worker
+    network_mode: host
+    healthcheck:
+      disable: true
+  db:
+    image: postgres:latest
+    privileged: true
+    pid: host
+    mem_limit: 1g
+    deploy:
+      restart_policy:
+        condition: on-failure
+  cache:
+    image: redis:${REDIS_TAG:-latest}
+    restart: "no"
+  web:
+    build: .
+`
+
+func TestAnalyzeCompose(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{"docker-compose.yml": testComposeFile},
+		BoolSignals: make(map[string]bool),
+		IntSignals:  make(map[string]int),
+	}
+	analyzeCompose(signals, ScanOptions{})
+
+	if got := signals.GetInt("compose_service_count"); got != 5 {
+		t.Fatalf("expected 5 services, got %d", got)
+	}
+
+	tests := []struct {
+		key      string
+		subjects []string
+	}{
+		{"compose_services_without_healthcheck", []string{"worker", "db", "cache", "web"}},
+		{"compose_services_without_restart", []string{"worker", "cache", "web"}},
+		{"compose_services_without_limits", []string{"worker", "cache", "web"}},
+		{"compose_services_latest_image", []string{"worker", "db", "cache"}},
+		{"compose_services_privileged", []string{"db"}},
+		{"compose_services_host_namespace", []string{"worker", "db"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			if len(evidence) != len(tt.subjects) {
+				t.Fatalf("expected %v, got %+v", tt.subjects, evidence)
+			}
+			for i, subject := range tt.subjects {
+				if evidence[i].Subject != subject {
+					t.Errorf("expected %s at %d, got %s", subject, i, evidence[i].Subject)
+				}
+			}
+		})
+	}
+
+	if signals.GetBool("compose_healthchecks_defined") {
+		t.Error("expected compose_healthchecks_defined to be false")
+	}
+	if ev := signals.GetEvidence("compose_services_privileged"); ev[0].Line != 16 {
+		t.Errorf("expected db service on line 16, got %d", ev[0].Line)
+	}
+}
+
+func TestAnalyzeComposeOverrides(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"deploy/docker-compose.yml": `services:
+  api:
+    image: registry/api:1.4.2
+    deploy:
+      resources:
+        reservations:
+          memory: 256M
+  db:
+    image: postgres:16
+    restart: always
+    healthcheck:
+      test: ["CMD", "pg_isready"]
+`,
+			"deploy/docker-compose.override.yml": "services:\n  api:\n    restart: unless-stopped\n",
+			"deploy/docker-compose.prod.yml": `services:
+  api:
+    healthcheck:
+      test: ["CMD", "curl", "-f", "http://localhost/health"]
+    deploy:
+      resources:
+        limits:
+          memory: 512M
+  db:
+    mem_limit: 1g
+  proxy:
+    image: nginx:1.27
+`,
+			// a separate project is not merged with deploy/
+			"docker-compose.yml": "services:\n  api:\n    image: registry/api:1.4.2\n",
+		},
+		BoolSignals: make(map[string]bool),
+		IntSignals:  make(map[string]int),
+	}
+	analyzeCompose(signals, ScanOptions{})
+
+	if got := signals.GetInt("compose_service_count"); got != 4 {
+		t.Fatalf("expected api, db and proxy of deploy/ and the root api, got %d", got)
+	}
+	// the default configuration is base + override and prod is base + prod,
+	// so prod limits do not cover the default api and db
+	limits := signals.GetEvidence("compose_services_without_limits")
+	if len(limits) != 4 || limits[0].Subject != "proxy" || limits[2].Subject != "db" || limits[2].Context != "" || limits[3].File != "docker-compose.yml" {
+		t.Errorf("expected api and db of the default configuration, proxy and the root api without limits, got %+v", limits)
+	}
+	// the override restart policy does not apply to prod
+	restarts := signals.GetEvidence("compose_services_without_restart")
+	if len(restarts) != 3 || restarts[1].Subject != "api" || restarts[1].Context != "merged with deploy/docker-compose.prod.yml" {
+		t.Errorf("expected the prod api, proxy and the root api without restart policy, got %+v", restarts)
+	}
+	healthchecks := signals.GetEvidence("compose_services_without_healthcheck")
+	if len(healthchecks) != 3 || healthchecks[1].Context != "merged with deploy/docker-compose.override.yml" {
+		t.Errorf("expected the default api, proxy and the root api without healthcheck, got %+v", healthchecks)
+	}
+
+	// evidence of a merged service points at its base declaration
+	services := mergeComposeServices(signals.FileContent, ScanOptions{})
+	if len(services) != 6 {
+		t.Fatalf("expected the root api and 2 configurations of deploy/, got %+v", services)
+	}
+	if ev := composeEvidence(&services[3], ""); ev.Subject != "api" || ev.File != "deploy/docker-compose.yml" || ev.Line != 2 ||
+		ev.Context != "merged with deploy/docker-compose.prod.yml" {
+		t.Errorf("unexpected merged evidence %+v", ev)
+	}
+	// a base service the environment file leaves alone names that file too
+	services = mergeComposeServices(map[string]string{
+		"docker-compose.yml":      "services:\n  api:\n    image: registry/api:1.4.2\n",
+		"docker-compose.prod.yml": "services:\n  worker:\n    image: registry/worker:1.4.2\n",
+	}, ScanOptions{})
+	if len(services) != 3 || composeEvidence(&services[1], "").Context != "unchanged by docker-compose.prod.yml" {
+		t.Errorf("expected the prod configuration to name its environment file, got %+v", services)
+	}
+}
+
+func TestIsComposeFile(t *testing.T) {
+	tests := map[string]bool{
+		"docker-compose.yml":              true,
+		"deploy/docker-compose.prod.yaml": true,
+		"compose.yaml":                    true,
+		"k8s/deployment.yaml":             false,
+		"docker-compose.md":               false,
+	}
+	for path, want := range tests {
+		if got := isComposeFile(path); got != want {
+			t.Errorf("isComposeFile(%q) = %v, want %v", path, got, want)
+		}
+	}
+}
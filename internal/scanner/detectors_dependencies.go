@@ -0,0 +1,167 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+)
+
+// depKindDetails describe each kind of unpinned dependency in evidence
+var depKindDetails = map[string]string{
+	depRange:     "version range",
+	depUnbounded: "unbounded version",
+	depVCS:       "git or URL source",
+}
+
+// analyzeDependencies parses the dependency manifests of Go, npm, Python,
+// Cargo, Ruby, Maven and Gradle projects and pairs each with its lockfile.
+// It records manifests that resolve versions at install time without a
+// lockfile, the ranges they leave open, dependencies fetched from git or a
+// URL, and Go replace directives and Cargo patches pointing at local paths
+// outside the repository. reproducible_builds sums these up for reports.
+// Vendored manifests are skipped.
+func analyzeDependencies(signals *RepoSignals, _ ScanOptions) {
+	files := signals.GetFiles()
+	manifests := parseDepManifests(signals.GetFileContentMap(), files)
+	if len(manifests) == 0 {
+		return
+	}
+
+	ecosystems := make(map[string]bool)
+	missing, unpinned, vcs, replaces := 0, 0, 0, 0
+	for _, m := range manifests {
+		ecosystems[m.Ecosystem] = true
+		locked := checkDepLockfile(signals, m)
+		if !locked {
+			missing++
+		}
+		u, v := checkDepPinning(signals, m, locked)
+		unpinned += u
+		vcs += v
+		replaces += checkDepReplaces(signals, m, files)
+	}
+
+	signals.SetInt("dependency_manifest_count", len(manifests))
+	signals.SetString("dependency_ecosystems", joinSet(ecosystems))
+	signals.SetInt("dependency_lockfile_missing_count", missing)
+	signals.SetInt("dependency_unpinned_count", unpinned)
+	signals.SetInt("dependency_vcs_count", vcs)
+	signals.SetInt("dependency_local_replace_count", replaces)
+	signals.SetBool("dependency_lockfiles_present", missing == 0)
+	signals.SetBool("reproducible_builds", missing == 0 && unpinned == 0 && vcs == 0 && replaces == 0)
+}
+
+// parseDepManifests returns the dependency manifests of a repository in
+// path order
+func parseDepManifests(contents map[string]string, files map[string]bool) []*depManifest {
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		if depManifestEcosystem(path) != "" {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+
+	var manifests []*depManifest
+	for _, path := range paths {
+		if m := parseDepManifest(contents[path], path, files); m != nil {
+			manifests = append(manifests, m)
+		}
+	}
+	return manifests
+}
+
+// checkDepLockfile records a manifest and its lockfile, and reports whether
+// its versions are locked: by a lockfile, or because the ecosystem needs
+// none
+func checkDepLockfile(signals *RepoSignals, m *depManifest) bool {
+	lockfile := m.Lockfile
+	if lockfile == "" {
+		lockfile = "none"
+	}
+	signals.AddEvidence("dependency_manifests", Evidence{
+		Subject: m.File,
+		File:    m.File,
+		Line:    1,
+		Detail:  fmt.Sprintf("%d dependencies; lockfile %s", len(m.Deps), lockfile),
+		Context: m.Ecosystem,
+	})
+	if m.Lockfile != "" || !m.NeedsLock {
+		return true
+	}
+	signals.AddEvidence("dependency_lockfiles_missing", Evidence{
+		Subject: m.File,
+		File:    m.File,
+		Line:    1,
+		Detail:  "no lockfile; versions are resolved at install time",
+		Context: m.Ecosystem,
+	})
+	return false
+}
+
+// checkDepPinning records the dependencies fetched from git or a URL and,
+// without a lockfile, those declared with ranges. It returns how many of
+// each it found.
+func checkDepPinning(signals *RepoSignals, m *depManifest, locked bool) (unpinned, vcs int) {
+	for _, dep := range m.Deps {
+		ev := Evidence{
+			Subject: dep.Name,
+			File:    m.File,
+			Line:    dep.Line,
+			Detail:  depKindDetails[dep.Kind],
+			Context: m.Ecosystem,
+		}
+		if dep.Version != "" {
+			ev.Detail += " " + dep.Version
+		}
+		switch {
+		case dep.Kind == depVCS:
+			vcs++
+			signals.AddEvidence("dependencies_vcs", ev)
+		case !locked && (dep.Kind == depRange || dep.Kind == depUnbounded):
+			// a lockfile pins ranges to the versions it resolved
+			unpinned++
+			signals.AddEvidence("dependencies_unpinned", ev)
+		}
+	}
+	return unpinned, vcs
+}
+
+// checkDepReplaces records the replacements of a manifest that point at
+// local paths and returns how many leave the repository. Replacements by a
+// directory of the repository, as in a monorepo, build the same everywhere
+// and are only recorded as dependencies_repo_replace.
+func checkDepReplaces(signals *RepoSignals, m *depManifest, files map[string]bool) int {
+	outside := 0
+	for _, r := range m.Replaces {
+		ev := Evidence{
+			Subject: r.Module,
+			File:    m.File,
+			Line:    r.Line,
+			Detail:  "replaced by local path " + r.Path,
+			Context: m.Ecosystem,
+		}
+		switch {
+		case filepath.IsAbs(r.Path):
+			ev.Detail += " (absolute path)"
+		case !depPathInRepo(filepath.Join(filepath.Dir(m.File), r.Path), files):
+			ev.Detail += " outside the repository"
+		default:
+			signals.AddEvidence("dependencies_repo_replace", ev)
+			continue
+		}
+		outside++
+		signals.AddEvidence("dependencies_local_replace", ev)
+	}
+	return outside
+}
+
+// depPathInRepo reports whether a replacement path resolves to a directory
+// holding files of the repository
+func depPathInRepo(dir string, files map[string]bool) bool {
+	dir = filepath.Clean(dir)
+	if dir == ".." || len(dir) > 2 && dir[:3] == ".."+string(filepath.Separator) {
+		return false
+	}
+	return dir == "." || hasFileUnder(files, dir)
+}
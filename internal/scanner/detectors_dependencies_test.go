@@ -0,0 +1,83 @@
+package scanner
+
+import "testing"
+
+func TestAnalyzeDependencies(t *testing.T) {
+	contents := map[string]string{
+		"go.mod":                    "module example.com/app\n\nrequire github.com/spf13/cobra v1.8.0\n\nreplace example.com/lib => ./lib\nreplace example.com/tools => ../tools\nreplace example.com/sdk => /opt/sdk\n",
+		"lib/lib.go":                "package lib\n",
+		"web/package.json":          `{"dependencies": {"react": "^18.2.0", "left-pad": "1.3.0", "ui": "github:acme/ui"}}`,
+		"vendor/x/package.json":     `{"dependencies": {"a": "*"}}`,
+		"requirements.txt":          "flask==3.0.2\n",
+		"services/api/Gemfile":      "gem \"rails\", \"~> 7.1\"\n",
+		"services/api/Gemfile.lock": "GEM\n",
+	}
+	signals := newFileSignals(contents)
+	signals.Files = map[string]bool{"go.sum": true}
+	for path := range contents {
+		signals.Files[path] = true
+	}
+	analyzeDependencies(signals, ScanOptions{})
+
+	if got := signals.GetInt("dependency_manifest_count"); got != 4 {
+		t.Fatalf("expected 4 manifests, got %d: %+v", got, signals.GetEvidence("dependency_manifests"))
+	}
+	if got := signals.GetString("dependency_ecosystems"); got != "go,npm,python,ruby" {
+		t.Errorf("unexpected ecosystems %q", got)
+	}
+
+	missing := signals.GetEvidence("dependency_lockfiles_missing")
+	if signals.GetBool("dependency_lockfiles_present") || len(missing) != 1 || missing[0].File != "web/package.json" {
+		t.Errorf("expected web/package.json without a lockfile, got %+v", missing)
+	}
+	unpinned := signals.GetEvidence("dependencies_unpinned")
+	if signals.GetInt("dependency_unpinned_count") != 1 || unpinned[0].Subject != "react" || unpinned[0].Detail != "version range ^18.2.0" {
+		t.Errorf("unexpected unpinned dependencies %+v", unpinned)
+	}
+	if vcs := signals.GetEvidence("dependencies_vcs"); len(vcs) != 1 || vcs[0].Subject != "ui" {
+		t.Errorf("unexpected git dependencies %+v", vcs)
+	}
+
+	// ./lib is part of the repository, ../tools and /opt/sdk are not
+	replaces := signals.GetEvidence("dependencies_local_replace")
+	if signals.GetInt("dependency_local_replace_count") != 2 || len(replaces) != 2 {
+		t.Fatalf("unexpected local replaces %+v", replaces)
+	}
+	if replaces[0].Detail != "replaced by local path ../tools outside the repository" || replaces[1].Detail != "replaced by local path /opt/sdk (absolute path)" {
+		t.Errorf("unexpected replace details %+v", replaces)
+	}
+	if repo := signals.GetEvidence("dependencies_repo_replace"); len(repo) != 1 || repo[0].Subject != "example.com/lib" {
+		t.Errorf("expected the in-repository replace as information, got %+v", repo)
+	}
+	if signals.GetBool("reproducible_builds") {
+		t.Error("expected builds not to be reproducible")
+	}
+}
+
+func TestAnalyzeDependenciesRepoReplace(t *testing.T) {
+	contents := map[string]string{
+		"go.mod":     "module example.com/app\n\nreplace example.com/lib => ./lib\n",
+		"lib/lib.go": "package lib\n",
+	}
+	signals := newFileSignals(contents)
+	signals.Files = map[string]bool{"go.mod": true, "go.sum": true, "lib/lib.go": true}
+	analyzeDependencies(signals, ScanOptions{})
+
+	if signals.GetInt("dependency_local_replace_count") != 0 || !signals.GetBool("reproducible_builds") {
+		t.Error("expected a replace by a directory of the repository to keep builds reproducible")
+	}
+}
+
+func TestAnalyzeDependenciesLocked(t *testing.T) {
+	contents := map[string]string{
+		"package.json":      `{"dependencies": {"react": "^18.2.0"}}`,
+		"package-lock.json": "{}",
+	}
+	signals := newFileSignals(contents)
+	signals.Files = map[string]bool{"package.json": true, "package-lock.json": true}
+	analyzeDependencies(signals, ScanOptions{})
+
+	if !signals.GetBool("reproducible_builds") || signals.GetInt("dependency_unpinned_count") != 0 {
+		t.Error("expected ranges pinned by the lockfile to be reproducible")
+	}
+}
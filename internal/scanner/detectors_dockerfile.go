@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// dockerSecretNamePattern matches ENV and ARG names that hold credentials.
+// Names pointing at a secret file (DB_PASSWORD_FILE) are not secrets themselves.
+var dockerSecretNamePattern = regexp.MustCompile(`(?i)(passw(or)?d|secret|token|api_?key|private_?key|access_?key|credential)`)
+
+// aptInstallPattern matches apt-get and apt package installs
+var aptInstallPattern = regexp.MustCompile(`\bapt(?:-get)?\s+(?:-\S+\s+)*install\b`)
+
+// analyzeDockerfiles parses every Dockerfile and checks the stage the image
+// is built from, together with the stages it inherits from
+func analyzeDockerfiles(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	var files []string
+	for path := range contents {
+		if isDockerfile(path) {
+			files = append(files, path)
+		}
+	}
+	sort.Strings(files)
+
+	var checked int
+	nonRoot, pinned, healthchecks, aptPinned := true, true, true, true
+	for _, path := range files {
+		d := parseDockerfile(contents[path], path)
+		final := d.finalStage()
+		if final == nil {
+			continue
+		}
+		checked++
+		chain := d.lineage(final)
+		subject := dockerStageSubject(final)
+
+		if ev, ok := checkDockerUser(d, chain, subject); !ok {
+			nonRoot = false
+			signals.AddEvidence("dockerfiles_running_as_root", ev)
+		}
+		if ev, ok := checkDockerBaseDigest(d, chain[0], subject); !ok {
+			pinned = false
+			signals.AddEvidence("dockerfiles_unpinned_base_image", ev)
+		}
+		if ev, ok := checkDockerHealthcheck(d, chain, subject); !ok {
+			healthchecks = false
+			signals.AddEvidence("dockerfiles_without_healthcheck", ev)
+		}
+
+		for _, stage := range chain {
+			for i := range stage.Instructions {
+				inst := &stage.Instructions[i]
+				switch inst.Cmd {
+				case "ADD":
+					if url, ok := dockerRemoteSource(inst.Args); ok {
+						signals.AddEvidence("dockerfile_remote_add", dockerEvidence(d, inst, subject, "ADD downloads "+url))
+					}
+				case "ENV", "ARG":
+					for _, name := range dockerSecretNames(inst) {
+						signals.AddEvidence("dockerfile_build_secrets", dockerEvidence(d, inst, subject, inst.Cmd+" "+name+" is baked into the image"))
+					}
+				case "RUN":
+					if packages := unpinnedAptPackages(inst.Args + "\n" + inst.Heredoc); len(packages) > 0 {
+						aptPinned = false
+						signals.AddEvidence("dockerfile_unpinned_apt_packages", dockerEvidence(d, inst, subject, "apt install without versions: "+strings.Join(packages, ", ")))
+					}
+				}
+			}
+		}
+	}
+	if checked == 0 {
+		return
+	}
+
+	signals.SetBool("dockerfile_detected", true)
+	signals.SetInt("dockerfile_count", checked)
+	// a Dockerfile running as root is overridden by pod specs that set a user
+	signals.SetBool("non_root_user_detected", nonRoot || signals.GetBool("k8s_run_as_non_root"))
+	signals.SetBool("dockerfile_base_image_pinned", pinned)
+	signals.SetBool("dockerfile_healthcheck_defined", healthchecks)
+	signals.SetBool("dockerfile_apt_packages_pinned", aptPinned)
+	signals.SetBool("dockerfile_remote_add_detected", len(signals.GetEvidence("dockerfile_remote_add")) > 0)
+	signals.SetBool("dockerfile_build_secrets_detected", len(signals.GetEvidence("dockerfile_build_secrets")) > 0)
+}
+
+// checkDockerUser checks that the last USER of the final stage, or of the
+// stages it is built from, is not root
+func checkDockerUser(d *dockerfile, chain []*dockerStage, subject string) (Evidence, bool) {
+	final := chain[len(chain)-1]
+	for s := len(chain) - 1; s >= 0; s-- {
+		instructions := chain[s].Instructions
+		for i := len(instructions) - 1; i >= 0; i-- {
+			inst := &instructions[i]
+			if inst.Cmd != "USER" {
+				continue
+			}
+			user, _, _ := strings.Cut(inst.Args, ":")
+			if user == "root" || user == "0" || user == "" {
+				return dockerEvidence(d, inst, subject, "USER "+inst.Args), false
+			}
+			return Evidence{}, true
+		}
+	}
+	return Evidence{Subject: subject, File: d.File, Line: final.Line, Detail: "no USER in the final stage (runs as root)"}, false
+}
+
+// checkDockerBaseDigest checks that the base image is pinned by digest.
+// Images that depend on unresolved build arguments are not reported.
+func checkDockerBaseDigest(d *dockerfile, root *dockerStage, subject string) (Evidence, bool) {
+	image := root.Image
+	if image == "" || strings.EqualFold(image, "scratch") || strings.Contains(image, "$") {
+		return Evidence{}, true
+	}
+	if _, _, digest := splitImageRef(image); digest != "" {
+		return Evidence{}, true
+	}
+	return Evidence{Subject: subject, File: d.File, Line: root.Line, Detail: "FROM " + image + " is not pinned by digest"}, false
+}
+
+// checkDockerHealthcheck checks that the image defines a HEALTHCHECK that is
+// not disabled with HEALTHCHECK NONE
+func checkDockerHealthcheck(d *dockerfile, chain []*dockerStage, subject string) (Evidence, bool) {
+	final := chain[len(chain)-1]
+	for s := len(chain) - 1; s >= 0; s-- {
+		instructions := chain[s].Instructions
+		for i := len(instructions) - 1; i >= 0; i-- {
+			inst := &instructions[i]
+			if inst.Cmd != "HEALTHCHECK" {
+				continue
+			}
+			if strings.EqualFold(strings.TrimSpace(inst.Args), "none") {
+				return dockerEvidence(d, inst, subject, "HEALTHCHECK NONE disables the healthcheck"), false
+			}
+			return Evidence{}, true
+		}
+	}
+	return Evidence{Subject: subject, File: d.File, Line: final.Line, Detail: "no HEALTHCHECK (unless the base image defines one)"}, false
+}
+
+// dockerRemoteSource returns the first remote source of an ADD instruction,
+// without its query string
+func dockerRemoteSource(args string) (string, bool) {
+	var fields []string
+	for _, field := range strings.Fields(args) {
+		if !strings.HasPrefix(field, "--") {
+			fields = append(fields, strings.Trim(field, `"',[]`))
+		}
+	}
+	// The last field is the destination
+	for i := 0; i < len(fields)-1; i++ {
+		source := fields[i]
+		if strings.HasPrefix(source, "http://") || strings.HasPrefix(source, "https://") || strings.HasPrefix(source, "git@") {
+			source, _, _ = strings.Cut(source, "?")
+			return source, true
+		}
+	}
+	return "", false
+}
+
+// dockerSecretNames returns the ENV or ARG names that look like credentials.
+// Only names are returned; values are never reported.
+func dockerSecretNames(inst *dockerInstruction) []string {
+	var names []string
+	if inst.Cmd == "ENV" {
+		for name := range parseDockerEnv(inst.Args) {
+			names = append(names, name)
+		}
+	} else {
+		for _, decl := range strings.Fields(inst.Args) {
+			name, _, _ := strings.Cut(decl, "=")
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var secrets []string
+	for _, name := range names {
+		upper := strings.ToUpper(name)
+		if strings.HasSuffix(upper, "_FILE") || strings.HasSuffix(upper, "_PATH") {
+			continue
+		}
+		if dockerSecretNamePattern.MatchString(name) {
+			secrets = append(secrets, name)
+		}
+	}
+	return secrets
+}
+
+// unpinnedAptPackages returns the packages of apt installs in a shell
+// script that are not pinned with package=version
+func unpinnedAptPackages(script string) []string {
+	script = strings.ReplaceAll(script, "\\\n", " ")
+	var packages []string
+	for _, loc := range aptInstallPattern.FindAllStringIndex(script, -1) {
+		rest := script[loc[1]:]
+		if end := strings.IndexAny(rest, "&;|\n"); end >= 0 {
+			rest = rest[:end]
+		}
+		for _, field := range strings.Fields(rest) {
+			if strings.HasPrefix(field, "-") || strings.HasPrefix(field, "$") || strings.Contains(field, "=") || field == `\` {
+				continue
+			}
+			packages = append(packages, field)
+		}
+	}
+	return packages
+}
+
+// dockerStageSubject names the final stage of a Dockerfile in evidence
+func dockerStageSubject(stage *dockerStage) string {
+	if stage.Name != "" {
+		return "stage " + stage.Name
+	}
+	return "FROM " + stage.Image
+}
+
+// dockerEvidence builds evidence for a Dockerfile instruction
+func dockerEvidence(d *dockerfile, inst *dockerInstruction, subject, detail string) Evidence {
+	return Evidence{Subject: subject, File: d.File, Line: inst.Line, Detail: detail}
+}
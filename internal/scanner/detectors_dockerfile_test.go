@@ -0,0 +1,223 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestParseDockerfile(t *testing.T) {
+	content := `# syntax=docker/dockerfile:1
+ARG GO_VERSION=1.22
+ARG BASE=gcr.io/distroless/static
+
+FROM golang:${GO_VERSION} AS build
+RUN go build \
+    # comments inside continuations are dropped
+    -o /app ./cmd/app
+
+FROM ${BASE}:nonroot AS runtime
+ARG BASE
+ENV APP_HOME=/srv LOG_LEVEL=info
+WORKDIR ${APP_HOME}/bin
+RUN <<EOF
+apt-get update
+apt-get install -y curl
+EOF
+COPY --from=build /app $APP_HOME/app
+USER ${UID:-65532}
+`
+	d := parseDockerfile(content, "Dockerfile")
+	if len(d.Stages) != 2 {
+		t.Fatalf("expected 2 stages, got %d", len(d.Stages))
+	}
+	if got := d.Stages[0].Image; got != "golang:1.22" {
+		t.Errorf("expected ARG substituted in FROM, got %q", got)
+	}
+	if got := d.Stages[0].Instructions[0].Args; got != "go build      -o /app ./cmd/app" {
+		t.Errorf("unexpected continuation join: %q", got)
+	}
+
+	final := d.finalStage()
+	if final.Name != "runtime" || final.Image != "gcr.io/distroless/static:nonroot" || final.Line != 10 {
+		t.Errorf("unexpected final stage %+v", final)
+	}
+	byCmd := make(map[string]dockerInstruction)
+	for _, inst := range final.Instructions {
+		byCmd[inst.Cmd] = inst
+	}
+	if got := byCmd["WORKDIR"].Args; got != "/srv/bin" {
+		t.Errorf("expected ENV substituted in WORKDIR, got %q", got)
+	}
+	if got := byCmd["COPY"]; got.Args != "--from=build /app /srv/app" || got.Line != 18 {
+		t.Errorf("unexpected COPY after heredoc: %+v", got)
+	}
+	if got := byCmd["RUN"].Heredoc; got != "apt-get update\napt-get install -y curl" {
+		t.Errorf("unexpected heredoc body %q", got)
+	}
+	if got := byCmd["USER"].Args; got != "65532" {
+		t.Errorf("expected default substituted in USER, got %q", got)
+	}
+}
+
+func TestSubstituteDockerVars(t *testing.T) {
+	vars := map[string]string{"TAG": "1.2", "EMPTY": ""}
+	tests := map[string]string{
+		"app:$TAG":                "app:1.2",
+		"app:${TAG}-slim":         "app:1.2-slim",
+		"app:${EMPTY:-latest}":    "app:latest",
+		"app${TAG:+-versioned}":   "app-versioned",
+		"app${UNSET:+-versioned}": "app",
+		"app:${UNSET}":            "app:${UNSET}",
+		`echo \$TAG`:              `echo \$TAG`,
+	}
+	for in, want := range tests {
+		if got := substituteDockerVars(in, vars); got != want {
+			t.Errorf("substituteDockerVars(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+func TestAnalyzeDockerfilesUser(t *testing.T) {
+	tests := []struct {
+		name     string
+		content  string
+		expected bool
+	}{
+		{
+			name:     "Non-root user",
+			content:  "FROM golang:1.21\nRUN useradd -m myuser\nUSER myuser\n",
+			expected: true,
+		},
+		{
+			name:     "UID user",
+			content:  "FROM alpine\nUSER 1000\n",
+			expected: true,
+		},
+		{
+			name:     "Explicit root user",
+			content:  "FROM ubuntu\nUSER root\n",
+			expected: false,
+		},
+		{
+			name:     "Explicit root UID with group",
+			content:  "FROM ubuntu\nUSER 0:0\n",
+			expected: false,
+		},
+		{
+			name:     "No USER instruction",
+			content:  "FROM node:18\nCOPY . .\n",
+			expected: false,
+		},
+		{
+			name:     "Only the builder stage sets USER",
+			content:  "FROM node:18 AS build\nUSER node\nRUN npm ci\n\nFROM node:18-slim\nCOPY --from=build /app /app\n",
+			expected: false,
+		},
+		{
+			name:     "USER inherited from a parent stage",
+			content:  "FROM node:18 AS base\nUSER node\n\nFROM base\nCOPY . .\n",
+			expected: true,
+		},
+		{
+			name:     "Root again at the end",
+			content:  "FROM alpine\nUSER app\nRUN make\nUSER root\n",
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := &RepoSignals{
+				FileContent: map[string]string{"Dockerfile": tt.content},
+				BoolSignals: make(map[string]bool),
+				IntSignals:  make(map[string]int),
+			}
+			analyzeDockerfiles(signals, ScanOptions{})
+
+			if signals.GetBool("non_root_user_detected") != tt.expected {
+				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("non_root_user_detected"))
+			}
+		})
+	}
+}
+
+func TestAnalyzeDockerfiles(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"api/Dockerfile": `FROM golang:1.22 AS build
+ARG NPM_TOKEN
+RUN go build -o /app .
+
+FROM debian:12@sha256:0123456789abcdef AS runtime
+ARG GITHUB_TOKEN=ghp_notarealtoken
+ENV DB_PASSWORD=hunter2 DB_PASSWORD_FILE=/run/secrets/db
+ADD https://example.com/tool.tar.gz?token=abc /opt/
+RUN apt-get update && apt-get install -y --no-install-recommends \
+    ca-certificates=20230311 curl git \
+ && rm -rf /var/lib/apt/lists/*
+HEALTHCHECK CMD curl -f http://localhost/health || exit 1
+USER 10001
+`,
+			"worker/Dockerfile.prod": "FROM python:3.12\nHEALTHCHECK NONE\n",
+			"README.md":              "FROM ubuntu\n",
+		},
+		BoolSignals: make(map[string]bool),
+		IntSignals:  make(map[string]int),
+	}
+	analyzeDockerfiles(signals, ScanOptions{})
+
+	if got := signals.GetInt("dockerfile_count"); got != 2 {
+		t.Fatalf("expected 2 Dockerfiles, got %d", got)
+	}
+
+	tests := []struct {
+		key    string
+		file   string
+		line   int
+		detail string
+	}{
+		{"dockerfiles_running_as_root", "worker/Dockerfile.prod", 1, "no USER in the final stage (runs as root)"},
+		{"dockerfiles_unpinned_base_image", "worker/Dockerfile.prod", 1, "FROM python:3.12 is not pinned by digest"},
+		{"dockerfiles_without_healthcheck", "worker/Dockerfile.prod", 2, "HEALTHCHECK NONE disables the healthcheck"},
+		{"dockerfile_remote_add", "api/Dockerfile", 8, "ADD downloads https://example.com/tool.tar.gz"},
+		{"dockerfile_unpinned_apt_packages", "api/Dockerfile", 9, "apt install without versions: curl, git"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			if len(evidence) != 1 {
+				t.Fatalf("expected 1 evidence entry, got %+v", evidence)
+			}
+			if ev := evidence[0]; ev.File != tt.file || ev.Line != tt.line || ev.Detail != tt.detail {
+				t.Errorf("unexpected evidence %+v", ev)
+			}
+		})
+	}
+
+	// Secrets are reported by name only; the builder stage is not part of the image
+	secrets := signals.GetEvidence("dockerfile_build_secrets")
+	if len(secrets) != 2 {
+		t.Fatalf("expected 2 secrets, got %+v", secrets)
+	}
+	for _, ev := range secrets {
+		if strings.Contains(ev.Detail, "hunter2") || strings.Contains(ev.Detail, "ghp_") || strings.Contains(ev.Detail, "NPM_TOKEN") {
+			t.Errorf("unexpected secret evidence %+v", ev)
+		}
+		if ev.Subject != "stage runtime" {
+			t.Errorf("expected subject stage runtime, got %q", ev.Subject)
+		}
+	}
+
+	for key, want := range map[string]bool{
+		"non_root_user_detected":            false,
+		"dockerfile_base_image_pinned":      false,
+		"dockerfile_healthcheck_defined":    false,
+		"dockerfile_apt_packages_pinned":    false,
+		"dockerfile_remote_add_detected":    true,
+		"dockerfile_build_secrets_detected": true,
+	} {
+		if got := signals.GetBool(key); got != want {
+			t.Errorf("expected %s = %v, got %v", key, want, got)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// analyzeHelmCharts renders every Helm chart in the repository offline and
+// records the rendered manifests, so the Kubernetes checks evaluate what
+// would actually be deployed rather than the raw templates
+func analyzeHelmCharts(signals *RepoSignals, opts ScanOptions) {
+	contents := signals.GetFileContentMap()
+
+	var chartDirs []string
+	for path := range contents {
+		if filepath.Base(path) == "Chart.yaml" {
+			chartDirs = append(chartDirs, filepath.Dir(path))
+		}
+	}
+	if len(chartDirs) == 0 {
+		return
+	}
+	sort.Strings(chartDirs)
+
+	rendered := 0
+	for _, dir := range chartDirs {
+		chartFile := filepath.Join(dir, "Chart.yaml")
+		chart := &helmChart{Dir: dir, Files: helmChartFiles(contents, dir)}
+
+		// Raw templates are superseded by the rendered output, and are not
+		// checked even when the chart fails to render
+		templatesDir := filepath.Join(dir, "templates") + string(filepath.Separator)
+		for path := range chart.Files {
+			if strings.HasPrefix(path, templatesDir) {
+				signals.removeK8sManifests(path)
+			}
+		}
+
+		if err := yaml.Unmarshal([]byte(contents[chartFile]), &chart.Metadata); err != nil {
+			signals.AddEvidence("helm_render_errors", Evidence{Subject: dir, File: chartFile, Detail: "invalid Chart.yaml"})
+			continue
+		}
+
+		var extraValues []string
+		for _, name := range opts.HelmValuesFiles {
+			if content, ok := chart.Files[filepath.Join(dir, name)]; ok {
+				extraValues = append(extraValues, content)
+			} else if opts.Debug {
+				opts.Logger.Printf("Helm chart %s: values file %s not found", dir, name)
+			}
+		}
+
+		templates, err := renderHelmChart(chart, extraValues)
+		if err != nil {
+			if opts.Debug {
+				opts.Logger.Printf("Helm chart %s: render failed: %v", dir, err)
+			}
+			signals.AddEvidence("helm_render_errors", Evidence{Subject: dir, File: chartFile, Detail: err.Error()})
+			continue
+		}
+
+		var objects []k8sObject
+		for _, tmpl := range templates {
+			if tmpl.Err != nil {
+				if opts.Debug {
+					opts.Logger.Printf("Helm chart %s: template %s failed: %v", dir, tmpl.Path, tmpl.Err)
+				}
+				signals.AddEvidence("helm_render_errors", Evidence{Subject: dir, File: tmpl.Path, Detail: tmpl.Err.Error()})
+				continue
+			}
+			objects = append(objects, helmTemplateObjects(tmpl, chart.Files[tmpl.Path], dir)...)
+		}
+		signals.addK8sManifests(dir, objects)
+		rendered++
+	}
+
+	signals.SetInt("helm_chart_count", rendered)
+}
+
+// helmChartFiles collects the scanned files that belong to a chart directory
+func helmChartFiles(contents map[string]string, dir string) map[string]string {
+	prefix := dir + string(filepath.Separator)
+	files := make(map[string]string)
+	for path, content := range contents {
+		if dir == "." || strings.HasPrefix(path, prefix) {
+			files[path] = content
+		}
+	}
+	return files
+}
+
+// helmTemplateKindPattern matches a kind declared literally in a template
+var helmTemplateKindPattern = regexp.MustCompile(`^kind:\s*["']?([A-Za-z]+)["']?\s*$`)
+
+// helmTemplateObjects decodes the objects a template rendered and maps them
+// back to the chart and to the template source. Lines of the rendered output
+// do not match the source, so each object points at the kind: line that
+// declares it, matched in order per kind, or at no line when the kind is
+// itself templated.
+func helmTemplateObjects(tmpl helmRenderedTemplate, source, chartDir string) []k8sObject {
+	kindLines := make(map[string][]int)
+	for i, line := range strings.Split(source, "\n") {
+		if m := helmTemplateKindPattern.FindStringSubmatch(strings.TrimRight(line, "\r")); m != nil {
+			kindLines[m[1]] = append(kindLines[m[1]], i+1)
+		}
+	}
+
+	objects := parseK8sManifests(tmpl.Content, tmpl.Path)
+	for i := range objects {
+		obj := &objects[i]
+		obj.Line = 0
+		if lines := kindLines[obj.Kind]; len(lines) > 0 {
+			obj.Line = lines[0]
+			kindLines[obj.Kind] = lines[1:]
+		}
+		obj.Origin = "chart " + chartDir
+	}
+	return objects
+}
@@ -0,0 +1,146 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+	"strings"
+)
+
+// imageClassDetails describe each class of image reference in evidence
+var imageClassDetails = map[string]string{
+	imageDigest:   "pinned by digest",
+	imageSemver:   "tagged with a version",
+	imageMutable:  "tagged with a mutable tag",
+	imageUntagged: "no tag (defaults to latest)",
+}
+
+// analyzeImageReferences extracts the image references of Dockerfile FROM
+// instructions, Kubernetes manifests (including rendered Helm charts and
+// kustomize overlays), Compose services, Helm values files and CI jobs,
+// and classifies each as digest-pinned, semver-tagged, mutable-tagged or
+// untagged. Images in CI jobs are not production images, nor are images
+// declared in files or overlays named after a non-production environment.
+func analyzeImageReferences(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var refs []imageRef
+	for _, path := range paths {
+		refs = append(refs, fileImageRefs(contents, path)...)
+	}
+	refs = append(refs, k8sImageRefs(signals)...)
+	if len(refs) == 0 {
+		return
+	}
+
+	counts := make(map[string]int)
+	production, prodPinned, deployed, deployedMutable := 0, 0, 0, 0
+	for i := range refs {
+		r := &refs[i]
+		r.Class = classifyImageRef(r.Image)
+		counts[r.Class]++
+		if !recordImageRef(signals, r) {
+			continue
+		}
+		production++
+		if r.Class == imageDigest {
+			prodPinned++
+		}
+		// base images are built upon, not released
+		if r.Source != imageSourceDockerfile {
+			deployed++
+			if r.Class == imageMutable || r.Class == imageUntagged {
+				deployedMutable++
+			}
+		}
+	}
+
+	mutable := counts[imageMutable] + counts[imageUntagged]
+	signals.SetInt("image_reference_count", len(refs))
+	signals.SetInt("image_digest_count", counts[imageDigest])
+	signals.SetInt("image_semver_count", counts[imageSemver])
+	signals.SetInt("image_mutable_count", counts[imageMutable])
+	signals.SetInt("image_untagged_count", counts[imageUntagged])
+	signals.SetBool("images_digest_pinned", counts[imageDigest] == len(refs))
+	signals.SetBool("images_immutable", mutable == 0)
+	signals.SetInt("prod_image_count", production)
+	if production > 0 {
+		signals.SetBool("prod_images_digest_pinned", prodPinned == production)
+	}
+	// the deployed images decide whether artifacts are versioned, whatever
+	// versioning words appear elsewhere in the repository. Mutable base and
+	// CI images are reported by the image pinning checks instead.
+	if deployed > 0 {
+		signals.SetBool("versioned_artifacts", deployedMutable == 0)
+	}
+}
+
+// fileImageRefs returns the image references of a Dockerfile, Compose file,
+// CI configuration or Helm values file
+func fileImageRefs(contents map[string]string, path string) []imageRef {
+	content := contents[path]
+	switch {
+	case isDockerfile(path):
+		return dockerfileImageRefs(parseDockerfile(content, path))
+	case isComposeFile(path):
+		return composeImageRefs(content, path)
+	case ciPlatform(path) != "":
+		return ciImageRefs(content, path)
+	case strings.HasPrefix(filepath.Base(path), "values") && (filepath.Ext(path) == ExtYAML || filepath.Ext(path) == ExtYML):
+		if appVersion, ok := chartAppVersion(contents, path); ok {
+			return helmValuesImageRefs(content, path, appVersion)
+		}
+	}
+	return nil
+}
+
+// composeImageRefs returns the images of the services of a Compose file
+func composeImageRefs(content, path string) []imageRef {
+	var refs []imageRef
+	services, _ := parseComposeServices(content, path)
+	for i := range services {
+		if image, ok := composeImage(&services[i]); ok && resolvableImage(image) {
+			refs = append(refs, imageRef{Image: image, Source: imageSourceCompose, File: path, Line: services[i].Line})
+		}
+	}
+	return refs
+}
+
+// k8sImageRefs returns the images of the containers of Kubernetes workloads
+func k8sImageRefs(signals *RepoSignals) []imageRef {
+	var refs []imageRef
+	workloads := k8sWorkloads(sortedManifestSets(signals.getK8sManifests()))
+	for i := range workloads {
+		w := &workloads[i]
+		for _, c := range w.AllContainers() {
+			if resolvableImage(c.Image) {
+				refs = append(refs, imageRef{Image: c.Image, Source: imageSourceKubernetes, File: w.File, Line: w.Line, Origin: w.Origin})
+			}
+		}
+	}
+	return refs
+}
+
+// recordImageRef records evidence for a classified reference and reports
+// whether it is a production image
+func recordImageRef(signals *RepoSignals, r *imageRef) bool {
+	ev := Evidence{Subject: r.Image, File: r.File, Line: r.Line, Detail: imageClassDetails[r.Class], Context: r.Source}
+	if r.Origin != "" {
+		ev.Context += ", " + r.Origin
+	}
+	signals.AddEvidence("image_references", ev)
+	if r.Class == imageMutable || r.Class == imageUntagged {
+		signals.AddEvidence("images_mutable_tag", ev)
+	}
+	if !r.Production() {
+		return false
+	}
+	if r.Class != imageDigest {
+		signals.AddEvidence("prod_images_not_digest_pinned", ev)
+	}
+	return true
+}
@@ -0,0 +1,79 @@
+package scanner
+
+import "testing"
+
+func TestAnalyzeImageReferences(t *testing.T) {
+	signals := newFileSignals(map[string]string{
+		"Dockerfile": "FROM golang:1.22 AS build\nFROM build AS test\nFROM gcr.io/distroless/static@sha256:0123abcd\n",
+		"docker-compose.yml": `services:
+  api:
+    image: acme/api:${TAG:-latest}
+  db:
+    image: postgres:16.2@sha256:4567cdef
+`,
+		".github/workflows/ci.yml": "jobs:\n  test:\n    container: node:20\n",
+		"k8s/staging/web.yaml": `kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx
+`,
+	})
+	detectK8sManifests(signals.GetFileContentMap()["k8s/staging/web.yaml"], "k8s/staging/web.yaml", signals)
+	analyzeImageReferences(signals, ScanOptions{})
+
+	if got := signals.GetInt("image_reference_count"); got != 6 {
+		t.Fatalf("expected 6 image references, got %d: %+v", got, signals.GetEvidence("image_references"))
+	}
+	counts := map[string]int{"image_digest_count": 2, "image_semver_count": 1, "image_mutable_count": 2, "image_untagged_count": 1}
+	for key, expected := range counts {
+		if got := signals.GetInt(key); got != expected {
+			t.Errorf("expected %s %d, got %d", key, expected, got)
+		}
+	}
+	if signals.GetBool("images_immutable") || signals.GetBool("images_digest_pinned") || signals.GetBool("versioned_artifacts") {
+		t.Error("expected mutable images")
+	}
+
+	// the CI image and the staging deployment are not production images
+	if got := signals.GetInt("prod_image_count"); got != 4 {
+		t.Errorf("expected 4 production images, got %d", got)
+	}
+	unpinned := signals.GetEvidence("prod_images_not_digest_pinned")
+	if signals.GetBool("prod_images_digest_pinned") || len(unpinned) != 2 {
+		t.Fatalf("unexpected unpinned production images %+v", unpinned)
+	}
+	for _, ev := range unpinned {
+		if ev.Subject != "golang:1.22" && ev.Subject != "acme/api:latest" {
+			t.Errorf("unexpected unpinned production image %+v", ev)
+		}
+	}
+}
+
+func TestAnalyzeImageReferencesVersioned(t *testing.T) {
+	signals := newFileSignals(map[string]string{
+		"Dockerfile":               "FROM python:3\n",
+		"docker-compose.yml":       "services:\n  api:\n    image: acme/api:1.4.2\n",
+		".github/workflows/ci.yml": "jobs:\n  test:\n    container: node:20\n",
+	})
+	signals.SetBool("versioned_artifacts", false)
+	analyzeImageReferences(signals, ScanOptions{})
+
+	// the mutable base and CI images do not make the deployed image unversioned
+	if !signals.GetBool("versioned_artifacts") || signals.GetBool("images_immutable") || signals.GetBool("prod_images_digest_pinned") {
+		t.Error("expected a version-tagged deployed image that is not digest-pinned")
+	}
+
+	signals = newFileSignals(map[string]string{
+		"Dockerfile": "FROM python:3.12-slim\n",
+	})
+	signals.SetBool("versioned_artifacts", true)
+	analyzeImageReferences(signals, ScanOptions{})
+	if !signals.GetBool("versioned_artifacts") {
+		t.Error("expected base images alone to leave versioned_artifacts unchanged")
+	}
+}
@@ -43,6 +43,11 @@ func detectInfrastructure(content, relPath string, signals *RepoSignals) {
 
 // detectRegions counts the number of unique cloud regions configured
 func detectRegions(content, relPath string, signals *RepoSignals) {
+	// Terraform and Pulumi regions come from their parsed configuration
+	if isTerraformFile(relPath) || isPulumiConfigFile(relPath) {
+		return
+	}
+
 	contentLower := strings.ToLower(content)
 
 	// AWS regions
@@ -66,35 +71,16 @@ func detectRegions(content, relPath string, signals *RepoSignals) {
 	signals.SetInt("region_count", signals.GetRegionCount())
 }
 
-// detectNonRootUser checks for non-root user configuration in Dockerfiles
-func detectNonRootUser(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("non_root_user_detected") {
-		return
-	}
-
-	// Only scan Dockerfiles
-	fileName := strings.ToLower(relPath)
-	if !strings.Contains(fileName, "dockerfile") {
-		return
-	}
-
-	contentLower := strings.ToLower(content)
-	nonRootUserPatterns := patterns.NonRootUserPatterns
-
-	for _, pattern := range nonRootUserPatterns {
-		if strings.Contains(contentLower, pattern) {
-			// Basic check: Ensure it's not USER root
-			lines := strings.Split(contentLower, "\n")
-			for _, line := range lines {
-				trimmedLine := strings.TrimSpace(line)
-				if strings.HasPrefix(trimmedLine, "user ") || strings.HasPrefix(trimmedLine, "user\t") {
-					parts := strings.Fields(trimmedLine)
-					if len(parts) >= 2 && parts[1] != "root" && parts[1] != "0" {
-						signals.SetBool("non_root_user_detected", true)
-						return
-					}
-				}
+// knownRegion normalizes a configured region or location (e.g. "West Europe")
+// and reports whether it is a known cloud region
+func knownRegion(value string) (string, bool) {
+	region := strings.ToLower(strings.ReplaceAll(value, " ", ""))
+	for _, list := range [][]string{patterns.AWSRegions, patterns.GCPRegions, patterns.AzureRegions} {
+		for _, known := range list {
+			if region == known {
+				return region, true
 			}
 		}
 	}
+	return "", false
 }
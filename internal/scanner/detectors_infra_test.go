@@ -53,9 +53,9 @@ func TestDetectInfrastructure(t *testing.T) {
 		expected bool
 	}{
 		{
-			name:     "Terraform detected",
-			content:  `provider "aws" { region = "us-east-1" }`,
-			expected: true,
+			name:     "Terraform text is not enough",
+			content:  `# TODO: manage this with terraform`,
+			expected: false,
 		},
 		{
 			name:     "Kubernetes YAML detected",
@@ -97,24 +97,36 @@ func TestDetectRegions(t *testing.T) {
 		}
 
 		// First file: us-east-1
-		detectRegions(`region = "us-east-1"`, "file1.tf", signals)
+		detectRegions(`region: "us-east-1"`, "file1.yaml", signals)
 		if signals.GetInt("region_count") != 1 {
 			t.Errorf("expected 1 region, got %d", signals.GetInt("region_count"))
 		}
 
 		// Second file: eu-west-1 (should increment count)
-		detectRegions(`backup_region = "eu-west-1"`, "file2.tf", signals)
+		detectRegions(`backup_region: "eu-west-1"`, "file2.yaml", signals)
 		if signals.GetInt("region_count") != 2 {
 			t.Errorf("expected 2 regions, got %d", signals.GetInt("region_count"))
 		}
 
 		// Third file: us-east-1 again (duplicate, should NOT increment)
-		detectRegions(`another_ref = "us-east-1"`, "file3.tf", signals)
+		detectRegions(`another_ref: "us-east-1"`, "file3.yaml", signals)
 		if signals.GetInt("region_count") != 2 {
 			t.Errorf("expected 2 regions, got %d", signals.GetInt("region_count"))
 		}
 	})
 
+	t.Run("Terraform and Pulumi files are left to their analysis", func(t *testing.T) {
+		signals := &RepoSignals{
+			IntSignals:      make(map[string]int),
+			DetectedRegions: make(map[string]bool),
+		}
+		detectRegions(`# failover to "eu-west-1" some day`, "main.tf", signals)
+		detectRegions("config:\n  aws:region: us-west-2\n", "infra/Pulumi.staging.yaml", signals)
+		if signals.GetRegionCount() != 0 {
+			t.Errorf("expected no regions from Terraform or Pulumi text, got %d", signals.GetRegionCount())
+		}
+	})
+
 	// 2. Test specific new regions
 	tests := []struct {
 		name          string
@@ -149,7 +161,7 @@ func TestDetectRegions(t *testing.T) {
 				IntSignals:      make(map[string]int),
 				DetectedRegions: make(map[string]bool),
 			}
-			detectRegions(tt.content, "test.tf", signals)
+			detectRegions(tt.content, "config.yaml", signals)
 
 			if signals.GetInt("region_count") != tt.expectedCount {
 				t.Errorf("expected %d regions, got %d", tt.expectedCount, signals.GetInt("region_count"))
@@ -157,81 +169,3 @@ func TestDetectRegions(t *testing.T) {
 		})
 	}
 }
-
-func TestDetectNonRootUser(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		relPath  string
-		expected bool
-	}{
-		{
-			name: "Non-root user detected",
-			content: `
-FROM golang:1.21
-RUN useradd -m myuser
-USER myuser
-ENTRYPOINT ["./app"]
-`,
-			relPath:  "Dockerfile",
-			expected: true,
-		},
-		{
-			name: "UID user detected",
-			content: `
-FROM alpine
-USER 1000
-`,
-			relPath:  "dockerfile",
-			expected: true,
-		},
-		{
-			name: "Explicit root user (root)",
-			content: `
-FROM ubuntu
-USER root
-`,
-			relPath:  "Dockerfile",
-			expected: false,
-		},
-		{
-			name: "Explicit root user (0)",
-			content: `
-FROM ubuntu
-USER 0
-`,
-			relPath:  "Dockerfile",
-			expected: false,
-		},
-		{
-			name: "No USER instruction",
-			content: `
-FROM node:18
-COPY . .
-`,
-			relPath:  "Dockerfile",
-			expected: false,
-		},
-		{
-			name: "Not a Dockerfile",
-			content: `
-USER myuser
-`,
-			relPath:  "readme.md",
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			signals := &RepoSignals{
-				BoolSignals: make(map[string]bool),
-			}
-			detectNonRootUser(tt.content, tt.relPath, signals)
-
-			if signals.GetBool("non_root_user_detected") != tt.expected {
-				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("non_root_user_detected"))
-			}
-		})
-	}
-}
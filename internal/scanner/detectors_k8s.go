@@ -1,212 +1,163 @@
 package scanner
 
 import (
-	"path/filepath"
 	"strings"
 
-	"gopkg.in/yaml.v3"
-
 	"github.com/chuanjin/production-readiness/internal/patterns"
 )
 
-// detectK8sDeploymentStrategy checks Kubernetes deployment files for strategy
-func detectK8sDeploymentStrategy(content, relPath string, signals *RepoSignals) {
-	if signals.GetString("k8s_deployment_strategy") != "" {
-		return
-	}
-
-	// Only check YAML files
-	ext := strings.ToLower(filepath.Ext(relPath))
-	if ext != ExtYAML && ext != ExtYML {
+// detectK8sManifests decodes every Kubernetes object in a YAML file once and
+// records it for the Kubernetes checks that run after the scan. Templates of
+// Helm charts are replaced by their rendered output once every file is known
+// (see analyzeHelmCharts); other files are checked even when they contain
+// template placeholders.
+func detectK8sManifests(content, relPath string, signals *RepoSignals) {
+	objects := parseK8sManifests(content, relPath)
+	if len(objects) == 0 {
 		return
 	}
+	signals.addK8sManifests(relPath, objects)
+}
 
-	var doc map[string]interface{}
-	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
+// analyzeK8sManifests runs all Kubernetes checks over the recorded manifests
+func analyzeK8sManifests(signals *RepoSignals, _ ScanOptions) {
+	sets := sortedManifestSets(signals.getK8sManifests())
+	if len(sets) == 0 {
 		return
 	}
+	runAllK8sChecks(sets, signals)
+}
 
-	kind, ok := doc["kind"].(string)
-	if !ok || kind != "Deployment" {
-		return
-	}
+// checkK8sWorkloads counts the pod-producing workloads across all manifests
+func checkK8sWorkloads(sets []k8sManifestSet, signals *RepoSignals) {
+	signals.SetInt("k8s_workload_count", len(k8sWorkloads(sets)))
+}
 
-	if spec, ok := doc["spec"].(map[string]interface{}); ok {
-		if strategy, ok := spec["strategy"].(map[string]interface{}); ok {
-			if strategyType, ok := strategy["type"].(string); ok {
+// checkK8sDeploymentStrategy records the strategy of the first Deployment that declares one
+func checkK8sDeploymentStrategy(sets []k8sManifestSet, signals *RepoSignals) {
+	for _, set := range sets {
+		for i := range set.Objects {
+			obj := &set.Objects[i]
+			if obj.Kind != "Deployment" {
+				continue
+			}
+			if strategyType, ok := nestedMap(obj.Spec(), "strategy")["type"].(string); ok {
 				signals.SetString("k8s_deployment_strategy", strategyType)
+				return
 			}
 		}
 	}
 }
 
-// detectK8sProbes checks for Kubernetes liveness/readiness probes
-func detectK8sProbes(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("k8s_probe_defined") {
-		return
-	}
-
-	// Only check YAML files
-	ext := strings.ToLower(filepath.Ext(relPath))
-	if ext != ".yaml" && ext != ".yml" {
-		return
-	}
-
-	var doc map[string]interface{}
-	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
-		return
-	}
-
-	// Check if it's a Kubernetes resource with containers
-	kind, ok := doc["kind"].(string)
-	if !ok {
-		return
-	}
-
-	// Look for probes in Pod, Deployment, StatefulSet, DaemonSet, etc.
-	validKinds := patterns.K8sValidKinds
-
-	if !validKinds[kind] {
-		return
-	}
-
-	// Navigate to containers
-	var containers []interface{}
-
-	if spec, ok := doc["spec"].(map[string]interface{}); ok {
-		// For Deployments, StatefulSets, etc., probes are in spec.template.spec.containers
-		if template, ok := spec["template"].(map[string]interface{}); ok {
-			if templateSpec, ok := template["spec"].(map[string]interface{}); ok {
-				if c, ok := templateSpec["containers"].([]interface{}); ok {
-					containers = c
-				}
+// checkK8sProbes checks every long-running container for liveness/readiness
+// probes. k8s_probe_defined only holds when no container is missing them,
+// k8s_any_probe_defined when at least one is probed, and each offending
+// container is recorded as evidence.
+func checkK8sProbes(sets []k8sManifestSet, signals *RepoSignals) {
+	checked, missing := 0, 0
+	workloads := k8sWorkloads(sets)
+	for i := range workloads {
+		w := &workloads[i]
+		// Batch workloads run to completion and are not probed
+		if w.Kind == "Job" || w.Kind == "CronJob" {
+			continue
+		}
+		for _, c := range w.Containers {
+			checked++
+			if containerHasProbe(c) {
+				continue
 			}
-		} else if c, ok := spec["containers"].([]interface{}); ok {
-			// For Pods, probes are directly in spec.containers
-			containers = c
+			missing++
+			signals.AddEvidence("k8s_containers_without_probes", k8sContainerEvidence(w, c, "no livenessProbe or readinessProbe"))
 		}
 	}
+	if checked > 0 {
+		signals.SetBool("k8s_probe_defined", missing == 0)
+		signals.SetBool("k8s_any_probe_defined", missing < checked)
+	}
+}
 
-	// Check if any container has probes
-	for _, container := range containers {
-		if c, ok := container.(map[string]interface{}); ok {
-			// Check for livenessProbe or readinessProbe
-			if _, hasLiveness := c["livenessProbe"]; hasLiveness {
-				signals.SetBool("k8s_probe_defined", true)
-				return
-			}
-			if _, hasReadiness := c["readinessProbe"]; hasReadiness {
-				signals.SetBool("k8s_probe_defined", true)
+// checkIngressRateLimit checks for rate limiting in Kubernetes Ingress
+func checkIngressRateLimit(sets []k8sManifestSet, signals *RepoSignals) {
+	for _, set := range sets {
+		for i := range set.Objects {
+			obj := &set.Objects[i]
+			if obj.Kind == "Ingress" && ingressHasRateLimit(obj) {
+				signals.SetBool("ingress_rate_limit", true)
 				return
 			}
 		}
 	}
 }
 
-// detectIngressRateLimit checks for rate limiting in Kubernetes Ingress
-func detectIngressRateLimit(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("ingress_rate_limit") {
-		return
-	}
-
-	// Only check YAML files
-	ext := strings.ToLower(filepath.Ext(relPath))
-	if ext != ".yaml" && ext != ".yml" {
-		return
-	}
-
-	var doc map[string]interface{}
-	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
-		return
-	}
-
-	// Check if it's an Ingress resource
-	kind, ok := doc["kind"].(string)
-	if !ok || kind != "Ingress" {
-		return
-	}
-
-	// Check annotations for rate limiting
-	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
-		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
-			// NGINX Ingress rate limiting annotations
-			rateLimitAnnotations := patterns.NginxIngressRateLimitAnnotations
-
-			for _, annotation := range rateLimitAnnotations {
-				if _, exists := annotations[annotation]; exists {
-					signals.SetBool("ingress_rate_limit", true)
-					return
-				}
-			}
-
-			// Also check if Kong plugins annotation contains rate-limiting
-			if plugins, ok := annotations["konghq.com/plugins"].(string); ok {
-				if strings.Contains(strings.ToLower(plugins), "rate-limit") {
-					signals.SetBool("ingress_rate_limit", true)
-					return
-				}
+// checkResourceLimits checks every container, including init containers, for
+// CPU/memory limits and records each container that has none
+func checkResourceLimits(sets []k8sManifestSet, signals *RepoSignals) {
+	checked, missing := 0, 0
+	workloads := k8sWorkloads(sets)
+	for i := range workloads {
+		w := &workloads[i]
+		for _, c := range w.AllContainers() {
+			checked++
+			if containerHasLimits(c) {
+				continue
 			}
+			missing++
+			signals.AddEvidence("k8s_containers_without_limits", k8sContainerEvidence(w, c, "no cpu or memory limits"))
 		}
 	}
-}
-
-// detectResourceLimits checks for Kubernetes resource limits configurations
-func detectResourceLimits(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("k8s_resource_limits_detected") {
-		return
+	if checked > 0 {
+		signals.SetBool("k8s_resource_limits_detected", missing == 0)
 	}
+}
 
-	ext := strings.ToLower(filepath.Ext(relPath))
-	if ext != ExtYAML && ext != ExtYML {
-		return
+// k8sContainerEvidence builds evidence for a container as namespace/kind/name/container
+func k8sContainerEvidence(w *k8sWorkload, c k8sContainer, detail string) Evidence {
+	name := c.Name
+	if name == "" {
+		name = "unnamed"
 	}
-
-	var doc map[string]interface{}
-	if err := yaml.Unmarshal([]byte(content), &doc); err != nil {
-		return
+	if c.Init {
+		detail = "init container: " + detail
 	}
-
-	// Check if it's a Kubernetes resource
-	kind, ok := doc["kind"].(string)
-	if !ok {
-		return
+	return Evidence{
+		Subject: w.ID() + "/" + name,
+		File:    w.File,
+		Line:    w.Line,
+		Detail:  detail,
+		Context: w.Origin,
 	}
+}
 
-	validKinds := patterns.K8sValidKinds
-
-	if !validKinds[kind] {
-		return
-	}
+// containerHasProbe reports whether a container defines a liveness or readiness probe
+func containerHasProbe(c k8sContainer) bool {
+	_, hasLiveness := c.Spec["livenessProbe"]
+	_, hasReadiness := c.Spec["readinessProbe"]
+	return hasLiveness || hasReadiness
+}
 
-	// Navigate to containers
-	var containers []interface{}
+// containerHasLimits reports whether a container limits CPU or memory
+func containerHasLimits(c k8sContainer) bool {
+	limits := nestedMap(c.Spec, "resources", "limits")
+	_, hasCPU := limits["cpu"]
+	_, hasMemory := limits["memory"]
+	return hasCPU || hasMemory
+}
 
-	if spec, ok := doc["spec"].(map[string]interface{}); ok {
-		if template, ok := spec["template"].(map[string]interface{}); ok {
-			if templateSpec, ok := template["spec"].(map[string]interface{}); ok {
-				if c, ok := templateSpec["containers"].([]interface{}); ok {
-					containers = c
-				}
-			}
-		} else if c, ok := spec["containers"].([]interface{}); ok {
-			// For Pods, probes are directly in spec.containers
-			containers = c
+// ingressHasRateLimit checks Ingress annotations for rate limiting
+func ingressHasRateLimit(obj *k8sObject) bool {
+	// NGINX Ingress rate limiting annotations
+	for _, annotation := range patterns.NginxIngressRateLimitAnnotations {
+		if _, exists := obj.Annotations[annotation]; exists {
+			return true
 		}
 	}
 
-	for _, container := range containers {
-		if c, ok := container.(map[string]interface{}); ok {
-			if resources, ok := c["resources"].(map[string]interface{}); ok {
-				if limits, ok := resources["limits"].(map[string]interface{}); ok {
-					// Check if cpu or memory limits are defined
-					_, hasCPU := limits["cpu"]
-					_, hasMemory := limits["memory"]
-					if hasCPU || hasMemory {
-						signals.SetBool("k8s_resource_limits_detected", true)
-						return
-					}
-				}
-			}
+	// Also check if Kong plugins annotation contains rate-limiting
+	if plugins, ok := obj.Annotations["konghq.com/plugins"]; ok {
+		if strings.Contains(strings.ToLower(plugins), "rate-limit") {
+			return true
 		}
 	}
+	return false
 }
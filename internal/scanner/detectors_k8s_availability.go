@@ -0,0 +1,297 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// k8sAvailability is a Deployment or StatefulSet paired with the
+// PodDisruptionBudgets that select its pods and the HorizontalPodAutoscalers
+// that scale it
+type k8sAvailability struct {
+	Workload *k8sWorkload
+	PDBs     []*k8sObject
+	HPAs     []*k8sObject
+}
+
+// MinReplicas returns the fewest replicas the workload runs with: the
+// lowest HPA minReplicas when it is autoscaled, its replicas otherwise
+func (a *k8sAvailability) MinReplicas() int {
+	if len(a.HPAs) == 0 {
+		return a.Workload.Replicas
+	}
+	lowest := -1
+	for _, hpa := range a.HPAs {
+		minReplicas := 1
+		if n, ok := hpa.Spec()["minReplicas"].(int); ok {
+			minReplicas = n
+		}
+		if lowest < 0 || minReplicas < lowest {
+			lowest = minReplicas
+		}
+	}
+	return lowest
+}
+
+// MaxReplicas returns the most replicas the workload runs with: the
+// highest HPA maxReplicas when it is autoscaled, its replicas otherwise
+func (a *k8sAvailability) MaxReplicas() int {
+	highest := a.Workload.Replicas
+	for _, hpa := range a.HPAs {
+		if n, ok := hpa.Spec()["maxReplicas"].(int); ok && n > highest {
+			highest = n
+		}
+	}
+	return highest
+}
+
+// checkK8sAvailability pairs every Deployment and StatefulSet with the
+// PodDisruptionBudgets and HorizontalPodAutoscalers in its namespace and
+// manifest set (raw manifests count as one set) and
+// records single-replica workloads, replicated workloads without a PDB,
+// PDBs that block every eviction and HPAs scaling on utilization of
+// containers that request no resources
+func checkK8sAvailability(sets []k8sManifestSet, signals *RepoSignals) {
+	pdbs, hpas := k8sDisruptionObjects(sets)
+	workloads := k8sWorkloads(sets)
+	checked, single, withoutPDB, blocking, withoutRequests := 0, 0, 0, 0, 0
+	for i := range workloads {
+		w := &workloads[i]
+		if w.Kind != "Deployment" && w.Kind != "StatefulSet" {
+			continue
+		}
+		checked++
+		a := pairK8sAvailability(w, pdbs, hpas)
+		signals.AddEvidence("k8s_workload_availability", k8sWorkloadEvidence(w, a.Summary()))
+
+		minReplicas := a.MinReplicas()
+		if minReplicas == 1 {
+			single++
+			detail := "replicas: 1"
+			switch {
+			case len(a.HPAs) > 0:
+				detail = "HPA minReplicas: 1"
+			case !w.HasReplicas:
+				detail = "replicas not set, defaults to 1"
+			}
+			signals.AddEvidence("k8s_single_replica_workloads", k8sWorkloadEvidence(w, detail))
+		}
+		// a budget cannot keep a single replica up through a drain, so only
+		// replicated workloads are expected to have one
+		if len(a.PDBs) == 0 && minReplicas > 1 {
+			withoutPDB++
+			signals.AddEvidence("k8s_workloads_without_pdb", k8sWorkloadEvidence(w, fmt.Sprintf("%d replicas and no PodDisruptionBudget", minReplicas)))
+		}
+		if problem := a.EvictionBlocked(); problem != "" {
+			blocking++
+			signals.AddEvidence("k8s_pdbs_blocking_eviction", k8sWorkloadEvidence(w, problem))
+		}
+		for _, hpa := range a.HPAs {
+			resources := hpaUtilizationResources(hpa)
+			for _, c := range w.Containers {
+				if missing := containerMissingRequests(c, resources); len(missing) > 0 {
+					withoutRequests++
+					detail := fmt.Sprintf("HPA %s scales on %s utilization but no %s request is set", hpa.Name, strings.Join(missing, ", "), strings.Join(missing, " or "))
+					signals.AddEvidence("k8s_hpa_containers_without_requests", k8sContainerEvidence(w, c, detail))
+				}
+			}
+		}
+	}
+	if checked == 0 {
+		return
+	}
+
+	signals.SetBool("k8s_pdb_detected", len(pdbs) > 0)
+	signals.SetBool("k8s_hpa_detected", len(hpas) > 0)
+	signals.SetInt("k8s_single_replica_count", single)
+	signals.SetInt("k8s_workloads_without_pdb_count", withoutPDB)
+	signals.SetInt("k8s_pdb_blocking_eviction_count", blocking)
+	signals.SetInt("k8s_hpa_without_requests_count", withoutRequests)
+}
+
+// k8sDisruptionObjects returns the PodDisruptionBudgets and
+// HorizontalPodAutoscalers across the given manifest sets
+func k8sDisruptionObjects(sets []k8sManifestSet) (pdbs, hpas []*k8sObject) {
+	for _, set := range sets {
+		for i := range set.Objects {
+			switch obj := &set.Objects[i]; obj.Kind {
+			case "PodDisruptionBudget":
+				pdbs = append(pdbs, obj)
+			case "HorizontalPodAutoscaler":
+				hpas = append(hpas, obj)
+			}
+		}
+	}
+	return pdbs, hpas
+}
+
+// pairK8sAvailability finds the PDBs whose selector matches the workload's
+// pod labels and the HPAs whose scaleTargetRef names it, among those
+// deployed with the workload
+func pairK8sAvailability(w *k8sWorkload, pdbs, hpas []*k8sObject) k8sAvailability {
+	a := k8sAvailability{Workload: w}
+	namespace := k8sNamespace(&w.k8sObject)
+	for _, pdb := range pdbs {
+		selector := nestedMap(pdb.Spec(), "selector")
+		// a PDB without a selector selects no pods
+		if w.sameDeployment(pdb) && k8sNamespace(pdb) == namespace && selector != nil && selectorMatches(selector, w.PodLabels) {
+			a.PDBs = append(a.PDBs, pdb)
+		}
+	}
+	for _, hpa := range hpas {
+		ref := nestedMap(hpa.Spec(), "scaleTargetRef")
+		if w.sameDeployment(hpa) && k8sNamespace(hpa) == namespace && ref["kind"] == w.Kind && ref["name"] == w.Name {
+			a.HPAs = append(a.HPAs, hpa)
+		}
+	}
+	return a
+}
+
+// Summary describes the replicas, budgets and autoscalers of the workload
+func (a *k8sAvailability) Summary() string {
+	parts := []string{fmt.Sprintf("replicas %d", a.Workload.Replicas)}
+	if len(a.PDBs) == 0 {
+		parts = append(parts, "no PodDisruptionBudget")
+	}
+	for _, pdb := range a.PDBs {
+		parts = append(parts, "PDB "+pdb.Name+" "+pdbBudget(pdb))
+	}
+	if len(a.HPAs) == 0 {
+		parts = append(parts, "no HorizontalPodAutoscaler")
+	}
+	for _, hpa := range a.HPAs {
+		minReplicas, ok := hpa.Spec()["minReplicas"].(int)
+		if !ok {
+			minReplicas = 1
+		}
+		maxReplicas, _ := hpa.Spec()["maxReplicas"].(int)
+		parts = append(parts, fmt.Sprintf("HPA %s %d-%d", hpa.Name, minReplicas, maxReplicas))
+	}
+	return strings.Join(parts, "; ")
+}
+
+// EvictionBlocked describes why no pod of the workload can be evicted, or
+// returns "" when drains can proceed
+func (a *k8sAvailability) EvictionBlocked() string {
+	if len(a.PDBs) > 1 {
+		// the eviction API refuses pods selected by more than one budget
+		names := make([]string, 0, len(a.PDBs))
+		for _, pdb := range a.PDBs {
+			names = append(names, pdb.Name)
+		}
+		return "pods are selected by several PodDisruptionBudgets (" + strings.Join(names, ", ") + ")"
+	}
+	for _, pdb := range a.PDBs {
+		spec := pdb.Spec()
+		if value, ok := spec["maxUnavailable"]; ok {
+			if n, percent, ok := pdbValue(value); ok && n == 0 {
+				return fmt.Sprintf("PDB %s sets maxUnavailable: %s", pdb.Name, pdbFormat(n, percent))
+			}
+		}
+		if value, ok := spec["minAvailable"]; ok {
+			n, percent, ok := pdbValue(value)
+			switch {
+			case !ok:
+			case percent && n >= 100:
+				return fmt.Sprintf("PDB %s sets minAvailable: %d%%", pdb.Name, n)
+			case !percent && n >= a.MinReplicas():
+				return fmt.Sprintf("PDB %s sets minAvailable: %d with %d replicas", pdb.Name, n, a.MinReplicas())
+			}
+		}
+	}
+	return ""
+}
+
+// pdbBudget describes the disruption budget of a PDB
+func pdbBudget(pdb *k8sObject) string {
+	spec := pdb.Spec()
+	for _, key := range []string{"minAvailable", "maxUnavailable"} {
+		if n, percent, ok := pdbValue(spec[key]); ok {
+			return key + " " + pdbFormat(n, percent)
+		}
+	}
+	return "without a budget"
+}
+
+// pdbValue parses a minAvailable or maxUnavailable value, either a count or
+// a percentage
+func pdbValue(value interface{}) (n int, percent, ok bool) {
+	switch v := value.(type) {
+	case int:
+		return v, false, true
+	case string:
+		s := strings.TrimSpace(v)
+		percent = strings.HasSuffix(s, "%")
+		n, err := strconv.Atoi(strings.TrimSuffix(s, "%"))
+		return n, percent, err == nil
+	}
+	return 0, false, false
+}
+
+// pdbFormat formats a budget value parsed by pdbValue
+func pdbFormat(n int, percent bool) string {
+	if percent {
+		return fmt.Sprintf("%d%%", n)
+	}
+	return strconv.Itoa(n)
+}
+
+// hpaUtilizationResources returns the resources an HPA scales on as a
+// percentage of requests. Without any metrics it scales on CPU.
+func hpaUtilizationResources(hpa *k8sObject) []string {
+	spec := hpa.Spec()
+	if _, ok := spec["targetCPUUtilizationPercentage"]; ok {
+		return []string{"cpu"}
+	}
+	metrics, _ := spec["metrics"].([]interface{})
+	if len(metrics) == 0 {
+		return []string{"cpu"}
+	}
+	var resources []string
+	for _, item := range metrics {
+		metric, _ := item.(map[string]interface{})
+		var resource map[string]interface{}
+		switch metric["type"] {
+		case "Resource":
+			resource = nestedMap(metric, "resource")
+		case "ContainerResource":
+			resource = nestedMap(metric, "containerResource")
+		default:
+			continue
+		}
+		name, _ := resource["name"].(string)
+		_, v2beta1 := resource["targetAverageUtilization"]
+		if name != "" && (nestedMap(resource, "target")["type"] == "Utilization" || v2beta1) {
+			resources = append(resources, name)
+		}
+	}
+	return resources
+}
+
+// containerMissingRequests returns the resources a container requests no
+// amount of. Limits count, as requests default to them.
+func containerMissingRequests(c k8sContainer, resources []string) []string {
+	requests := nestedMap(c.Spec, "resources", "requests")
+	limits := nestedMap(c.Spec, "resources", "limits")
+	var missing []string
+	for _, resource := range resources {
+		_, requested := requests[resource]
+		_, limited := limits[resource]
+		if !requested && !limited {
+			missing = append(missing, resource)
+		}
+	}
+	return missing
+}
+
+// k8sWorkloadEvidence builds evidence for a workload as namespace/kind/name
+func k8sWorkloadEvidence(w *k8sWorkload, detail string) Evidence {
+	return Evidence{
+		Subject: w.ID(),
+		File:    w.File,
+		Line:    w.Line,
+		Detail:  detail,
+		Context: w.Origin,
+	}
+}
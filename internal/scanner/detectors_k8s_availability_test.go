@@ -0,0 +1,276 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+const testAvailabilityManifests = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  replicas: 3
+  selector:
+    matchLabels:
+      app: api
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+        resources:
+          requests:
+            cpu: 100m
+      - name: proxy
+        image: envoy:1.30.0
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: api
+  namespace: shop
+spec:
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: api
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: api
+  namespace: shop
+spec:
+  scaleTargetRef:
+    apiVersion: apps/v1
+    kind: Deployment
+    name: api
+  minReplicas: 2
+  maxReplicas: 10
+  metrics:
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: Utilization
+        averageUtilization: 70
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: worker
+    spec:
+      containers:
+      - name: worker
+        image: worker:1.0.0
+---
+apiVersion: apps/v1
+kind: StatefulSet
+metadata:
+  name: db
+  namespace: shop
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: db
+    spec:
+      containers:
+      - name: db
+        image: postgres:16.2
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: db
+  namespace: shop
+spec:
+  minAvailable: 3
+  selector:
+    matchLabels:
+      app: db
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: web:1.0.0
+`
+
+func TestCheckK8sAvailability(t *testing.T) {
+	signals := newFileSignals(nil)
+	checkK8sAvailability(k8sSetsFor(testAvailabilityManifests, "k8s/shop.yaml"), signals)
+
+	if !signals.GetBool("k8s_pdb_detected") || !signals.GetBool("k8s_hpa_detected") {
+		t.Error("expected PDBs and HPAs to be detected")
+	}
+
+	availability := signals.GetEvidence("k8s_workload_availability")
+	if len(availability) != 4 {
+		t.Fatalf("expected 4 workloads, got %+v", availability)
+	}
+	if got := availability[0]; got.Subject != "shop/Deployment/api" || got.Detail != "replicas 3; PDB api maxUnavailable 1; HPA api 2-10" {
+		t.Errorf("unexpected availability %+v", got)
+	}
+
+	single := signals.GetEvidence("k8s_single_replica_workloads")
+	if signals.GetInt("k8s_single_replica_count") != 1 || len(single) != 1 || single[0].Subject != "shop/Deployment/worker" || single[0].Detail != "replicas not set, defaults to 1" {
+		t.Errorf("unexpected single replica evidence %+v", single)
+	}
+
+	withoutPDB := signals.GetEvidence("k8s_workloads_without_pdb")
+	if signals.GetInt("k8s_workloads_without_pdb_count") != 1 || len(withoutPDB) != 1 || withoutPDB[0].Subject != "shop/Deployment/web" {
+		t.Errorf("unexpected workloads without PDB %+v", withoutPDB)
+	}
+
+	blocking := signals.GetEvidence("k8s_pdbs_blocking_eviction")
+	if signals.GetInt("k8s_pdb_blocking_eviction_count") != 1 || len(blocking) != 1 || blocking[0].Detail != "PDB db sets minAvailable: 3 with 3 replicas" {
+		t.Errorf("unexpected blocking PDB evidence %+v", blocking)
+	}
+
+	requests := signals.GetEvidence("k8s_hpa_containers_without_requests")
+	if signals.GetInt("k8s_hpa_without_requests_count") != 1 || len(requests) != 1 || requests[0].Subject != "shop/Deployment/api/proxy" {
+		t.Errorf("unexpected HPA request evidence %+v", requests)
+	}
+}
+
+func TestCheckK8sAvailabilityOverlays(t *testing.T) {
+	// both overlays build the shared base with its Deployment and PDB, and
+	// the raw manifests split them across files
+	base := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: policy/v1
+kind: PodDisruptionBudget
+metadata:
+  name: api
+spec:
+  maxUnavailable: 1
+  selector:
+    matchLabels:
+      app: api
+`
+	deployment, pdb, _ := strings.Cut(base, "---\n")
+	sets := []k8sManifestSet{
+		k8sSetsFor(deployment, "k8s/api.yaml")[0],
+		k8sSetsFor(pdb, "k8s/pdb.yaml")[0],
+		k8sOverlaySet(base, "overlays/prod"),
+		k8sOverlaySet(base, "overlays/staging"),
+	}
+	signals := newFileSignals(nil)
+	checkK8sAvailability(sets, signals)
+
+	if blocking := signals.GetEvidence("k8s_pdbs_blocking_eviction"); len(blocking) != 0 {
+		t.Errorf("expected each overlay's PDB to select only its own pods, got %+v", blocking)
+	}
+	availability := signals.GetEvidence("k8s_workload_availability")
+	if len(availability) != 3 {
+		t.Fatalf("expected the raw and both overlay workloads, got %+v", availability)
+	}
+	for _, ev := range availability {
+		if ev.Detail != "replicas 3; PDB api maxUnavailable 1; no HorizontalPodAutoscaler" {
+			t.Errorf("expected exactly one PDB for %s, got %q", ev.Context, ev.Detail)
+		}
+	}
+}
+
+func TestK8sAvailabilityEvictionBlocked(t *testing.T) {
+	tests := []struct {
+		name     string
+		budget   string
+		replicas int
+		expected string
+	}{
+		{"max unavailable", "maxUnavailable: 1", 3, ""},
+		{"zero max unavailable", "maxUnavailable: 0", 3, "PDB api sets maxUnavailable: 0"},
+		{"zero percent", `maxUnavailable: "0%"`, 3, "PDB api sets maxUnavailable: 0%"},
+		{"min available below replicas", "minAvailable: 2", 3, ""},
+		{"min available equals replicas", "minAvailable: 1", 1, "PDB api sets minAvailable: 1 with 1 replicas"},
+		{"full percentage", `minAvailable: "100%"`, 3, "PDB api sets minAvailable: 100%"},
+		{"partial percentage", `minAvailable: "50%"`, 3, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdb := parseK8sManifests("kind: PodDisruptionBudget\nmetadata:\n  name: api\nspec:\n  "+tt.budget+"\n", "pdb.yaml")[0]
+			a := k8sAvailability{Workload: &k8sWorkload{Replicas: tt.replicas}, PDBs: []*k8sObject{&pdb}}
+			if got := a.EvictionBlocked(); got != tt.expected {
+				t.Errorf("expected %q, got %q", tt.expected, got)
+			}
+		})
+	}
+}
+
+func TestHPAUtilizationResources(t *testing.T) {
+	tests := []struct {
+		name     string
+		spec     string
+		expected []string
+	}{
+		{"default", "maxReplicas: 5", []string{"cpu"}},
+		{"v1", "targetCPUUtilizationPercentage: 80", []string{"cpu"}},
+		{
+			"v2 utilization and value",
+			`metrics:
+  - type: Resource
+    resource:
+      name: memory
+      target:
+        type: Utilization
+        averageUtilization: 80
+  - type: Resource
+    resource:
+      name: cpu
+      target:
+        type: AverageValue
+        averageValue: 500m
+  - type: Pods
+    pods:
+      metric:
+        name: requests_per_second`,
+			[]string{"memory"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			hpa := parseK8sManifests("kind: HorizontalPodAutoscaler\nmetadata:\n  name: api\nspec:\n  "+tt.spec+"\n", "hpa.yaml")[0]
+			if got := hpaUtilizationResources(&hpa); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,158 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// k8sNamespaceCoverage is the default-deny state of a namespace within one
+// manifest set and where its first workload is declared
+type k8sNamespaceCoverage struct {
+	Namespace   string
+	DenyIngress bool
+	DenyEgress  bool
+	Production  bool
+	Workload    *k8sWorkload
+}
+
+// checkNetworkPolicies works out which workloads Kubernetes, Cilium and
+// Calico network policies select and which namespaces deny ingress and
+// egress by default. It records the share of workloads selected by any
+// policy and the production namespaces without default-deny. Namespaces are
+// production unless their name, or the path of every workload in them, names
+// a development, staging or test environment. Policies only apply to the
+// workloads of their own manifest set, so each chart or overlay, and the raw
+// manifests together, has its own namespaces.
+func checkNetworkPolicies(sets []k8sManifestSet, signals *RepoSignals) {
+	policies := collectNetworkPolicies(sets)
+	workloads := k8sWorkloads(sets)
+	if len(workloads) == 0 {
+		return
+	}
+	namespaces := make(map[string]*k8sNamespaceCoverage) // by manifest set and namespace
+	covered, ingressIsolated, egressIsolated := 0, 0, 0
+	for i := range workloads {
+		w := &workloads[i]
+		namespace := k8sNamespace(&w.k8sObject)
+		key := w.Origin + "\x00" + namespace
+		ns, ok := namespaces[key]
+		if !ok {
+			ns = namespaceDefaultDeny(policies, w)
+			namespaces[key] = ns
+		}
+		if !nonProductionName(namespace) && !nonProductionName(w.File+" "+w.Origin) {
+			ns.Production = true
+		}
+
+		names, ingress, egress := selectingPolicies(policies, w)
+		if len(names) == 0 {
+			signals.AddEvidence("k8s_workloads_without_network_policy", k8sWorkloadEvidence(w, "selected by no network policy"))
+			continue
+		}
+		covered++
+		if ingress {
+			ingressIsolated++
+		}
+		if egress {
+			egressIsolated++
+		}
+		detail := fmt.Sprintf("policies %s; ingress %s; egress %s", strings.Join(names, ", "), netpolIsolation(ingress), netpolIsolation(egress))
+		signals.AddEvidence("k8s_workload_network_policies", k8sWorkloadEvidence(w, detail))
+	}
+	denyIngress, denyEgress, prodWithoutDeny := checkDefaultDeny(namespaces, signals)
+
+	signals.SetBool("k8s_network_policy_detected", len(policies) > 0)
+	signals.SetInt("k8s_network_policy_coverage", covered*100/len(workloads))
+	signals.SetInt("k8s_workloads_without_network_policy_count", len(workloads)-covered)
+	signals.SetInt("k8s_ingress_isolated_workload_count", ingressIsolated)
+	signals.SetInt("k8s_egress_isolated_workload_count", egressIsolated)
+	signals.SetBool("k8s_default_deny_ingress", denyIngress)
+	signals.SetBool("k8s_default_deny_egress", denyEgress)
+	signals.SetInt("k8s_prod_namespaces_without_default_deny_count", prodWithoutDeny)
+}
+
+// collectNetworkPolicies returns the network policies of all manifest sets
+func collectNetworkPolicies(sets []k8sManifestSet) []networkPolicy {
+	var policies []networkPolicy
+	for _, set := range sets {
+		for i := range set.Objects {
+			if p, ok := parseNetworkPolicy(&set.Objects[i]); ok {
+				policies = append(policies, p)
+			}
+		}
+	}
+	return policies
+}
+
+// namespaceDefaultDeny returns the default-deny state the namespace of
+// workload w gets from its own policies and cluster-wide ones deployed with it
+func namespaceDefaultDeny(policies []networkPolicy, w *k8sWorkload) *k8sNamespaceCoverage {
+	ns := &k8sNamespaceCoverage{Namespace: k8sNamespace(&w.k8sObject), Workload: w}
+	for i := range policies {
+		p := &policies[i]
+		if !w.sameDeployment(p.Object) {
+			continue
+		}
+		if p.ClusterWide || k8sNamespace(p.Object) == ns.Namespace {
+			ns.DenyIngress = ns.DenyIngress || p.DenyIngress()
+			ns.DenyEgress = ns.DenyEgress || p.DenyEgress()
+		}
+	}
+	return ns
+}
+
+// selectingPolicies returns the sorted names of the policies deployed with
+// a workload that select its pods, and whether any of them isolates ingress
+// or egress
+func selectingPolicies(policies []networkPolicy, w *k8sWorkload) (names []string, ingress, egress bool) {
+	for i := range policies {
+		p := &policies[i]
+		if !w.sameDeployment(p.Object) || !p.Selects(k8sNamespace(&w.k8sObject), w.PodLabels) {
+			continue
+		}
+		names = append(names, p.Object.Name)
+		ingress = ingress || p.Ingress
+		egress = egress || p.Egress
+	}
+	sort.Strings(names)
+	return names, ingress, egress
+}
+
+// checkDefaultDeny records the production namespaces that do not deny
+// ingress and egress by default. It reports whether every namespace denies
+// each direction and how many production namespaces lack default-deny.
+func checkDefaultDeny(namespaces map[string]*k8sNamespaceCoverage, signals *RepoSignals) (denyIngress, denyEgress bool, prodWithoutDeny int) {
+	denyIngress, denyEgress = true, true
+	for _, ns := range namespaces {
+		denyIngress = denyIngress && ns.DenyIngress
+		denyEgress = denyEgress && ns.DenyEgress
+		var missing []string
+		if !ns.DenyIngress {
+			missing = append(missing, "ingress")
+		}
+		if !ns.DenyEgress {
+			missing = append(missing, "egress")
+		}
+		if !ns.Production || len(missing) == 0 {
+			continue
+		}
+		prodWithoutDeny++
+		signals.AddEvidence("k8s_prod_namespaces_without_default_deny", Evidence{
+			Subject: ns.Namespace,
+			File:    ns.Workload.File,
+			Line:    ns.Workload.Line,
+			Detail:  "no default-deny " + strings.Join(missing, " or ") + " policy",
+			Context: ns.Workload.Origin,
+		})
+	}
+	return denyIngress, denyEgress, prodWithoutDeny
+}
+
+// netpolIsolation describes whether a traffic direction is isolated by a policy
+func netpolIsolation(isolated bool) string {
+	if isolated {
+		return "isolated"
+	}
+	return "open"
+}
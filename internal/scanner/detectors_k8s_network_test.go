@@ -0,0 +1,166 @@
+package scanner
+
+import "testing"
+
+func TestCheckNetworkPolicies(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: web:1.0.0
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: api
+  namespace: shop
+spec:
+  podSelector:
+    matchLabels:
+      app: api
+  policyTypes: [Ingress]
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop-staging
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: payments
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: payments
+spec:
+  podSelector: {}
+  policyTypes: [Ingress, Egress]
+`
+	signals := newFileSignals(nil)
+	checkNetworkPolicies(k8sSetsFor(content, "k8s/all.yaml"), signals)
+
+	if !signals.GetBool("k8s_network_policy_detected") {
+		t.Error("expected network policies to be detected")
+	}
+	if got := signals.GetInt("k8s_network_policy_coverage"); got != 50 {
+		t.Errorf("expected 50%% coverage, got %d", got)
+	}
+	if signals.GetBool("k8s_default_deny_ingress") || signals.GetBool("k8s_default_deny_egress") {
+		t.Error("expected namespaces without default-deny")
+	}
+
+	uncovered := signals.GetEvidence("k8s_workloads_without_network_policy")
+	if signals.GetInt("k8s_workloads_without_network_policy_count") != 2 || len(uncovered) != 2 ||
+		uncovered[0].Subject != "shop/Deployment/web" || uncovered[1].Subject != "shop-staging/Deployment/api" {
+		t.Errorf("unexpected uncovered workloads %+v", uncovered)
+	}
+
+	covered := signals.GetEvidence("k8s_workload_network_policies")
+	if len(covered) != 2 || covered[1].Subject != "payments/Deployment/api" || covered[1].Detail != "policies default-deny; ingress isolated; egress isolated" {
+		t.Errorf("unexpected covered workloads %+v", covered)
+	}
+
+	// staging is not production and payments denies by default
+	prod := signals.GetEvidence("k8s_prod_namespaces_without_default_deny")
+	if signals.GetInt("k8s_prod_namespaces_without_default_deny_count") != 1 || len(prod) != 1 ||
+		prod[0].Subject != "shop" || prod[0].Detail != "no default-deny ingress or egress policy" {
+		t.Errorf("unexpected namespaces without default-deny %+v", prod)
+	}
+}
+
+func TestCheckNetworkPoliciesOverlays(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  namespace: shop
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+`
+	defaultDeny := `---
+apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+  namespace: shop
+spec:
+  podSelector: {}
+  policyTypes:
+  - Ingress
+  - Egress
+`
+	sets := []k8sManifestSet{
+		k8sOverlaySet(deployment, "overlays/prod"),
+		k8sOverlaySet(deployment+defaultDeny, "overlays/staging"),
+	}
+	signals := newFileSignals(nil)
+	checkNetworkPolicies(sets, signals)
+
+	// the staging default-deny does not protect the prod overlay
+	missing := signals.GetEvidence("k8s_prod_namespaces_without_default_deny")
+	if signals.GetInt("k8s_prod_namespaces_without_default_deny_count") != 1 || len(missing) != 1 ||
+		missing[0].Subject != "shop" || missing[0].Context != "overlay overlays/prod" {
+		t.Errorf("expected the prod overlay namespace without default-deny, got %+v", missing)
+	}
+	uncovered := signals.GetEvidence("k8s_workloads_without_network_policy")
+	if len(uncovered) != 1 || uncovered[0].Context != "overlay overlays/prod" {
+		t.Errorf("expected only the prod workload to be unselected, got %+v", uncovered)
+	}
+	if got := signals.GetInt("k8s_network_policy_coverage"); got != 50 {
+		t.Errorf("expected 50%% coverage, got %d", got)
+	}
+}
@@ -0,0 +1,333 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// Pod Security Standards levels
+const (
+	pssPrivileged = "privileged"
+	pssBaseline   = "baseline"
+	pssRestricted = "restricted"
+)
+
+var (
+	// capabilities the baseline level allows containers to add
+	pssBaselineCapabilities = map[string]bool{
+		"AUDIT_WRITE": true, "CHOWN": true, "DAC_OVERRIDE": true, "FOWNER": true, "FSETID": true, "KILL": true,
+		"MKNOD": true, "NET_BIND_SERVICE": true, "SETFCAP": true, "SETGID": true, "SETPCAP": true, "SETUID": true,
+		"SYS_CHROOT": true,
+	}
+
+	// volume types the restricted level allows
+	pssRestrictedVolumes = map[string]bool{
+		"configMap": true, "csi": true, "downwardAPI": true, "emptyDir": true, "ephemeral": true,
+		"persistentVolumeClaim": true, "projected": true, "secret": true,
+	}
+)
+
+// podSecurityResult is the evaluation of one container against the Pod
+// Security Standards, including the pod-level settings it runs with
+type podSecurityResult struct {
+	Baseline   []string // baseline violations
+	Restricted []string // violations of the restricted level beyond baseline
+	NonRoot    bool     // runs as a non-root user
+	ReadOnly   bool     // root filesystem is read-only
+}
+
+// checkPodSecurity evaluates every container, including init containers,
+// against the baseline and restricted Pod Security Standards and records
+// the user it runs as and whether its root filesystem is read-only. The
+// levels are the strictest all containers meet.
+func checkPodSecurity(sets []k8sManifestSet, signals *RepoSignals) {
+	workloads := k8sWorkloads(sets)
+	checked, baseline, restricted, root, writable := 0, 0, 0, 0, 0
+	for i := range workloads {
+		w := &workloads[i]
+		for _, c := range w.AllContainers() {
+			checked++
+			r := evaluatePodSecurity(w, c)
+			if len(r.Baseline) > 0 {
+				baseline++
+				signals.AddEvidence("k8s_pss_baseline_violations", k8sContainerEvidence(w, c, strings.Join(r.Baseline, "; ")))
+			}
+			if len(r.Restricted) > 0 {
+				restricted++
+				signals.AddEvidence("k8s_pss_restricted_violations", k8sContainerEvidence(w, c, strings.Join(r.Restricted, "; ")))
+			}
+			if !r.NonRoot {
+				root++
+			}
+			if !r.ReadOnly {
+				writable++
+				signals.AddEvidence("k8s_containers_writable_root_fs", k8sContainerEvidence(w, c, "readOnlyRootFilesystem is not true"))
+			}
+		}
+	}
+	if checked == 0 {
+		return
+	}
+
+	level := pssRestricted
+	switch {
+	case baseline > 0:
+		level = pssPrivileged
+	case restricted > 0:
+		level = pssBaseline
+	}
+	signals.SetString("k8s_pod_security_level", level)
+	signals.SetBool("k8s_pss_baseline", baseline == 0)
+	signals.SetBool("k8s_pss_restricted", baseline == 0 && restricted == 0)
+	signals.SetInt("k8s_pss_baseline_violation_count", baseline)
+	signals.SetInt("k8s_pss_restricted_violation_count", restricted)
+	signals.SetBool("k8s_run_as_non_root", root == 0)
+	if root == 0 {
+		// the pod spec sets the effective user, whatever the image declares
+		signals.SetBool("non_root_user_detected", true)
+	}
+	signals.SetBool("k8s_read_only_root_fs", writable == 0)
+}
+
+// evaluatePodSecurity checks a container against the Pod Security Standards.
+// Container securityContext settings override those of the pod.
+func evaluatePodSecurity(w *k8sWorkload, c k8sContainer) podSecurityResult {
+	in := &podSecurityInput{
+		workload:  w,
+		container: c,
+		pod:       nestedMap(w.PodSpec, "securityContext"),
+		sc:        nestedMap(c.Spec, "securityContext"),
+	}
+	var r podSecurityResult
+	for _, control := range pssBaselineControls {
+		r.Baseline = append(r.Baseline, control(in)...)
+	}
+	for _, control := range pssRestrictedControls {
+		r.Restricted = append(r.Restricted, control(in)...)
+	}
+	runAsUser, userSet := in.runAsUser()
+	r.NonRoot = (in.runAsNonRoot() || (userSet && runAsUser > 0)) && !(userSet && runAsUser == 0)
+	r.ReadOnly = in.sc["readOnlyRootFilesystem"] == true
+	return r
+}
+
+// podSecurityInput is a container and the pod it runs in
+type podSecurityInput struct {
+	workload  *k8sWorkload
+	container k8sContainer
+	pod       map[string]interface{} // pod securityContext
+	sc        map[string]interface{} // container securityContext
+}
+
+// seccompType returns the seccomp profile type the container runs with
+func (in *podSecurityInput) seccompType() string {
+	if seccomp, _ := nestedMap(in.sc, "seccompProfile")["type"].(string); seccomp != "" {
+		return seccomp
+	}
+	seccomp, _ := nestedMap(in.pod, "seccompProfile")["type"].(string)
+	return seccomp
+}
+
+// runAsNonRoot returns the runAsNonRoot setting the container runs with
+func (in *podSecurityInput) runAsNonRoot() bool {
+	if runAsNonRoot, ok := in.sc["runAsNonRoot"].(bool); ok {
+		return runAsNonRoot
+	}
+	runAsNonRoot, _ := in.pod["runAsNonRoot"].(bool)
+	return runAsNonRoot
+}
+
+// runAsUser returns the user the container runs as, if one is set
+func (in *podSecurityInput) runAsUser() (int, bool) {
+	if user, ok := in.sc["runAsUser"].(int); ok {
+		return user, true
+	}
+	user, ok := in.pod["runAsUser"].(int)
+	return user, ok
+}
+
+// addedCapabilities returns the capabilities the container adds
+func (in *podSecurityInput) addedCapabilities() []string {
+	return stringList(nestedMap(in.sc, "capabilities")["add"])
+}
+
+// volumes returns the named volumes of the pod
+func (in *podSecurityInput) volumes() []map[string]interface{} {
+	items, _ := in.workload.PodSpec["volumes"].([]interface{})
+	var volumes []map[string]interface{}
+	for _, item := range items {
+		if volume, ok := item.(map[string]interface{}); ok {
+			volumes = append(volumes, volume)
+		}
+	}
+	return volumes
+}
+
+// pssBaselineControls check host namespaces, privileged containers, added
+// capabilities, hostPath volumes, host ports and unconfined seccomp
+var pssBaselineControls = []func(in *podSecurityInput) []string{
+	pssHostNamespaces,
+	pssPrivilegedContainer,
+	pssDisallowedCapabilities,
+	pssHostPathVolumes,
+	pssHostPorts,
+	pssUnconfinedSeccomp,
+}
+
+// pssRestrictedControls check non-root users, privilege escalation,
+// dropped capabilities, the seccomp profile and the volume types
+var pssRestrictedControls = []func(in *podSecurityInput) []string{
+	pssNonRootUser,
+	pssPrivilegeEscalation,
+	pssDropAllCapabilities,
+	pssRestrictedCapabilities,
+	pssSeccompProfile,
+	pssVolumeTypes,
+}
+
+// pssHostNamespaces is the pod-level host namespace control
+func pssHostNamespaces(in *podSecurityInput) []string {
+	var violations []string
+	for _, field := range []string{"hostNetwork", "hostPID", "hostIPC"} {
+		if in.workload.PodSpec[field] == true {
+			violations = append(violations, field+": true")
+		}
+	}
+	return violations
+}
+
+// pssPrivilegedContainer is the container-level privileged control
+func pssPrivilegedContainer(in *podSecurityInput) []string {
+	if in.sc["privileged"] == true {
+		return []string{"privileged: true"}
+	}
+	return nil
+}
+
+// pssDisallowedCapabilities is the baseline capability control: only the
+// default runtime capabilities may be added
+func pssDisallowedCapabilities(in *podSecurityInput) []string {
+	var disallowed []string
+	for _, capability := range in.addedCapabilities() {
+		if !pssBaselineCapabilities[strings.TrimPrefix(strings.ToUpper(capability), "CAP_")] {
+			disallowed = append(disallowed, capability)
+		}
+	}
+	if len(disallowed) > 0 {
+		return []string{"adds capabilities " + strings.Join(disallowed, ", ")}
+	}
+	return nil
+}
+
+// pssHostPathVolumes is the pod-level hostPath volume control
+func pssHostPathVolumes(in *podSecurityInput) []string {
+	var hostPaths []string
+	for _, volume := range in.volumes() {
+		if hostPath := nestedMap(volume, "hostPath"); hostPath != nil {
+			name, _ := volume["name"].(string)
+			path, _ := hostPath["path"].(string)
+			hostPaths = append(hostPaths, fmt.Sprintf("%s (%s)", name, path))
+		}
+	}
+	if len(hostPaths) > 0 {
+		return []string{"hostPath volumes " + strings.Join(hostPaths, ", ")}
+	}
+	return nil
+}
+
+// pssHostPorts is the container-level host port control
+func pssHostPorts(in *podSecurityInput) []string {
+	var violations []string
+	ports, _ := in.container.Spec["ports"].([]interface{})
+	for _, item := range ports {
+		if port, ok := item.(map[string]interface{}); ok {
+			if hostPort, ok := port["hostPort"].(int); ok && hostPort != 0 {
+				violations = append(violations, fmt.Sprintf("hostPort %d", hostPort))
+			}
+		}
+	}
+	return violations
+}
+
+// pssUnconfinedSeccomp is the baseline seccomp control
+func pssUnconfinedSeccomp(in *podSecurityInput) []string {
+	if in.seccompType() == "Unconfined" {
+		return []string{"seccompProfile Unconfined"}
+	}
+	return nil
+}
+
+// pssNonRootUser is the restricted control on runAsNonRoot and runAsUser
+func pssNonRootUser(in *podSecurityInput) []string {
+	var violations []string
+	if !in.runAsNonRoot() {
+		violations = append(violations, "runAsNonRoot is not true")
+	}
+	if user, ok := in.runAsUser(); ok && user == 0 {
+		violations = append(violations, "runAsUser: 0")
+	}
+	return violations
+}
+
+// pssPrivilegeEscalation is the container-level privilege escalation control
+func pssPrivilegeEscalation(in *podSecurityInput) []string {
+	if in.sc["allowPrivilegeEscalation"] != false {
+		return []string{"allowPrivilegeEscalation is not false"}
+	}
+	return nil
+}
+
+// pssDropAllCapabilities requires containers to drop ALL capabilities
+func pssDropAllCapabilities(in *podSecurityInput) []string {
+	dropped := stringList(nestedMap(in.sc, "capabilities")["drop"])
+	if !slices.ContainsFunc(dropped, func(s string) bool { return strings.EqualFold(s, "ALL") }) {
+		return []string{"capabilities do not drop ALL"}
+	}
+	return nil
+}
+
+// pssRestrictedCapabilities is the restricted capability control: of the
+// capabilities baseline allows, only NET_BIND_SERVICE may be added
+func pssRestrictedCapabilities(in *podSecurityInput) []string {
+	var violations []string
+	for _, capability := range in.addedCapabilities() {
+		if name := strings.TrimPrefix(strings.ToUpper(capability), "CAP_"); name != "NET_BIND_SERVICE" && pssBaselineCapabilities[name] {
+			violations = append(violations, "adds capability "+capability)
+		}
+	}
+	return violations
+}
+
+// pssSeccompProfile is the restricted seccomp control. Unconfined is
+// already a baseline violation.
+func pssSeccompProfile(in *podSecurityInput) []string {
+	switch in.seccompType() {
+	case "RuntimeDefault", "Localhost", "Unconfined":
+		return nil
+	}
+	return []string{"no RuntimeDefault or Localhost seccompProfile"}
+}
+
+// pssVolumeTypes is the restricted volume type control. hostPath volumes
+// are already baseline violations.
+func pssVolumeTypes(in *podSecurityInput) []string {
+	var other []string
+	for _, volume := range in.volumes() {
+		if nestedMap(volume, "hostPath") != nil {
+			continue
+		}
+		name, _ := volume["name"].(string)
+		for key := range volume {
+			if key != "name" && !pssRestrictedVolumes[key] {
+				other = append(other, fmt.Sprintf("%s (%s)", name, key))
+			}
+		}
+	}
+	if len(other) == 0 {
+		return nil
+	}
+	sort.Strings(other)
+	return []string{"volume types " + strings.Join(other, ", ")}
+}
@@ -0,0 +1,189 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+const testRestrictedDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      securityContext:
+        runAsNonRoot: true
+        seccompProfile:
+          type: RuntimeDefault
+      containers:
+      - name: api
+        image: api:1.0.0
+        securityContext:
+          allowPrivilegeEscalation: false
+          readOnlyRootFilesystem: true
+          capabilities:
+            drop: ["ALL"]
+            add: ["NET_BIND_SERVICE"]
+      volumes:
+      - name: tmp
+        emptyDir: {}
+`
+
+func TestEvaluatePodSecurity(t *testing.T) {
+	tests := []struct {
+		name       string
+		content    string
+		baseline   []string
+		restricted []string
+		nonRoot    bool
+		readOnly   bool
+	}{
+		{
+			name:     "restricted",
+			content:  testRestrictedDeployment,
+			nonRoot:  true,
+			readOnly: true,
+		},
+		{
+			name: "defaults",
+			content: `kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    spec:
+      containers:
+      - name: web
+        image: nginx:1.25
+`,
+			restricted: []string{
+				"runAsNonRoot is not true",
+				"allowPrivilegeEscalation is not false",
+				"capabilities do not drop ALL",
+				"no RuntimeDefault or Localhost seccompProfile",
+			},
+		},
+		{
+			name: "privileged",
+			content: `kind: DaemonSet
+metadata:
+  name: agent
+spec:
+  template:
+    spec:
+      hostNetwork: true
+      hostPID: true
+      securityContext:
+        runAsUser: 1000
+        seccompProfile:
+          type: Unconfined
+      containers:
+      - name: agent
+        image: agent:2.0.0
+        ports:
+        - containerPort: 9100
+          hostPort: 9100
+        securityContext:
+          runAsUser: 0
+          privileged: true
+          capabilities:
+            add: ["SYS_ADMIN", "CHOWN"]
+      volumes:
+      - name: root
+        hostPath:
+          path: /
+      - name: data
+        nfs:
+          server: nfs.internal
+`,
+			baseline: []string{
+				"hostNetwork: true",
+				"hostPID: true",
+				"privileged: true",
+				"adds capabilities SYS_ADMIN",
+				"hostPath volumes root (/)",
+				"hostPort 9100",
+				"seccompProfile Unconfined",
+			},
+			restricted: []string{
+				"runAsNonRoot is not true",
+				"runAsUser: 0",
+				"allowPrivilegeEscalation is not false",
+				"capabilities do not drop ALL",
+				"adds capability CHOWN",
+				"volume types data (nfs)",
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workloads := k8sWorkloads(k8sSetsFor(tt.content, "deploy.yaml"))
+			if len(workloads) != 1 || len(workloads[0].Containers) != 1 {
+				t.Fatalf("expected 1 workload with 1 container, got %+v", workloads)
+			}
+			r := evaluatePodSecurity(&workloads[0], workloads[0].Containers[0])
+			if !slices.Equal(r.Baseline, tt.baseline) {
+				t.Errorf("baseline: expected %q, got %q", tt.baseline, r.Baseline)
+			}
+			if !slices.Equal(r.Restricted, tt.restricted) {
+				t.Errorf("restricted: expected %q, got %q", tt.restricted, r.Restricted)
+			}
+			if r.NonRoot != tt.nonRoot || r.ReadOnly != tt.readOnly {
+				t.Errorf("expected nonRoot %v readOnly %v, got %+v", tt.nonRoot, tt.readOnly, r)
+			}
+		})
+	}
+}
+
+func TestCheckPodSecurity(t *testing.T) {
+	content := testRestrictedDeployment + `---
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  template:
+    spec:
+      initContainers:
+      - name: migrate
+        image: worker:1.0.0
+        securityContext:
+          privileged: true
+      containers:
+      - name: worker
+        image: worker:1.0.0
+        securityContext:
+          runAsUser: 1000
+`
+	signals := newFileSignals(nil)
+	checkPodSecurity(k8sSetsFor(content, "k8s/all.yaml"), signals)
+
+	if got := signals.GetString("k8s_pod_security_level"); got != pssPrivileged {
+		t.Errorf("expected privileged, got %q", got)
+	}
+	if signals.GetBool("k8s_pss_baseline") || signals.GetBool("k8s_pss_restricted") || signals.GetBool("k8s_run_as_non_root") {
+		t.Error("expected the privileged init container to fail every level")
+	}
+	baseline := signals.GetEvidence("k8s_pss_baseline_violations")
+	if len(baseline) != 1 || baseline[0].Subject != "default/Deployment/worker/migrate" || baseline[0].Detail != "init container: privileged: true" {
+		t.Errorf("unexpected baseline evidence %+v", baseline)
+	}
+	if got := signals.GetInt("k8s_pss_restricted_violation_count"); got != 2 {
+		t.Errorf("expected 2 containers violating restricted, got %d", got)
+	}
+	if got := len(signals.GetEvidence("k8s_containers_writable_root_fs")); got != 2 {
+		t.Errorf("expected 2 writable root filesystems, got %d", got)
+	}
+}
+
+func TestCheckPodSecurityNonRoot(t *testing.T) {
+	signals := newFileSignals(nil)
+	checkPodSecurity(k8sSetsFor(testRestrictedDeployment, "deploy.yaml"), signals)
+
+	if !signals.GetBool("k8s_pss_restricted") || signals.GetString("k8s_pod_security_level") != pssRestricted {
+		t.Error("expected the deployment to meet the restricted level")
+	}
+	if !signals.GetBool("non_root_user_detected") {
+		t.Error("expected runAsNonRoot in the pod spec to count as a non-root user")
+	}
+}
@@ -4,6 +4,20 @@ import (
 	"testing"
 )
 
+// k8sSetsFor parses content as a single manifest file for the Kubernetes checks
+func k8sSetsFor(content, relPath string) []k8sManifestSet {
+	return []k8sManifestSet{{Source: relPath, Objects: parseK8sManifests(content, relPath)}}
+}
+
+// k8sOverlaySet builds the manifest set of a built kustomize overlay
+func k8sOverlaySet(content, dir string) k8sManifestSet {
+	objects := parseK8sManifests(content, dir+"/kustomization.yaml")
+	for i := range objects {
+		objects[i].Origin = "overlay " + dir
+	}
+	return k8sManifestSet{Source: dir, Objects: objects}
+}
+
 func TestDetectK8sDeploymentStrategy(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -62,7 +76,7 @@ strategy: RollingUpdate
 			signals := &RepoSignals{
 				StringSignals: make(map[string]string),
 			}
-			detectK8sDeploymentStrategy(tt.content, tt.relPath, signals)
+			checkK8sDeploymentStrategy(k8sSetsFor(tt.content, tt.relPath), signals)
 
 			if got := signals.StringSignals["k8s_deployment_strategy"]; got != tt.expected {
 				t.Errorf("detectK8sDeploymentStrategy() = %v, want %v", got, tt.expected)
@@ -163,7 +177,7 @@ spec:
 			signals := &RepoSignals{
 				BoolSignals: make(map[string]bool),
 			}
-			detectIngressRateLimit(tt.content, tt.relPath, signals)
+			checkIngressRateLimit(k8sSetsFor(tt.content, tt.relPath), signals)
 
 			if got := signals.GetBool("ingress_rate_limit"); got != tt.expected {
 				t.Errorf("detectIngressRateLimit() = %v, want %v", got, tt.expected)
@@ -183,7 +197,7 @@ metadata:
   annotations:
     nginx.ingress.kubernetes.io/limit-rps: "10"
 `
-		detectIngressRateLimit(content, "ingress.yaml", signals)
+		checkIngressRateLimit(k8sSetsFor(content, "ingress.yaml"), signals)
 		// Should still be true, function returns early
 		if !signals.GetBool("ingress_rate_limit") {
 			t.Error("expected signal to remain true")
@@ -248,7 +262,7 @@ spec:
 			signals := &RepoSignals{
 				BoolSignals: make(map[string]bool),
 			}
-			detectK8sProbes(tt.content, "deployment.yaml", signals)
+			checkK8sProbes(k8sSetsFor(tt.content, "deployment.yaml"), signals)
 
 			if signals.GetBool("k8s_probe_defined") != tt.expected {
 				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("k8s_probe_defined"))
@@ -325,7 +339,7 @@ spec:
 			signals := &RepoSignals{
 				BoolSignals: make(map[string]bool),
 			}
-			detectResourceLimits(tt.content, "deploy.yaml", signals)
+			checkResourceLimits(k8sSetsFor(tt.content, "deploy.yaml"), signals)
 
 			if signals.GetBool("k8s_resource_limits_detected") != tt.expected {
 				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("k8s_resource_limits_detected"))
@@ -333,3 +347,142 @@ spec:
 		})
 	}
 }
+
+func TestK8sChecksMultiDocument(t *testing.T) {
+	content := `
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  strategy:
+    type: RollingUpdate
+  template:
+    spec:
+      containers:
+      - name: app
+        readinessProbe:
+          httpGet:
+            path: /ready
+---
+apiVersion: batch/v1
+kind: CronJob
+metadata:
+  name: report
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          initContainers:
+          - name: setup
+            resources:
+              limits:
+                memory: "64Mi"
+          containers:
+          - name: report
+`
+	signals := &RepoSignals{
+		BoolSignals:   make(map[string]bool),
+		StringSignals: make(map[string]string),
+		IntSignals:    make(map[string]int),
+	}
+	detectK8sManifests(content, "k8s/all.yaml", signals)
+	analyzeK8sManifests(signals, ScanOptions{})
+
+	if got := signals.GetString("k8s_deployment_strategy"); got != "RollingUpdate" {
+		t.Errorf("expected RollingUpdate strategy from second document, got %q", got)
+	}
+	if !signals.GetBool("k8s_probe_defined") {
+		t.Error("expected probe in second document to be detected")
+	}
+	limits := signals.GetEvidence("k8s_containers_without_limits")
+	if len(limits) != 2 || limits[1].Subject != "default/CronJob/report/report" {
+		t.Errorf("expected the CronJob init container to be covered by its limits, got %+v", limits)
+	}
+	if got := signals.GetInt("k8s_workload_count"); got != 2 {
+		t.Errorf("expected 2 workloads, got %d", got)
+	}
+}
+
+func TestK8sChecksPerWorkloadEvidence(t *testing.T) {
+	content := `
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: good
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+        readinessProbe:
+          httpGet:
+            path: /ready
+        resources:
+          limits:
+            cpu: "1"
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: bad
+  namespace: prod
+spec:
+  template:
+    spec:
+      containers:
+      - name: app
+      - name: sidecar
+        livenessProbe:
+          tcpSocket:
+            port: 8080
+---
+apiVersion: batch/v1
+kind: Job
+metadata:
+  name: once
+spec:
+  template:
+    spec:
+      containers:
+      - name: run
+        resources:
+          limits:
+            memory: "64Mi"
+`
+	signals := &RepoSignals{BoolSignals: make(map[string]bool)}
+	sets := k8sSetsFor(content, "k8s/apps.yaml")
+	checkK8sProbes(sets, signals)
+	checkResourceLimits(sets, signals)
+
+	// One good Deployment must not hide the bad one
+	if signals.GetBool("k8s_probe_defined") {
+		t.Error("expected k8s_probe_defined to be false when a container lacks probes")
+	}
+	if !signals.GetBool("k8s_any_probe_defined") {
+		t.Error("expected k8s_any_probe_defined to hold while the good Deployment is probed")
+	}
+	if signals.GetBool("k8s_resource_limits_detected") {
+		t.Error("expected k8s_resource_limits_detected to be false when a container lacks limits")
+	}
+
+	probes := signals.GetEvidence("k8s_containers_without_probes")
+	if len(probes) != 1 || probes[0].Subject != "prod/Deployment/bad/app" {
+		t.Errorf("expected only prod/Deployment/bad/app without probes (Jobs are skipped), got %+v", probes)
+	}
+	if probes[0].File != "k8s/apps.yaml" || probes[0].Line != 19 {
+		t.Errorf("expected evidence at k8s/apps.yaml:19, got %s", probes[0].Location())
+	}
+
+	limits := signals.GetEvidence("k8s_containers_without_limits")
+	if len(limits) != 2 || limits[0].Subject != "prod/Deployment/bad/app" || limits[1].Subject != "prod/Deployment/bad/sidecar" {
+		t.Errorf("expected both bad containers without limits, got %+v", limits)
+	}
+}
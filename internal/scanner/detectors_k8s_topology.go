@@ -0,0 +1,164 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+)
+
+// Well-known node labels for the zone a node runs in
+const (
+	topologyZoneKey       = "topology.kubernetes.io/zone"
+	topologyLegacyZoneKey = "failure-domain.beta.kubernetes.io/zone"
+)
+
+// podSpread is how a workload's pods are spread across failure domains
+type podSpread struct {
+	Policies []string        // topology spread constraints and anti-affinity terms
+	Keys     map[string]bool // topology keys the policies spread over
+	Zones    []string        // zones the pods are pinned to by node selection
+}
+
+// Zonal reports whether the pods are spread across zones
+func (s *podSpread) Zonal() bool {
+	return s.Keys[topologyZoneKey] || s.Keys[topologyLegacyZoneKey]
+}
+
+// checkK8sTopologySpread records how every multi-replica Deployment and
+// StatefulSet spreads its pods across nodes and zones through
+// topologySpreadConstraints and podAntiAffinity, the workloads without a
+// spread policy and those whose node selection pins them to one zone
+func checkK8sTopologySpread(sets []k8sManifestSet, signals *RepoSignals) {
+	_, hpas := k8sDisruptionObjects(sets)
+	workloads := k8sWorkloads(sets)
+	checked, zonal, unspread, pinned := 0, 0, 0, 0
+	for i := range workloads {
+		w := &workloads[i]
+		if w.Kind != "Deployment" && w.Kind != "StatefulSet" {
+			continue
+		}
+		a := pairK8sAvailability(w, nil, hpas)
+		replicas := a.MaxReplicas()
+		if replicas < 2 {
+			continue
+		}
+		checked++
+
+		spread := evaluatePodSpread(w)
+		if len(spread.Policies) == 0 {
+			unspread++
+			signals.AddEvidence("k8s_workloads_without_spread", k8sWorkloadEvidence(w, fmt.Sprintf("up to %d replicas and no topologySpreadConstraints or podAntiAffinity", replicas)))
+		} else {
+			signals.AddEvidence("k8s_workload_spread", k8sWorkloadEvidence(w, strings.Join(spread.Policies, "; ")))
+		}
+		if spread.Zonal() {
+			zonal++
+		}
+		if len(spread.Zones) == 1 {
+			pinned++
+			signals.AddEvidence("k8s_workloads_zone_pinned", k8sWorkloadEvidence(w, "node selection pins all replicas to zone "+spread.Zones[0]))
+		}
+	}
+	if checked == 0 {
+		return
+	}
+
+	signals.SetInt("k8s_multi_replica_workload_count", checked)
+	signals.SetInt("k8s_workloads_without_spread_count", unspread)
+	signals.SetInt("k8s_zone_pinned_workload_count", pinned)
+	signals.SetBool("k8s_topology_spread", unspread == 0)
+	signals.SetBool("k8s_zone_spread", zonal == checked && pinned == 0)
+}
+
+// evaluatePodSpread collects the spread policies that select the workload's
+// own pods and the zones its node selection allows
+func evaluatePodSpread(w *k8sWorkload) podSpread {
+	spread := podSpread{Keys: make(map[string]bool)}
+
+	constraints, _ := w.PodSpec["topologySpreadConstraints"].([]interface{})
+	for _, item := range constraints {
+		constraint, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := constraint["topologyKey"].(string)
+		selector := nestedMap(constraint, "labelSelector")
+		// without a selector the constraint counts no pods and spreads nothing
+		if key == "" || selector == nil || !selectorMatches(selector, w.PodLabels) {
+			continue
+		}
+		policy := "topologySpreadConstraints on " + key
+		if when, ok := constraint["whenUnsatisfiable"].(string); ok {
+			policy += " (" + when + ")"
+		}
+		spread.Keys[key] = true
+		spread.Policies = append(spread.Policies, policy)
+	}
+
+	antiAffinity := nestedMap(w.PodSpec, "affinity", "podAntiAffinity")
+	terms, _ := antiAffinity["requiredDuringSchedulingIgnoredDuringExecution"].([]interface{})
+	addAntiAffinity := func(term map[string]interface{}, mode string) {
+		key, _ := term["topologyKey"].(string)
+		selector := nestedMap(term, "labelSelector")
+		if key == "" || selector == nil || !selectorMatches(selector, w.PodLabels) {
+			return
+		}
+		spread.Keys[key] = true
+		spread.Policies = append(spread.Policies, "podAntiAffinity on "+key+" ("+mode+")")
+	}
+	for _, item := range terms {
+		if term, ok := item.(map[string]interface{}); ok {
+			addAntiAffinity(term, "required")
+		}
+	}
+	weighted, _ := antiAffinity["preferredDuringSchedulingIgnoredDuringExecution"].([]interface{})
+	for _, item := range weighted {
+		if weightedTerm, ok := item.(map[string]interface{}); ok {
+			if term := nestedMap(weightedTerm, "podAffinityTerm"); term != nil {
+				addAntiAffinity(term, "preferred")
+			}
+		}
+	}
+
+	spread.Zones = podNodeZones(w.PodSpec)
+	return spread
+}
+
+// podNodeZones returns the zones a pod spec's nodeSelector or required node
+// affinity restricts it to, or nil when it may run in any zone
+func podNodeZones(podSpec map[string]interface{}) []string {
+	selector := stringMap(podSpec["nodeSelector"])
+	for _, key := range []string{topologyZoneKey, topologyLegacyZoneKey} {
+		if zone, ok := selector[key]; ok {
+			return []string{zone}
+		}
+	}
+
+	required := nestedMap(podSpec, "affinity", "nodeAffinity", "requiredDuringSchedulingIgnoredDuringExecution")
+	terms, _ := required["nodeSelectorTerms"].([]interface{})
+	// node selector terms are ORed, so every term must restrict the zone
+	zones := make(map[string]bool)
+	for _, item := range terms {
+		term, _ := item.(map[string]interface{})
+		expressions, _ := term["matchExpressions"].([]interface{})
+		restricted := false
+		for _, raw := range expressions {
+			expr, _ := raw.(map[string]interface{})
+			key, _ := expr["key"].(string)
+			if (key != topologyZoneKey && key != topologyLegacyZoneKey) || expr["operator"] != "In" {
+				continue
+			}
+			values, _ := expr["values"].([]interface{})
+			for _, v := range values {
+				zones[yamlScalarString(v)] = true
+			}
+			restricted = true
+		}
+		if !restricted {
+			return nil
+		}
+	}
+	if len(zones) == 0 {
+		return nil
+	}
+	return strings.Split(joinSet(zones), ",")
+}
@@ -0,0 +1,214 @@
+package scanner
+
+import (
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestCheckK8sTopologySpread(t *testing.T) {
+	content := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      topologySpreadConstraints:
+      - maxSkew: 1
+        topologyKey: topology.kubernetes.io/zone
+        whenUnsatisfiable: DoNotSchedule
+        labelSelector:
+          matchLabels:
+            app: api
+      affinity:
+        podAntiAffinity:
+          preferredDuringSchedulingIgnoredDuringExecution:
+          - weight: 100
+            podAffinityTerm:
+              topologyKey: kubernetes.io/hostname
+              labelSelector:
+                matchLabels:
+                  app: api
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  replicas: 2
+  template:
+    metadata:
+      labels:
+        app: worker
+    spec:
+      nodeSelector:
+        topology.kubernetes.io/zone: eu-west-1a
+      topologySpreadConstraints:
+      - maxSkew: 1
+        topologyKey: kubernetes.io/hostname
+        labelSelector:
+          matchLabels:
+            app: other
+      containers:
+      - name: worker
+        image: worker:1.0.0
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: web
+spec:
+  template:
+    metadata:
+      labels:
+        app: web
+    spec:
+      containers:
+      - name: web
+        image: web:1.0.0
+---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: web
+spec:
+  scaleTargetRef:
+    kind: Deployment
+    name: web
+  maxReplicas: 5
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: cron
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: cron
+        image: cron:1.0.0
+`
+	signals := newFileSignals(nil)
+	checkK8sTopologySpread(k8sSetsFor(content, "k8s/all.yaml"), signals)
+
+	if got := signals.GetInt("k8s_multi_replica_workload_count"); got != 3 {
+		t.Errorf("expected 3 multi-replica workloads, got %d", got)
+	}
+	if signals.GetBool("k8s_topology_spread") || signals.GetBool("k8s_zone_spread") {
+		t.Error("expected workloads without spread")
+	}
+
+	spread := signals.GetEvidence("k8s_workload_spread")
+	expected := "topologySpreadConstraints on topology.kubernetes.io/zone (DoNotSchedule); podAntiAffinity on kubernetes.io/hostname (preferred)"
+	if len(spread) != 1 || spread[0].Subject != "default/Deployment/api" || spread[0].Detail != expected {
+		t.Errorf("unexpected spread evidence %+v", spread)
+	}
+
+	// the worker constraint selects other pods, so it spreads nothing
+	unspread := signals.GetEvidence("k8s_workloads_without_spread")
+	if signals.GetInt("k8s_workloads_without_spread_count") != 2 || len(unspread) != 2 {
+		t.Fatalf("unexpected workloads without spread %+v", unspread)
+	}
+	if unspread[1].Subject != "default/Deployment/web" || unspread[1].Detail != "up to 5 replicas and no topologySpreadConstraints or podAntiAffinity" {
+		t.Errorf("unexpected evidence %+v", unspread[1])
+	}
+
+	pinned := signals.GetEvidence("k8s_workloads_zone_pinned")
+	if len(pinned) != 1 || pinned[0].Subject != "default/Deployment/worker" {
+		t.Errorf("unexpected zone pinned evidence %+v", pinned)
+	}
+}
+
+func TestCheckK8sTopologySpreadOverlays(t *testing.T) {
+	deployment := `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+`
+	hpa := `---
+apiVersion: autoscaling/v2
+kind: HorizontalPodAutoscaler
+metadata:
+  name: api
+spec:
+  scaleTargetRef:
+    kind: Deployment
+    name: api
+  maxReplicas: 4
+`
+	sets := []k8sManifestSet{
+		k8sOverlaySet(deployment, "overlays/dev"),
+		k8sOverlaySet(deployment+hpa, "overlays/prod"),
+	}
+	signals := newFileSignals(nil)
+	checkK8sTopologySpread(sets, signals)
+
+	// only prod autoscales, so the dev Deployment keeps its single replica
+	unspread := signals.GetEvidence("k8s_workloads_without_spread")
+	if len(unspread) != 1 || unspread[0].Context != "overlay overlays/prod" {
+		t.Errorf("expected only the autoscaled prod Deployment, got %+v", unspread)
+	}
+}
+
+func TestPodNodeZones(t *testing.T) {
+	tests := []struct {
+		name     string
+		podSpec  string
+		expected []string
+	}{
+		{"unrestricted", "containers: []", nil},
+		{"node selector", "nodeSelector:\n  topology.kubernetes.io/zone: us-east-1a", []string{"us-east-1a"}},
+		{
+			"node affinity",
+			`affinity:
+  nodeAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+      nodeSelectorTerms:
+      - matchExpressions:
+        - key: topology.kubernetes.io/zone
+          operator: In
+          values: [us-east-1b, us-east-1a]`,
+			[]string{"us-east-1a", "us-east-1b"},
+		},
+		{
+			"unrestricted term",
+			`affinity:
+  nodeAffinity:
+    requiredDuringSchedulingIgnoredDuringExecution:
+      nodeSelectorTerms:
+      - matchExpressions:
+        - key: topology.kubernetes.io/zone
+          operator: In
+          values: [us-east-1a]
+      - matchExpressions:
+        - key: node.kubernetes.io/instance-type
+          operator: In
+          values: [m5.large]`,
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseK8sManifests("kind: Pod\nmetadata:\n  name: p\nspec:\n  "+strings.ReplaceAll(tt.podSpec, "\n", "\n  ")+"\n", "pod.yaml")
+			if got := podNodeZones(objects[0].Spec()); !slices.Equal(got, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, got)
+			}
+		})
+	}
+}
@@ -0,0 +1,72 @@
+package scanner
+
+import (
+	"path/filepath"
+	"sort"
+)
+
+// analyzeKustomizations builds every kustomize overlay in the repository
+// offline and records each overlay's effective manifests as its own set, so
+// the Kubernetes checks report per overlay (e.g. prod passes, staging does not)
+func analyzeKustomizations(signals *RepoSignals, opts ScanOptions) {
+	contents := signals.GetFileContentMap()
+
+	var dirs []string
+	for path := range contents {
+		for _, name := range kustomizationFileNames {
+			if filepath.Base(path) == name {
+				dirs = append(dirs, filepath.Dir(path))
+			}
+		}
+	}
+	if len(dirs) == 0 {
+		return
+	}
+	sort.Strings(dirs)
+
+	// Kustomizations referenced by another one are bases, not overlays
+	referenced := make(map[string]bool)
+	for _, dir := range dirs {
+		path, _ := findKustomization(contents, dir)
+		k, err := parseKustomization(contents[path])
+		if err != nil {
+			continue
+		}
+		for _, res := range append(append([]string{}, k.Resources...), k.Bases...) {
+			if resDir := filepath.Join(dir, res); resDir != dir {
+				if _, ok := findKustomization(contents, resDir); ok {
+					referenced[resDir] = true
+				}
+			}
+		}
+	}
+
+	b := &kustomizeBuild{files: contents, consumed: make(map[string]bool)}
+	built := 0
+	for _, dir := range dirs {
+		if referenced[dir] {
+			continue
+		}
+		objects, err := b.build(dir, make(map[string]bool))
+		if err != nil {
+			if opts.Debug {
+				opts.Logger.Printf("Kustomization %s: build failed: %v", dir, err)
+			}
+			path, _ := findKustomization(contents, dir)
+			signals.AddEvidence("kustomize_build_errors", Evidence{Subject: dir, File: path, Detail: err.Error()})
+			continue
+		}
+		for i := range objects {
+			objects[i].Origin = "overlay " + dir
+		}
+		signals.addK8sManifests(dir, objects)
+		built++
+	}
+
+	// Files consumed by a build are superseded by the overlay output
+	for path := range b.consumed {
+		signals.removeK8sManifests(path)
+	}
+
+	signals.SetInt("kustomize_overlay_count", built)
+}
@@ -0,0 +1,346 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// Metrics clients
+const (
+	metricsClientPrometheus = "prometheus"
+	metricsClientMicrometer = "micrometer"
+	metricsClientStatsD     = "statsd"
+)
+
+// metricsClientPatterns recognize metrics client libraries by their import
+// paths and package names, in code and dependency manifests
+var metricsClientPatterns = map[string]*regexp.Regexp{
+	metricsClientPrometheus: regexp.MustCompile(`github\.com/prometheus/client_golang|\bprometheus_client\b|["']prom-client["']|\bio\.prometheus\b|\bprometheus-client\b|["']prometheus/client["']`),
+	metricsClientMicrometer: regexp.MustCompile(`\bio\.micrometer\b|\bmicrometer-registry-\w+`),
+	metricsClientStatsD:     regexp.MustCompile(`github\.com/(?:DataDog/datadog-go|cactus/go-statsd-client|smira/go-statsd|alexcesaro/statsd)|\bimport statsd\b|\bfrom (?:statsd|datadog) import\b|\bdogstatsd\b|["'](?:hot-shots|node-statsd|statsd-client|node-dogstatsd)["']|\bcom\.timgroup\.statsd\b|\bjava-dogstatsd-client\b`),
+}
+
+var (
+	// handlers that serve the Prometheus exposition format
+	metricsEndpointPattern = regexp.MustCompile(`\bpromhttp\.(?:Handler|HandlerFor|InstrumentMetricHandler)\(|\bstart_http_server\(|\bmake_(?:wsgi|asgi)_app\(|\bgenerate_latest\(|\bregister\.metrics\(\)|\bregistry\.metrics\(\)|\bnew\s+HTTPServer\(|\.scrape\(\)|Prometheus::Middleware::Exporter|["']/metrics["']`)
+
+	// middleware that serves /metrics and records request counts by status
+	// and request durations on its own
+	metricsMiddlewarePattern = regexp.MustCompile(`\bginprometheus\.|["']express-prom-bundle["']|\bdjango_prometheus\b|\bprometheus_fastapi_instrumentator\b|\bprometheus_flask_exporter\b|\bPrometheusMetrics\(`)
+
+	// Spring Boot Actuator exposing its prometheus endpoint, as a property
+	// or as a YAML include list
+	actuatorPrometheusPattern = regexp.MustCompile(`exposure\.include\s*[=:][^\n]*(?:prometheus|\*)|include:\s*[^\n]*(?:prometheus|"\*"|'\*')|include:\s*\n(?:\s*-\s*\S+\s*\n)*?\s*-\s*prometheus\b`)
+
+	// RED roles by metric name
+	metricsRequestNamePattern  = regexp.MustCompile(`(?i)request|rpc|calls|http_server|grpc_server|handled|processed`)
+	metricsErrorNamePattern    = regexp.MustCompile(`(?i)error|fail|exception`)
+	metricsDurationNamePattern = regexp.MustCompile(`(?i)duration|latency|seconds|_time|millis`)
+)
+
+// metricsDependencyFiles are searched for client packages alongside code
+var metricsDependencyFiles = map[string]bool{
+	"go.mod": true, "requirements.txt": true, "pyproject.toml": true, "Pipfile": true,
+	"package.json": true, "pom.xml": true, "build.gradle": true, "build.gradle.kts": true, "Gemfile": true,
+}
+
+// analyzeMetrics finds metrics clients, /metrics handlers, scrape
+// configuration (ServiceMonitor and PodMonitor resources, prometheus.io
+// annotations and Prometheus scrape configs) and the request rate, error
+// and duration (RED) metrics the code defines. metrics_exported also holds
+// when analyzeOpenTelemetry found an OpenTelemetry metric exporter.
+func analyzeMetrics(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	scan := &metricsScan{signals: signals, clients: make(map[string]bool)}
+	for _, path := range paths {
+		scan.file(path, contents[path])
+	}
+	clients := scan.clients
+	// a "/metrics" route only counts in a service that uses a client
+	if clients[metricsClientPrometheus] || clients[metricsClientMicrometer] {
+		for _, ev := range scan.handlers {
+			scan.endpoints++
+			signals.AddEvidence("metrics_endpoints", ev)
+		}
+	}
+
+	scraped := checkMetricsScraping(signals)
+
+	if scan.endpoints == 0 {
+		for _, ev := range scan.pullClients {
+			ev.Detail = "metrics are defined but no /metrics handler serves them"
+			signals.AddEvidence("metrics_clients_without_endpoint", ev)
+		}
+	}
+
+	defs := metricDefinitions(contents)
+	red := checkMetricsRED(signals, defs, scan.autoRED)
+	var missing []string
+	for _, role := range metricsREDRoles {
+		if !red[role] {
+			missing = append(missing, role)
+		}
+	}
+
+	pull := (clients[metricsClientPrometheus] || clients[metricsClientMicrometer]) && scan.endpoints > 0
+	exported := signals.GetBool("metrics_exported") || pull || clients[metricsClientStatsD]
+
+	signals.SetBool("metrics_client_detected", len(clients) > 0)
+	signals.SetString("metrics_clients", joinSet(clients))
+	signals.SetBool("metrics_endpoint_detected", scan.endpoints > 0)
+	signals.SetBool("metrics_statsd_detected", clients[metricsClientStatsD])
+	signals.SetBool("metrics_scrape_configured", scraped)
+	signals.SetInt("metrics_definition_count", len(defs))
+	signals.SetBool("metrics_red_rate", red[metricsRoleRate])
+	signals.SetBool("metrics_red_errors", red[metricsRoleErrors])
+	signals.SetBool("metrics_red_duration", red[metricsRoleDuration])
+	signals.SetBool("metrics_red_complete", len(missing) == 0)
+	signals.SetString("metrics_red_missing", strings.Join(missing, ","))
+	signals.SetBool("metrics_exported", exported)
+}
+
+// metricsScan collects the metrics clients and endpoints of a repository
+type metricsScan struct {
+	signals     *RepoSignals
+	clients     map[string]bool
+	pullClients []Evidence // Prometheus and Micrometer clients used in code
+	handlers    []Evidence // /metrics handlers, which count once a client is found
+	endpoints   int
+	autoRED     bool // an endpoint that records RED metrics on its own
+}
+
+// file scans code and dependency manifests for clients and endpoints, and
+// other files for metrics configuration
+func (s *metricsScan) file(path, content string) {
+	base := filepath.Base(path)
+	ext := strings.ToLower(filepath.Ext(path))
+	code := metricSourceExts[ext]
+	if !code && !metricsDependencyFiles[base] && !strings.HasPrefix(base, "requirements") {
+		s.config(path, content, base, ext)
+		return
+	}
+	s.clientLibraries(path, content, code)
+	if code {
+		s.endpoint(path, content)
+	}
+}
+
+// config records the Spring Boot Actuator prometheus endpoint and
+// Prometheus scrape configs
+func (s *metricsScan) config(path, content, base, ext string) {
+	if strings.HasPrefix(base, "application") {
+		if m := actuatorPrometheusPattern.FindStringIndex(content); m != nil {
+			s.endpoints++
+			s.autoRED = true
+			s.signals.AddEvidence("metrics_endpoints", metricsMatchEvidence(content, path, m, "actuator", "Spring Boot Actuator prometheus endpoint"))
+		}
+	}
+	if (ext == ExtYAML || ext == ExtYML) && strings.Contains(content, "scrape_configs:") {
+		m := []int{strings.Index(content, "scrape_configs:"), 0}
+		s.signals.AddEvidence("metrics_scrape_configs", metricsMatchEvidence(content, path, m, "prometheus", "Prometheus scrape_configs"))
+	}
+}
+
+// clientLibraries records each metrics client library a file uses
+func (s *metricsScan) clientLibraries(path, content string, code bool) {
+	for name, pattern := range metricsClientPatterns {
+		m := pattern.FindStringIndex(content)
+		if m == nil {
+			continue
+		}
+		s.clients[name] = true
+		ev := metricsMatchEvidence(content, path, m, name, content[m[0]:m[1]])
+		s.signals.AddEvidence("metrics_clients", ev)
+		if name != metricsClientStatsD && code {
+			s.pullClients = append(s.pullClients, ev)
+		}
+	}
+}
+
+// endpoint records middleware that serves /metrics, or keeps a /metrics
+// handler for later
+func (s *metricsScan) endpoint(path, content string) {
+	if m := metricsMiddlewarePattern.FindStringIndex(content); m != nil {
+		s.clients[metricsClientPrometheus] = true
+		s.endpoints++
+		s.autoRED = true
+		s.signals.AddEvidence("metrics_endpoints", metricsMatchEvidence(content, path, m, "middleware", content[m[0]:m[1]]))
+	} else if m := metricsEndpointPattern.FindStringIndex(content); m != nil {
+		s.handlers = append(s.handlers, metricsMatchEvidence(content, path, m, "handler", content[m[0]:m[1]]))
+	}
+}
+
+// RED roles of a metric
+const (
+	metricsRoleRate     = "rate"
+	metricsRoleErrors   = "errors"
+	metricsRoleDuration = "duration"
+)
+
+// metricsREDRoles are the RED roles in report order
+var metricsREDRoles = []string{metricsRoleRate, metricsRoleErrors, metricsRoleDuration}
+
+// checkMetricsRED records the definitions that serve a RED role and returns
+// the roles covered. Middleware that records RED metrics covers them all.
+func checkMetricsRED(signals *RepoSignals, defs []metricDefinition, autoRED bool) map[string]bool {
+	covered := make(map[string]bool)
+	for _, role := range metricsREDRoles {
+		covered[role] = autoRED
+	}
+	for _, def := range defs {
+		roles := metricREDRoles(def)
+		if len(roles) == 0 {
+			continue
+		}
+		for _, role := range roles {
+			covered[role] = true
+		}
+		signals.AddEvidence("metrics_red_definitions", Evidence{
+			Subject: def.Name,
+			File:    def.File,
+			Line:    def.Line,
+			Detail:  fmt.Sprintf("%s used for %s", def.Type, strings.Join(roles, ", ")),
+			Context: "red",
+		})
+	}
+	return covered
+}
+
+// metricREDRoles returns the RED roles a definition serves, judged by its
+// name, type and labels
+func metricREDRoles(def metricDefinition) []string {
+	var roles []string
+	request := metricsRequestNamePattern.MatchString(def.Name)
+	if request && (def.Type == "counter" || def.Type == "timer") {
+		roles = append(roles, metricsRoleRate)
+	}
+	if (def.Type == "counter" && metricsErrorNamePattern.MatchString(def.Name)) || (request && def.HasStatus) {
+		roles = append(roles, metricsRoleErrors)
+	}
+	if def.Type == "timer" || ((def.Type == "histogram" || def.Type == "summary") && metricsDurationNamePattern.MatchString(def.Name)) {
+		roles = append(roles, metricsRoleDuration)
+	}
+	return roles
+}
+
+// checkMetricsScraping records ServiceMonitor and PodMonitor resources and
+// prometheus.io/scrape annotations, and the long-running workloads none of
+// them scrapes. It reports whether any scrape configuration exists.
+func checkMetricsScraping(signals *RepoSignals) bool {
+	var monitors, services []*k8sObject
+	var workloads []k8sWorkload
+	for _, set := range sortedManifestSets(signals.getK8sManifests()) {
+		for i := range set.Objects {
+			obj := &set.Objects[i]
+			switch obj.Kind {
+			case "ServiceMonitor", "PodMonitor":
+				monitors = append(monitors, obj)
+				selector := stringMap(nestedMap(obj.Spec(), "selector")["matchLabels"])
+				signals.AddEvidence("metrics_scrape_configs", Evidence{
+					Subject: obj.ID(),
+					File:    obj.File,
+					Line:    obj.Line,
+					Detail:  "selects " + formatLabels(selector),
+					Context: obj.Origin,
+				})
+			case "Service":
+				services = append(services, obj)
+			}
+			if w, ok := obj.workload(); ok && (w.Kind == "Deployment" || w.Kind == "StatefulSet" || w.Kind == "DaemonSet") {
+				workloads = append(workloads, w)
+			}
+		}
+	}
+
+	scraped := len(signals.GetEvidence("metrics_scrape_configs")) > 0
+	for i := range workloads {
+		w := &workloads[i]
+		if w.PodAnnotations["prometheus.io/scrape"] == "true" {
+			scraped = true
+			signals.AddEvidence("metrics_scrape_configs", Evidence{
+				Subject: w.ID(),
+				File:    w.File,
+				Line:    w.Line,
+				Detail:  "prometheus.io/scrape annotation",
+				Context: w.Origin,
+			})
+			continue
+		}
+		if !metricsWorkloadMonitored(w, monitors, services) {
+			signals.AddEvidence("k8s_workloads_not_scraped", Evidence{
+				Subject: w.ID(),
+				File:    w.File,
+				Line:    w.Line,
+				Detail:  "no ServiceMonitor, PodMonitor or prometheus.io/scrape annotation selects its pods",
+				Context: w.Origin,
+			})
+		}
+	}
+	return scraped
+}
+
+// metricsWorkloadMonitored reports whether a PodMonitor selects a workload's
+// pods, or a ServiceMonitor or scrape annotation selects a Service in front
+// of them. Namespaces are not compared.
+func metricsWorkloadMonitored(w *k8sWorkload, monitors, services []*k8sObject) bool {
+	var fronting []*k8sObject
+	for _, svc := range services {
+		selector := stringMap(svc.Spec()["selector"])
+		if len(selector) == 0 {
+			continue
+		}
+		if selectorMatches(map[string]interface{}{"matchLabels": svc.Spec()["selector"]}, w.PodLabels) {
+			if svc.Annotations["prometheus.io/scrape"] == "true" {
+				return true
+			}
+			fronting = append(fronting, svc)
+		}
+	}
+	for _, monitor := range monitors {
+		selector := nestedMap(monitor.Spec(), "selector")
+		if monitor.Kind == "PodMonitor" {
+			if selectorMatches(selector, w.PodLabels) {
+				return true
+			}
+			continue
+		}
+		for _, svc := range fronting {
+			if selectorMatches(selector, svc.Labels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// formatLabels formats labels as sorted key=value pairs
+func formatLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return "everything"
+	}
+	pairs := make([]string, 0, len(labels))
+	for key, value := range labels {
+		pairs = append(pairs, key+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// metricsMatchEvidence builds evidence for a pattern match in a file
+func metricsMatchEvidence(content, path string, m []int, subject, detail string) Evidence {
+	return Evidence{
+		Subject: subject,
+		File:    path,
+		Line:    strings.Count(content[:m[0]], "\n") + 1,
+		Detail:  detail,
+	}
+}
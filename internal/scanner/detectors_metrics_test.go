@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzeMetrics(t *testing.T) {
+	files := map[string]string{
+		"go.mod": "module shop\n\nrequire github.com/prometheus/client_golang v1.19.0\n",
+		"internal/metrics/metrics.go": `package metrics
+
+import "github.com/prometheus/client_golang/prometheus"
+
+var Requests = prometheus.NewCounterVec(prometheus.CounterOpts{
+	Name: "http_requests_total",
+}, []string{"method", "code"})
+
+var Duration = prometheus.NewHistogramVec(prometheus.HistogramOpts{
+	Name: "http_request_duration_seconds",
+}, []string{"method"})
+`,
+		"cmd/api/main.go": `package main
+
+import "github.com/prometheus/client_golang/prometheus/promhttp"
+
+func main() {
+	http.Handle("/metrics", promhttp.Handler())
+}
+`,
+		"k8s/api.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      containers:
+      - name: api
+        image: api:1.0.0
+---
+apiVersion: v1
+kind: Service
+metadata:
+  name: api
+  labels:
+    app: api
+spec:
+  selector:
+    app: api
+---
+apiVersion: monitoring.coreos.com/v1
+kind: ServiceMonitor
+metadata:
+  name: api
+spec:
+  selector:
+    matchLabels:
+      app: api
+`,
+		"k8s/worker.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: worker
+spec:
+  template:
+    metadata:
+      labels:
+        app: worker
+    spec:
+      containers:
+      - name: worker
+        image: worker:1.0.0
+`,
+	}
+	signals := newFileSignals(files)
+	for path, content := range files {
+		detectK8sManifests(content, path, signals)
+	}
+	analyzeMetrics(signals, ScanOptions{})
+
+	for _, key := range []string{"metrics_client_detected", "metrics_endpoint_detected", "metrics_scrape_configured", "metrics_red_complete", "metrics_exported"} {
+		if !signals.GetBool(key) {
+			t.Errorf("expected %s", key)
+		}
+	}
+	if got := signals.GetString("metrics_clients"); got != "prometheus" {
+		t.Errorf("unexpected clients %q", got)
+	}
+	if got := signals.GetInt("metrics_definition_count"); got != 2 {
+		t.Errorf("expected 2 metric definitions, got %d", got)
+	}
+	red := signals.GetEvidence("metrics_red_definitions")
+	if len(red) != 2 || red[0].Detail != "counter used for rate, errors" || red[1].Detail != "histogram used for duration" {
+		t.Errorf("unexpected RED evidence %+v", red)
+	}
+	notScraped := signals.GetEvidence("k8s_workloads_not_scraped")
+	if len(notScraped) != 1 || notScraped[0].Subject != "default/Deployment/worker" {
+		t.Errorf("unexpected unscraped workloads %+v", notScraped)
+	}
+}
+
+func TestAnalyzeMetricsWithoutEndpoint(t *testing.T) {
+	signals := newFileSignals(map[string]string{
+		"worker/jobs.py": "from prometheus_client import Counter\n\nJOBS = Counter('jobs_processed', 'Jobs processed')\n",
+	})
+	analyzeMetrics(signals, ScanOptions{})
+
+	if signals.GetBool("metrics_exported") || signals.GetBool("metrics_endpoint_detected") {
+		t.Error("expected metrics without a /metrics handler not to be exported")
+	}
+	missing := signals.GetEvidence("metrics_clients_without_endpoint")
+	if len(missing) != 1 || missing[0].File != "worker/jobs.py" || missing[0].Line != 1 {
+		t.Errorf("unexpected evidence %+v", missing)
+	}
+	if got := signals.GetString("metrics_red_missing"); got != "errors,duration" {
+		t.Errorf("unexpected missing RED metrics %q", got)
+	}
+}
+
+func TestAnalyzeMetricsMiddleware(t *testing.T) {
+	tests := []struct {
+		name  string
+		files map[string]string
+	}{
+		{"fastapi instrumentator", map[string]string{
+			"app/main.py": "from prometheus_fastapi_instrumentator import Instrumentator\n\nInstrumentator().instrument(app).expose(app)\n",
+		}},
+		{"spring actuator", map[string]string{
+			"src/main/resources/application.yml": "management:\n  endpoints:\n    web:\n      exposure:\n        include: health,prometheus\n",
+			"pom.xml":                            "<artifactId>micrometer-registry-prometheus</artifactId>",
+		}},
+		{"statsd", map[string]string{
+			"app/stats.py": "from datadog import statsd\n\nstatsd.increment('orders')\n",
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			signals := newFileSignals(tt.files)
+			analyzeMetrics(signals, ScanOptions{})
+			if !signals.GetBool("metrics_exported") {
+				t.Errorf("expected metrics_exported, evidence %+v", signals.Evidence)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMetricsNone(t *testing.T) {
+	signals := newFileSignals(map[string]string{"main.go": "package main\n\n// GET /metrics\n"})
+	analyzeMetrics(signals, ScanOptions{})
+
+	if signals.GetBool("metrics_exported") || signals.GetBool("metrics_endpoint_detected") {
+		t.Error("expected no metrics")
+	}
+}
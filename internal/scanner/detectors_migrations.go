@@ -0,0 +1,169 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/chuanjin/production-readiness/internal/patterns"
+)
+
+// Migration risk levels
+const (
+	riskHigh   = "high"
+	riskMedium = "medium"
+	riskLow    = "low"
+)
+
+// migrationOpLabels name operations in evidence
+var migrationOpLabels = map[string]string{
+	opCreateTable:  "CREATE TABLE",
+	opDropTable:    "DROP TABLE",
+	opRenameTable:  "RENAME TABLE",
+	opTruncate:     "TRUNCATE",
+	opAddColumn:    "ADD COLUMN",
+	opDropColumn:   "DROP COLUMN",
+	opRenameColumn: "RENAME COLUMN",
+	opAlterType:    "ALTER COLUMN TYPE",
+	opSetNotNull:   "SET NOT NULL",
+	opDropNotNull:  "DROP NOT NULL",
+	opCreateIndex:  "CREATE INDEX",
+	opBackfill:     "UPDATE",
+}
+
+// analyzeMigrations parses the migrations of Flyway, Liquibase,
+// golang-migrate, Alembic, Rails and Prisma statement by statement and
+// classifies the risk of each schema change. Each history is also replayed
+// in order to find the migrations that break the previous application version.
+func analyzeMigrations(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	histories := loadMigrationHistories(contents)
+	if len(histories) == 0 {
+		return
+	}
+	postgres := usesPostgres(contents)
+
+	tools := make(map[string]bool)
+	count, high, medium, breaking, sequences := 0, 0, 0, 0, 0
+	for i := range histories {
+		h := &histories[i]
+		tools[h.Tool] = true
+		count += len(h.Migrations)
+		compat := verifyMigrationHistory(h)
+		for _, ev := range compat.Sequences {
+			sequences++
+			signals.AddEvidence("migration_expand_contract_sequences", ev)
+		}
+		for j := range h.Migrations {
+			m := &h.Migrations[j]
+			if ev, ok := breakingMigrationEvidence(m, &compat); ok {
+				breaking++
+				signals.AddEvidence("migrations_breaking_compatibility", ev)
+			}
+			created := createdTables(m)
+			for k := range m.Ops {
+				op := &m.Ops[k]
+				// the contract step of a verified expand/contract sequence
+				if compat.Contracted[op] {
+					continue
+				}
+				risk, reason := migrationOpRisk(op, postgres, created)
+				switch risk {
+				case riskHigh:
+					high++
+					signals.AddEvidence("migration_high_risk_operations", migrationEvidence(m, op, reason))
+				case riskMedium:
+					medium++
+					signals.AddEvidence("migration_medium_risk_operations", migrationEvidence(m, op, reason))
+				}
+			}
+		}
+	}
+
+	names := make([]string, 0, len(tools))
+	for tool := range tools {
+		names = append(names, tool)
+	}
+	sort.Strings(names)
+	signals.SetBool("migration_tool_detected", true)
+	signals.SetString("migration_tools", strings.Join(names, ","))
+	signals.SetInt("migration_count", count)
+	signals.SetBool("unsafe_migration_detected", high > 0)
+	signals.SetBool("migration_locking_risk_detected", medium > 0)
+	signals.SetInt("migration_breaking_change_count", breaking)
+	signals.SetBool("migration_breaking_change_detected", breaking > 0)
+	signals.SetBool("migration_expand_contract_detected", sequences > 0)
+	signals.SetBool("backward_compatible_migration_hint", breaking == 0)
+}
+
+// migrationOpRisk classifies an operation. Changes to tables created by the
+// same migration are safe, since no running code uses them yet.
+func migrationOpRisk(op *migrationOp, postgres bool, created map[string]bool) (risk, reason string) {
+	if created[op.Table] && op.Kind != opCreateTable {
+		return riskLow, ""
+	}
+	switch op.Kind {
+	case opDropTable:
+		return riskHigh, "drops the table and its data"
+	case opTruncate:
+		return riskHigh, "deletes every row of the table"
+	case opRenameTable:
+		return riskHigh, "renames a table the running version still uses"
+	case opDropColumn:
+		return riskHigh, "removes a column the running version may still read or write"
+	case opRenameColumn:
+		return riskHigh, "renames a column the running version still uses"
+	case opAlterType:
+		return riskHigh, "changes the column type, which can rewrite the table under an exclusive lock"
+	case opAddColumn:
+		if op.NotNull && !op.Default {
+			return riskHigh, "adds a NOT NULL column without a default, which fails on existing rows and breaks inserts from the running version"
+		}
+	case opSetNotNull:
+		return riskMedium, "scans the whole table under an exclusive lock to validate existing rows"
+	case opCreateIndex:
+		if postgres && !op.Concurrent {
+			return riskMedium, "builds the index without CONCURRENTLY, blocking writes for the whole build"
+		}
+	}
+	return riskLow, ""
+}
+
+// createdTables returns the tables a migration creates
+func createdTables(m *migration) map[string]bool {
+	created := make(map[string]bool)
+	for i := range m.Ops {
+		if m.Ops[i].Kind == opCreateTable {
+			created[m.Ops[i].Table] = true
+		}
+	}
+	return created
+}
+
+// usesPostgres reports whether the repository's database is PostgreSQL
+func usesPostgres(contents map[string]string) bool {
+	for _, content := range contents {
+		if containsAny(strings.ToLower(content), patterns.PostgresMarkers) {
+			return true
+		}
+	}
+	return false
+}
+
+// migrationTarget names the table or column an operation changes
+func migrationTarget(op *migrationOp) string {
+	if op.Column != "" {
+		return op.Table + "." + op.Column
+	}
+	return op.Table
+}
+
+// migrationEvidence builds evidence for a migration operation
+func migrationEvidence(m *migration, op *migrationOp, reason string) Evidence {
+	return Evidence{
+		Subject: migrationTarget(op),
+		File:    m.File,
+		Line:    op.Line,
+		Detail:  migrationOpLabels[op.Kind] + " " + reason,
+		Context: m.Tool + " " + m.Version,
+	}
+}
@@ -0,0 +1,170 @@
+package scanner
+
+import (
+	"testing"
+)
+
+var testMigrationFiles = map[string]string{
+	// Flyway: V10 sorts after V2
+	"db/migration/V1__create_users.sql": "CREATE TABLE users (id BIGSERIAL PRIMARY KEY, name TEXT NOT NULL);\nCREATE INDEX idx_users_name ON users (name);\n",
+	"db/migration/V2__rename.sql":       "ALTER TABLE users RENAME COLUMN name TO full_name;\n",
+	"db/migration/V10__index.sql":       "-- speeds up lookups\nCREATE INDEX idx_users_full_name ON users (full_name);\n",
+
+	// golang-migrate: only up migrations are analyzed
+	"migrations/000001_orders.up.sql":   "ALTER TABLE orders ADD COLUMN total NUMERIC NOT NULL;\n",
+	"migrations/000001_orders.down.sql": "ALTER TABLE orders DROP COLUMN total;\n",
+
+	// Rails: the down method is a rollback
+	"db/migrate/20240101000000_remove_legacy.rb": `class RemoveLegacy < ActiveRecord::Migration[7.1]
+  def up
+    remove_column :accounts, :legacy_id
+    add_index :accounts, :email, algorithm: :concurrently
+    change_column_null :accounts, :email, false
+  end
+
+  def down
+    add_column :accounts, :legacy_id, :integer
+  end
+end
+`,
+
+	// Alembic
+	"alembic/versions/b2_retype.py": `revision = "b2"
+down_revision = "a1"
+
+def upgrade():
+    op.alter_column("invoices", "amount", type_=sa.Numeric(12, 2))
+
+def downgrade():
+    op.drop_table("invoices")
+`,
+	"alembic/versions/a1_create.py": `revision = "a1"
+down_revision = None
+
+def upgrade():
+    op.create_table("invoices", sa.Column("id", sa.Integer))
+`,
+
+	// Prisma
+	"prisma/migrations/20240102000000_drop_tags/migration.sql": "DROP TABLE \"tags\";\n",
+
+	// Liquibase YAML and XML changelogs
+	"db/changelog/changes.yaml": `databaseChangeLog:
+  - changeSet:
+      id: 1
+      author: dba
+      changes:
+        - dropColumn:
+            tableName: customers
+            columnName: fax
+`,
+	"db/changelog/master.xml": `<databaseChangeLog>
+  <changeSet id="2" author="dba">
+    <addColumn tableName="customers">
+      <column name="tier" type="varchar(20)">
+        <constraints nullable="false"/>
+      </column>
+    </addColumn>
+    <rollback>
+      <dropColumn tableName="customers" columnName="tier"/>
+    </rollback>
+  </changeSet>
+</databaseChangeLog>
+`,
+
+	// Docs mentioning destructive statements are not migrations
+	"docs/runbook.md": "Never run DROP TABLE users in production.\n",
+	"go.mod":          "module example\n\nrequire github.com/jackc/pgx/v5 v5.5.0\n",
+}
+
+func TestLoadMigrationHistories(t *testing.T) {
+	histories := loadMigrationHistories(testMigrationFiles)
+
+	versions := make(map[string][]string)
+	for _, h := range histories {
+		for _, m := range h.Migrations {
+			versions[h.Tool] = append(versions[h.Tool], m.Version)
+		}
+	}
+	tests := map[string][]string{
+		migrationFlyway:        {"1", "2", "10"},
+		migrationGolangMigrate: {"000001"},
+		migrationRails:         {"20240101000000"},
+		migrationAlembic:       {"a1", "b2"},
+		migrationPrisma:        {"20240102000000"},
+		migrationLiquibase:     {"1", "2"},
+	}
+	for tool, want := range tests {
+		got := versions[tool]
+		if len(got) != len(want) {
+			t.Errorf("%s: expected %v, got %v", tool, want, got)
+			continue
+		}
+		for i := range want {
+			if got[i] != want[i] {
+				t.Errorf("%s: expected %v, got %v", tool, want, got)
+				break
+			}
+		}
+	}
+}
+
+func TestAnalyzeMigrations(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent:   testMigrationFiles,
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeMigrations(signals, ScanOptions{})
+
+	if got := signals.GetInt("migration_count"); got != 10 {
+		t.Errorf("expected 10 migrations, got %d", got)
+	}
+	if !signals.GetBool("unsafe_migration_detected") {
+		t.Error("expected unsafe_migration_detected")
+	}
+	if got := signals.GetInt("migration_breaking_change_count"); got != 7 {
+		t.Errorf("expected 7 breaking migrations, got %d", got)
+	}
+	if signals.GetBool("backward_compatible_migration_hint") {
+		t.Error("expected backward_compatible_migration_hint to be false")
+	}
+
+	type finding struct {
+		subject, file string
+		line          int
+	}
+	tests := []struct {
+		key  string
+		want []finding
+	}{
+		{"migration_high_risk_operations", []finding{
+			{"invoices.amount", "alembic/versions/b2_retype.py", 5},
+			{"customers.fax", "db/changelog/changes.yaml", 6},
+			{"customers.tier", "db/changelog/master.xml", 3},
+			{"accounts.legacy_id", "db/migrate/20240101000000_remove_legacy.rb", 3},
+			{"users.name", "db/migration/V2__rename.sql", 1},
+			{"orders.total", "migrations/000001_orders.up.sql", 1},
+			{"tags", "prisma/migrations/20240102000000_drop_tags/migration.sql", 1},
+		}},
+		{"migration_medium_risk_operations", []finding{
+			{"accounts.email", "db/migrate/20240101000000_remove_legacy.rb", 5},
+			{"users", "db/migration/V10__index.sql", 2},
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			if len(evidence) != len(tt.want) {
+				t.Fatalf("expected %d findings, got %+v", len(tt.want), evidence)
+			}
+			for i, want := range tt.want {
+				ev := evidence[i]
+				if ev.Subject != want.subject || ev.File != want.file || ev.Line != want.line {
+					t.Errorf("expected %+v, got %+v", want, ev)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,110 @@
+package scanner
+
+import (
+	"sort"
+	"strings"
+)
+
+// analyzeOpenTelemetry finds OpenTelemetry SDK setup in Go, Python, Node and
+// Java code, auto-instrumentation agents and Collector configurations. Tracing
+// is configured when a tracer provider and a trace exporter are set up, and
+// metrics are exported when a meter provider and a metric exporter are.
+// Trace context is propagated when a propagator or instrumented HTTP/gRPC
+// middleware is set up.
+func analyzeOpenTelemetry(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// language -> setup kind -> calls
+	setups := make(map[string]map[string][]otelSetup)
+	var pipelines []otelPipeline
+	for _, path := range paths {
+		for _, setup := range parseOTelSetup(contents[path], path) {
+			if setups[setup.Language] == nil {
+				setups[setup.Language] = make(map[string][]otelSetup)
+			}
+			setups[setup.Language][setup.Kind] = append(setups[setup.Language][setup.Kind], setup)
+		}
+		pipelines = append(pipelines, parseOTelCollectorConfig(contents[path], path)...)
+	}
+
+	languages := make(map[string]bool)
+	// providers and exporters are paired per language, so a Go exporter does
+	// not make up for a Python service that never exports its spans
+	tracing, metrics, propagation := false, false, false
+	for language, byKind := range setups {
+		languages[language] = true
+		agents := byKind[otelAgent]
+
+		if len(agents) > 0 || (len(byKind[otelTracerProvider]) > 0 && len(byKind[otelTraceExporter]) > 0) {
+			tracing = true
+			addOTelEvidence(signals, "otel_tracing_setup", agents, byKind[otelTracerProvider], byKind[otelTraceExporter])
+		} else {
+			for _, setup := range byKind[otelTracerProvider] {
+				signals.AddEvidence("otel_tracer_providers_without_exporter", otelEvidence(setup, "spans are created but never exported"))
+			}
+		}
+		if len(agents) > 0 || (len(byKind[otelMeterProvider]) > 0 && len(byKind[otelMetricExporter]) > 0) {
+			metrics = true
+			addOTelEvidence(signals, "otel_metrics_setup", agents, byKind[otelMeterProvider], byKind[otelMetricExporter])
+		}
+		if len(agents) > 0 || len(byKind[otelPropagation]) > 0 {
+			propagation = true
+			addOTelEvidence(signals, "otel_propagation_setup", agents, byKind[otelPropagation])
+		}
+	}
+
+	collectorSignals := make(map[string]bool)
+	for i := range pipelines {
+		p := &pipelines[i]
+		collectorSignals[p.Signal()] = true
+		signals.AddEvidence("otel_collector_pipelines", Evidence{
+			Subject: p.Name,
+			File:    p.File,
+			Line:    p.Line,
+			Detail:  strings.Join(p.Receivers, ", ") + " -> " + strings.Join(p.Exporters, ", "),
+			Context: "collector",
+		})
+		for _, problem := range p.Problems {
+			signals.AddEvidence("otel_collector_issues", Evidence{
+				Subject: p.Name,
+				File:    p.File,
+				Line:    p.Line,
+				Detail:  "pipeline " + problem,
+				Context: "collector",
+			})
+		}
+	}
+
+	signals.SetBool("otel_sdk_detected", len(languages) > 0)
+	signals.SetString("otel_languages", joinSet(languages))
+	signals.SetBool("otel_collector_detected", len(pipelines) > 0)
+	signals.SetString("otel_collector_pipelines", joinSet(collectorSignals))
+	signals.SetBool("tracing_configured", tracing)
+	signals.SetBool("metrics_exported", metrics)
+	signals.SetBool("trace_propagation", propagation)
+}
+
+// addOTelEvidence records setup calls as evidence
+func addOTelEvidence(signals *RepoSignals, key string, groups ...[]otelSetup) {
+	for _, group := range groups {
+		for _, setup := range group {
+			signals.AddEvidence(key, otelEvidence(setup, setup.Detail))
+		}
+	}
+}
+
+// otelEvidence builds evidence for a setup call
+func otelEvidence(setup otelSetup, detail string) Evidence {
+	return Evidence{
+		Subject: setup.Kind,
+		File:    setup.File,
+		Line:    setup.Line,
+		Detail:  detail,
+		Context: setup.Language,
+	}
+}
@@ -0,0 +1,57 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzeOpenTelemetry(t *testing.T) {
+	signals := newFileSignals(testOTelFiles)
+	analyzeOpenTelemetry(signals, ScanOptions{})
+
+	for _, key := range []string{"otel_sdk_detected", "otel_collector_detected", "tracing_configured", "metrics_exported", "trace_propagation"} {
+		if !signals.GetBool(key) {
+			t.Errorf("expected %s", key)
+		}
+	}
+	if got := signals.GetString("otel_languages"); got != "go,java,node,python" {
+		t.Errorf("unexpected languages %q", got)
+	}
+	if got := signals.GetString("otel_collector_pipelines"); got != "logs,metrics,traces" {
+		t.Errorf("unexpected pipelines %q", got)
+	}
+	if got := len(signals.GetEvidence("otel_collector_issues")); got != 3 {
+		t.Errorf("expected 3 collector issues, got %d", got)
+	}
+	// the agent and the Go and Node SDKs, which set up exporters
+	if got := len(signals.GetEvidence("otel_tracing_setup")); got != 5 {
+		t.Errorf("expected 5 tracing setup calls, got %+v", signals.GetEvidence("otel_tracing_setup"))
+	}
+}
+
+func TestAnalyzeOpenTelemetryWithoutExporter(t *testing.T) {
+	signals := newFileSignals(map[string]string{
+		"worker/tracing.py": testOTelFiles["worker/tracing.py"],
+		"main.go":           `import "net/http" // x-request-id`,
+	})
+	analyzeOpenTelemetry(signals, ScanOptions{})
+
+	if signals.GetBool("tracing_configured") || signals.GetBool("metrics_exported") || signals.GetBool("trace_propagation") {
+		t.Error("expected providers without exporters not to count")
+	}
+	missing := signals.GetEvidence("otel_tracer_providers_without_exporter")
+	if len(missing) != 1 || missing[0].File != "worker/tracing.py" || missing[0].Line != 4 {
+		t.Errorf("unexpected evidence %+v", missing)
+	}
+}
+
+func TestAnalyzeOpenTelemetryNone(t *testing.T) {
+	signals := newFileSignals(map[string]string{"main.go": "package main\n"})
+	analyzeOpenTelemetry(signals, ScanOptions{})
+
+	if signals.GetBool("otel_sdk_detected") || signals.GetBool("tracing_configured") {
+		t.Error("expected no OpenTelemetry")
+	}
+	if _, ok := signals.BoolSignals["tracing_configured"]; !ok {
+		t.Error("expected tracing_configured to be set")
+	}
+}
@@ -72,36 +72,6 @@ func detectMigrationTool(content, relPath string, signals *RepoSignals) {
 	}
 }
 
-// detectBackwardCompatibleMigration checks for backward compatibility hints
-func detectBackwardCompatibleMigration(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("backward_compatible_migration_hint") {
-		return
-	}
-
-	contentLower := strings.ToLower(content)
-
-	backwardCompatPatterns := patterns.BackwardCompatPatterns
-
-	matchCount := 0
-	for _, pattern := range backwardCompatPatterns {
-		if strings.Contains(contentLower, pattern) {
-			matchCount++
-			// Strong indicators
-			if strings.Contains(pattern, "backward") ||
-				strings.Contains(pattern, "zero-downtime") ||
-				strings.Contains(pattern, "expand-contract") {
-				signals.SetBool("backward_compatible_migration_hint", true)
-				return
-			}
-			// Weaker indicators - need multiple
-			if matchCount >= 2 {
-				signals.SetBool("backward_compatible_migration_hint", true)
-				return
-			}
-		}
-	}
-}
-
 // detectMigrationValidation checks for migration validation steps
 func detectMigrationValidation(content, relPath string, signals *RepoSignals) {
 	if signals.GetBool("migration_validation_step") {
@@ -133,24 +103,6 @@ func detectMigrationValidation(content, relPath string, signals *RepoSignals) {
 	}
 }
 
-// detectUnsafeMigration checks for destructive or risky migration steps
-func detectUnsafeMigration(content, relPath string, signals *RepoSignals) {
-	if signals.GetBool("unsafe_migration_detected") {
-		return
-	}
-
-	contentLower := strings.ToLower(content)
-
-	unsafePatterns := patterns.UnsafeMigrationPatterns
-
-	for _, pattern := range unsafePatterns {
-		if strings.Contains(contentLower, pattern) {
-			signals.SetBool("unsafe_migration_detected", true)
-			return
-		}
-	}
-}
-
 // detectGracefulShutdown checks for graceful shutdown handling
 func detectGracefulShutdown(content, _ string, signals *RepoSignals) {
 	if signals.GetBool("graceful_shutdown_detected") {
@@ -118,48 +118,6 @@ func TestDetectMigrationTool(t *testing.T) {
 	}
 }
 
-func TestDetectBackwardCompatibleMigration(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		expected bool
-	}{
-		{
-			name:     "Backward compatible mentioned",
-			content:  `# This migration is backward compatible`,
-			expected: true,
-		},
-		{
-			name:     "Zero-downtime deployment",
-			content:  `Supports zero-downtime deployment`,
-			expected: true,
-		},
-		{
-			name:     "Nullable column",
-			content:  `ALTER TABLE users ADD COLUMN email VARCHAR(255) NULL DEFAULT '';`,
-			expected: true,
-		},
-		{
-			name:     "No compatibility info",
-			content:  `ALTER TABLE users DROP COLUMN email;`,
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			signals := &RepoSignals{
-				BoolSignals: make(map[string]bool),
-			}
-			detectBackwardCompatibleMigration(tt.content, "migration.sql", signals)
-
-			if signals.GetBool("backward_compatible_migration_hint") != tt.expected {
-				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("backward_compatible_migration_hint"))
-			}
-		})
-	}
-}
-
 func TestDetectMigrationValidation(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -202,48 +160,6 @@ func TestDetectMigrationValidation(t *testing.T) {
 	}
 }
 
-func TestDetectUnsafeMigration(t *testing.T) {
-	tests := []struct {
-		name     string
-		content  string
-		expected bool
-	}{
-		{
-			name:     "Drop table detected",
-			content:  `DROP TABLE users;`,
-			expected: true,
-		},
-		{
-			name:     "Rename column detected",
-			content:  `ALTER TABLE users RENAME COLUMN name TO full_name;`,
-			expected: true,
-		},
-		{
-			name:     "Alter column type detected",
-			content:  `ALTER TABLE users ALTER COLUMN age TYPE bigint;`,
-			expected: true,
-		},
-		{
-			name:     "Safe migration",
-			content:  `ALTER TABLE users ADD COLUMN email TEXT;`,
-			expected: false,
-		},
-	}
-
-	for _, tt := range tests {
-		t.Run(tt.name, func(t *testing.T) {
-			signals := &RepoSignals{
-				BoolSignals: make(map[string]bool),
-			}
-			detectUnsafeMigration(tt.content, "migration.sql", signals)
-
-			if signals.GetBool("unsafe_migration_detected") != tt.expected {
-				t.Errorf("expected %v, got %v", tt.expected, signals.GetBool("unsafe_migration_detected"))
-			}
-		})
-	}
-}
-
 func TestDetectGracefulShutdown(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -0,0 +1,205 @@
+package scanner
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/chuanjin/production-readiness/internal/patterns"
+)
+
+// promBurnRatePattern matches burn-rate alerts by name, by the burn-rate
+// series Sloth and Pyrra record, or by a threshold of the form
+// 14.4 * (1 - 0.999)
+var promBurnRatePattern = regexp.MustCompile(`(?i)burn_?rate|error_?budget|\d+(?:\.\d+)?\s*\*\s*\(?\s*(?:1\s*-\s*)?0?\.\d+`)
+
+// analyzePrometheusRules extracts alerting and recording rules from
+// Prometheus rules files and PrometheusRule resources and checks the alerts
+// for burn-rate conditions, severity and runbook metadata, pending durations
+// and references to metrics the service's code defines
+func analyzePrometheusRules(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	rules := collectPromRules(signals, contents)
+	if len(rules) == 0 {
+		return
+	}
+
+	recorded := make(map[string]string) // recorded series -> expression
+	var alerts []*promRule
+	for i := range rules {
+		if rules[i].Record != "" {
+			recorded[rules[i].Record] += " " + rules[i].Expr
+		} else {
+			alerts = append(alerts, &rules[i])
+		}
+	}
+	signals.SetBool("prometheus_rules_detected", true)
+	signals.SetInt("prometheus_alert_count", len(alerts))
+	signals.SetInt("prometheus_recording_rule_count", len(rules)-len(alerts))
+	if len(alerts) == 0 {
+		return
+	}
+	signals.SetBool("prometheus_alerts_detected", true)
+
+	exported := exportedMetricNames(contents)
+	var counts promAlertCounts
+	for _, alert := range alerts {
+		checkPromAlert(signals, alert, &counts)
+		if len(exported) > 0 {
+			checkPromAlertMetrics(signals, alert, recorded, exported, &counts)
+		}
+	}
+
+	signals.SetBool("prometheus_burn_rate_alerts", counts.burnRate > 0)
+	signals.SetInt("prometheus_burn_rate_alert_count", counts.burnRate)
+	signals.SetBool("prometheus_alerts_have_runbooks", counts.withoutRunbook == 0)
+	signals.SetBool("prometheus_alerts_have_severity", counts.withoutSeverity == 0)
+	signals.SetBool("prometheus_alerts_have_for", counts.withoutFor == 0)
+	if len(exported) > 0 {
+		signals.SetBool("prometheus_alert_metrics_exported", counts.unknown == 0)
+	}
+}
+
+// promAlertCounts counts the alerts that pass or fail each check
+type promAlertCounts struct {
+	burnRate        int
+	withoutRunbook  int
+	withoutSeverity int
+	withoutFor      int
+	unknown         int // alerts selecting metrics nothing defines
+}
+
+// collectPromRules returns the rules of plain rules files, in path order,
+// followed by those of PrometheusRule resources
+func collectPromRules(signals *RepoSignals, contents map[string]string) []promRule {
+	var rules []promRule
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	for _, path := range paths {
+		rules = append(rules, parsePromRulesFile(contents[path], path)...)
+	}
+	covered := make(map[string]bool)
+	for _, set := range sortedManifestSets(signals.getK8sManifests()) {
+		for i := range set.Objects {
+			obj := &set.Objects[i]
+			covered[obj.File] = true
+			if obj.Kind == "PrometheusRule" {
+				rules = append(rules, promRulesFromManifest(obj, contents[obj.File])...)
+			}
+		}
+	}
+	// alert annotations use {{ $labels }} templates, which keep these files
+	// out of the Kubernetes manifests
+	for _, path := range paths {
+		if covered[path] || !strings.Contains(contents[path], "PrometheusRule") {
+			continue
+		}
+		objects := parseK8sManifests(contents[path], path)
+		for i := range objects {
+			if objects[i].Kind == "PrometheusRule" {
+				rules = append(rules, promRulesFromManifest(&objects[i], contents[path])...)
+			}
+		}
+	}
+	return rules
+}
+
+// checkPromAlert checks an alert's burn-rate condition, runbook, severity
+// and pending duration
+func checkPromAlert(signals *RepoSignals, alert *promRule, counts *promAlertCounts) {
+	if promBurnRatePattern.MatchString(alert.Alert) || promBurnRatePattern.MatchString(alert.Expr) {
+		counts.burnRate++
+	}
+	if !promAlertHasRunbook(alert) {
+		counts.withoutRunbook++
+		signals.AddEvidence("prometheus_alerts_without_runbook", promAlertEvidence(alert, "no runbook_url annotation"))
+	}
+	if alert.Labels["severity"] == "" {
+		counts.withoutSeverity++
+		signals.AddEvidence("prometheus_alerts_without_severity", promAlertEvidence(alert, "no severity label"))
+	}
+	if !promAlertHasFor(alert) {
+		counts.withoutFor++
+		signals.AddEvidence("prometheus_alerts_without_for", promAlertEvidence(alert, "fires on the first failing evaluation (no for: duration)"))
+	}
+}
+
+// checkPromAlertMetrics checks that the series an alert selects are defined
+// by the service's code, an exporter or a recording rule
+func checkPromAlertMetrics(signals *RepoSignals, alert *promRule, recorded map[string]string, exported map[string]bool, counts *promAlertCounts) {
+	if missing := promUnknownMetrics(alert.Expr, recorded, exported); len(missing) > 0 {
+		counts.unknown++
+		signals.AddEvidence("prometheus_alerts_unknown_metrics", promAlertEvidence(alert,
+			"selects "+strings.Join(missing, ", ")+", which the service's code does not define"))
+	}
+}
+
+// promAlertHasRunbook reports whether an alert links a runbook
+func promAlertHasRunbook(alert *promRule) bool {
+	return alert.Annotations["runbook_url"] != "" || alert.Annotations["runbook"] != "" || alert.Labels["runbook_url"] != ""
+}
+
+// promAlertHasFor reports whether an alert waits before firing. The
+// always-firing Watchdog alert (vector(1)) needs no duration.
+func promAlertHasFor(alert *promRule) bool {
+	if strings.ReplaceAll(alert.Expr, " ", "") == "vector(1)" {
+		return true
+	}
+	// "0s" and "0m" are no duration at all
+	return strings.ContainsAny(alert.For, "123456789")
+}
+
+// promUnknownMetrics returns the series an expression selects, through
+// recording rules, that are neither exporter metrics nor defined by the
+// service's code
+func promUnknownMetrics(expr string, recorded map[string]string, exported map[string]bool) []string {
+	var missing []string
+	seen := make(map[string]bool)
+	var walk func(expr string, depth int)
+	walk = func(expr string, depth int) {
+		for _, series := range promQLMetrics(expr) {
+			if seen[series] {
+				continue
+			}
+			seen[series] = true
+			if source, ok := recorded[series]; ok && depth < 8 {
+				walk(source, depth+1)
+				continue
+			}
+			if !infraMetric(series) && !metricExported(series, exported) {
+				missing = append(missing, series)
+			}
+		}
+	}
+	walk(expr, 0)
+	return missing
+}
+
+// infraMetric reports whether a series comes from an exporter or from
+// Prometheus itself
+func infraMetric(series string) bool {
+	for _, prefix := range patterns.InfraMetricPrefixes {
+		if strings.HasSuffix(prefix, "_") || strings.HasSuffix(prefix, ":") {
+			if strings.HasPrefix(series, prefix) {
+				return true
+			}
+		} else if series == prefix {
+			return true
+		}
+	}
+	return false
+}
+
+// promAlertEvidence builds evidence for an alerting rule
+func promAlertEvidence(alert *promRule, detail string) Evidence {
+	return Evidence{
+		Subject: alert.Alert,
+		File:    alert.File,
+		Line:    alert.Line,
+		Detail:  detail,
+		Context: alert.Source,
+	}
+}
@@ -0,0 +1,91 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzePrometheusRules(t *testing.T) {
+	files := map[string]string{
+		"deploy/alerts.yaml": `apiVersion: monitoring.coreos.com/v1
+kind: PrometheusRule
+metadata:
+  name: checkout
+  namespace: shop
+spec:
+  groups:
+    - name: slo
+      rules:
+        - alert: CheckoutErrorBudgetBurn
+          expr: job:checkout_errors:ratio_rate1h > (14.4 * (1 - 0.999))
+          for: 2m
+          labels:
+            severity: page
+          annotations:
+            runbook_url: https://runbooks.example.com/checkout
+            summary: "{{ $labels.job }} burns its error budget"
+        - alert: QueueBacklog
+          expr: queue_depth > 100
+        - alert: Watchdog
+          expr: vector(1)
+          labels:
+            severity: none
+`,
+		"monitoring/recording.rules.yml": `groups:
+  - name: checkout
+    rules:
+      - record: job:checkout_errors:ratio_rate1h
+        expr: sum(rate(checkout_requests_total{code=~"5.."}[1h])) / sum(rate(checkout_requests_total[1h]))
+`,
+		"metrics.go": `var requests = prometheus.NewCounterVec(prometheus.CounterOpts{Name: "checkout_requests_total"}, labels)`,
+	}
+	signals := &RepoSignals{
+		FileContent:   files,
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	for path, content := range files {
+		detectK8sManifests(content, path, signals)
+	}
+	analyzePrometheusRules(signals, ScanOptions{})
+
+	if got := signals.GetInt("prometheus_alert_count"); got != 3 {
+		t.Errorf("expected 3 alerts, got %d", got)
+	}
+	if got := signals.GetInt("prometheus_recording_rule_count"); got != 1 {
+		t.Errorf("expected 1 recording rule, got %d", got)
+	}
+	if got := signals.GetInt("prometheus_burn_rate_alert_count"); got != 1 {
+		t.Errorf("expected 1 burn-rate alert, got %d", got)
+	}
+	if signals.GetBool("prometheus_alert_metrics_exported") {
+		t.Error("expected queue_depth to be reported as not exported")
+	}
+
+	tests := []struct {
+		key  string
+		want []string
+		line int
+	}{
+		{"prometheus_alerts_without_runbook", []string{"QueueBacklog", "Watchdog"}, 18},
+		{"prometheus_alerts_without_severity", []string{"QueueBacklog"}, 18},
+		{"prometheus_alerts_without_for", []string{"QueueBacklog"}, 18},
+		{"prometheus_alerts_unknown_metrics", []string{"QueueBacklog"}, 18},
+	}
+	for _, tt := range tests {
+		t.Run(tt.key, func(t *testing.T) {
+			evidence := signals.GetEvidence(tt.key)
+			if len(evidence) != len(tt.want) {
+				t.Fatalf("expected %v, got %+v", tt.want, evidence)
+			}
+			for i, want := range tt.want {
+				if evidence[i].Subject != want {
+					t.Errorf("expected %q, got %+v", want, evidence[i])
+				}
+			}
+			if evidence[0].Line != tt.line || evidence[0].Context != "PrometheusRule shop/checkout" {
+				t.Errorf("unexpected location %+v", evidence[0])
+			}
+		})
+	}
+}
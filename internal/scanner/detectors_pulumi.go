@@ -0,0 +1,210 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// pulumiProject is the subset of Pulumi.yaml used for analysis
+type pulumiProject struct {
+	Name      string                 `yaml:"name"`
+	Main      string                 `yaml:"main"`
+	Config    map[string]interface{} `yaml:"config"`
+	Resources map[string]struct {
+		Type       string                 `yaml:"type"`
+		Properties map[string]interface{} `yaml:"properties"`
+	} `yaml:"resources"`
+}
+
+// pulumiProgramMarkers identify source files that declare Pulumi resources
+var pulumiProgramMarkers = []string{"@pulumi/", "import pulumi", "github.com/pulumi/pulumi"}
+
+// isPulumiConfigFile reports whether a path is a Pulumi project or stack
+// configuration file
+func isPulumiConfigFile(relPath string) bool {
+	base := filepath.Base(relPath)
+	ext := filepath.Ext(base)
+	return strings.HasPrefix(base, "Pulumi.") && (ext == ".yaml" || ext == ".yml")
+}
+
+// analyzePulumi recognizes Pulumi projects, extracts the resources their
+// programs declare and reads the regions of their stack configuration
+func analyzePulumi(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+
+	var projectFiles []string
+	for path := range contents {
+		if base := filepath.Base(path); base == "Pulumi.yaml" || base == "Pulumi.yml" {
+			projectFiles = append(projectFiles, path)
+		}
+	}
+	if len(projectFiles) == 0 {
+		return
+	}
+	sort.Strings(projectFiles)
+
+	var decls []iacDeclaration
+	for _, projectFile := range projectFiles {
+		var project pulumiProject
+		if err := yaml.Unmarshal([]byte(contents[projectFile]), &project); err != nil {
+			signals.AddEvidence("pulumi_parse_errors", Evidence{Subject: projectFile, File: projectFile, Detail: err.Error()})
+			continue
+		}
+		dir := filepath.Dir(projectFile)
+
+		// Pulumi YAML programs declare resources in the project file itself
+		names := make([]string, 0, len(project.Resources))
+		for name := range project.Resources {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			res := project.Resources[name]
+			decls = append(decls, iacDeclaration{Type: res.Type, Name: name, File: projectFile, Props: res.Properties})
+		}
+
+		programDir := dir
+		if project.Main != "" {
+			programDir = filepath.Join(dir, project.Main)
+		}
+		for _, path := range filesUnder(contents, programDir) {
+			if containsAny(contents[path], pulumiProgramMarkers) {
+				decls = append(decls, extractIaCDeclarations(contents[path], path)...)
+			}
+		}
+
+		checkPulumiRegions(dir, project.Config, contents, signals)
+	}
+
+	signals.SetBool("pulumi_detected", true)
+	signals.SetBool("infra_as_code_detected", true)
+	signals.SetInt("pulumi_resource_count", len(decls))
+	for i := range decls {
+		signals.AddEvidence("pulumi_resources", Evidence{Subject: decls[i].Name, File: decls[i].File, Line: decls[i].Line, Detail: decls[i].Type})
+	}
+	checkPulumiRDS(decls, signals)
+
+	signals.SetInt("region_count", signals.GetRegionCount())
+}
+
+// checkPulumiRegions records the regions configured for a project: defaults
+// in Pulumi.yaml and values in each Pulumi.<stack>.yaml. Stacks are
+// alternative deployments, so their regions are counted per stack, with the
+// project defaults applying to stacks that leave a key unset.
+func checkPulumiRegions(dir string, projectConfig map[string]interface{}, contents map[string]string, signals *RepoSignals) {
+	projectFile := filepath.Join(dir, "Pulumi.yaml")
+	defaults := pulumiConfigRegions(projectConfig)
+	for key, region := range defaults {
+		signals.AddEvidence("pulumi_regions", Evidence{Subject: key, File: projectFile, Detail: region})
+	}
+
+	stacks := 0
+	for path, content := range contents {
+		base := filepath.Base(path)
+		if filepath.Dir(path) != dir || !isPulumiConfigFile(path) || base == "Pulumi.yaml" || base == "Pulumi.yml" {
+			continue
+		}
+		var stack struct {
+			Config map[string]interface{} `yaml:"config"`
+		}
+		if yaml.Unmarshal([]byte(content), &stack) != nil {
+			continue
+		}
+		stacks++
+		name := strings.TrimSuffix(strings.TrimPrefix(base, "Pulumi."), filepath.Ext(base))
+		regions := pulumiConfigRegions(stack.Config)
+		for key, region := range regions {
+			signals.AddEvidence("pulumi_regions", Evidence{Subject: key, File: path, Detail: region})
+		}
+		for key, region := range defaults {
+			if _, ok := regions[key]; !ok {
+				regions[key] = region
+			}
+		}
+		for _, region := range regions {
+			signals.SetEnvironmentRegion(name, region)
+		}
+	}
+	if stacks == 0 {
+		for _, region := range defaults {
+			signals.SetRegion(region)
+		}
+	}
+}
+
+// pulumiConfigRegions returns the known regions of the region and location
+// keys of a Pulumi config section by key
+func pulumiConfigRegions(config map[string]interface{}) map[string]string {
+	regions := make(map[string]string)
+	for key, raw := range config {
+		// aws:region, gcp:region, azure-native:location, ...
+		if !strings.HasSuffix(key, ":region") && !strings.HasSuffix(key, ":location") {
+			continue
+		}
+		value, _ := raw.(string)
+		if m, ok := raw.(map[string]interface{}); ok {
+			value, _ = m["default"].(string)
+			if v, ok := m["value"].(string); ok {
+				value = v
+			}
+		}
+		if region, ok := knownRegion(value); ok {
+			regions[key] = region
+		}
+	}
+	return regions
+}
+
+// checkPulumiRDS checks declared RDS instances for Multi-AZ, deletion
+// protection and backup retention. Values that are not literals are skipped.
+func checkPulumiRDS(decls []iacDeclaration, signals *RepoSignals) {
+	for i := range decls {
+		d := &decls[i]
+		if d.kind() != "rds.instance" {
+			continue
+		}
+		if _, replica := d.propValue(iacPropReplicaSource); replica {
+			continue
+		}
+
+		if multiAZ, _, known := d.boolProp(iacPropMultiAZ); known {
+			if !multiAZ {
+				signals.AddEvidence("rds_instances_without_multi_az", iacEvidence(d, "multiAz is not enabled"))
+			}
+			setAllBool(signals, "rds_multi_az", multiAZ)
+		}
+
+		if enabled, _, known := d.boolProp(iacPropDeletionProtection); known {
+			if !enabled {
+				signals.AddEvidence("resources_without_deletion_protection", iacEvidence(d, "deletionProtection is not enabled"))
+			}
+			setAllBool(signals, "deletion_protection_enabled", enabled)
+		}
+
+		if days, set, known := d.numberProp(iacPropBackupRetention); known {
+			adequate := set && days >= minBackupRetentionDays
+			if !adequate {
+				detail := "backupRetentionPeriod is unset (provider default)"
+				if set {
+					detail = fmt.Sprintf("backupRetentionPeriod is %g days", days)
+				}
+				signals.AddEvidence("rds_backup_retention_short", iacEvidence(d, detail))
+			}
+			setAllBool(signals, "rds_backup_retention_configured", adequate)
+		}
+	}
+}
+
+// containsAny reports whether content contains any of the markers
+func containsAny(content string, markers []string) bool {
+	for _, marker := range markers {
+		if strings.Contains(content, marker) {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,102 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzePulumi(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"infra/Pulumi.yaml":      "name: shop\nruntime: nodejs\nconfig:\n  aws:region:\n    default: us-east-1\n",
+			"infra/Pulumi.prod.yaml": "config:\n  aws:region: eu-west-1\n",
+			"infra/Pulumi.dev.yaml":  "config:\n  shop:replicas: 1\n",
+			"infra/index.ts": `import * as aws from "@pulumi/aws";
+
+const db = new aws.rds.Instance("orders", {
+    engine: "postgres",
+    multiAz: true,
+    deletionProtection: true,
+    backupRetentionPeriod: 3,
+});
+
+const bucket = new aws.s3.Bucket("assets");
+`,
+			"infra/helpers.ts": `export const region = "ap-south-1";`,
+			"svc/main.go": `package main
+
+import "github.com/pulumi/pulumi-aws/sdk/v6/go/aws/rds"
+
+func run(ctx *pulumi.Context) error {
+	_, err := rds.NewInstance(ctx, "legacy", &rds.InstanceArgs{
+		MultiAz: pulumi.Bool(false),
+	})
+	return err
+}
+`,
+			"svc/Pulumi.yaml": `name: svc
+runtime: go
+resources:
+  cache:
+    type: aws:rds:Instance
+    properties:
+      multiAz: ${multiAz}
+`,
+		},
+		BoolSignals:     make(map[string]bool),
+		IntSignals:      make(map[string]int),
+		DetectedRegions: make(map[string]bool),
+	}
+	analyzePulumi(signals, ScanOptions{})
+
+	if !signals.GetBool("pulumi_detected") || !signals.GetBool("infra_as_code_detected") {
+		t.Error("expected Pulumi projects to count as IaC")
+	}
+	if got := signals.GetInt("pulumi_resource_count"); got != 4 {
+		t.Errorf("expected 4 resources, got %d: %+v", got, signals.GetEvidence("pulumi_resources"))
+	}
+	// prod overrides the default and dev uses it: one region per stack
+	if got := signals.GetInt("region_count"); got != 1 {
+		t.Errorf("expected 1 region per stack, got %d", got)
+	}
+	if got := len(signals.GetEvidence("pulumi_regions")); got != 2 {
+		t.Errorf("expected the config default and stack regions only, got %d", got)
+	}
+
+	multiAZ := signals.GetEvidence("rds_instances_without_multi_az")
+	if len(multiAZ) != 1 || multiAZ[0].Subject != "legacy (rds.Instance)" || multiAZ[0].Line != 6 {
+		t.Errorf("expected only the Go instance to lack Multi-AZ, got %+v", multiAZ)
+	}
+	retention := signals.GetEvidence("rds_backup_retention_short")
+	if len(retention) != 3 || retention[0].Detail != "backupRetentionPeriod is 3 days" {
+		t.Errorf("expected every instance to report short retention, got %+v", retention)
+	}
+}
+
+func TestExtractIaCDeclarations(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    string
+		content string
+		kind    string
+		declID  string
+		multiAZ bool
+	}{
+		{"TypeScript CDK", "lib/stack.ts", "new rds.DatabaseInstance(this, 'Db', { multiAz: true })", "rds.databaseinstance", "Db", true},
+		{"Python Pulumi", "__main__.py", `db = aws.rds.Instance("db", multi_az=True)`, "rds.instance", "db", true},
+		{"Go CDK", "app.go", `awsrds.NewDatabaseInstance(stack, jsii.String("Db"), &awsrds.DatabaseInstanceProps{})`, "rds.databaseinstance", "Db", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			decls := extractIaCDeclarations(tt.content, tt.path)
+			if len(decls) != 1 {
+				t.Fatalf("expected 1 declaration, got %+v", decls)
+			}
+			if decls[0].kind() != tt.kind || decls[0].Name != tt.declID {
+				t.Errorf("got kind %q name %q", decls[0].kind(), decls[0].Name)
+			}
+			if val, set, known := decls[0].boolProp(iacPropMultiAZ); val != tt.multiAZ || set != tt.multiAZ || !known {
+				t.Errorf("expected multiAz %v, got %v (set=%v known=%v)", tt.multiAZ, val, set, known)
+			}
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package scanner
+
+// detectHardcodedSecrets reports credentials written into a file, found by
+// provider signatures or by the entropy of credential-named values. Only a
+// redacted snippet of each line is recorded.
+func detectHardcodedSecrets(content, relPath string, signals *RepoSignals) {
+	findings := findSecrets(content, relPath)
+	if len(findings) == 0 {
+		return
+	}
+	for _, f := range findings {
+		signals.AddEvidence("hardcoded_secrets", Evidence{
+			Subject: f.Kind,
+			File:    f.File,
+			Line:    f.Line,
+			Detail:  f.Snippet,
+			Context: f.Method,
+		})
+	}
+	signals.SetBool("hardcoded_secrets_detected", true)
+}
@@ -0,0 +1,19 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestDetectHardcodedSecrets(t *testing.T) {
+	signals := newFileSignals(nil)
+	detectHardcodedSecrets("package main\n\nvar key = \""+testAWSKeyID+"\"\n", "main.go", signals)
+	detectHardcodedSecrets("package main\n", "clean.go", signals)
+
+	if !signals.GetBool("hardcoded_secrets_detected") {
+		t.Fatal("expected hardcoded_secrets_detected")
+	}
+	evidence := signals.GetEvidence("hardcoded_secrets")
+	if len(evidence) != 1 || evidence[0].File != "main.go" || evidence[0].Line != 3 || evidence[0].Context != "signature" {
+		t.Errorf("unexpected evidence %+v", evidence)
+	}
+}
@@ -0,0 +1,81 @@
+package scanner
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"time"
+)
+
+// minErrorBudget is the error budget per window below which an objective is
+// too tight to act on
+const minErrorBudget = 5 * time.Minute
+
+// analyzeSLOSpecs parses Sloth, Pyrra and OpenSLO documents and validates
+// each objective. Valid objectives replace the keyword results of
+// detectSLOConfig and detectErrorBudget, and the tightest error budget is
+// exposed in parts per million and in minutes per window.
+func analyzeSLOSpecs(signals *RepoSignals, _ ScanOptions) {
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	var specs []sloSpec
+	for _, path := range paths {
+		specs = append(specs, parseSLOSpecs(contents[path], path)...)
+	}
+	if len(specs) == 0 {
+		return
+	}
+
+	formats := make(map[string]bool)
+	valid := 0
+	minPPM, minMinutes := math.MaxInt, math.MaxInt
+	for i := range specs {
+		s := &specs[i]
+		formats[s.Format] = true
+		if reason := s.validate(); reason != "" {
+			signals.AddEvidence("slo_invalid_objectives", sloEvidence(s, reason))
+			continue
+		}
+		valid++
+		budget := s.ErrorBudgetDuration()
+		detail := fmt.Sprintf("%s%% over %s, error budget %s", formatPercent(s.Objective), s.Window, budget.Round(time.Second))
+		signals.AddEvidence("slo_definitions", sloEvidence(s, detail))
+		if budget < minErrorBudget {
+			signals.AddEvidence("slo_small_error_budgets", sloEvidence(s, detail))
+		}
+		minPPM = min(minPPM, int(math.Round(s.ErrorBudget()*1e6)))
+		minMinutes = min(minMinutes, int(budget/time.Minute))
+	}
+
+	signals.SetBool("slo_spec_detected", true)
+	signals.SetString("slo_formats", joinSet(formats))
+	signals.SetInt("slo_count", len(specs))
+	signals.SetInt("slo_valid_count", valid)
+	signals.SetInt("slo_invalid_count", len(specs)-valid)
+	signals.SetBool("slo_config_detected", valid > 0)
+	signals.SetBool("error_budget_detected", valid > 0)
+	if valid > 0 {
+		signals.SetInt("slo_min_error_budget_ppm", minPPM)
+		signals.SetInt("slo_min_error_budget_minutes", minMinutes)
+	}
+}
+
+// sloEvidence builds evidence for an objective
+func sloEvidence(s *sloSpec, detail string) Evidence {
+	subject := s.Name
+	if s.Service != "" && s.Service != s.Name {
+		subject = s.Service + "/" + s.Name
+	}
+	return Evidence{
+		Subject: subject,
+		File:    s.File,
+		Line:    s.Line,
+		Detail:  detail,
+		Context: s.Format,
+	}
+}
@@ -0,0 +1,93 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestAnalyzeSLOSpecs(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent:   testSLOFiles,
+		BoolSignals:   map[string]bool{"slo_config_detected": true},
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeSLOSpecs(signals, ScanOptions{})
+
+	ints := map[string]int{
+		"slo_count":         5,
+		"slo_valid_count":   3,
+		"slo_invalid_count": 2,
+		// 99.9% over 28 days is the tightest budget
+		"slo_min_error_budget_ppm":     1000,
+		"slo_min_error_budget_minutes": 40,
+	}
+	for key, want := range ints {
+		if got := signals.GetInt(key); got != want {
+			t.Errorf("%s: expected %d, got %d", key, want, got)
+		}
+	}
+	if got := signals.GetString("slo_formats"); got != "openslo,pyrra,sloth" {
+		t.Errorf("unexpected formats %q", got)
+	}
+
+	invalid := signals.GetEvidence("slo_invalid_objectives")
+	if len(invalid) != 2 || invalid[0].Subject != "no-window" || invalid[1].Subject != "checkout/perfect" {
+		t.Errorf("unexpected invalid objectives %+v", invalid)
+	}
+	if small := signals.GetEvidence("slo_small_error_budgets"); len(small) != 0 {
+		t.Errorf("expected no objective under the minimum budget, got %+v", small)
+	}
+}
+
+func TestAnalyzeSLOSpecsSmallErrorBudget(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"slo.yaml": `version: prometheus/v1
+service: api
+slos:
+  - name: availability
+    objective: 99.9
+    sli:
+      raw:
+        error_ratio_query: sum(rate(errors[{{.window}}])) / sum(rate(requests[{{.window}}]))
+  - name: latency
+    objective: 99.99
+    sli:
+      raw:
+        error_ratio_query: sum(rate(slow[{{.window}}])) / sum(rate(requests[{{.window}}]))
+`,
+		},
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeSLOSpecs(signals, ScanOptions{})
+
+	// 99.99% over 30 days leaves 4m19s, 99.9% leaves 43m12s
+	small := signals.GetEvidence("slo_small_error_budgets")
+	if len(small) != 1 || small[0].Subject != "api/latency" {
+		t.Errorf("expected only the latency objective, got %+v", small)
+	}
+	if got := len(signals.GetEvidence("slo_definitions")); got != 2 {
+		t.Errorf("expected both objectives to be defined, got %d", got)
+	}
+}
+
+func TestAnalyzeSLOSpecsInvalidOnly(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"slo.yaml": "version: prometheus/v1\nservice: api\nslos:\n  - name: all\n    objective: 100\n",
+		},
+		BoolSignals:   map[string]bool{"slo_config_detected": true},
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeSLOSpecs(signals, ScanOptions{})
+
+	if signals.GetBool("slo_config_detected") {
+		t.Error("expected an invalid objective not to count as an SLO")
+	}
+	if _, ok := signals.GetIntSignal("slo_min_error_budget_ppm"); ok {
+		t.Error("expected no error budget without a valid objective")
+	}
+}
@@ -0,0 +1,249 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// analyzeTerraform parses every Terraform module in the repository and
+// derives infrastructure signals from the declared providers and resources
+// rather than from text matches
+func analyzeTerraform(signals *RepoSignals, opts ScanOptions) {
+	modules := loadTerraformModules(signals.GetFileContentMap())
+	if len(modules) == 0 {
+		return
+	}
+
+	providers := make(map[string]bool)
+	resourceCount := 0
+	for _, m := range modules {
+		for _, ev := range m.Errors {
+			if opts.Debug {
+				opts.Logger.Printf("Terraform %s: parse failed: %s", ev.File, ev.Detail)
+			}
+			signals.AddEvidence("terraform_parse_errors", ev)
+		}
+		for i := range m.Providers {
+			providers[m.Providers[i].Type] = true
+		}
+		for i := range m.Resources {
+			// Resource types are prefixed with their provider (aws_, google_, ...)
+			if provider, _, ok := strings.Cut(m.Resources[i].Type, "_"); ok {
+				providers[provider] = true
+			}
+		}
+		resourceCount += len(m.Resources)
+		for i := range m.Modules {
+			mod := &m.Modules[i]
+			source := strings.Join(m.resolveStrings(mod.Body.Attributes["source"]), ", ")
+			signals.AddEvidence("terraform_modules", Evidence{Subject: mod.address(), File: mod.File, Line: mod.Line, Detail: "source " + source})
+		}
+
+		checkTerraformRegions(m, signals)
+		checkTerraformRDS(m, signals)
+		checkTerraformDeletionProtection(m, signals)
+		checkTerraformAutoscaling(m, signals)
+	}
+
+	if len(providers) > 0 || resourceCount > 0 {
+		signals.SetBool("infra_as_code_detected", true)
+	}
+	names := make([]string, 0, len(providers))
+	for name := range providers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	signals.SetString("terraform_providers", strings.Join(names, ","))
+	signals.SetInt("terraform_resource_count", resourceCount)
+
+	// Terraform regions are added to those found in other files
+	signals.SetInt("region_count", signals.GetRegionCount())
+}
+
+// terraformRegionAttributes are the attributes that place providers and
+// resources in a region (AWS/GCP region, Azure/GCP location)
+var terraformRegionAttributes = []string{"region", "location"}
+
+// checkTerraformRegions records the known cloud regions that providers and
+// resources are actually configured with. Variable file sets are alternative
+// environments, so their regions are counted per environment instead of
+// adding up.
+func checkTerraformRegions(m *terraformModule, signals *RepoSignals) {
+	blocks := append(append([]terraformBlock{}, m.Providers...), m.Resources...)
+	for i := range blocks {
+		b := &blocks[i]
+		subject := "provider." + b.Type
+		if b.Name != "" {
+			subject = b.address()
+		}
+		for _, attr := range terraformRegionAttributes {
+			raw, ok := b.Body.Attributes[attr]
+			if !ok {
+				continue
+			}
+			var regions []string
+			if len(m.VarSets) == 0 {
+				regions = knownRegions(m.resolveStrings(raw))
+				for _, region := range regions {
+					signals.SetRegion(region)
+				}
+			}
+			for _, set := range m.VarSets {
+				for _, region := range knownRegions(m.withVarSet(set).resolveStrings(raw)) {
+					signals.SetEnvironmentRegion(set.Name, region)
+					if !slices.Contains(regions, region) {
+						regions = append(regions, region)
+					}
+				}
+			}
+			for _, region := range regions {
+				signals.AddEvidence("terraform_regions", Evidence{
+					Subject: subject,
+					File:    b.File,
+					Line:    b.Body.AttrLines[attr],
+					Detail:  region,
+				})
+			}
+		}
+	}
+}
+
+// knownRegions returns the known cloud regions among configured values
+func knownRegions(values []string) []string {
+	var regions []string
+	for _, value := range values {
+		if region, ok := knownRegion(value); ok {
+			regions = append(regions, region)
+		}
+	}
+	return regions
+}
+
+// minBackupRetentionDays is the retention below which database backups
+// are reported
+const minBackupRetentionDays = 7
+
+// checkTerraformRDS checks RDS instances for Multi-AZ and RDS instances and
+// clusters for an adequate automated backup retention
+func checkTerraformRDS(m *terraformModule, signals *RepoSignals) {
+	instances := m.resources("aws_db_instance")
+	for i := range instances {
+		db := &instances[i]
+		// Read replicas inherit availability from their source
+		if _, replica := db.Body.Attributes["replicate_source_db"]; replica {
+			continue
+		}
+		multiAZ, known := m.attrBool(db, "multi_az", false)
+		if !known {
+			continue
+		}
+		if !multiAZ {
+			signals.AddEvidence("rds_instances_without_multi_az", terraformEvidence(db, "multi_az is not enabled"))
+		}
+		setAllBool(signals, "rds_multi_az", multiAZ)
+	}
+
+	databases := m.resources("aws_db_instance", "aws_rds_cluster")
+	for i := range databases {
+		db := &databases[i]
+		if _, replica := db.Body.Attributes["replicate_source_db"]; replica {
+			continue
+		}
+		days, set, known := m.attrMinNumber(db, "backup_retention_period")
+		if !known {
+			continue
+		}
+		adequate := set && days >= minBackupRetentionDays
+		if !adequate {
+			detail := "backup_retention_period is unset (provider default)"
+			if set {
+				detail = fmt.Sprintf("backup_retention_period is %g days", days)
+			}
+			signals.AddEvidence("rds_backup_retention_short", terraformEvidence(db, detail))
+		}
+		setAllBool(signals, "rds_backup_retention_configured", adequate)
+	}
+}
+
+// terraformDeletionProtection maps resource types to their deletion
+// protection attribute and its provider default
+var terraformDeletionProtection = map[string]struct {
+	Attribute string
+	Default   bool
+}{
+	"aws_db_instance":              {"deletion_protection", false},
+	"aws_rds_cluster":              {"deletion_protection", false},
+	"aws_docdb_cluster":            {"deletion_protection", false},
+	"aws_neptune_cluster":          {"deletion_protection", false},
+	"aws_dynamodb_table":           {"deletion_protection_enabled", false},
+	"aws_lb":                       {"enable_deletion_protection", false},
+	"aws_alb":                      {"enable_deletion_protection", false},
+	"google_sql_database_instance": {"deletion_protection", true},
+}
+
+// checkTerraformDeletionProtection checks stateful and entry-point resources
+// for deletion protection
+func checkTerraformDeletionProtection(m *terraformModule, signals *RepoSignals) {
+	for i := range m.Resources {
+		r := &m.Resources[i]
+		setting, ok := terraformDeletionProtection[r.Type]
+		if !ok {
+			continue
+		}
+		enabled, known := m.attrBool(r, setting.Attribute, setting.Default)
+		if !known {
+			continue
+		}
+		if !enabled {
+			signals.AddEvidence("resources_without_deletion_protection", terraformEvidence(r, setting.Attribute+" is not enabled"))
+		}
+		setAllBool(signals, "deletion_protection_enabled", enabled)
+	}
+}
+
+// terraformAutoscalingTypes are resources that scale compute automatically
+var terraformAutoscalingTypes = []string{
+	"aws_autoscaling_group",
+	"aws_appautoscaling_target",
+	"google_compute_autoscaler",
+	"google_compute_region_autoscaler",
+	"azurerm_linux_virtual_machine_scale_set",
+	"azurerm_windows_virtual_machine_scale_set",
+	"azurerm_monitor_autoscale_setting",
+}
+
+// checkTerraformAutoscaling records autoscaling groups and AWS groups that
+// may shrink to a single instance
+func checkTerraformAutoscaling(m *terraformModule, signals *RepoSignals) {
+	groups := m.resources(terraformAutoscalingTypes...)
+	if len(groups) == 0 {
+		return
+	}
+	signals.SetBool("autoscaling_group_detected", true)
+	signals.SetInt("autoscaling_group_count", signals.GetInt("autoscaling_group_count")+len(groups))
+
+	for i := range groups {
+		g := &groups[i]
+		if g.Type != "aws_autoscaling_group" {
+			continue
+		}
+		if minSize, set, known := m.attrMinNumber(g, "min_size"); known && set && minSize < 2 {
+			signals.AddEvidence("autoscaling_groups_single_instance", terraformEvidence(g, fmt.Sprintf("min_size is %g", minSize)))
+		}
+	}
+}
+
+// terraformEvidence builds evidence for a resource at its block
+func terraformEvidence(b *terraformBlock, detail string) Evidence {
+	return Evidence{Subject: b.address(), File: b.File, Line: b.Line, Detail: detail}
+}
+
+// setAllBool sets a signal that only holds while every checked item passes
+func setAllBool(signals *RepoSignals, key string, val bool) {
+	if current, ok := signals.GetBoolSignal(key); ok && !current {
+		return
+	}
+	signals.SetBool(key, val)
+}
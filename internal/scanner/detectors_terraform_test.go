@@ -0,0 +1,198 @@
+package scanner
+
+import (
+	"reflect"
+	"testing"
+)
+
+func testTerraformSignals(files map[string]string) *RepoSignals {
+	signals := &RepoSignals{
+		FileContent:     files,
+		BoolSignals:     make(map[string]bool),
+		StringSignals:   make(map[string]string),
+		IntSignals:      make(map[string]int),
+		DetectedRegions: make(map[string]bool),
+	}
+	for path, content := range files {
+		detectRegions(content, path, signals)
+		detectInfrastructure(content, path, signals)
+	}
+	analyzeTerraform(signals, ScanOptions{Logger: &NoopLogger{}})
+	return signals
+}
+
+func TestAnalyzeTerraform(t *testing.T) {
+	signals := testTerraformSignals(map[string]string{
+		"infra/variables.tf": `variable "region" {
+  # description mentions "ap-south-1" but it is not deployed there
+  default = "us-east-1"
+}
+
+variable "multi_az" {
+  default = false
+}
+`,
+		"infra/prod.tfvars": "region = \"eu-west-1\"\n",
+		"infra/main.tf": `provider "aws" {
+  region = var.region
+}
+
+module "vpc" {
+  source  = "terraform-aws-modules/vpc/aws"
+  version = "5.0.0"
+}
+
+resource "aws_db_instance" "main" {
+  multi_az                = true
+  deletion_protection     = true
+  backup_retention_period = 14
+}
+
+resource "aws_db_instance" "reports" {
+  multi_az = var.multi_az
+}
+
+resource "aws_db_instance" "reports_replica" {
+  replicate_source_db = aws_db_instance.reports.identifier
+}
+
+resource "aws_autoscaling_group" "web" {
+  min_size = 1
+  max_size = 4
+}
+`,
+	})
+
+	if !signals.GetBool("infra_as_code_detected") {
+		t.Error("expected infra_as_code_detected")
+	}
+	// prod.tfvars overrides the us-east-1 default
+	if got := signals.GetInt("region_count"); got != 1 {
+		t.Errorf("expected only the tfvars region, got %d", got)
+	}
+	if got := signals.GetString("terraform_providers"); got != "aws" {
+		t.Errorf("expected aws provider, got %q", got)
+	}
+	if got := signals.GetInt("terraform_resource_count"); got != 4 {
+		t.Errorf("expected 4 resources, got %d", got)
+	}
+	if mods := signals.GetEvidence("terraform_modules"); len(mods) != 1 || mods[0].Detail != "source terraform-aws-modules/vpc/aws" {
+		t.Errorf("unexpected module evidence %+v", mods)
+	}
+
+	multiAZ := signals.GetEvidence("rds_instances_without_multi_az")
+	if len(multiAZ) != 1 || multiAZ[0].Subject != "aws_db_instance.reports" || multiAZ[0].Line != 16 {
+		t.Errorf("expected only the reports instance to lack Multi-AZ, got %+v", multiAZ)
+	}
+	if val, ok := signals.GetBoolSignal("rds_multi_az"); !ok || val {
+		t.Error("expected rds_multi_az to be false")
+	}
+	if got := len(signals.GetEvidence("resources_without_deletion_protection")); got != 2 {
+		t.Errorf("expected the reports instance and its replica without deletion protection, got %d", got)
+	}
+	if got := len(signals.GetEvidence("rds_backup_retention_short")); got != 1 {
+		t.Errorf("expected 1 database with short backup retention, got %d", got)
+	}
+	if !signals.GetBool("autoscaling_group_detected") || len(signals.GetEvidence("autoscaling_groups_single_instance")) != 1 {
+		t.Error("expected the autoscaling group and its min_size of 1 to be reported")
+	}
+}
+
+func TestTerraformVariableFileSets(t *testing.T) {
+	modules := loadTerraformModules(map[string]string{
+		"infra/variables.tf": `variable "region" {
+  default = "us-east-1"
+}
+
+variable "replicas" {
+  default = 1
+}
+
+variable "tier" {}
+`,
+		"infra/terraform.tfvars":  "replicas = 2\ntier = \"standard\"\n",
+		"infra/zz.auto.tfvars":    "replicas = 3\n",
+		"infra/prod.tfvars":       "region = \"eu-west-1\"\ntier = \"premium\"\n",
+		"infra/staging.tfvars":    "tier = \"premium\"\n",
+		"single/variables.tf":     "variable \"region\" {\n  default = \"us-east-1\"\n}\n",
+		"single/terraform.tfvars": "region = \"eu-central-1\"\n",
+	})
+	if len(modules) != 2 {
+		t.Fatalf("expected 2 modules, got %d", len(modules))
+	}
+
+	vars := modules[0].Variables
+	// staging leaves the region unset, so its default still applies there
+	if got := vars["region"]; !reflect.DeepEqual(got, []interface{}{"eu-west-1", "us-east-1"}) {
+		t.Errorf("unexpected region candidates %v", got)
+	}
+	// *.auto.tfvars load after terraform.tfvars in every set
+	if got := vars["replicas"]; !reflect.DeepEqual(got, []interface{}{float64(3)}) {
+		t.Errorf("unexpected replicas candidates %v", got)
+	}
+	if got := vars["tier"]; !reflect.DeepEqual(got, []interface{}{"premium"}) {
+		t.Errorf("unexpected tier candidates %v", got)
+	}
+	if got := modules[1].Variables["region"]; !reflect.DeepEqual(got, []interface{}{"eu-central-1"}) {
+		t.Errorf("expected terraform.tfvars to override the default, got %v", got)
+	}
+}
+
+func TestTerraformRegionsPerVariableFile(t *testing.T) {
+	signals := testTerraformSignals(map[string]string{
+		"infra/variables.tf":   "variable \"region\" {\n  default = \"us-east-1\"\n}\n",
+		"infra/main.tf":        "provider \"aws\" {\n  region = var.region\n}\n",
+		"infra/prod.tfvars":    "region = \"eu-west-1\"\n",
+		"infra/staging.tfvars": "region = \"us-west-2\"\n",
+	})
+	// prod and staging are alternative deployments of one region each
+	if got := signals.GetInt("region_count"); got != 1 {
+		t.Errorf("expected 1 region per environment, got %d", got)
+	}
+	if got := len(signals.GetEvidence("terraform_regions")); got != 2 {
+		t.Errorf("expected evidence for both environment regions, got %d", got)
+	}
+
+	signals = testTerraformSignals(map[string]string{
+		"infra/main.tf": `provider "aws" {
+  region = var.region
+}
+
+provider "aws" {
+  alias  = "dr"
+  region = "us-east-1"
+}
+`,
+		"infra/prod.tfvars":    "region = \"eu-west-1\"\n",
+		"infra/staging.tfvars": "region = \"eu-west-1\"\n",
+	})
+	if got := signals.GetInt("region_count"); got != 2 {
+		t.Errorf("expected the shared and the environment region, got %d", got)
+	}
+}
+
+func TestAnalyzeTerraformConservative(t *testing.T) {
+	t.Run("Mentions of terraform are not IaC", func(t *testing.T) {
+		signals := testTerraformSignals(map[string]string{
+			"main.go": `// deployed with terraform`,
+		})
+		if signals.GetBool("infra_as_code_detected") {
+			t.Error("expected no IaC from a comment")
+		}
+	})
+
+	t.Run("Unresolvable values are not reported", func(t *testing.T) {
+		signals := testTerraformSignals(map[string]string{
+			"db.tf": `resource "aws_db_instance" "main" {
+  multi_az = data.external.flags.result.multi_az
+}
+`,
+		})
+		if _, ok := signals.GetBoolSignal("rds_multi_az"); ok {
+			t.Error("expected unknown multi_az to leave the signal unset")
+		}
+		if got := signals.GetInt("region_count"); got != 0 {
+			t.Errorf("expected no regions, got %d", got)
+		}
+	})
+}
@@ -0,0 +1,96 @@
+package scanner
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+)
+
+// analyzeVulnerabilities matches the resolved dependencies of lockfiles and
+// exact-version manifests against the OSV database given by
+// ScanOptions.OSVDatabase, comparing versions by the rules of each
+// ecosystem. It records each advisory affecting a dependency with the
+// versions that fix it, and how old the database snapshot is. Nothing is
+// recorded without a database.
+func analyzeVulnerabilities(signals *RepoSignals, opts ScanOptions) {
+	db := opts.osv
+	if db == nil {
+		return
+	}
+	contents := signals.GetFileContentMap()
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	// the same package version may be resolved by several files
+	seen := make(map[string]bool)
+	var packages []resolvedPackage
+	for _, path := range paths {
+		if !isDepLockfile(path) && depManifestEcosystem(path) == "" {
+			continue
+		}
+		for _, p := range resolvedPackages(contents[path], path) {
+			key := osvPackageKey(p.Ecosystem, p.Name) + "@" + p.Version
+			if !seen[key] {
+				seen[key] = true
+				packages = append(packages, p)
+			}
+		}
+	}
+
+	vulnerable, advisories := 0, 0
+	for _, p := range packages {
+		matches := db.Match(p.Ecosystem, p.Name, p.Version)
+		if len(matches) == 0 {
+			continue
+		}
+		vulnerable++
+		advisories += len(matches)
+		for _, m := range matches {
+			signals.AddEvidence("vulnerable_dependencies", Evidence{
+				Subject: p.Name + "@" + p.Version,
+				File:    p.File,
+				Line:    p.Line,
+				Detail:  osvMatchDetail(m),
+				Context: p.Ecosystem,
+			})
+		}
+	}
+
+	age := int(time.Since(db.Snapshot).Hours() / 24)
+	signals.SetInt("osv_advisory_count", db.Advisories)
+	signals.SetString("osv_snapshot_date", db.Snapshot.UTC().Format(time.DateOnly))
+	signals.SetInt("osv_snapshot_age_days", age)
+	signals.SetInt("resolved_dependency_count", len(packages))
+	signals.SetInt("vulnerable_dependency_count", vulnerable)
+	signals.SetInt("vulnerability_advisory_count", advisories)
+}
+
+// osvMatchDetail describes an advisory and its fixes, such as
+// "GHSA-xxxx (CVE-2024-1234, high): summary; fixed in 1.2.4"
+func osvMatchDetail(m osvMatch) string {
+	a := m.Advisory
+	var notes []string
+	for _, alias := range a.Aliases {
+		if strings.HasPrefix(alias, "CVE-") {
+			notes = append(notes, alias)
+		}
+	}
+	if a.DatabaseSpecific.Severity != "" {
+		notes = append(notes, strings.ToLower(a.DatabaseSpecific.Severity))
+	}
+	detail := a.ID
+	if len(notes) > 0 {
+		detail += " (" + strings.Join(notes, ", ") + ")"
+	}
+	if a.Summary != "" {
+		detail += ": " + a.Summary
+	}
+	if len(m.Fixed) == 0 {
+		return detail + "; no fixed version"
+	}
+	return fmt.Sprintf("%s; fixed in %s", detail, strings.Join(m.Fixed, ", "))
+}
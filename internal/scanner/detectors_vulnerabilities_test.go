@@ -0,0 +1,73 @@
+package scanner
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestAnalyzeVulnerabilities(t *testing.T) {
+	db, err := loadOSVDatabase(writeOSVExport(t, map[string]string{
+		"GHSA-aaaa-bbbb-cccc.json": osvTestAdvisory,
+		"GO-2026-1.json": `{
+  "id": "GO-2026-1",
+  "modified": "2026-09-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "Go", "name": "golang.org/x/net"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "0.23.0"}]}]
+  }]
+}`,
+	}, nil))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	signals := newFileSignals(map[string]string{
+		"go.mod":                "module example.com/app\n\nrequire golang.org/x/net v0.17.0\n",
+		"web/package.json":      `{"dependencies": {"lodash": "^4.17.0"}}`,
+		"web/yarn.lock":         "lodash@^4.17.0:\n  version \"4.17.20\"\n",
+		"admin/yarn.lock":       "lodash@^4.17.0:\n  version \"4.17.20\"\n\nreact@^18.2.0:\n  version \"18.2.0\"\n",
+		"vendor/x/go.mod":       "module x\n\nrequire golang.org/x/net v0.1.0\n",
+		"docs/requirements.txt": "mkdocs==1.5.3\n",
+	})
+	analyzeVulnerabilities(signals, ScanOptions{osv: db})
+
+	// lodash 4.17.20 is resolved twice but counted once
+	if got := signals.GetInt("resolved_dependency_count"); got != 4 {
+		t.Errorf("expected 4 resolved dependencies, got %d", got)
+	}
+	if got := signals.GetInt("vulnerable_dependency_count"); got != 2 {
+		t.Errorf("expected 2 vulnerable dependencies, got %d", got)
+	}
+	findings := signals.GetEvidence("vulnerable_dependencies")
+	if len(findings) != 2 || signals.GetInt("vulnerability_advisory_count") != 2 {
+		t.Fatalf("unexpected findings %+v", findings)
+	}
+	if f := findings[1]; f.Subject != "golang.org/x/net@0.17.0" || f.File != "go.mod" || f.Line != 3 || f.Detail != "GO-2026-1; fixed in 0.23.0" {
+		t.Errorf("unexpected Go finding %+v", f)
+	}
+	expected := "GHSA-aaaa-bbbb-cccc (CVE-2026-0001, high): Prototype pollution; fixed in 4.17.21"
+	if f := findings[0]; f.Subject != "lodash@4.17.20" || f.File != "admin/yarn.lock" || f.Context != osvNPM || f.Detail != expected {
+		t.Errorf("unexpected npm finding %+v", f)
+	}
+
+	if got := signals.GetInt("osv_advisory_count"); got != 2 {
+		t.Errorf("expected 2 advisories, got %d", got)
+	}
+	if got := signals.GetString("osv_snapshot_date"); got != "2026-09-30" {
+		t.Errorf("unexpected snapshot date %q", got)
+	}
+	if age, ok := signals.GetIntSignal("osv_snapshot_age_days"); !ok || age < 0 {
+		t.Errorf("unexpected snapshot age %d", age)
+	}
+}
+
+func TestAnalyzeVulnerabilitiesWithoutDatabase(t *testing.T) {
+	signals := newFileSignals(map[string]string{"go.mod": "module x\n\nrequire golang.org/x/net v0.17.0\n"})
+	analyzeVulnerabilities(signals, ScanOptions{})
+
+	for key := range signals.IntSignals {
+		if strings.HasPrefix(key, "osv_") || strings.Contains(key, "vulnerab") {
+			t.Errorf("unexpected signal %s without a database", key)
+		}
+	}
+}
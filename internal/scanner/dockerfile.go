@@ -0,0 +1,300 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+// dockerInstruction is one logical instruction of a Dockerfile, with line
+// continuations joined and build arguments substituted
+type dockerInstruction struct {
+	Cmd     string // upper-case instruction, e.g. RUN
+	Args    string
+	Heredoc string // bodies of the heredocs that follow the instruction
+	Line    int
+}
+
+// dockerStage is a build stage, starting at a FROM instruction
+type dockerStage struct {
+	Name         string // the AS name, if any
+	Image        string // the base image or the name of an earlier stage
+	Line         int
+	Instructions []dockerInstruction
+}
+
+// dockerfile is a parsed Dockerfile
+type dockerfile struct {
+	File   string
+	Stages []dockerStage
+}
+
+// heredocPattern matches heredoc openers such as <<EOF, <<-EOF and <<"EOF"
+var heredocPattern = regexp.MustCompile(`<<(-?)["']?([A-Za-z_]\w*)["']?`)
+
+// isDockerfile reports whether a path is a Dockerfile, e.g. Dockerfile,
+// Dockerfile.prod, api.dockerfile or Containerfile
+func isDockerfile(relPath string) bool {
+	base := strings.ToLower(filepath.Base(relPath))
+	return base == "dockerfile" || base == "containerfile" ||
+		strings.HasPrefix(base, "dockerfile.") || strings.HasSuffix(base, ".dockerfile")
+}
+
+// parseDockerfile splits a Dockerfile into stages and instructions
+func parseDockerfile(content, relPath string) *dockerfile {
+	lines := strings.Split(strings.ReplaceAll(content, "\r\n", "\n"), "\n")
+	escape := dockerEscapeChar(lines)
+
+	d := &dockerfile{File: relPath}
+	globalArgs := make(map[string]string)
+	var vars map[string]string // ARG and ENV values in scope of the current stage
+
+	for i := 0; i < len(lines); i++ {
+		trimmed := strings.TrimSpace(lines[i])
+		if trimmed == "" || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+
+		start := i + 1
+		var logical string
+		logical, i = joinDockerContinuations(lines, i, escape)
+		cmd, args, _ := strings.Cut(strings.TrimSpace(logical), " ")
+		inst := dockerInstruction{Cmd: strings.ToUpper(cmd), Args: strings.TrimSpace(args), Line: start}
+		if inst.Cmd == "RUN" || inst.Cmd == "COPY" || inst.Cmd == "ADD" {
+			inst.Heredoc, i = readDockerHeredocs(lines, i, inst.Args)
+		}
+
+		switch inst.Cmd {
+		case "FROM":
+			inst.Args = substituteDockerVars(inst.Args, globalArgs)
+			d.Stages = append(d.Stages, newDockerStage(inst))
+			vars = make(map[string]string)
+			continue
+		case "ARG":
+			if len(d.Stages) == 0 {
+				declareDockerArgs(inst.Args, globalArgs, nil)
+			} else {
+				declareDockerArgs(inst.Args, vars, globalArgs)
+			}
+		}
+		if len(d.Stages) == 0 {
+			continue
+		}
+
+		inst.Args = substituteDockerVars(inst.Args, vars)
+		if inst.Cmd == "ENV" {
+			for name, value := range parseDockerEnv(inst.Args) {
+				vars[name] = value
+			}
+		}
+		stage := &d.Stages[len(d.Stages)-1]
+		stage.Instructions = append(stage.Instructions, inst)
+	}
+	return d
+}
+
+// joinDockerContinuations joins the lines of the instruction starting at
+// line i, dropping comments and blank lines inside it, and returns the
+// logical line and the index of its last physical line
+func joinDockerContinuations(lines []string, i int, escape string) (string, int) {
+	logical := strings.TrimRight(lines[i], " \t")
+	for strings.HasSuffix(logical, escape) && i+1 < len(lines) {
+		logical = strings.TrimSuffix(logical, escape)
+		i++
+		next := strings.TrimSpace(lines[i])
+		for (next == "" || strings.HasPrefix(next, "#")) && i+1 < len(lines) {
+			i++
+			next = strings.TrimSpace(lines[i])
+		}
+		logical += " " + strings.TrimRight(lines[i], " \t")
+	}
+	return logical, i
+}
+
+// readDockerHeredocs reads the bodies of the heredocs an instruction ending
+// at line i opens, and returns them with the index of the last line read
+func readDockerHeredocs(lines []string, i int, args string) (string, int) {
+	var bodies []string
+	for _, m := range heredocPattern.FindAllStringSubmatch(args, -1) {
+		var body []string
+		for i+1 < len(lines) {
+			i++
+			line := lines[i]
+			if m[1] == "-" {
+				line = strings.TrimLeft(line, "\t")
+			}
+			if strings.TrimRight(line, " \t") == m[2] {
+				break
+			}
+			body = append(body, line)
+		}
+		bodies = append(bodies, strings.Join(body, "\n"))
+	}
+	return strings.Join(bodies, "\n"), i
+}
+
+// declareDockerArgs records the ARG declarations of an instruction in scope.
+// Inside a stage, redeclaring a global ARG without a default brings the
+// global default into the stage; global is nil before the first FROM.
+func declareDockerArgs(args string, scope, global map[string]string) {
+	for _, decl := range strings.Fields(args) {
+		name, value, hasDefault := strings.Cut(decl, "=")
+		if hasDefault {
+			scope[name] = substituteDockerVars(strings.Trim(value, `"'`), scope)
+		} else if value, ok := global[name]; ok {
+			scope[name] = value
+		}
+	}
+}
+
+// dockerEscapeChar reads the escape parser directive, which defaults to a backslash
+func dockerEscapeChar(lines []string) string {
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if !strings.HasPrefix(trimmed, "#") {
+			break
+		}
+		directive := strings.ToLower(strings.ReplaceAll(strings.TrimPrefix(trimmed, "#"), " ", ""))
+		if value, ok := strings.CutPrefix(directive, "escape="); ok && value == "`" {
+			return "`"
+		}
+	}
+	return `\`
+}
+
+// newDockerStage builds a stage from its FROM instruction, skipping flags
+// such as --platform
+func newDockerStage(from dockerInstruction) dockerStage {
+	stage := dockerStage{Line: from.Line}
+	var fields []string
+	for _, field := range strings.Fields(from.Args) {
+		if !strings.HasPrefix(field, "--") {
+			fields = append(fields, field)
+		}
+	}
+	if len(fields) > 0 {
+		stage.Image = fields[0]
+	}
+	if len(fields) >= 3 && strings.EqualFold(fields[1], "as") {
+		stage.Name = fields[2]
+	}
+	return stage
+}
+
+// parseDockerEnv returns the variables set by ENV, in both the KEY=value
+// and the legacy "KEY value" form
+func parseDockerEnv(args string) map[string]string {
+	env := make(map[string]string)
+	fields := strings.Fields(args)
+	if len(fields) > 0 && !strings.Contains(fields[0], "=") {
+		env[fields[0]] = strings.TrimSpace(strings.TrimPrefix(args, fields[0]))
+		return env
+	}
+	for _, field := range fields {
+		if name, value, ok := strings.Cut(field, "="); ok {
+			env[name] = strings.Trim(value, `"'`)
+		}
+	}
+	return env
+}
+
+// substituteDockerVars expands $NAME, ${NAME}, ${NAME:-default} and
+// ${NAME:+alternative}. Unknown variables are kept verbatim.
+func substituteDockerVars(s string, vars map[string]string) string {
+	if !strings.Contains(s, "$") {
+		return s
+	}
+	var b strings.Builder
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '\\' && i+1 < len(s) && s[i+1] == '$':
+			b.WriteString(`\$`)
+			i++
+		case c != '$' || i+1 >= len(s):
+			b.WriteByte(c)
+		case s[i+1] == '{':
+			end := strings.IndexByte(s[i:], '}')
+			if end < 0 {
+				b.WriteString(s[i:])
+				return b.String()
+			}
+			b.WriteString(expandDockerVar(s[i:i+end+1], vars))
+			i += end
+		default:
+			j := dockerVarNameEnd(s, i+1)
+			if value, ok := vars[s[i+1:j]]; ok && j > i+1 {
+				b.WriteString(value)
+			} else {
+				b.WriteString(s[i:j])
+			}
+			i = j - 1
+		}
+	}
+	return b.String()
+}
+
+// expandDockerVar expands a ${...} reference, applying the :- (default)
+// and :+ (alternative) modifiers
+func expandDockerVar(ref string, vars map[string]string) string {
+	expr := ref[2 : len(ref)-1]
+	name, word, modifier := expr, "", ""
+	if idx := strings.Index(expr, ":"); idx >= 0 && idx+1 < len(expr) {
+		name, modifier, word = expr[:idx], expr[idx+1:idx+2], expr[idx+2:]
+	}
+	value, ok := vars[name]
+	set := ok && value != ""
+	switch {
+	case modifier == "-" && !set:
+		return word
+	case modifier == "+" && set:
+		return word
+	case modifier == "+":
+		return ""
+	case ok:
+		return value
+	}
+	return ref
+}
+
+// dockerVarNameEnd returns the end of the variable name starting at i
+func dockerVarNameEnd(s string, i int) int {
+	for i < len(s) && (s[i] == '_' || s[i] >= 'a' && s[i] <= 'z' || s[i] >= 'A' && s[i] <= 'Z' || s[i] >= '0' && s[i] <= '9') {
+		i++
+	}
+	return i
+}
+
+// finalStage returns the stage the image is built from
+func (d *dockerfile) finalStage() *dockerStage {
+	if len(d.Stages) == 0 {
+		return nil
+	}
+	return &d.Stages[len(d.Stages)-1]
+}
+
+// lineage returns the stage and the earlier stages it is built FROM, root
+// first, since a stage inherits the USER, HEALTHCHECK and layers of its parent
+func (d *dockerfile) lineage(stage *dockerStage) []*dockerStage {
+	chain := []*dockerStage{stage}
+	for {
+		parent := d.stageNamed(chain[0].Image, chain[0])
+		if parent == nil {
+			return chain
+		}
+		chain = append([]*dockerStage{parent}, chain...)
+	}
+}
+
+// stageNamed returns the stage called name defined before the given stage
+func (d *dockerfile) stageNamed(name string, before *dockerStage) *dockerStage {
+	for i := range d.Stages {
+		if &d.Stages[i] == before {
+			return nil
+		}
+		if d.Stages[i].Name != "" && strings.EqualFold(d.Stages[i].Name, name) {
+			return &d.Stages[i]
+		}
+	}
+	return nil
+}
@@ -2,10 +2,12 @@ package scanner
 
 import (
 	"context"
+	"fmt"
 	"os"
 	"path/filepath"
 	"runtime"
 	"strings"
+	"time"
 
 	"github.com/bmatcuk/doublestar/v4"
 	"golang.org/x/sync/errgroup"
@@ -36,6 +38,16 @@ type Logger interface {
 type ScanOptions struct {
 	Debug  bool
 	Logger Logger
+
+	// HelmValuesFiles are extra values files, relative to each chart
+	// directory, merged over values.yaml when rendering Helm charts
+	HelmValuesFiles []string
+
+	// OSVDatabase is a directory holding an OSV JSON export, matched offline
+	// against the resolved dependencies
+	OSVDatabase string
+
+	osv *osvDatabase
 }
 
 // NoopLogger is a no-op logger (exported for external use)
@@ -61,6 +73,7 @@ func ScanRepoWithOptions(root string, opts ScanOptions) (*RepoSignals, error) {
 		StringSignals:   make(map[string]string),
 		IntSignals:      make(map[string]int),
 		DetectedRegions: make(map[string]bool),
+		Evidence:        make(map[string][]Evidence),
 	}
 
 	// Use provided logger or default to noop
@@ -69,6 +82,10 @@ func ScanRepoWithOptions(root string, opts ScanOptions) (*RepoSignals, error) {
 		logger = &NoopLogger{}
 	}
 
+	if err := loadScanOSVDatabase(&opts, logger); err != nil {
+		return signals, err
+	}
+
 	ignorePatterns := parsePrIgnore(root)
 
 	if opts.Debug {
@@ -141,6 +158,10 @@ func ScanRepoWithOptions(root string, opts ScanOptions) (*RepoSignals, error) {
 	})
 
 	err := g.Wait()
+	if err == nil {
+		opts.Logger = logger
+		runAllRepoAnalyzers(signals, opts)
+	}
 
 	if opts.Debug {
 		logger.Println("\n=== Summary ===")
@@ -152,6 +173,23 @@ func ScanRepoWithOptions(root string, opts ScanOptions) (*RepoSignals, error) {
 	return signals, err
 }
 
+// loadScanOSVDatabase loads the OSV database named by the options, if any,
+// for analyzeVulnerabilities
+func loadScanOSVDatabase(opts *ScanOptions, logger Logger) error {
+	if opts.OSVDatabase == "" {
+		return nil
+	}
+	db, err := loadOSVDatabase(opts.OSVDatabase)
+	if err != nil {
+		return fmt.Errorf("loading OSV database: %w", err)
+	}
+	opts.osv = db
+	if opts.Debug {
+		logger.Printf("Loaded %d OSV advisories modified up to %s", db.Advisories, db.Snapshot.Format(time.RFC3339))
+	}
+	return nil
+}
+
 func isText(s string) bool {
 	return strings.IndexByte(s, 0) == -1
 }
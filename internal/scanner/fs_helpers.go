@@ -6,6 +6,9 @@ import (
 	"strings"
 )
 
+// maxLockfileSize is the size up to which lockfiles are read
+const maxLockfileSize = 32 << 20
+
 // handleDir processes directories during walk
 func handleDir(path, root, name string, ignorePatterns []string, debug bool, logger Logger) error {
 	if defaultIgnoredDirs[name] {
@@ -78,6 +81,17 @@ func handleFile(path, root string, info os.DirEntry, ignorePatterns []string, op
 			// Run all detectors dynamically
 			runAllDetectors(content, relPath, signals)
 		}
+	} else if opts.osv != nil && finfo.Size() < maxLockfileSize && isDepLockfile(relPath) {
+		// Lockfiles of large projects are kept for vulnerability matching,
+		// but are not worth running the detectors over
+		// #nosec G304 - path is validated to be within root directory
+		data, err := os.ReadFile(path)
+		if err == nil && isText(string(data)) {
+			signals.SetContent(relPath, string(data))
+			if opts.Debug {
+				opts.Logger.Println("  -> Added to FileContent (lockfile)")
+			}
+		}
 	} else if opts.Debug {
 		opts.Logger.Println("  -> Skipped (too large)")
 	}
@@ -139,3 +139,23 @@ func TestScanRepo(t *testing.T) {
 		t.Fatalf("expected region_count to be 1, got %d", got)
 	}
 }
+
+func TestScanRepoOSVDatabase(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "go.mod"), []byte("module x\n\nrequire golang.org/x/net v0.17.0\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	if _, err := ScanRepoWithOptions(root, ScanOptions{OSVDatabase: filepath.Join(root, "missing")}); err == nil {
+		t.Error("expected an error for a missing OSV database")
+	}
+
+	db := writeOSVExport(t, map[string]string{"GHSA-aaaa-bbbb-cccc.json": osvTestAdvisory}, nil)
+	signals, err := ScanRepoWithOptions(root, ScanOptions{OSVDatabase: db})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if signals.GetInt("osv_advisory_count") != 1 || signals.GetInt("resolved_dependency_count") != 1 {
+		t.Errorf("expected the database to be matched against go.mod, got %v", signals.IntSignals)
+	}
+}
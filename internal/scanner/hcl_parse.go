@@ -0,0 +1,557 @@
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// hclBlock is a block such as `resource "aws_db_instance" "main" { ... }`
+type hclBlock struct {
+	Type   string
+	Labels []string
+	Body   *hclBody
+	Line   int
+}
+
+// hclBody holds the attributes and nested blocks of a file or block
+type hclBody struct {
+	Attributes map[string]interface{}
+	AttrLines  map[string]int
+	Blocks     []*hclBlock
+}
+
+// hclExpr is an expression that is not a literal (a reference, function
+// call, conditional, ...), kept as its source text
+type hclExpr string
+
+// blocksOfType returns the nested blocks with the given type
+func (b *hclBody) blocksOfType(blockType string) []*hclBlock {
+	var res []*hclBlock
+	for _, block := range b.Blocks {
+		if block.Type == blockType {
+			res = append(res, block)
+		}
+	}
+	return res
+}
+
+// label returns the i-th label of a block, or ""
+func (b *hclBlock) label(i int) string {
+	if i < len(b.Labels) {
+		return b.Labels[i]
+	}
+	return ""
+}
+
+// hclParser is a small recursive-descent parser for the HCL native syntax.
+// Literals (strings, numbers, bools, lists and objects) are decoded; any
+// other expression is kept as an hclExpr.
+type hclParser struct {
+	src  []rune
+	pos  int
+	line int
+}
+
+// parseHCL parses an HCL file into its top-level body
+func parseHCL(content string) (*hclBody, error) {
+	p := &hclParser{src: []rune(content), line: 1}
+	body, err := p.parseBody(false)
+	if err != nil {
+		return nil, fmt.Errorf("line %d: %w", p.line, err)
+	}
+	return body, nil
+}
+
+func (p *hclParser) eof() bool { return p.pos >= len(p.src) }
+
+func (p *hclParser) peek() rune {
+	if p.eof() {
+		return 0
+	}
+	return p.src[p.pos]
+}
+
+func (p *hclParser) peekAt(offset int) rune {
+	if p.pos+offset >= len(p.src) {
+		return 0
+	}
+	return p.src[p.pos+offset]
+}
+
+func (p *hclParser) next() rune {
+	r := p.src[p.pos]
+	p.pos++
+	if r == '\n' {
+		p.line++
+	}
+	return r
+}
+
+// skipSpace skips blanks and comments, and newlines when newlines is set
+func (p *hclParser) skipSpace(newlines bool) {
+	for !p.eof() {
+		r := p.peek()
+		switch {
+		case r == ' ' || r == '\t' || r == '\r':
+			p.next()
+		case r == '\n' && newlines:
+			p.next()
+		case r == '#' || (r == '/' && p.peekAt(1) == '/'):
+			for !p.eof() && p.peek() != '\n' {
+				p.next()
+			}
+		case r == '/' && p.peekAt(1) == '*':
+			p.next()
+			p.next()
+			for !p.eof() && (p.peek() != '*' || p.peekAt(1) != '/') {
+				p.next()
+			}
+			if !p.eof() {
+				p.next()
+				p.next()
+			}
+		default:
+			return
+		}
+	}
+}
+
+func isHCLIdentRune(r rune, first bool) bool {
+	if r == '_' || (r >= 'a' && r <= 'z') || (r >= 'A' && r <= 'Z') {
+		return true
+	}
+	return !first && (r == '-' || (r >= '0' && r <= '9'))
+}
+
+func (p *hclParser) parseIdent() string {
+	start := p.pos
+	for !p.eof() && isHCLIdentRune(p.peek(), p.pos == start) {
+		p.next()
+	}
+	return string(p.src[start:p.pos])
+}
+
+// parseBody parses attributes and blocks until EOF or a closing brace
+func (p *hclParser) parseBody(inBlock bool) (*hclBody, error) {
+	body := &hclBody{Attributes: map[string]interface{}{}, AttrLines: map[string]int{}}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			if inBlock {
+				return nil, fmt.Errorf("unclosed block")
+			}
+			return body, nil
+		}
+		if p.peek() == '}' && inBlock {
+			p.next()
+			return body, nil
+		}
+
+		line := p.line
+		name := p.parseIdent()
+		if name == "" {
+			return nil, fmt.Errorf("unexpected %q", p.peek())
+		}
+		p.skipSpace(false)
+
+		if p.peek() == '=' && p.peekAt(1) != '=' {
+			p.next()
+			p.skipSpace(false)
+			value, err := p.parseExpr()
+			if err != nil {
+				return nil, err
+			}
+			body.Attributes[name] = value
+			body.AttrLines[name] = line
+			continue
+		}
+
+		block := &hclBlock{Type: name, Line: line}
+		for p.peek() != '{' {
+			switch {
+			case p.peek() == '"':
+				label, err := p.parseString()
+				if err != nil {
+					return nil, err
+				}
+				block.Labels = append(block.Labels, label)
+			case isHCLIdentRune(p.peek(), true):
+				block.Labels = append(block.Labels, p.parseIdent())
+			default:
+				return nil, fmt.Errorf("unexpected %q in block header", p.peek())
+			}
+			p.skipSpace(false)
+		}
+		p.next()
+		nested, err := p.parseBody(true)
+		if err != nil {
+			return nil, err
+		}
+		block.Body = nested
+		body.Blocks = append(body.Blocks, block)
+	}
+}
+
+// parseExpr parses an expression, decoding it when it is a plain literal
+func (p *hclParser) parseExpr() (interface{}, error) {
+	start, startLine := p.pos, p.line
+	var value interface{}
+	var err error
+
+	r := p.peek()
+	switch {
+	case r == '"':
+		value, err = p.parseString()
+	case r == '<' && p.peekAt(1) == '<':
+		value, err = p.parseHeredoc()
+	case (r == '[' || r == '{') && p.isForExpr():
+		return p.parseRaw(start, startLine)
+	case r == '[':
+		value, err = p.parseList()
+	case r == '{':
+		value, err = p.parseObject()
+	case r == '-' || (r >= '0' && r <= '9'):
+		value = p.parseNumber()
+	case isHCLIdentRune(r, true):
+		switch ident := p.parseIdent(); ident {
+		case "true", "false":
+			value = ident == "true"
+		case "null":
+			value = nil
+		default:
+			return p.parseRaw(start, startLine)
+		}
+	default:
+		return p.parseRaw(start, startLine)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// Operators, traversals or calls after the literal make it an expression
+	p.skipSpace(false)
+	if !p.atExprEnd() {
+		return p.parseRaw(start, startLine)
+	}
+	return value, nil
+}
+
+// atExprEnd reports whether the expression ends at the current position
+func (p *hclParser) atExprEnd() bool {
+	if p.eof() {
+		return true
+	}
+	switch p.peek() {
+	case '\n', ',', '}', ']', ')':
+		return true
+	}
+	return false
+}
+
+// isForExpr reports whether a bracket opens a for expression
+func (p *hclParser) isForExpr() bool {
+	i := p.pos + 1
+	for i < len(p.src) && (p.src[i] == ' ' || p.src[i] == '\t' || p.src[i] == '\n' || p.src[i] == '\r') {
+		i++
+	}
+	return i+4 <= len(p.src) && string(p.src[i:i+3]) == "for" && !isHCLIdentRune(p.src[i+3], false)
+}
+
+// parseRaw consumes the rest of an expression from start, keeping brackets
+// and strings balanced, and returns its source text
+func (p *hclParser) parseRaw(start, startLine int) (interface{}, error) {
+	p.pos, p.line = start, startLine
+	depth := 0
+	for !p.eof() {
+		skipped, err := p.skipTemplate()
+		if err != nil {
+			return nil, err
+		}
+		if skipped {
+			continue
+		}
+		r := p.peek()
+		switch {
+		case r == '(' || r == '[' || r == '{':
+			depth++
+		case r == ')' || r == ']' || r == '}':
+			if depth == 0 {
+				return p.rawText(start), nil
+			}
+			depth--
+		case depth == 0 && p.atRawExprEnd():
+			return p.rawText(start), nil
+		}
+		p.next()
+	}
+	if depth > 0 {
+		return nil, fmt.Errorf("unbalanced expression")
+	}
+	return p.rawText(start), nil
+}
+
+// skipTemplate consumes a quoted string or heredoc at the current position,
+// whose brackets do not count towards the expression
+func (p *hclParser) skipTemplate() (bool, error) {
+	var err error
+	switch {
+	case p.peek() == '"':
+		_, err = p.parseString()
+	case p.peek() == '<' && p.peekAt(1) == '<' && (isHCLIdentRune(p.peekAt(2), true) || p.peekAt(2) == '-'):
+		_, err = p.parseHeredoc()
+	default:
+		return false, nil
+	}
+	return true, err
+}
+
+// atRawExprEnd reports whether an expression outside brackets ends at the
+// current position: at a newline, comma or comment
+func (p *hclParser) atRawExprEnd() bool {
+	switch p.peek() {
+	case '\n', ',', '#':
+		return true
+	case '/':
+		return p.peekAt(1) == '/' || p.peekAt(1) == '*'
+	}
+	return false
+}
+
+// rawText returns the trimmed source text from start to the current position
+func (p *hclParser) rawText(start int) hclExpr {
+	return hclExpr(strings.TrimSpace(string(p.src[start:p.pos])))
+}
+
+// parseString parses a quoted template. Interpolations are kept verbatim.
+func (p *hclParser) parseString() (string, error) {
+	p.next() // opening quote
+	var b strings.Builder
+	for {
+		if p.eof() || p.peek() == '\n' {
+			return "", fmt.Errorf("unterminated string")
+		}
+		r := p.next()
+		switch {
+		case r == '"':
+			return b.String(), nil
+		case r == '\\' && !p.eof():
+			switch esc := p.next(); esc {
+			case 'n':
+				b.WriteRune('\n')
+			case 't':
+				b.WriteRune('\t')
+			case 'r':
+				b.WriteRune('\r')
+			default:
+				b.WriteRune(esc)
+			}
+		case (r == '$' || r == '%') && p.peek() == '{':
+			b.WriteRune(r)
+			if err := p.copyInterpolation(&b); err != nil {
+				return "", err
+			}
+		default:
+			b.WriteRune(r)
+		}
+	}
+}
+
+// copyInterpolation copies a ${...} sequence, including nested strings
+func (p *hclParser) copyInterpolation(b *strings.Builder) error {
+	depth := 0
+	for !p.eof() {
+		r := p.peek()
+		if r == '"' {
+			s, err := p.parseString()
+			if err != nil {
+				return err
+			}
+			b.WriteString(strconv.Quote(s))
+			continue
+		}
+		b.WriteRune(p.next())
+		switch r {
+		case '{':
+			depth++
+		case '}':
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+	return fmt.Errorf("unterminated interpolation")
+}
+
+// parseHeredoc parses <<EOF and <<-EOF templates
+func (p *hclParser) parseHeredoc() (string, error) {
+	p.next()
+	p.next()
+	indented := false
+	if p.peek() == '-' {
+		p.next()
+		indented = true
+	}
+	marker := p.parseIdent()
+	if marker == "" {
+		return "", fmt.Errorf("invalid heredoc marker")
+	}
+	for !p.eof() && p.peek() != '\n' {
+		p.next()
+	}
+
+	var lines []string
+	for !p.eof() {
+		p.next() // newline
+		start := p.pos
+		for !p.eof() && p.peek() != '\n' {
+			p.next()
+		}
+		line := string(p.src[start:p.pos])
+		if strings.TrimSpace(line) == marker {
+			if indented {
+				return dedentLines(lines), nil
+			}
+			return strings.Join(lines, "\n"), nil
+		}
+		lines = append(lines, line)
+	}
+	return "", fmt.Errorf("unterminated heredoc %s", marker)
+}
+
+// dedentLines removes the common leading whitespace of <<- heredocs
+func dedentLines(lines []string) string {
+	minIndent := -1
+	for _, line := range lines {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		indent := len(line) - len(strings.TrimLeft(line, " \t"))
+		if minIndent < 0 || indent < minIndent {
+			minIndent = indent
+		}
+	}
+	for i, line := range lines {
+		if len(line) >= minIndent && minIndent > 0 {
+			lines[i] = line[minIndent:]
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+// parseNumber parses a number literal into a float64
+func (p *hclParser) parseNumber() interface{} {
+	start := p.pos
+	if p.peek() == '-' {
+		p.next()
+	}
+	for !p.eof() && strings.ContainsRune("0123456789.eE+-", p.peek()) {
+		if (p.peek() == '+' || p.peek() == '-') && p.src[p.pos-1] != 'e' && p.src[p.pos-1] != 'E' {
+			break
+		}
+		p.next()
+	}
+	f, err := strconv.ParseFloat(string(p.src[start:p.pos]), 64)
+	if err != nil {
+		return hclExpr(string(p.src[start:p.pos]))
+	}
+	return f
+}
+
+// parseList parses a tuple literal
+func (p *hclParser) parseList() (interface{}, error) {
+	p.next()
+	list := []interface{}{}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, fmt.Errorf("unclosed list")
+		}
+		if p.peek() == ']' {
+			p.next()
+			return list, nil
+		}
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		list = append(list, value)
+		p.skipSpace(true)
+		if p.peek() == ',' {
+			p.next()
+		}
+	}
+}
+
+// parseObject parses an object literal; keys may be identifiers or strings
+func (p *hclParser) parseObject() (interface{}, error) {
+	p.next()
+	obj := map[string]interface{}{}
+	for {
+		p.skipSpace(true)
+		if p.eof() {
+			return nil, fmt.Errorf("unclosed object")
+		}
+		if p.peek() == '}' {
+			p.next()
+			return obj, nil
+		}
+
+		var key string
+		switch {
+		case p.peek() == '"':
+			k, err := p.parseString()
+			if err != nil {
+				return nil, err
+			}
+			key = k
+		case p.peek() == '(':
+			raw, err := p.parseRawUntil('=', ':')
+			if err != nil {
+				return nil, err
+			}
+			key = raw
+		default:
+			key = p.parseIdent()
+			if key == "" {
+				return nil, fmt.Errorf("unexpected %q in object", p.peek())
+			}
+		}
+		p.skipSpace(false)
+		if p.peek() != '=' && p.peek() != ':' {
+			return nil, fmt.Errorf("expected = after object key %q", key)
+		}
+		p.next()
+		p.skipSpace(false)
+		value, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		obj[key] = value
+		p.skipSpace(false)
+		if p.peek() == ',' {
+			p.next()
+		}
+	}
+}
+
+// parseRawUntil consumes balanced source text until one of the stop runes
+func (p *hclParser) parseRawUntil(stops ...rune) (string, error) {
+	start := p.pos
+	depth := 0
+	for !p.eof() {
+		r := p.peek()
+		if depth == 0 && strings.ContainsRune(string(stops), r) {
+			return strings.TrimSpace(string(p.src[start:p.pos])), nil
+		}
+		switch r {
+		case '(', '[', '{':
+			depth++
+		case ')', ']', '}':
+			depth--
+		}
+		p.next()
+	}
+	return "", fmt.Errorf("unexpected end of input")
+}
@@ -0,0 +1,99 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestParseHCL(t *testing.T) {
+	content := `# comment
+terraform {
+  required_version = ">= 1.5"
+}
+
+/* block
+   comment */
+provider "aws" {
+  region = var.region // trailing comment
+  default_tags {
+    tags = { Team = "core", "cost-center" = 42 }
+  }
+}
+
+resource "aws_db_instance" "main" {
+  multi_az                = true
+  backup_retention_period = 14
+  identifier              = "${local.prefix}-db"
+  tags                    = merge(var.tags, {
+    Name = "main"
+  })
+  subnets = ["a", "b",
+    "c"]
+  names = [for s in var.subnets : upper(s)]
+  policy = <<-EOT
+    {"Version": "2012-10-17"}
+  EOT
+  count = var.enabled ? 1 : 0
+}
+`
+	body, err := parseHCL(content)
+	if err != nil {
+		t.Fatalf("parseHCL() error = %v", err)
+	}
+	if len(body.Blocks) != 3 {
+		t.Fatalf("expected 3 top-level blocks, got %d", len(body.Blocks))
+	}
+
+	provider := body.blocksOfType("provider")[0]
+	if provider.label(0) != "aws" || provider.Line != 8 {
+		t.Errorf("unexpected provider block %q on line %d", provider.label(0), provider.Line)
+	}
+	if provider.Body.Attributes["region"] != hclExpr("var.region") {
+		t.Errorf("expected region reference, got %#v", provider.Body.Attributes["region"])
+	}
+	tags := provider.Body.blocksOfType("default_tags")[0].Body.Attributes["tags"].(map[string]interface{})
+	if tags["Team"] != "core" || tags["cost-center"] != float64(42) {
+		t.Errorf("unexpected object literal %v", tags)
+	}
+
+	db := body.blocksOfType("resource")[0]
+	attrs := db.Body.Attributes
+	if db.label(0) != "aws_db_instance" || db.label(1) != "main" {
+		t.Errorf("unexpected resource labels %v", db.Labels)
+	}
+	literals := map[string]interface{}{
+		"multi_az":                true,
+		"backup_retention_period": float64(14),
+		"identifier":              "${local.prefix}-db",        // interpolation kept verbatim
+		"policy":                  `{"Version": "2012-10-17"}`, // dedented heredoc
+		"count":                   hclExpr("var.enabled ? 1 : 0"),
+	}
+	for name, expected := range literals {
+		if attrs[name] != expected {
+			t.Errorf("expected %s = %#v, got %#v", name, expected, attrs[name])
+		}
+	}
+	if _, ok := attrs["tags"].(hclExpr); !ok {
+		t.Errorf("expected function call kept as expression, got %#v", attrs["tags"])
+	}
+	if list, ok := attrs["subnets"].([]interface{}); !ok || len(list) != 3 {
+		t.Errorf("expected multi-line list of 3, got %#v", attrs["subnets"])
+	}
+	if _, ok := attrs["names"].(hclExpr); !ok {
+		t.Errorf("expected for expression kept as expression, got %#v", attrs["names"])
+	}
+	if db.Body.AttrLines["count"] != 28 {
+		t.Errorf("expected count on line 28, got %d", db.Body.AttrLines["count"])
+	}
+}
+
+func TestParseHCLErrors(t *testing.T) {
+	for name, content := range map[string]string{
+		"unclosed block":        "resource \"a\" \"b\" {\n  x = 1\n",
+		"unbalanced expression": "x = merge(var.a, {\n",
+		"unterminated heredoc":  "x = <<EOT\nbody\n",
+	} {
+		if _, err := parseHCL(content); err == nil {
+			t.Errorf("%s: expected an error", name)
+		}
+	}
+}
@@ -0,0 +1,989 @@
+package scanner
+
+import (
+	"bytes"
+	"crypto/sha1" // #nosec G505 - sha1sum is part of the Sprig function set
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/adler32"
+	"os"
+	"path/filepath"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"text/template"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// helmChart is a chart found in the repository together with its files
+type helmChart struct {
+	Dir      string                 // chart directory relative to the repo root
+	Metadata map[string]interface{} // decoded Chart.yaml
+	Files    map[string]string      // chart files keyed by repo-relative path
+}
+
+// helmRenderedTemplate is the output of a single chart template
+type helmRenderedTemplate struct {
+	Path    string // repo-relative path of the template
+	Content string
+	Err     error // set when the template failed to parse or execute
+}
+
+// helmAPIVersions exposes .Capabilities.APIVersions to templates
+type helmAPIVersions []string
+
+// Has reports whether an API version is available; offline rendering assumes
+// every group/version is served
+func (helmAPIVersions) Has(string) bool { return true }
+
+// helmFiles exposes .Files to templates
+type helmFiles map[string]string
+
+// Get returns the content of a chart file relative to the chart root
+func (f helmFiles) Get(name string) string { return f[filepath.ToSlash(name)] }
+
+// renderHelmChart renders every template of a chart offline using values.yaml
+// merged with the given extra values documents. Partials (files starting with
+// an underscore) only contribute definitions. A template that fails to parse
+// or execute is returned with its error so the rest of the chart still
+// renders; only invalid values fail the whole chart.
+func renderHelmChart(chart *helmChart, extraValues []string) ([]helmRenderedTemplate, error) {
+	values, err := helmValues(chart, extraValues)
+	if err != nil {
+		return nil, err
+	}
+
+	chartName, _ := chart.Metadata["name"].(string)
+	if chartName == "" {
+		chartName = filepath.Base(chart.Dir)
+	}
+	relFiles, templatePaths := helmChartLayout(chart)
+
+	root := template.New(chartName)
+	root.Funcs(helmFuncMap(root))
+	parseErrs := make(map[string]error)
+	for _, path := range templatePaths {
+		name := helmTemplateName(chartName, chart.Dir, path)
+		if _, err := root.New(name).Parse(chart.Files[path]); err != nil {
+			parseErrs[path] = err
+		}
+	}
+
+	data := map[string]interface{}{
+		"Values": values,
+		"Chart":  helmChartData(chart.Metadata, chartName),
+		"Release": map[string]interface{}{
+			"Name":      chartName,
+			"Namespace": "default",
+			"Service":   "Helm",
+			"IsInstall": true,
+			"IsUpgrade": false,
+			"Revision":  1,
+		},
+		"Capabilities": map[string]interface{}{
+			"KubeVersion": map[string]interface{}{"Version": "v1.30.0", "GitVersion": "v1.30.0", "Major": "1", "Minor": "30"},
+			"APIVersions": helmAPIVersions{},
+		},
+		"Files": relFiles,
+	}
+
+	var rendered []helmRenderedTemplate
+	for _, path := range templatePaths {
+		if err := parseErrs[path]; err != nil {
+			rendered = append(rendered, helmRenderedTemplate{Path: path, Err: err})
+			continue
+		}
+		if strings.HasPrefix(filepath.Base(path), "_") {
+			continue
+		}
+		name := helmTemplateName(chartName, chart.Dir, path)
+		data["Template"] = map[string]interface{}{"Name": name, "BasePath": chartName + "/templates"}
+
+		var buf bytes.Buffer
+		if err := root.ExecuteTemplate(&buf, name, data); err != nil {
+			rendered = append(rendered, helmRenderedTemplate{Path: path, Err: err})
+			continue
+		}
+		rendered = append(rendered, helmRenderedTemplate{
+			Path:    path,
+			Content: strings.ReplaceAll(buf.String(), "<no value>", ""),
+		})
+	}
+	return rendered, nil
+}
+
+// helmValues merges values.yaml with the extra values documents in order
+func helmValues(chart *helmChart, extraValues []string) (map[string]interface{}, error) {
+	values := map[string]interface{}{}
+	if content, ok := chart.Files[filepath.Join(chart.Dir, "values.yaml")]; ok {
+		if err := yaml.Unmarshal([]byte(content), &values); err != nil {
+			return nil, fmt.Errorf("values.yaml: %w", err)
+		}
+	}
+	for _, extra := range extraValues {
+		var override map[string]interface{}
+		if err := yaml.Unmarshal([]byte(extra), &override); err != nil {
+			return nil, fmt.Errorf("extra values: %w", err)
+		}
+		values = mergeYAMLMaps(values, override)
+	}
+	return values, nil
+}
+
+// helmChartLayout returns the chart files relative to the chart root, as
+// .Files serves them, and the sorted paths of the chart templates
+func helmChartLayout(chart *helmChart) (helmFiles, []string) {
+	templatesDir := filepath.Join(chart.Dir, "templates") + string(filepath.Separator)
+	relFiles := helmFiles{}
+	var templatePaths []string
+	for path, content := range chart.Files {
+		rel, err := filepath.Rel(chart.Dir, path)
+		if err == nil {
+			relFiles[filepath.ToSlash(rel)] = content
+		}
+		if strings.HasPrefix(path, templatesDir) && isHelmTemplateFile(path) {
+			templatePaths = append(templatePaths, path)
+		}
+	}
+	sort.Strings(templatePaths)
+	return relFiles, templatePaths
+}
+
+// isHelmTemplateFile reports whether a file under templates/ is a template
+func isHelmTemplateFile(path string) bool {
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ExtYAML, ExtYML, ".tpl":
+		return true
+	}
+	return false
+}
+
+// helmTemplateName names a template the way Helm does: <chart>/templates/<file>
+func helmTemplateName(chartName, chartDir, path string) string {
+	rel, err := filepath.Rel(chartDir, path)
+	if err != nil {
+		rel = path
+	}
+	return chartName + "/" + filepath.ToSlash(rel)
+}
+
+// helmChartData maps Chart.yaml keys to the capitalized names templates use
+func helmChartData(metadata map[string]interface{}, chartName string) map[string]interface{} {
+	data := map[string]interface{}{"Name": chartName}
+	for k, v := range metadata {
+		if k == "" {
+			continue
+		}
+		data[strings.ToUpper(k[:1])+k[1:]] = v
+	}
+	data["Name"] = chartName
+	return data
+}
+
+// helmFuncMap returns the subset of Helm/Sprig template functions that charts
+// commonly rely on. Functions needing the cluster (lookup) return empty results.
+// A template calling a function outside this set fails on its own and is
+// reported, without dropping the rest of the chart.
+func helmFuncMap(root *template.Template) template.FuncMap {
+	return template.FuncMap{
+		"include": func(name string, data interface{}) (string, error) {
+			var buf bytes.Buffer
+			err := root.ExecuteTemplate(&buf, name, data)
+			return buf.String(), err
+		},
+		"tpl": helmTpl(root),
+		"required": func(msg string, v interface{}) (interface{}, error) {
+			if helmEmpty(v) {
+				return nil, errors.New(msg)
+			}
+			return v, nil
+		},
+		"fail":   func(msg string) (string, error) { return "", errors.New(msg) },
+		"lookup": func(...interface{}) map[string]interface{} { return map[string]interface{}{} },
+
+		"default": func(def interface{}, given ...interface{}) interface{} {
+			if len(given) == 0 || helmEmpty(given[0]) {
+				return def
+			}
+			return given[0]
+		},
+		"empty":    helmEmpty,
+		"coalesce": helmCoalesce,
+		"ternary": func(a, b interface{}, cond bool) interface{} {
+			if cond {
+				return a
+			}
+			return b
+		},
+
+		"toYaml":   helmToYAML,
+		"toJson":   helmToJSON,
+		"fromYaml": helmFromYAML,
+		"indent":   helmIndent,
+		"nindent":  func(n int, s string) string { return "\n" + helmIndent(n, s) },
+
+		"quote":      func(v ...interface{}) string { return helmJoinQuoted(v, "\"") },
+		"squote":     func(v ...interface{}) string { return helmJoinQuoted(v, "'") },
+		"toString":   helmToString,
+		"lower":      strings.ToLower,
+		"upper":      strings.ToUpper,
+		"title":      helmTitle,
+		"trim":       strings.TrimSpace,
+		"trimSuffix": func(suffix, s string) string { return strings.TrimSuffix(s, suffix) },
+		"trimPrefix": func(prefix, s string) string { return strings.TrimPrefix(s, prefix) },
+		"trunc":      helmTrunc,
+		"replace":    func(old, replacement, s string) string { return strings.ReplaceAll(s, old, replacement) },
+		"contains":   func(substr, s string) bool { return strings.Contains(s, substr) },
+		"hasPrefix":  func(prefix, s string) bool { return strings.HasPrefix(s, prefix) },
+		"hasSuffix":  func(suffix, s string) bool { return strings.HasSuffix(s, suffix) },
+		"repeat":     func(n int, s string) string { return strings.Repeat(s, n) },
+		"join":       helmJoin,
+		"split":      helmSplit,
+		"splitList":  func(sep, s string) []string { return strings.Split(s, sep) },
+		"regexMatch": func(re, s string) bool { ok, _ := regexp.MatchString(re, s); return ok },
+		"b64enc":     func(s string) string { return base64.StdEncoding.EncodeToString([]byte(s)) },
+		"sha256sum": func(s string) string {
+			sum := sha256.Sum256([]byte(s))
+			return hex.EncodeToString(sum[:])
+		},
+		"randAlphaNum": func(n int) string { return strings.Repeat("x", n) },
+
+		"list":   func(v ...interface{}) []interface{} { return v },
+		"dict":   helmDict,
+		"get":    helmGet,
+		"set":    func(m map[string]interface{}, k string, v interface{}) map[string]interface{} { m[k] = v; return m },
+		"hasKey": func(m map[string]interface{}, k string) bool { _, ok := m[k]; return ok },
+		"keys":   helmKeys,
+		"merge":  helmMerge,
+		"first":  helmFirst,
+		"append": func(list, v interface{}) []interface{} { return append(helmList(list), v) },
+		"kindIs": func(kind string, v interface{}) bool { return helmKind(v) == kind },
+		"typeOf": func(v interface{}) string { return fmt.Sprintf("%T", v) },
+
+		"int":           helmToInt,
+		"int64":         func(v interface{}) int64 { return int64(helmToInt(v)) },
+		"add":           helmAdd,
+		"add1":          func(a interface{}) int { return helmToInt(a) + 1 },
+		"sub":           func(a, b interface{}) int { return helmToInt(a) - helmToInt(b) },
+		"mul":           helmMul,
+		"max":           func(a interface{}, rest ...interface{}) int { return helmExtreme(a, rest, 1) },
+		"min":           func(a interface{}, rest ...interface{}) int { return helmExtreme(a, rest, -1) },
+		"div":           func(a, b interface{}) int { return helmDivide(helmToInt(a), helmToInt(b), false) },
+		"mod":           func(a, b interface{}) int { return helmDivide(helmToInt(a), helmToInt(b), true) },
+		"atoi":          func(s string) int { return helmToInt(s) },
+		"float64":       helmToFloat,
+		"until":         helmUntil,
+		"semverCompare": helmSemverCompare,
+
+		"b64dec":     helmB64Decode,
+		"sha1sum":    helmSHA1,
+		"adler32sum": func(s string) string { return strconv.FormatUint(uint64(adler32.Checksum([]byte(s))), 10) },
+		"substr":     helmSubstr,
+		"nospace":    func(s string) string { return strings.Join(strings.Fields(s), "") },
+		"trimAll":    func(cutset, s string) string { return strings.Trim(s, cutset) },
+		"cat":        helmCat,
+		"snakecase":  func(s string) string { return helmJoinWords(s, "_") },
+		"kebabcase":  func(s string) string { return helmJoinWords(s, "-") },
+		"regexReplaceAll": func(re, s, repl string) string {
+			return helmRegexp(re).ReplaceAllString(s, repl)
+		},
+		"regexFind": func(re, s string) string { return helmRegexp(re).FindString(s) },
+		"base":      filepath.Base,
+		"dir":       filepath.Dir,
+		"ext":       filepath.Ext,
+
+		"has":            helmHas,
+		"last":           helmLast,
+		"rest":           func(list interface{}) []interface{} { return helmSlice(helmList(list), 1) },
+		"uniq":           helmUniq,
+		"compact":        helmCompact,
+		"concat":         helmConcat,
+		"sortAlpha":      helmSortAlpha,
+		"values":         helmMapValues,
+		"pluck":          helmPluck,
+		"dig":            helmDig,
+		"omit":           func(m map[string]interface{}, keys ...string) map[string]interface{} { return helmPick(m, keys, false) },
+		"pick":           func(m map[string]interface{}, keys ...string) map[string]interface{} { return helmPick(m, keys, true) },
+		"deepCopy":       helmDeepCopy,
+		"mergeOverwrite": helmMergeOverwrite,
+		"fromJson":       helmFromJSON,
+		"toPrettyJson":   helmToPrettyJSON,
+		"toRawJson":      helmToRawJSON,
+
+		// Functions depending on time, randomness, key material or the
+		// environment render fixed placeholders, as lookup does for the
+		// cluster: the clock is helmRenderTime, random strings have the
+		// requested length, keys and certificates are empty and the
+		// environment has no variables
+		"now":               func() time.Time { return helmRenderTime },
+		"date":              helmDate,
+		"uuidv4":            func() string { return "00000000-0000-4000-8000-000000000000" },
+		"randAlpha":         func(n int) string { return strings.Repeat("x", n) },
+		"randNumeric":       func(n int) string { return strings.Repeat("0", n) },
+		"randAscii":         func(n int) string { return strings.Repeat("x", n) },
+		"derivePassword":    func(...interface{}) string { return "" },
+		"genPrivateKey":     func(string) string { return "" },
+		"genCA":             func(...interface{}) helmCertificate { return helmCertificate{} },
+		"genSelfSignedCert": func(...interface{}) helmCertificate { return helmCertificate{} },
+		"genSignedCert":     func(...interface{}) helmCertificate { return helmCertificate{} },
+		"env":               func(string) string { return "" },
+		"expandenv":         func(s string) string { return os.Expand(s, func(string) string { return "" }) },
+	}
+}
+
+// helmRenderTime is what now returns, so rendering is reproducible
+var helmRenderTime = time.Date(2000, time.January, 1, 0, 0, 0, 0, time.UTC)
+
+// helmCertificate is what the gen* certificate functions return
+type helmCertificate struct {
+	Cert string
+	Key  string
+}
+
+// helmEmpty mirrors Sprig's notion of an empty value
+func helmEmpty(v interface{}) bool {
+	if v == nil {
+		return true
+	}
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.String, reflect.Slice, reflect.Map, reflect.Array:
+		return rv.Len() == 0
+	case reflect.Bool:
+		return !rv.Bool()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return rv.Int() == 0
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return rv.Uint() == 0
+	case reflect.Float32, reflect.Float64:
+		return rv.Float() == 0
+	case reflect.Ptr, reflect.Interface:
+		return rv.IsNil()
+	}
+	return false
+}
+
+func helmCoalesce(v ...interface{}) interface{} {
+	for _, item := range v {
+		if !helmEmpty(item) {
+			return item
+		}
+	}
+	return nil
+}
+
+func helmToYAML(v interface{}) string {
+	if v == nil {
+		return ""
+	}
+	var buf bytes.Buffer
+	enc := yaml.NewEncoder(&buf)
+	enc.SetIndent(2)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	_ = enc.Close()
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+func helmToJSON(v interface{}) string {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+func helmFromYAML(s string) map[string]interface{} {
+	m := map[string]interface{}{}
+	_ = yaml.Unmarshal([]byte(s), &m)
+	return m
+}
+
+func helmIndent(n int, s string) string {
+	pad := strings.Repeat(" ", n)
+	return pad + strings.ReplaceAll(s, "\n", "\n"+pad)
+}
+
+func helmToString(v interface{}) string {
+	switch s := v.(type) {
+	case nil:
+		return ""
+	case string:
+		return s
+	case []byte:
+		return string(s)
+	}
+	return fmt.Sprint(v)
+}
+
+func helmJoinQuoted(v []interface{}, q string) string {
+	parts := make([]string, 0, len(v))
+	for _, item := range v {
+		if item == nil {
+			continue
+		}
+		s := helmToString(item)
+		if q == "\"" {
+			parts = append(parts, strconv.Quote(s))
+		} else {
+			parts = append(parts, q+s+q)
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+func helmTitle(s string) string {
+	words := strings.Fields(s)
+	for i, w := range words {
+		words[i] = strings.ToUpper(w[:1]) + w[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// helmTrunc keeps the first n bytes of a string, or the last -n
+func helmTrunc(n int, s string) string {
+	switch {
+	case n >= 0 && len(s) > n:
+		return s[:n]
+	case n < 0 && len(s)+n > 0:
+		return s[len(s)+n:]
+	}
+	return s
+}
+
+func helmJoin(sep string, v interface{}) string {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return helmToString(v)
+	}
+	parts := make([]string, 0, rv.Len())
+	for i := 0; i < rv.Len(); i++ {
+		parts = append(parts, helmToString(rv.Index(i).Interface()))
+	}
+	return strings.Join(parts, sep)
+}
+
+func helmSplit(sep, s string) map[string]string {
+	res := map[string]string{}
+	for i, part := range strings.Split(s, sep) {
+		res["_"+strconv.Itoa(i)] = part
+	}
+	return res
+}
+
+func helmDict(v ...interface{}) map[string]interface{} {
+	m := map[string]interface{}{}
+	for i := 0; i+1 < len(v); i += 2 {
+		m[helmToString(v[i])] = v[i+1]
+	}
+	return m
+}
+
+func helmKeys(maps ...map[string]interface{}) []string {
+	var keys []string
+	for _, m := range maps {
+		for k := range m {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func helmMerge(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	for _, src := range srcs {
+		for k, v := range mergeYAMLMaps(src, dst) {
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+func helmFirst(v interface{}) interface{} {
+	rv := reflect.ValueOf(v)
+	if (rv.Kind() == reflect.Slice || rv.Kind() == reflect.Array) && rv.Len() > 0 {
+		return rv.Index(0).Interface()
+	}
+	return nil
+}
+
+// helmTpl returns tpl, which renders text with the chart's templates: the
+// text may include or call them and define its own. Each call works on a
+// copy of the chart's template set, so definitions do not leak between
+// calls.
+func helmTpl(root *template.Template) func(text string, data interface{}) (string, error) {
+	return func(text string, data interface{}) (string, error) {
+		set, err := root.Clone()
+		if err != nil {
+			return "", err
+		}
+		set.Funcs(helmFuncMap(set))
+		t, err := set.New("tpl").Parse(text)
+		if err != nil {
+			return "", err
+		}
+		var buf bytes.Buffer
+		err = t.Execute(&buf, data)
+		return buf.String(), err
+	}
+}
+
+// helmGet returns the value of a key, or "" when the map lacks it
+func helmGet(m map[string]interface{}, k string) interface{} {
+	if v, ok := m[k]; ok {
+		return v
+	}
+	return ""
+}
+
+// helmKind names the kind of a value the way Sprig's kindIs does
+func helmKind(v interface{}) string {
+	if v == nil {
+		return "invalid"
+	}
+	kind := reflect.ValueOf(v).Kind()
+	if kind == reflect.Interface || kind == reflect.Ptr {
+		return "ptr"
+	}
+	return kind.String()
+}
+
+func helmToInt(v interface{}) int {
+	switch n := v.(type) {
+	case int:
+		return n
+	case int64:
+		return int(n)
+	case float64:
+		return int(n)
+	case string:
+		i, _ := strconv.Atoi(n)
+		return i
+	}
+	return 0
+}
+
+func helmToFloat(v interface{}) float64 {
+	switch n := v.(type) {
+	case int:
+		return float64(n)
+	case int64:
+		return float64(n)
+	case float64:
+		return n
+	case string:
+		f, _ := strconv.ParseFloat(n, 64)
+		return f
+	}
+	return 0
+}
+
+func helmAdd(v ...interface{}) int {
+	sum := 0
+	for _, n := range v {
+		sum += helmToInt(n)
+	}
+	return sum
+}
+
+func helmMul(a interface{}, v ...interface{}) int {
+	product := helmToInt(a)
+	for _, n := range v {
+		product *= helmToInt(n)
+	}
+	return product
+}
+
+// helmExtreme returns the largest of its arguments for sign 1 and the
+// smallest for sign -1
+func helmExtreme(a interface{}, rest []interface{}, sign int) int {
+	extreme := helmToInt(a)
+	for _, v := range rest {
+		if n := helmToInt(v); (n-extreme)*sign > 0 {
+			extreme = n
+		}
+	}
+	return extreme
+}
+
+// helmDivide divides, or returns the remainder, without panicking on zero
+func helmDivide(a, b int, remainder bool) int {
+	switch {
+	case b == 0:
+		return 0
+	case remainder:
+		return a % b
+	}
+	return a / b
+}
+
+func helmUntil(n int) []int {
+	list := make([]int, 0, max(n, 0))
+	for i := 0; i < n; i++ {
+		list = append(list, i)
+	}
+	return list
+}
+
+// helmSHA1 backs sha1sum, which charts use for checksum annotations
+func helmSHA1(s string) string {
+	sum := sha1.Sum([]byte(s)) // #nosec G401 - not used for security
+	return hex.EncodeToString(sum[:])
+}
+
+func helmB64Decode(s string) string {
+	data, err := base64.StdEncoding.DecodeString(s)
+	if err != nil {
+		return err.Error()
+	}
+	return string(data)
+}
+
+func helmSubstr(start, end int, s string) string {
+	if start < 0 || start > len(s) {
+		start = 0
+	}
+	if end < 0 || end > len(s) {
+		end = len(s)
+	}
+	if start > end {
+		return ""
+	}
+	return s[start:end]
+}
+
+// helmWordPattern splits camelCase, snake_case and kebab-case words
+var helmWordPattern = regexp.MustCompile(`[A-Z]?[a-z0-9]+|[A-Z]+(?:[^a-z]|$)`)
+
+// helmJoinWords lowercases the words of a string and joins them by sep
+func helmJoinWords(s, sep string) string {
+	words := helmWordPattern.FindAllString(s, -1)
+	for i, w := range words {
+		words[i] = strings.ToLower(strings.Trim(w, "_- "))
+	}
+	return strings.Join(words, sep)
+}
+
+// helmRegexp compiles a template-supplied expression; invalid expressions
+// match nothing, as Sprig's non-must regex functions do
+func helmRegexp(expr string) *regexp.Regexp {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return regexp.MustCompile(`[^\s\S]`)
+	}
+	return re
+}
+
+func helmDate(layout string, date interface{}) string {
+	t, ok := date.(time.Time)
+	if !ok {
+		t = helmRenderTime
+	}
+	return t.Format(layout)
+}
+
+// helmList converts any slice or array into a list of values
+func helmList(v interface{}) []interface{} {
+	rv := reflect.ValueOf(v)
+	if rv.Kind() != reflect.Slice && rv.Kind() != reflect.Array {
+		return nil
+	}
+	list := make([]interface{}, rv.Len())
+	for i := range list {
+		list[i] = rv.Index(i).Interface()
+	}
+	return list
+}
+
+func helmSlice(list []interface{}, from int) []interface{} {
+	if from >= len(list) {
+		return []interface{}{}
+	}
+	return list[from:]
+}
+
+// helmHas reports whether a list holds a value equal to needle
+func helmHas(needle, list interface{}) bool {
+	return slices.ContainsFunc(helmList(list), func(item interface{}) bool { return reflect.DeepEqual(item, needle) })
+}
+
+func helmLast(v interface{}) interface{} {
+	list := helmList(v)
+	if len(list) == 0 {
+		return nil
+	}
+	return list[len(list)-1]
+}
+
+func helmUniq(v interface{}) []interface{} {
+	var out []interface{}
+	for _, item := range helmList(v) {
+		if !helmHas(item, out) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func helmCompact(v interface{}) []interface{} {
+	var out []interface{}
+	for _, item := range helmList(v) {
+		if !helmEmpty(item) {
+			out = append(out, item)
+		}
+	}
+	return out
+}
+
+func helmConcat(lists ...interface{}) []interface{} {
+	var out []interface{}
+	for _, list := range lists {
+		out = append(out, helmList(list)...)
+	}
+	return out
+}
+
+func helmSortAlpha(v interface{}) []string {
+	list := helmList(v)
+	out := make([]string, 0, len(list))
+	for _, item := range list {
+		out = append(out, helmToString(item))
+	}
+	sort.Strings(out)
+	return out
+}
+
+func helmMapValues(m map[string]interface{}) []interface{} {
+	out := make([]interface{}, 0, len(m))
+	for _, k := range helmKeys(m) {
+		out = append(out, m[k])
+	}
+	return out
+}
+
+func helmPluck(key string, maps ...map[string]interface{}) []interface{} {
+	var out []interface{}
+	for _, m := range maps {
+		if v, ok := m[key]; ok {
+			out = append(out, v)
+		}
+	}
+	return out
+}
+
+// helmDig walks nested maps by key; the last argument is the map and the one
+// before it the default
+func helmDig(args ...interface{}) interface{} {
+	if len(args) < 3 {
+		return nil
+	}
+	current, _ := args[len(args)-1].(map[string]interface{})
+	def := args[len(args)-2]
+	keys := args[:len(args)-2]
+	for i, key := range keys {
+		v, ok := current[helmToString(key)]
+		if !ok {
+			return def
+		}
+		if i == len(keys)-1 {
+			return v
+		}
+		if current, ok = v.(map[string]interface{}); !ok {
+			return def
+		}
+	}
+	return def
+}
+
+// helmPick keeps the given keys of a map, or every other key
+func helmPick(m map[string]interface{}, keys []string, keep bool) map[string]interface{} {
+	out := map[string]interface{}{}
+	for k, v := range m {
+		if slices.Contains(keys, k) == keep {
+			out[k] = v
+		}
+	}
+	return out
+}
+
+func helmMergeOverwrite(dst map[string]interface{}, srcs ...map[string]interface{}) map[string]interface{} {
+	for _, src := range srcs {
+		for k, v := range mergeYAMLMaps(dst, src) {
+			dst[k] = v
+		}
+	}
+	return dst
+}
+
+// helmDeepCopy copies a value and every map and list nested in it
+func helmDeepCopy(v interface{}) interface{} {
+	switch value := v.(type) {
+	case map[string]interface{}:
+		out := make(map[string]interface{}, len(value))
+		for k, item := range value {
+			out[k] = helmDeepCopy(item)
+		}
+		return out
+	case []interface{}:
+		out := make([]interface{}, len(value))
+		for i, item := range value {
+			out[i] = helmDeepCopy(item)
+		}
+		return out
+	}
+	return v
+}
+
+func helmFromJSON(s string) map[string]interface{} {
+	m := map[string]interface{}{}
+	_ = json.Unmarshal([]byte(s), &m)
+	return m
+}
+
+func helmToPrettyJSON(v interface{}) string {
+	data, err := json.MarshalIndent(v, "", "  ")
+	if err != nil {
+		return ""
+	}
+	return string(data)
+}
+
+// helmToRawJSON encodes without escaping HTML characters
+func helmToRawJSON(v interface{}) string {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(v); err != nil {
+		return ""
+	}
+	return strings.TrimSuffix(buf.String(), "\n")
+}
+
+// helmCat joins the non-nil values with spaces
+func helmCat(v ...interface{}) string {
+	parts := make([]string, 0, len(v))
+	for _, item := range v {
+		if item != nil {
+			parts = append(parts, fmt.Sprint(item))
+		}
+	}
+	return strings.Join(parts, " ")
+}
+
+// helmSemverCompare reports whether a version satisfies a Masterminds-style
+// constraint: comparisons joined by commas or spaces, alternatives by ||,
+// and ~ and ^ ranges, as charts use against .Capabilities.KubeVersion
+func helmSemverCompare(constraint, version string) (bool, error) {
+	if strings.TrimSpace(version) == "" {
+		return false, fmt.Errorf("invalid semantic version %q", version)
+	}
+	for _, alternative := range strings.Split(constraint, "||") {
+		terms, err := helmConstraintTerms(alternative)
+		if err != nil {
+			return false, err
+		}
+		if !slices.ContainsFunc(terms, func(term [2]string) bool { return !helmSemverSatisfies(term[0], term[1], version) }) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// helmConstraintOperators are tried longest first
+var helmConstraintOperators = []string{">=", "=>", "<=", "=<", "!=", ">", "<", "=", "~", "^"}
+
+// helmConstraintTerms splits a constraint into operator and version pairs.
+// An operator may be separated from its version by spaces.
+func helmConstraintTerms(constraint string) ([][2]string, error) {
+	var terms [][2]string
+	op := ""
+	for _, field := range strings.FieldsFunc(constraint, func(r rune) bool { return r == ',' || r == ' ' }) {
+		for _, candidate := range helmConstraintOperators {
+			if strings.HasPrefix(field, candidate) {
+				op, field = op+candidate, strings.TrimPrefix(field, candidate)
+				break
+			}
+		}
+		if field == "" {
+			continue
+		}
+		if field != "*" && !strings.ContainsAny(field[:1], "0123456789vxX") {
+			return nil, fmt.Errorf("invalid semver constraint %q", constraint)
+		}
+		terms = append(terms, [2]string{op, field})
+		op = ""
+	}
+	return terms, nil
+}
+
+// helmSemverSatisfies evaluates one comparison. Wildcards such as 1.x or *
+// and missing minor or patch numbers match any value there.
+func helmSemverSatisfies(op, bound, version string) bool {
+	bound = strings.TrimPrefix(bound, "v")
+	if bound == "*" || bound == "x" || bound == "X" {
+		return true
+	}
+	parts := strings.Split(strings.SplitN(bound, "-", 2)[0], ".")
+	wildcard := len(parts) < 3 || slices.ContainsFunc(parts, func(p string) bool { return p == "x" || p == "X" || p == "*" })
+	if wildcard && (op == "" || op == "=") {
+		op = "~"
+	}
+	c := compareSemver(version, bound)
+	switch op {
+	case "", "=":
+		return c == 0
+	case "!=":
+		return c != 0
+	case ">":
+		return c > 0
+	case "<":
+		return c < 0
+	case ">=", "=>":
+		return c >= 0
+	case "<=", "=<":
+		return c <= 0
+	}
+	return c >= 0 && compareSemver(version, helmSemverCeiling(op, parts)) < 0
+}
+
+// helmSemverCeiling returns the exclusive upper bound of a ~ or ^ range:
+// ~1.2.3 allows patch releases, ~1 minor releases, and ^ everything below
+// the next breaking release
+func helmSemverCeiling(op string, parts []string) string {
+	numbers := make([]int, 0, 3)
+	for _, p := range parts {
+		n, err := strconv.Atoi(p)
+		if err != nil {
+			break
+		}
+		numbers = append(numbers, n)
+	}
+	if len(numbers) == 0 {
+		return "999999"
+	}
+	bump := 0 // index of the number to increment
+	switch {
+	case op == "~" && len(numbers) > 1:
+		bump = 1
+	case op == "^":
+		for bump < len(numbers)-1 && numbers[bump] == 0 {
+			bump++
+		}
+	}
+	ceiling := make([]string, 0, 3)
+	for i := 0; i < bump; i++ {
+		ceiling = append(ceiling, strconv.Itoa(numbers[i]))
+	}
+	ceiling = append(ceiling, strconv.Itoa(numbers[bump]+1))
+	return strings.Join(ceiling, ".") + "-0"
+}
@@ -0,0 +1,392 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"text/template"
+)
+
+const testHelmHelpers = `{{- define "api.fullname" -}}
+{{- printf "%s-%s" .Release.Name .Chart.Name | trunc 63 | trimSuffix "-" }}
+{{- end }}
+{{- define "api.labels" -}}
+app.kubernetes.io/name: {{ .Chart.Name }}
+app.kubernetes.io/version: {{ .Chart.AppVersion | quote }}
+{{- end }}
+`
+
+const testHelmDeployment = `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: {{ include "api.fullname" . }}
+  labels:
+    {{- include "api.labels" . | nindent 4 }}
+spec:
+  replicas: {{ .Values.replicaCount }}
+  template:
+    spec:
+      containers:
+        - name: {{ .Chart.Name }}
+          image: "{{ .Values.image.repository }}:{{ .Values.image.tag | default .Chart.AppVersion }}"
+          {{- with .Values.resources }}
+          resources:
+            {{- toYaml . | nindent 12 }}
+          {{- end }}
+`
+
+func testHelmChart() *helmChart {
+	return &helmChart{
+		Dir:      "charts/api",
+		Metadata: map[string]interface{}{"name": "api", "version": "0.1.0", "appVersion": "1.4.2"},
+		Files: map[string]string{
+			"charts/api/Chart.yaml":                "name: api\nversion: 0.1.0\nappVersion: 1.4.2\n",
+			"charts/api/values.yaml":               "replicaCount: 2\nimage:\n  repository: registry/api\n  tag: \"\"\nresources: {}\n",
+			"charts/api/values-prod.yaml":          "resources:\n  limits:\n    memory: 256Mi\n",
+			"charts/api/templates/_helpers.tpl":    testHelmHelpers,
+			"charts/api/templates/deployment.yaml": testHelmDeployment,
+		},
+	}
+}
+
+func TestRenderHelmChart(t *testing.T) {
+	t.Run("Default values", func(t *testing.T) {
+		templates, err := renderHelmChart(testHelmChart(), nil)
+		if err != nil {
+			t.Fatalf("renderHelmChart() error = %v", err)
+		}
+		if len(templates) != 1 || templates[0].Path != "charts/api/templates/deployment.yaml" {
+			t.Fatalf("expected only the deployment to render, got %+v", templates)
+		}
+
+		objects := parseK8sManifests(templates[0].Content, templates[0].Path)
+		if len(objects) != 1 {
+			t.Fatalf("expected rendered deployment to parse, got:\n%s", templates[0].Content)
+		}
+		w, ok := objects[0].workload()
+		if !ok {
+			t.Fatal("expected rendered object to be a workload")
+		}
+		if w.Name != "api-api" || w.Replicas != 2 || w.Labels["app.kubernetes.io/version"] != "1.4.2" {
+			t.Errorf("unexpected rendered workload %+v", w)
+		}
+		if w.Containers[0].Image != "registry/api:1.4.2" {
+			t.Errorf("expected image tag to default to appVersion, got %q", w.Containers[0].Image)
+		}
+		if containerHasLimits(w.Containers[0]) {
+			t.Error("expected no limits with default values")
+		}
+	})
+
+	t.Run("Extra values override", func(t *testing.T) {
+		chart := testHelmChart()
+		templates, err := renderHelmChart(chart, []string{chart.Files["charts/api/values-prod.yaml"]})
+		if err != nil {
+			t.Fatalf("renderHelmChart() error = %v", err)
+		}
+		w, _ := parseK8sManifests(templates[0].Content, templates[0].Path)[0].workload()
+		if !containerHasLimits(w.Containers[0]) {
+			t.Errorf("expected limits from extra values, got:\n%s", templates[0].Content)
+		}
+	})
+}
+
+func TestRenderHelmChartTemplateErrors(t *testing.T) {
+	t.Run("Required value missing", func(t *testing.T) {
+		chart := testHelmChart()
+		chart.Files["charts/api/templates/secret.yaml"] = `kind: Secret
+data:
+  token: {{ required "token is required" .Values.token }}
+`
+		templates, err := renderHelmChart(chart, nil)
+		if err != nil {
+			t.Fatalf("renderHelmChart() error = %v", err)
+		}
+		if len(templates) != 2 || templates[0].Err != nil {
+			t.Fatalf("expected the deployment to render despite the failing secret, got %+v", templates)
+		}
+		if templates[1].Err == nil || !strings.Contains(templates[1].Err.Error(), "token is required") {
+			t.Errorf("expected required error, got %v", templates[1].Err)
+		}
+	})
+
+	t.Run("Unknown function fails only its template", func(t *testing.T) {
+		chart := testHelmChart()
+		chart.Files["charts/api/templates/configmap.yaml"] = "kind: ConfigMap\ndata:\n  v: {{ fancyFunc .Values.x }}\n"
+		templates, err := renderHelmChart(chart, nil)
+		if err != nil {
+			t.Fatalf("renderHelmChart() error = %v", err)
+		}
+		if len(templates) != 2 || templates[0].Err == nil || templates[1].Err != nil {
+			t.Errorf("expected only the configmap to fail, got %+v", templates)
+		}
+	})
+}
+
+func TestRenderHelmChartSprigStubs(t *testing.T) {
+	chart := testHelmChart()
+	chart.Files["charts/api/templates/secret.yaml"] = `kind: Secret
+metadata:
+  annotations:
+    checksum: {{ .Values.replicaCount | toString | sha256sum | trunc 8 }}
+    created: {{ now | date "2006" | quote }}
+    id: {{ uuidv4 | substr 0 8 }}
+data:
+  token: {{ "c2VjcmV0" | b64dec | b64enc }}
+  existing: {{ (lookup "v1" "Secret" "default" "token").data | default "none" }}
+`
+	templates, err := renderHelmChart(chart, nil)
+	if err != nil {
+		t.Fatalf("renderHelmChart() error = %v", err)
+	}
+	if templates[1].Err != nil {
+		t.Fatalf("expected the stubs to render, got %v", templates[1].Err)
+	}
+	for _, expected := range []string{"checksum: d4735e3a", `created: "2000"`, "id: 00000000", "token: c2VjcmV0", "existing: none"} {
+		if !strings.Contains(templates[1].Content, expected) {
+			t.Errorf("expected %q in:\n%s", expected, templates[1].Content)
+		}
+	}
+}
+
+// helmFuncTestData is the dot of each helmFuncMap case; cases may modify it
+func helmFuncTestData() map[string]interface{} {
+	return map[string]interface{}{
+		"m": map[string]interface{}{"a": "1", "nested": map[string]interface{}{"k": "v"}},
+		"l": []interface{}{"b", "a", "b"},
+		"v": "val",
+	}
+}
+
+func TestHelmFuncMap(t *testing.T) {
+	tests := []struct {
+		fn       string // the function the case covers
+		text     string
+		expected string
+		err      bool
+	}{
+		{fn: "include", text: `{{ include "named" "x" }}`, expected: "hi x"},
+		{fn: "tpl", text: `{{ tpl "{{ template \"named\" .v }}" . }}`, expected: "hi val"},
+		{fn: "tpl", text: `{{ tpl "{{ define \"own\" }}own {{ . }}{{ end }}{{ include \"own\" .v }}" . }}`, expected: "own val"},
+		{fn: "required", text: `{{ required "msg" "v" }}`, expected: "v"},
+		{fn: "required", text: `{{ required "msg" "" }}`, err: true},
+		{fn: "fail", text: `{{ fail "msg" }}`, err: true},
+		{fn: "lookup", text: `{{ len (lookup "v1" "Secret" "ns" "name") }}`, expected: "0"},
+		{fn: "default", text: `{{ default "d" "" }} {{ default "d" "x" }}`, expected: "d x"},
+		{fn: "empty", text: `{{ empty "" }} {{ empty 0 }} {{ empty .m }}`, expected: "true true false"},
+		{fn: "coalesce", text: `{{ coalesce "" 0 "a" }}`, expected: "a"},
+		{fn: "ternary", text: `{{ ternary "a" "b" true }}{{ ternary "a" "b" false }}`, expected: "ab"},
+		{fn: "toYaml", text: `{{ toYaml .m.nested }}`, expected: "k: v"},
+		{fn: "toJson", text: `{{ toJson (dict "a" "<b>") }}`, expected: `{"a":"\u003cb\u003e"}`},
+		{fn: "fromYaml", text: `{{ (fromYaml "a: 1").a }}`, expected: "1"},
+		{fn: "indent", text: `{{ indent 2 "a\nb" }}`, expected: "  a\n  b"},
+		{fn: "nindent", text: `{{ nindent 2 "a" }}`, expected: "\n  a"},
+		{fn: "quote", text: `{{ quote "a" 1 nil }}`, expected: `"a" "1"`},
+		{fn: "squote", text: `{{ squote "a" }}`, expected: "'a'"},
+		{fn: "toString", text: `{{ toString 1 }}{{ toString nil }}`, expected: "1"},
+		{fn: "lower", text: `{{ lower "AbC" }}`, expected: "abc"},
+		{fn: "upper", text: `{{ upper "AbC" }}`, expected: "ABC"},
+		{fn: "title", text: `{{ title "hello world" }}`, expected: "Hello World"},
+		{fn: "trim", text: `{{ trim " a " }}`, expected: "a"},
+		{fn: "trimSuffix", text: `{{ trimSuffix "-x" "a-x" }}`, expected: "a"},
+		{fn: "trimPrefix", text: `{{ trimPrefix "x-" "x-a" }}`, expected: "a"},
+		{fn: "trunc", text: `{{ trunc 3 "abcdef" }} {{ trunc -2 "abcdef" }} {{ trunc 9 "ab" }}`, expected: "abc ef ab"},
+		{fn: "replace", text: `{{ replace "a" "b" "aa" }}`, expected: "bb"},
+		{fn: "contains", text: `{{ contains "b" "abc" }}`, expected: "true"},
+		{fn: "hasPrefix", text: `{{ hasPrefix "a" "abc" }}`, expected: "true"},
+		{fn: "hasSuffix", text: `{{ hasSuffix "a" "abc" }}`, expected: "false"},
+		{fn: "repeat", text: `{{ repeat 2 "ab" }}`, expected: "abab"},
+		{fn: "join", text: `{{ join "," .l }}`, expected: "b,a,b"},
+		{fn: "split", text: `{{ (split "," "a,b")._1 }}`, expected: "b"},
+		{fn: "splitList", text: `{{ index (splitList "," "a,b") 1 }}`, expected: "b"},
+		{fn: "regexMatch", text: `{{ regexMatch "^a" "abc" }}`, expected: "true"},
+		{fn: "b64enc", text: `{{ b64enc "hi" }}`, expected: "aGk="},
+		{fn: "sha256sum", text: `{{ sha256sum "hi" }}`, expected: "8f434346648f6b96df89dda901c5176b10a6d83961dd3c1ac88b59b2dc327aa4"},
+		{fn: "randAlphaNum", text: `{{ len (randAlphaNum 5) }}`, expected: "5"},
+		{fn: "list", text: `{{ list 1 "a" }}`, expected: "[1 a]"},
+		{fn: "dict", text: `{{ (dict "a" 1).a }}`, expected: "1"},
+		{fn: "get", text: `{{ get .m "a" }}`, expected: "1"},
+		{fn: "get", text: `{{ kindIs "string" (get .m "missing") }}`, expected: "true"},
+		{fn: "set", text: `{{ $_ := set .m "a" "2" }}{{ .m.a }}`, expected: "2"},
+		{fn: "hasKey", text: `{{ hasKey .m "a" }} {{ hasKey .m "b" }}`, expected: "true false"},
+		{fn: "keys", text: `{{ keys .m }}`, expected: "[a nested]"},
+		{fn: "merge", text: `{{ $d := dict "a" "x" }}{{ $_ := merge $d .m }}{{ $d.a }} {{ $d.nested.k }}`, expected: "x v"},
+		{fn: "first", text: `{{ first .l }}`, expected: "b"},
+		{fn: "append", text: `{{ append (splitList "," "a") "b" }}`, expected: "[a b]"},
+		{fn: "kindIs", text: `{{ kindIs "map" .m }} {{ kindIs "slice" .m }}`, expected: "true false"},
+		{fn: "typeOf", text: `{{ typeOf 1 }}`, expected: "int"},
+		{fn: "int", text: `{{ int "3" }}`, expected: "3"},
+		{fn: "int64", text: `{{ int64 3.7 }}`, expected: "3"},
+		{fn: "add", text: `{{ add 1 2 3 }}`, expected: "6"},
+		{fn: "add1", text: `{{ add1 1 }}`, expected: "2"},
+		{fn: "sub", text: `{{ sub 3 1 }}`, expected: "2"},
+		{fn: "mul", text: `{{ mul 2 3 4 }}`, expected: "24"},
+		{fn: "max", text: `{{ max 1 5 3 }}`, expected: "5"},
+		{fn: "min", text: `{{ min 4 1 3 }}`, expected: "1"},
+		{fn: "div", text: `{{ div 7 2 }}`, expected: "3"},
+		{fn: "mod", text: `{{ mod 7 2 }}`, expected: "1"},
+		{fn: "atoi", text: `{{ atoi "4" }}`, expected: "4"},
+		{fn: "float64", text: `{{ float64 "1.5" }}`, expected: "1.5"},
+		{fn: "until", text: `{{ until 3 }}`, expected: "[0 1 2]"},
+		{fn: "semverCompare", text: `{{ semverCompare ">=1.20" "v1.30.0" }}`, expected: "true"},
+		{fn: "b64dec", text: `{{ b64dec "aGk=" }}`, expected: "hi"},
+		{fn: "sha1sum", text: `{{ sha1sum "hi" }}`, expected: "c22b5f9178342609428d6f51b2c5af4c0bde6a42"},
+		{fn: "adler32sum", text: `{{ adler32sum "hi" }}`, expected: "20644050"},
+		{fn: "substr", text: `{{ substr 1 3 "abcd" }}`, expected: "bc"},
+		{fn: "nospace", text: `{{ nospace " a b " }}`, expected: "ab"},
+		{fn: "trimAll", text: `{{ trimAll "-" "-a-" }}`, expected: "a"},
+		{fn: "cat", text: `{{ cat "a" "b" nil 1 }}`, expected: "a b 1"},
+		{fn: "snakecase", text: `{{ snakecase "fooBar" }}`, expected: "foo_bar"},
+		{fn: "kebabcase", text: `{{ kebabcase "fooBar" }}`, expected: "foo-bar"},
+		{fn: "regexReplaceAll", text: `{{ regexReplaceAll "a(x*)b" "-ab-axxb-" "${1}W" }}`, expected: "-W-xxW-"},
+		{fn: "regexFind", text: `{{ regexFind "[0-9]+" "ab12cd" }}`, expected: "12"},
+		{fn: "base", text: `{{ base "a/b.txt" }}`, expected: "b.txt"},
+		{fn: "dir", text: `{{ dir "a/b.txt" }}`, expected: "a"},
+		{fn: "ext", text: `{{ ext "a/b.txt" }}`, expected: ".txt"},
+		{fn: "has", text: `{{ has "a" .l }} {{ has (dict "k" "v") (list .m.nested) }}`, expected: "true true"},
+		{fn: "last", text: `{{ last .l }}`, expected: "b"},
+		{fn: "rest", text: `{{ rest .l }}`, expected: "[a b]"},
+		{fn: "uniq", text: `{{ uniq .l }} {{ len (uniq (list .m.nested .m.nested)) }}`, expected: "[b a] 1"},
+		{fn: "compact", text: `{{ compact (list "a" "" "b") }}`, expected: "[a b]"},
+		{fn: "concat", text: `{{ concat (list 1) (list 2) }}`, expected: "[1 2]"},
+		{fn: "sortAlpha", text: `{{ sortAlpha .l }}`, expected: "[a b b]"},
+		{fn: "values", text: `{{ values .m.nested }}`, expected: "[v]"},
+		{fn: "pluck", text: `{{ pluck "k" .m.nested (dict "k" "w") }}`, expected: "[v w]"},
+		{fn: "dig", text: `{{ dig "nested" "k" "d" .m }} {{ dig "nested" "x" "d" .m }}`, expected: "v d"},
+		{fn: "omit", text: `{{ keys (omit .m "a") }}`, expected: "[nested]"},
+		{fn: "pick", text: `{{ keys (pick .m "a") }}`, expected: "[a]"},
+		{fn: "deepCopy", text: `{{ $c := deepCopy .m }}{{ $_ := set $c.nested "k" "changed" }}{{ .m.nested.k }} {{ $c.nested.k }}`, expected: "v changed"},
+		{fn: "mergeOverwrite", text: `{{ $d := dict "a" "x" }}{{ $_ := mergeOverwrite $d .m }}{{ $d.a }}`, expected: "1"},
+		{fn: "fromJson", text: `{{ (fromJson "{\"a\":1}").a }}`, expected: "1"},
+		{fn: "toPrettyJson", text: `{{ toPrettyJson .m.nested }}`, expected: "{\n  \"k\": \"v\"\n}"},
+		{fn: "toRawJson", text: `{{ toRawJson (dict "a" "<b>") }}`, expected: `{"a":"<b>"}`},
+		{fn: "now", text: `{{ now.Year }}`, expected: "2000"},
+		{fn: "date", text: `{{ date "2006-01-02" now }}`, expected: "2000-01-01"},
+		{fn: "uuidv4", text: `{{ uuidv4 }}`, expected: "00000000-0000-4000-8000-000000000000"},
+		{fn: "randAlpha", text: `{{ len (randAlpha 3) }}`, expected: "3"},
+		{fn: "randNumeric", text: `{{ len (randNumeric 3) }}`, expected: "3"},
+		{fn: "randAscii", text: `{{ len (randAscii 3) }}`, expected: "3"},
+		{fn: "derivePassword", text: `{{ derivePassword 1 "long" "pw" "user" "example.com" }}`, expected: ""},
+		{fn: "genPrivateKey", text: `{{ genPrivateKey "rsa" }}`, expected: ""},
+		{fn: "genCA", text: `{{ (genCA "ca" 365).Cert }}`, expected: ""},
+		{fn: "genSelfSignedCert", text: `{{ (genSelfSignedCert "api" nil nil 365).Key }}`, expected: ""},
+		{fn: "genSignedCert", text: `{{ $ca := genCA "ca" 365 }}{{ (genSignedCert "api" nil nil 365 $ca).Cert }}`, expected: ""},
+		{fn: "env", text: `{{ env "HOME" }}`, expected: ""},
+		{fn: "expandenv", text: `{{ expandenv "a-$HOME" }}`, expected: "a-"},
+	}
+
+	covered := make(map[string]bool)
+	for i, tt := range tests {
+		covered[tt.fn] = true
+		root := template.New("chart")
+		root.Funcs(helmFuncMap(root))
+		template.Must(root.New("named").Parse(`hi {{ . }}`))
+		tmpl, err := root.New(fmt.Sprintf("case%d", i)).Parse(tt.text)
+		if err != nil {
+			t.Errorf("%s: parse error %v", tt.fn, err)
+			continue
+		}
+		var buf strings.Builder
+		err = tmpl.Execute(&buf, helmFuncTestData())
+		if tt.err {
+			if err == nil {
+				t.Errorf("%s %s: expected an error, got %q", tt.fn, tt.text, buf.String())
+			}
+			continue
+		}
+		if err != nil || buf.String() != tt.expected {
+			t.Errorf("%s %s: expected %q, got %q (%v)", tt.fn, tt.text, tt.expected, buf.String(), err)
+		}
+	}
+	for name := range helmFuncMap(nil) {
+		if !covered[name] {
+			t.Errorf("function %s has no test case", name)
+		}
+	}
+}
+
+func TestHelmSemverCompare(t *testing.T) {
+	tests := []struct {
+		constraint string
+		version    string
+		expected   bool
+	}{
+		{">=1.19-0", "v1.30.0", true},
+		{">= 1.31.0-0", "v1.30.0", false},
+		{"<1.25", "v1.30.0", false},
+		{">=1.21-0, <1.31-0", "v1.30.0", true},
+		{"<1.19 || >=1.30", "v1.30.0", true},
+		{"~1.30.0", "v1.30.4", true},
+		{"~1.29.0", "v1.30.0", false},
+		{"^1.2.0", "v1.30.0", true},
+		{"^0.2.0", "0.3.0", false},
+		{"1.30.x", "v1.30.2", true},
+		{"1.29", "v1.30.0", false},
+		{"!=1.30.0", "v1.30.0", false},
+	}
+	for _, tt := range tests {
+		got, err := helmSemverCompare(tt.constraint, tt.version)
+		if err != nil || got != tt.expected {
+			t.Errorf("semverCompare %q %q: expected %v, got %v (%v)", tt.constraint, tt.version, tt.expected, got, err)
+		}
+	}
+	if _, err := helmSemverCompare("at least 1.19", "v1.30.0"); err == nil {
+		t.Error("expected an error for an invalid constraint")
+	}
+}
+
+func TestAnalyzeHelmCharts(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent:   testHelmChart().Files,
+		BoolSignals:   make(map[string]bool),
+		StringSignals: make(map[string]string),
+		IntSignals:    make(map[string]int),
+	}
+	// A chart template that fails on its own, and a manifest with
+	// placeholders in a templates/ directory that belongs to no chart
+	signals.FileContent["charts/api/templates/job.yaml"] = "kind: Job\nspec: {{ required \"job is required\" .Values.job }}\n"
+	signals.FileContent["templates/worker.yaml"] = "apiVersion: apps/v1\nkind: Deployment\nmetadata:\n  name: worker\nspec:\n  template:\n    spec:\n      containers:\n        - name: worker\n          image: \"registry/worker:{{ VERSION }}\"\n"
+	for path, content := range signals.FileContent {
+		detectK8sManifests(content, path, signals)
+	}
+
+	analyzeHelmCharts(signals, ScanOptions{Logger: &NoopLogger{}})
+	for source := range signals.getK8sManifests() {
+		if source != "charts/api" && source != "templates/worker.yaml" {
+			t.Errorf("expected raw chart templates to be replaced by the rendered chart, got %s", source)
+		}
+	}
+	analyzeK8sManifests(signals, ScanOptions{})
+
+	if got := signals.GetInt("helm_chart_count"); got != 1 {
+		t.Errorf("expected 1 rendered chart, got %d", got)
+	}
+	limits := signals.GetEvidence("k8s_containers_without_limits")
+	if len(limits) != 2 {
+		t.Fatalf("expected the rendered and the plain deployment, got %+v", limits)
+	}
+	if l := limits[0]; l.File != "charts/api/templates/deployment.yaml" || l.Line != 2 || l.Context != "chart charts/api" {
+		t.Errorf("expected rendered container evidence mapped to its template, got %+v", l)
+	}
+	if l := limits[1]; l.File != "templates/worker.yaml" || l.Context != "" {
+		t.Errorf("expected the templated manifest outside a chart to be checked, got %+v", l)
+	}
+	// The job template fails to render on its own
+	errs := signals.GetEvidence("helm_render_errors")
+	if len(errs) != 1 || errs[0].File != "charts/api/templates/job.yaml" {
+		t.Errorf("expected a render error for the job template, got %+v", errs)
+	}
+
+	// Extra values files from the scan options are merged over values.yaml
+	withProd := &RepoSignals{
+		FileContent:   testHelmChart().Files,
+		BoolSignals:   make(map[string]bool),
+		StringSignals: make(map[string]string),
+		IntSignals:    make(map[string]int),
+	}
+	analyzeHelmCharts(withProd, ScanOptions{HelmValuesFiles: []string{"values-prod.yaml"}})
+	analyzeK8sManifests(withProd, ScanOptions{})
+	if !withProd.GetBool("k8s_resource_limits_detected") {
+		t.Error("expected limits from values-prod.yaml to be detected")
+	}
+}
@@ -0,0 +1,204 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// iacDeclaration is a resource or construct declared by an infrastructure
+// program (Pulumi or CDK) or a Pulumi YAML program
+type iacDeclaration struct {
+	Type  string // e.g. aws.rds.Instance, rds.DatabaseInstance, aws:rds:Instance
+	Name  string // resource name or construct ID
+	File  string
+	Line  int
+	Args  string                 // constructor arguments as source text (code programs)
+	Props map[string]interface{} // properties (YAML programs)
+}
+
+var (
+	// new aws.rds.Instance("db", ...) and new rds.DatabaseInstance(this, "Db", ...)
+	tsDeclarationPattern = regexp.MustCompile("new\\s+([A-Za-z_]\\w*(?:\\.\\w+)*\\.[A-Z]\\w*)\\(\\s*(?:this\\s*,\\s*)?[\"'`]([^\"'`]+)[\"'`]")
+
+	// aws.rds.Instance("db", ...) and rds.DatabaseInstance(self, "Db", ...)
+	pyDeclarationPattern = regexp.MustCompile(`\b([a-z_]\w*(?:\.\w+)*\.[A-Z]\w*)\(\s*(?:self\s*,\s*)?["']([^"']+)["']`)
+
+	// rds.NewInstance(ctx, "db", ...) and awsrds.NewDatabaseInstance(stack, jsii.String("Db"), ...)
+	goDeclarationPattern = regexp.MustCompile(`\b([a-z]\w*)\.New([A-Z]\w*)\(\s*\w+\s*,\s*(?:jsii\.String\(\s*)?"([^"]+)"`)
+)
+
+// extractIaCDeclarations finds resource declarations in program source
+func extractIaCDeclarations(content, relPath string) []iacDeclaration {
+	var pattern *regexp.Regexp
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".ts", ".js", ".mjs":
+		pattern = tsDeclarationPattern
+	case ".py":
+		pattern = pyDeclarationPattern
+	case ".go":
+		pattern = goDeclarationPattern
+	default:
+		return nil
+	}
+
+	var decls []iacDeclaration
+	for _, m := range pattern.FindAllStringSubmatchIndex(content, -1) {
+		d := iacDeclaration{
+			File: relPath,
+			Line: strings.Count(content[:m[0]], "\n") + 1,
+		}
+		if pattern == goDeclarationPattern {
+			d.Type = content[m[2]:m[3]] + "." + content[m[4]:m[5]]
+			d.Name = content[m[6]:m[7]]
+		} else {
+			d.Type = content[m[2]:m[3]]
+			d.Name = content[m[4]:m[5]]
+		}
+		d.Args = balancedArgs(content, strings.IndexByte(content[m[0]:], '(')+m[0])
+		decls = append(decls, d)
+	}
+	return decls
+}
+
+// balancedArgs returns the text between the parenthesis at open and its
+// matching close, bounded to keep pathological inputs cheap
+func balancedArgs(content string, open int) string {
+	const maxArgs = 8192
+	depth := 0
+	var quote byte
+	for i := open; i < len(content) && i-open < maxArgs; i++ {
+		c := content[i]
+		switch {
+		case quote != 0:
+			if c == '\\' {
+				i++
+			} else if c == quote {
+				quote = 0
+			}
+		case c == '"' || c == '\'' || c == '`':
+			quote = c
+		case c == '(' || c == '{' || c == '[':
+			depth++
+		case c == ')' || c == '}' || c == ']':
+			depth--
+			if depth == 0 {
+				return content[open+1 : i]
+			}
+		}
+	}
+	return content[open+1 : min(len(content), open+maxArgs)]
+}
+
+// kind returns the lower-cased module and type name, e.g. "rds.instance",
+// for every language and type token spelling
+func (d *iacDeclaration) kind() string {
+	var parts []string
+	if strings.Contains(d.Type, ":") {
+		// Pulumi type tokens: aws:rds:Instance or aws:rds/instance:Instance
+		parts = strings.Split(d.Type, ":")
+		if len(parts) == 3 {
+			parts[1], _, _ = strings.Cut(parts[1], "/")
+		}
+	} else {
+		parts = strings.Split(d.Type, ".")
+	}
+	if len(parts) < 2 {
+		return strings.ToLower(d.Type)
+	}
+	module := strings.TrimPrefix(parts[len(parts)-2], "aws")
+	return strings.ToLower(module + "." + parts[len(parts)-1])
+}
+
+// iacProp is a property of a declaration, matched under the spellings of
+// every language, compiled once from a name pattern such as `multi_?az`
+type iacProp struct {
+	name *regexp.Regexp // a key of a YAML program
+	arg  *regexp.Regexp // an argument of a code program, capturing its value
+}
+
+// newIaCProp compiles the patterns of a property. TypeScript (multiAz: true),
+// Python (multi_az=True) and Go (MultiAz: pulumi.Bool(true)) argument
+// spellings are matched.
+func newIaCProp(namePattern string) iacProp {
+	return iacProp{
+		name: regexp.MustCompile(`(?i)^` + namePattern + `$`),
+		arg:  regexp.MustCompile(`(?i)\b` + namePattern + `["']?\s*[:=]\s*(?:pulumi\.\w+\(\s*|jsii\.\w+\(\s*)?([\w.]+)`),
+	}
+}
+
+// Properties of RDS instances
+var (
+	iacPropReplicaSource      = newIaCProp(`replicate_?source_?db`)
+	iacPropMultiAZ            = newIaCProp(`multi_?az`)
+	iacPropDeletionProtection = newIaCProp(`deletion_?protection`)
+	iacPropBackupRetention    = newIaCProp(`backup_?retention_?period`)
+)
+
+// propValue returns the literal value of a property in the declaration, as text
+func (d *iacDeclaration) propValue(prop iacProp) (value string, set bool) {
+	if d.Props != nil {
+		for key, v := range d.Props {
+			if prop.name.MatchString(key) {
+				switch val := v.(type) {
+				case bool:
+					return strconv.FormatBool(val), true
+				case int:
+					return strconv.Itoa(val), true
+				case float64:
+					return strconv.FormatFloat(val, 'f', -1, 64), true
+				case string:
+					return val, true
+				}
+				return "", true
+			}
+		}
+		return "", false
+	}
+
+	match := prop.arg.FindStringSubmatch(d.Args)
+	if match == nil {
+		return "", false
+	}
+	return match[1], true
+}
+
+// boolProp returns a boolean property; variables and expressions are unknown
+func (d *iacDeclaration) boolProp(prop iacProp) (val, set, known bool) {
+	raw, set := d.propValue(prop)
+	if !set {
+		return false, false, true
+	}
+	b, err := strconv.ParseBool(strings.ToLower(raw))
+	return b, true, err == nil
+}
+
+// numberProp returns a numeric property; variables and expressions are unknown
+func (d *iacDeclaration) numberProp(prop iacProp) (val float64, set, known bool) {
+	raw, set := d.propValue(prop)
+	if !set {
+		return 0, false, true
+	}
+	f, err := strconv.ParseFloat(raw, 64)
+	return f, true, err == nil
+}
+
+// iacEvidence builds evidence for a declaration
+func iacEvidence(d *iacDeclaration, detail string) Evidence {
+	return Evidence{Subject: d.Name + " (" + d.Type + ")", File: d.File, Line: d.Line, Detail: detail}
+}
+
+// filesUnder returns the sorted paths of scanned files below dir
+func filesUnder(contents map[string]string, dir string) []string {
+	prefix := dir + string(filepath.Separator)
+	var paths []string
+	for path := range contents {
+		if dir == "." || strings.HasPrefix(path, prefix) {
+			paths = append(paths, path)
+		}
+	}
+	sort.Strings(paths)
+	return paths
+}
@@ -0,0 +1,251 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/chuanjin/production-readiness/internal/patterns"
+	"gopkg.in/yaml.v3"
+)
+
+// Image reference classes
+const (
+	imageDigest   = "digest"   // pinned by digest
+	imageSemver   = "semver"   // tagged with a specific version or build
+	imageMutable  = "mutable"  // tagged with a tag that moves, such as latest or 3
+	imageUntagged = "untagged" // no tag, so latest
+)
+
+// Image reference sources
+const (
+	imageSourceDockerfile = "dockerfile"
+	imageSourceKubernetes = "kubernetes"
+	imageSourceCompose    = "compose"
+	imageSourceHelm       = "helm values"
+	imageSourceCI         = "ci"
+)
+
+// imageRef is an image reference found in a file
+type imageRef struct {
+	Image  string
+	Class  string
+	Source string
+	File   string
+	Line   int
+	Origin string // rendering context of Kubernetes manifests
+}
+
+// Production reports whether the image is deployed rather than used to build
+// or test, and is not declared for a development or staging environment
+func (r *imageRef) Production() bool {
+	return r.Source != imageSourceCI && !nonProductionName(r.File+" "+r.Origin)
+}
+
+var (
+	// a major version only, which moves with every release: 3, v20, 3-slim
+	imageMajorTagPattern = regexp.MustCompile(`^v?\d{1,3}(?:[-_][a-z][\w.-]*)?$`)
+
+	// image 'node:20', docker.image("node:20") and agent { docker { image ... } }
+	jenkinsImagePattern = regexp.MustCompile(`\b(?:image|docker\.image)\s*\(?\s*['"]([^'"\s$]+)['"]`)
+)
+
+// classifyImageRef classifies an image reference by how it is pinned. Tags
+// that name a specific version, build or commit count as semver; tags
+// without digits, major versions and well-known floating tags are mutable.
+func classifyImageRef(image string) string {
+	_, tag, digest := splitImageRef(image)
+	lower := strings.ToLower(tag)
+	switch {
+	case digest != "":
+		return imageDigest
+	case tag == "":
+		return imageUntagged
+	case slices.Contains(patterns.FloatingImageTags, lower),
+		imageMajorTagPattern.MatchString(lower),
+		!strings.ContainsAny(lower, "0123456789"):
+		return imageMutable
+	}
+	// versions such as 1.2.3 or 3.12-slim, build numbers, dates and commit SHAs
+	return imageSemver
+}
+
+// resolvableImage reports whether an image reference is complete, rather
+// than a template or variable filled in at build or deploy time
+func resolvableImage(image string) bool {
+	return image != "" && !strings.ContainsAny(image, "${} ") && !strings.EqualFold(image, "scratch")
+}
+
+// dockerfileImageRefs returns the images Dockerfile stages are built FROM.
+// Stages built from an earlier stage are skipped.
+func dockerfileImageRefs(d *dockerfile) []imageRef {
+	var refs []imageRef
+	for i := range d.Stages {
+		stage := &d.Stages[i]
+		if !resolvableImage(stage.Image) || d.stageNamed(stage.Image, stage) != nil {
+			continue
+		}
+		refs = append(refs, imageRef{Image: stage.Image, Source: imageSourceDockerfile, File: d.File, Line: stage.Line})
+	}
+	return refs
+}
+
+// helmValuesImageRefs returns the images set in a chart values file, either
+// as an image string or as repository, tag and digest keys. An empty tag
+// defaults to the chart's appVersion.
+func helmValuesImageRefs(content, relPath, appVersion string) []imageRef {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	var refs []imageRef
+	var walk func(node *yaml.Node)
+	walk = func(node *yaml.Node) {
+		if node.Kind == yaml.SequenceNode {
+			for _, child := range node.Content {
+				walk(child)
+			}
+			return
+		}
+		if node.Kind != yaml.MappingNode {
+			return
+		}
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			if strings.EqualFold(key.Value, "image") {
+				if image := helmValuesImage(value, appVersion); resolvableImage(image) {
+					refs = append(refs, imageRef{Image: image, Source: imageSourceHelm, File: relPath, Line: key.Line})
+					continue
+				}
+			}
+			walk(value)
+		}
+	}
+	walk(root.Content[0])
+	return refs
+}
+
+// helmValuesImage builds an image reference from an image value
+func helmValuesImage(node *yaml.Node, appVersion string) string {
+	if node.Kind == yaml.ScalarNode {
+		return node.Value
+	}
+	if node.Kind != yaml.MappingNode {
+		return ""
+	}
+	value := func(key string) string {
+		if v := yamlMappingValue(node, key); v != nil && v.Kind == yaml.ScalarNode {
+			return v.Value
+		}
+		return ""
+	}
+	image := value("repository")
+	if image == "" {
+		return ""
+	}
+	if registry := value("registry"); registry != "" {
+		image = registry + "/" + image
+	}
+	tag := value("tag")
+	if tag == "" {
+		tag = appVersion
+	}
+	if tag != "" {
+		image += ":" + tag
+	}
+	if digest := value("digest"); digest != "" {
+		image += "@" + digest
+	}
+	return image
+}
+
+// ciImageRefs returns the images CI jobs and their services run in: GitHub
+// Actions container and services images and docker:// steps, GitLab CI
+// image and services, and Jenkins docker agents
+func ciImageRefs(content, relPath string) []imageRef {
+	if ciPlatform(relPath) == ciJenkins {
+		return jenkinsImageRefs(content, relPath)
+	}
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return nil
+	}
+	var refs []imageRef
+	ciYAMLImages(root.Content[0], func(image string, line int) {
+		image = strings.TrimPrefix(image, "docker://")
+		if resolvableImage(image) {
+			refs = append(refs, imageRef{Image: image, Source: imageSourceCI, File: relPath, Line: line})
+		}
+	})
+	return refs
+}
+
+// jenkinsImageRefs returns the images of the docker agents of a Jenkinsfile
+func jenkinsImageRefs(content, relPath string) []imageRef {
+	var refs []imageRef
+	for _, m := range jenkinsImagePattern.FindAllStringSubmatchIndex(content, -1) {
+		refs = append(refs, imageRef{
+			Image:  content[m[2]:m[3]],
+			Source: imageSourceCI,
+			File:   relPath,
+			Line:   strings.Count(content[:m[0]], "\n") + 1,
+		})
+	}
+	return refs
+}
+
+// ciYAMLImages walks a GitHub Actions workflow or GitLab CI file and adds
+// each image it finds with its line
+func ciYAMLImages(node *yaml.Node, add func(image string, line int)) {
+	switch node.Kind {
+	case yaml.SequenceNode:
+		for _, child := range node.Content {
+			ciYAMLImages(child, add)
+		}
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(node.Content); i += 2 {
+			key, value := node.Content[i], node.Content[i+1]
+			switch {
+			case key.Value == "uses" && strings.HasPrefix(value.Value, "docker://"):
+				add(value.Value, value.Line)
+			case (key.Value == "image" || key.Value == "container") && value.Kind == yaml.ScalarNode:
+				add(value.Value, value.Line)
+			case key.Value == "image" && value.Kind == yaml.MappingNode:
+				// GitLab image: {name: ..., entrypoint: ...}
+				if name := yamlMappingValue(value, "name"); name != nil {
+					add(name.Value, name.Line)
+				}
+			case key.Value == "services" && value.Kind == yaml.SequenceNode:
+				gitlabServiceImages(value, add)
+			default:
+				ciYAMLImages(value, add)
+			}
+		}
+	}
+}
+
+// gitlabServiceImages adds the images of GitLab services, which are images
+// or {name: ...}
+func gitlabServiceImages(services *yaml.Node, add func(image string, line int)) {
+	for _, service := range services.Content {
+		if service.Kind == yaml.ScalarNode {
+			add(service.Value, service.Line)
+		} else if name := yamlMappingValue(service, "name"); name != nil {
+			add(name.Value, name.Line)
+		}
+	}
+}
+
+// chartAppVersion returns the appVersion of the chart a values file belongs to
+func chartAppVersion(contents map[string]string, valuesPath string) (string, bool) {
+	chart, ok := contents[filepath.Join(filepath.Dir(valuesPath), "Chart.yaml")]
+	if !ok {
+		return "", false
+	}
+	var metadata map[string]interface{}
+	if err := yaml.Unmarshal([]byte(chart), &metadata); err != nil {
+		return "", true
+	}
+	return yamlScalarString(metadata["appVersion"]), true
+}
@@ -0,0 +1,127 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestClassifyImageRef(t *testing.T) {
+	tests := []struct {
+		image    string
+		expected string
+	}{
+		{"nginx", imageUntagged},
+		{"registry.example.com:5000/team/app", imageUntagged},
+		{"nginx:latest", imageMutable},
+		{"node:20", imageMutable},
+		{"python:3-slim", imageMutable},
+		{"debian:bookworm", imageMutable},
+		{"app:main", imageMutable},
+		{"nginx:1.25.3", imageSemver},
+		{"python:3.12-slim", imageSemver},
+		{"app:v2.0.0-rc.1", imageSemver},
+		{"app:sha-3f2a1bc", imageSemver},
+		{"registry.example.com:5000/app:20240101", imageSemver},
+		{"nginx@sha256:0123abcd", imageDigest},
+		{"nginx:latest@sha256:0123abcd", imageDigest},
+	}
+	for _, tt := range tests {
+		if got := classifyImageRef(tt.image); got != tt.expected {
+			t.Errorf("%s: expected %s, got %s", tt.image, tt.expected, got)
+		}
+	}
+}
+
+func TestHelmValuesImageRefs(t *testing.T) {
+	content := `image:
+  registry: ghcr.io
+  repository: acme/app
+  tag: ""
+worker:
+  image:
+    repository: acme/worker
+    tag: 2.1.0
+    digest: sha256:0123abcd
+sidecars:
+- name: proxy
+  image: envoyproxy/envoy:v1.30.1
+- name: templated
+  image: "{{ .Values.proxy }}"
+`
+	refs := helmValuesImageRefs(content, "chart/values.yaml", "1.4.0")
+	var images []string
+	for _, r := range refs {
+		images = append(images, r.Image)
+	}
+	expected := []string{"ghcr.io/acme/app:1.4.0", "acme/worker:2.1.0@sha256:0123abcd", "envoyproxy/envoy:v1.30.1"}
+	if !slices.Equal(images, expected) {
+		t.Errorf("expected %v, got %v", expected, images)
+	}
+	if refs[0].Line != 1 || refs[0].Source != imageSourceHelm {
+		t.Errorf("unexpected reference %+v", refs[0])
+	}
+}
+
+func TestCIImageRefs(t *testing.T) {
+	tests := []struct {
+		name     string
+		relPath  string
+		content  string
+		expected []string
+	}{
+		{
+			name:    "github actions",
+			relPath: ".github/workflows/ci.yml",
+			content: `jobs:
+  test:
+    container:
+      image: node:20
+    services:
+      db:
+        image: postgres:16.2
+    steps:
+      - uses: docker://alpine:3.19
+      - uses: actions/checkout@v4
+`,
+			expected: []string{"node:20", "postgres:16.2", "alpine:3.19"},
+		},
+		{
+			name:    "gitlab",
+			relPath: ".gitlab-ci.yml",
+			content: `image: golang:1.22
+services:
+  - redis:7
+  - name: postgres:16
+    alias: db
+build:
+  image:
+    name: $CI_REGISTRY_IMAGE/builder:latest
+  script: make
+`,
+			expected: []string{"golang:1.22", "redis:7", "postgres:16"},
+		},
+		{
+			name:    "jenkins",
+			relPath: "Jenkinsfile",
+			content: `pipeline {
+  agent { docker { image 'maven:3.9.6' } }
+  stages {
+    stage('Test') { steps { script { docker.image("redis:${version}").inside {} } } }
+  }
+}
+`,
+			expected: []string{"maven:3.9.6"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var images []string
+			for _, r := range ciImageRefs(tt.content, tt.relPath) {
+				images = append(images, r.Image)
+			}
+			if !slices.Equal(images, tt.expected) {
+				t.Errorf("expected %v, got %v", tt.expected, images)
+			}
+		})
+	}
+}
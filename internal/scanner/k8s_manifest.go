@@ -0,0 +1,344 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// k8sObject is a single Kubernetes resource decoded from a manifest document
+type k8sObject struct {
+	APIVersion  string
+	Kind        string
+	Name        string
+	Namespace   string
+	Labels      map[string]string
+	Annotations map[string]string
+	File        string // file the object was decoded (or rendered) from
+	Line        int    // line of the document within File
+	Origin      string // rendering context, e.g. a Helm chart or kustomize overlay
+	Doc         map[string]interface{}
+}
+
+// k8sContainer is a container (or init container) of a workload pod template
+type k8sContainer struct {
+	Name  string
+	Image string
+	Init  bool
+	Spec  map[string]interface{}
+}
+
+// k8sWorkload is a pod-producing object normalized across workload kinds
+type k8sWorkload struct {
+	k8sObject
+	Replicas       int  // declared replicas, 1 when unset
+	HasReplicas    bool // whether spec.replicas was set explicitly
+	PodLabels      map[string]string
+	PodAnnotations map[string]string
+	PodSpec        map[string]interface{}
+	Containers     []k8sContainer
+	InitContainers []k8sContainer
+}
+
+// k8sManifestSet is a group of objects that are evaluated together
+type k8sManifestSet struct {
+	Source  string // where the set came from, e.g. a file, chart or overlay
+	Objects []k8sObject
+}
+
+// parseK8sManifests decodes every document of a YAML file into Kubernetes
+// objects. List kinds are expanded into their items and documents without a
+// kind are dropped. Decoding stops at the first malformed document.
+func parseK8sManifests(content, relPath string) []k8sObject {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if ext != ExtYAML && ext != ExtYML {
+		return nil
+	}
+
+	var objects []k8sObject
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return objects
+			}
+			break
+		}
+
+		var doc map[string]interface{}
+		if err := node.Decode(&doc); err != nil || doc == nil {
+			continue
+		}
+
+		line := node.Line
+		if len(node.Content) > 0 {
+			line = node.Content[0].Line
+		}
+		objects = append(objects, k8sObjectsFromDoc(doc, relPath, line)...)
+	}
+	return objects
+}
+
+// k8sObjectsFromDoc converts a decoded document into objects, expanding lists
+func k8sObjectsFromDoc(doc map[string]interface{}, relPath string, line int) []k8sObject {
+	kind, _ := doc["kind"].(string)
+	if kind == "" {
+		return nil
+	}
+
+	// v1 List, or a typed list such as PodList from kubectl get; custom
+	// resources whose kind ends in List are objects of their own
+	apiVersion, _ := doc["apiVersion"].(string)
+	if kind == "List" || (strings.HasSuffix(kind, "List") && apiVersion == "v1") {
+		items, ok := doc["items"].([]interface{})
+		if !ok {
+			return nil
+		}
+		var objects []k8sObject
+		for _, item := range items {
+			if m, ok := item.(map[string]interface{}); ok {
+				objects = append(objects, k8sObjectsFromDoc(m, relPath, line)...)
+			}
+		}
+		return objects
+	}
+
+	obj := k8sObject{
+		Kind: kind,
+		File: relPath,
+		Line: line,
+		Doc:  doc,
+	}
+	obj.APIVersion = apiVersion
+	if metadata, ok := doc["metadata"].(map[string]interface{}); ok {
+		obj.Name, _ = metadata["name"].(string)
+		obj.Namespace, _ = metadata["namespace"].(string)
+		obj.Labels = stringMap(metadata["labels"])
+		obj.Annotations = stringMap(metadata["annotations"])
+	}
+	return []k8sObject{obj}
+}
+
+// ID identifies the object as namespace/kind/name
+func (o *k8sObject) ID() string {
+	name := o.Name
+	if name == "" {
+		name = "unnamed"
+	}
+	return k8sNamespace(o) + "/" + o.Kind + "/" + name
+}
+
+// k8sNamespace returns the namespace of an object, "default" when unset
+func k8sNamespace(o *k8sObject) string {
+	if o.Namespace == "" {
+		return "default"
+	}
+	return o.Namespace
+}
+
+// Spec returns the top-level spec of the object, if any
+func (o *k8sObject) Spec() map[string]interface{} {
+	spec, _ := o.Doc["spec"].(map[string]interface{})
+	return spec
+}
+
+// workload normalizes the object into a workload when its kind runs pods
+func (o *k8sObject) workload() (k8sWorkload, bool) {
+	spec := o.Spec()
+	if spec == nil {
+		return k8sWorkload{}, false
+	}
+
+	var template map[string]interface{}
+	switch o.Kind {
+	case "Pod":
+		template = map[string]interface{}{"metadata": o.Doc["metadata"], "spec": spec}
+	case "Deployment", "StatefulSet", "DaemonSet", "ReplicaSet", "Job":
+		template = nestedMap(spec, "template")
+	case "CronJob":
+		template = nestedMap(spec, "jobTemplate", "spec", "template")
+	default:
+		return k8sWorkload{}, false
+	}
+	if template == nil {
+		return k8sWorkload{}, false
+	}
+
+	w := k8sWorkload{
+		k8sObject:      *o,
+		Replicas:       1,
+		PodLabels:      stringMap(nestedMap(template, "metadata")["labels"]),
+		PodAnnotations: stringMap(nestedMap(template, "metadata")["annotations"]),
+		PodSpec:        nestedMap(template, "spec"),
+	}
+	if replicas, ok := spec["replicas"].(int); ok {
+		w.Replicas = replicas
+		w.HasReplicas = true
+	}
+	w.Containers = k8sContainers(w.PodSpec["containers"], false)
+	w.InitContainers = k8sContainers(w.PodSpec["initContainers"], true)
+	return w, true
+}
+
+// AllContainers returns init containers followed by regular containers
+func (w *k8sWorkload) AllContainers() []k8sContainer {
+	all := make([]k8sContainer, 0, len(w.InitContainers)+len(w.Containers))
+	all = append(all, w.InitContainers...)
+	return append(all, w.Containers...)
+}
+
+// k8sContainers converts a raw containers list into typed containers
+func k8sContainers(raw interface{}, init bool) []k8sContainer {
+	list, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	var containers []k8sContainer
+	for _, item := range list {
+		c, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		container := k8sContainer{Init: init, Spec: c}
+		container.Name, _ = c["name"].(string)
+		container.Image, _ = c["image"].(string)
+		containers = append(containers, container)
+	}
+	return containers
+}
+
+// sameDeployment reports whether two objects are applied together: rendered
+// from the same chart, built by the same overlay, or both raw manifests.
+// Objects of different charts or overlays never act on each other, even
+// when an overlay repeats the objects of a shared base.
+func (o *k8sObject) sameDeployment(other *k8sObject) bool {
+	return o.Origin == other.Origin
+}
+
+// k8sWorkloads returns every workload across the given manifest sets
+func k8sWorkloads(sets []k8sManifestSet) []k8sWorkload {
+	var workloads []k8sWorkload
+	for _, set := range sets {
+		for i := range set.Objects {
+			if w, ok := set.Objects[i].workload(); ok {
+				workloads = append(workloads, w)
+			}
+		}
+	}
+	return workloads
+}
+
+// sortedManifestSets returns the manifest sets ordered by source
+func sortedManifestSets(manifests map[string][]k8sObject) []k8sManifestSet {
+	sources := make([]string, 0, len(manifests))
+	for source := range manifests {
+		sources = append(sources, source)
+	}
+	sort.Strings(sources)
+
+	sets := make([]k8sManifestSet, 0, len(sources))
+	for _, source := range sources {
+		sets = append(sets, k8sManifestSet{Source: source, Objects: manifests[source]})
+	}
+	return sets
+}
+
+// nestedMap walks a chain of map keys and returns the map at the end
+func nestedMap(m map[string]interface{}, keys ...string) map[string]interface{} {
+	current := m
+	for _, key := range keys {
+		next, ok := current[key].(map[string]interface{})
+		if !ok {
+			return nil
+		}
+		current = next
+	}
+	return current
+}
+
+// mergeYAMLMaps deep-merges override into base, override winning: mappings
+// are merged key by key and any other value is replaced
+func mergeYAMLMaps(base, override map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, v := range override {
+		if bv, ok := out[k].(map[string]interface{}); ok {
+			if ov, ok := v.(map[string]interface{}); ok {
+				out[k] = mergeYAMLMaps(bv, ov)
+				continue
+			}
+		}
+		out[k] = v
+	}
+	return out
+}
+
+// stringMap converts a decoded YAML mapping into a map of strings
+func stringMap(raw interface{}) map[string]string {
+	m, ok := raw.(map[string]interface{})
+	if !ok {
+		return nil
+	}
+	res := make(map[string]string, len(m))
+	for k, v := range m {
+		if s, ok := v.(string); ok {
+			res[k] = s
+		}
+	}
+	return res
+}
+
+// selectorMatches reports whether a label selector (matchLabels and
+// matchExpressions) selects the given labels. An empty selector selects
+// everything, as it does for NetworkPolicies and PodDisruptionBudgets.
+func selectorMatches(selector map[string]interface{}, labels map[string]string) bool {
+	for key, value := range stringMap(selector["matchLabels"]) {
+		if labels[key] != value {
+			return false
+		}
+	}
+	expressions, _ := selector["matchExpressions"].([]interface{})
+	for _, item := range expressions {
+		expr, ok := item.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		key, _ := expr["key"].(string)
+		operator, _ := expr["operator"].(string)
+		value, exists := labels[key]
+		values, _ := expr["values"].([]interface{})
+		in := false
+		for _, v := range values {
+			if yamlScalarString(v) == value {
+				in = true
+				break
+			}
+		}
+		switch operator {
+		case "In":
+			if !exists || !in {
+				return false
+			}
+		case "NotIn":
+			if exists && in {
+				return false
+			}
+		case "Exists":
+			if !exists {
+				return false
+			}
+		case "DoesNotExist":
+			if exists {
+				return false
+			}
+		}
+	}
+	return true
+}
@@ -0,0 +1,224 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestParseK8sManifests(t *testing.T) {
+	t.Run("Multiple documents", func(t *testing.T) {
+		content := `apiVersion: v1
+kind: ConfigMap
+metadata:
+  name: config
+  namespace: prod
+---
+# comment-only documents are skipped
+---
+apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+  labels:
+    app: api
+`
+		objects := parseK8sManifests(content, "app.yaml")
+		if len(objects) != 2 {
+			t.Fatalf("expected 2 objects, got %d", len(objects))
+		}
+		if objects[0].ID() != "prod/ConfigMap/config" {
+			t.Errorf("unexpected ID %q", objects[0].ID())
+		}
+		if objects[1].ID() != "default/Deployment/api" {
+			t.Errorf("unexpected ID %q", objects[1].ID())
+		}
+		if objects[1].Line != 9 {
+			t.Errorf("expected Deployment on line 9, got %d", objects[1].Line)
+		}
+		if objects[1].Labels["app"] != "api" {
+			t.Errorf("expected labels to be decoded, got %v", objects[1].Labels)
+		}
+	})
+
+	t.Run("List kind is expanded", func(t *testing.T) {
+		content := `apiVersion: v1
+kind: List
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: a
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: b
+`
+		objects := parseK8sManifests(content, "list.yml")
+		if len(objects) != 2 || objects[0].Name != "a" || objects[1].Name != "b" {
+			t.Fatalf("expected list items a and b, got %+v", objects)
+		}
+	})
+
+	t.Run("List kinds of custom resources are not expanded", func(t *testing.T) {
+		content := `apiVersion: lists.example.com/v1
+kind: AllowList
+metadata:
+  name: ips
+items:
+- 10.0.0.0/8
+---
+apiVersion: v1
+kind: PodList
+items:
+- apiVersion: v1
+  kind: Pod
+  metadata:
+    name: a
+`
+		objects := parseK8sManifests(content, "lists.yml")
+		if len(objects) != 2 || objects[0].Kind != "AllowList" || objects[1].Name != "a" {
+			t.Fatalf("expected the AllowList and pod a, got %+v", objects)
+		}
+	})
+
+	t.Run("Malformed document keeps earlier objects", func(t *testing.T) {
+		content := "kind: Service\nmetadata:\n  name: ok\n---\ninvalid: yaml: [[[\n"
+		objects := parseK8sManifests(content, "svc.yaml")
+		if len(objects) != 1 {
+			t.Fatalf("expected 1 object before the malformed document, got %d", len(objects))
+		}
+	})
+
+	t.Run("Non-YAML file", func(t *testing.T) {
+		if objects := parseK8sManifests("kind: Pod", "pod.json"); objects != nil {
+			t.Fatalf("expected no objects for non-YAML file, got %v", objects)
+		}
+	})
+}
+
+func TestK8sWorkload(t *testing.T) {
+	tests := []struct {
+		name           string
+		content        string
+		isWorkload     bool
+		replicas       int
+		containers     int
+		initContainers int
+	}{
+		{
+			name: "Deployment",
+			content: `kind: Deployment
+spec:
+  replicas: 3
+  template:
+    metadata:
+      labels:
+        app: api
+    spec:
+      initContainers:
+      - name: migrate
+      containers:
+      - name: app
+        image: api:1.0.0
+`,
+			isWorkload:     true,
+			replicas:       3,
+			containers:     1,
+			initContainers: 1,
+		},
+		{
+			name: "CronJob",
+			content: `kind: CronJob
+spec:
+  jobTemplate:
+    spec:
+      template:
+        spec:
+          containers:
+          - name: job
+`,
+			isWorkload: true,
+			replicas:   1,
+			containers: 1,
+		},
+		{
+			name: "Pod",
+			content: `kind: Pod
+spec:
+  containers:
+  - name: a
+  - name: b
+`,
+			isWorkload: true,
+			replicas:   1,
+			containers: 2,
+		},
+		{
+			name: "Service",
+			content: `kind: Service
+spec:
+  type: ClusterIP
+`,
+			isWorkload: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			objects := parseK8sManifests(tt.content, "w.yaml")
+			if len(objects) != 1 {
+				t.Fatalf("expected 1 object, got %d", len(objects))
+			}
+			w, ok := objects[0].workload()
+			if ok != tt.isWorkload {
+				t.Fatalf("workload() ok = %v, want %v", ok, tt.isWorkload)
+			}
+			if !ok {
+				return
+			}
+			if w.Replicas != tt.replicas {
+				t.Errorf("expected %d replicas, got %d", tt.replicas, w.Replicas)
+			}
+			if len(w.Containers) != tt.containers {
+				t.Errorf("expected %d containers, got %d", tt.containers, len(w.Containers))
+			}
+			if len(w.InitContainers) != tt.initContainers {
+				t.Errorf("expected %d init containers, got %d", tt.initContainers, len(w.InitContainers))
+			}
+		})
+	}
+}
+
+func TestSelectorMatches(t *testing.T) {
+	labels := map[string]string{"app": "api", "tier": "web"}
+	tests := []struct {
+		name     string
+		selector map[string]interface{}
+		want     bool
+	}{
+		{"empty", map[string]interface{}{}, true},
+		{"matchLabels", map[string]interface{}{"matchLabels": map[string]interface{}{"app": "api"}}, true},
+		{"matchLabels mismatch", map[string]interface{}{"matchLabels": map[string]interface{}{"app": "worker"}}, false},
+		{"In", selectorExpression("tier", "In", "web", "api"), true},
+		{"In missing", selectorExpression("zone", "In", "a"), false},
+		{"NotIn", selectorExpression("tier", "NotIn", "web"), false},
+		{"Exists", selectorExpression("app", "Exists"), true},
+		{"DoesNotExist", selectorExpression("app", "DoesNotExist"), false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := selectorMatches(tt.selector, labels); got != tt.want {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func selectorExpression(key, operator string, values ...string) map[string]interface{} {
+	list := make([]interface{}, len(values))
+	for i, v := range values {
+		list[i] = v
+	}
+	return map[string]interface{}{"matchExpressions": []interface{}{
+		map[string]interface{}{"key": key, "operator": operator, "values": list},
+	}}
+}
@@ -0,0 +1,500 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// kustomizationFileNames are the file names kustomize recognizes
+var kustomizationFileNames = []string{"kustomization.yaml", "kustomization.yml", "Kustomization"}
+
+// kustomization is the subset of kustomization.yaml supported offline
+type kustomization struct {
+	Resources             []string          `yaml:"resources"`
+	Bases                 []string          `yaml:"bases"`
+	PatchesStrategicMerge []string          `yaml:"patchesStrategicMerge"`
+	PatchesJSON6902       []kustomizePatch  `yaml:"patchesJson6902"`
+	Patches               []kustomizePatch  `yaml:"patches"`
+	Images                []kustomizeImage  `yaml:"images"`
+	NamePrefix            string            `yaml:"namePrefix"`
+	NameSuffix            string            `yaml:"nameSuffix"`
+	Namespace             string            `yaml:"namespace"`
+	CommonLabels          map[string]string `yaml:"commonLabels"`
+}
+
+// kustomizePatch is an entry of patches or patchesJson6902
+type kustomizePatch struct {
+	Path   string           `yaml:"path"`
+	Patch  string           `yaml:"patch"`
+	Target *kustomizeTarget `yaml:"target"`
+}
+
+// kustomizeTarget selects the objects a patch applies to
+type kustomizeTarget struct {
+	Kind      string `yaml:"kind"`
+	Name      string `yaml:"name"`
+	Namespace string `yaml:"namespace"`
+}
+
+// kustomizeImage rewrites container images by name
+type kustomizeImage struct {
+	Name    string `yaml:"name"`
+	NewName string `yaml:"newName"`
+	NewTag  string `yaml:"newTag"`
+	Digest  string `yaml:"digest"`
+}
+
+// kustomizeBuild builds kustomizations from scanned file contents
+type kustomizeBuild struct {
+	files    map[string]string
+	consumed map[string]bool // files read while building
+}
+
+// findKustomization returns the kustomization file of a directory, if any
+func findKustomization(files map[string]string, dir string) (string, bool) {
+	for _, name := range kustomizationFileNames {
+		path := filepath.Join(dir, name)
+		if _, ok := files[path]; ok {
+			return path, true
+		}
+	}
+	return "", false
+}
+
+// parseKustomization decodes a kustomization file
+func parseKustomization(content string) (*kustomization, error) {
+	var k kustomization
+	if err := yaml.Unmarshal([]byte(content), &k); err != nil {
+		return nil, err
+	}
+	return &k, nil
+}
+
+// build produces the effective objects of the kustomization in dir
+func (b *kustomizeBuild) build(dir string, visiting map[string]bool) ([]k8sObject, error) {
+	if visiting[dir] {
+		return nil, fmt.Errorf("kustomization cycle at %s", dir)
+	}
+	visiting[dir] = true
+	defer delete(visiting, dir)
+
+	path, ok := findKustomization(b.files, dir)
+	if !ok {
+		return nil, fmt.Errorf("no kustomization in %s", dir)
+	}
+	b.consumed[path] = true
+	k, err := parseKustomization(b.files[path])
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+
+	var objects []k8sObject
+	for _, res := range append(append([]string{}, k.Resources...), k.Bases...) {
+		if strings.Contains(res, "://") || strings.HasPrefix(res, "github.com/") {
+			return nil, fmt.Errorf("remote resource %s cannot be built offline", res)
+		}
+		resPath := filepath.Join(dir, res)
+		if _, isDir := findKustomization(b.files, resPath); isDir {
+			sub, err := b.build(resPath, visiting)
+			if err != nil {
+				return nil, err
+			}
+			objects = append(objects, sub...)
+			continue
+		}
+		content, ok := b.files[resPath]
+		if !ok {
+			return nil, fmt.Errorf("resource %s not found", resPath)
+		}
+		b.consumed[resPath] = true
+		objects = append(objects, parseK8sManifests(content, resPath)...)
+	}
+
+	if err := b.applyPatches(dir, k, objects); err != nil {
+		return nil, err
+	}
+	applyKustomizeTransformers(k, objects)
+
+	// Refresh names, namespaces and labels after patches and transformers
+	for i := range objects {
+		refreshed := k8sObjectsFromDoc(objects[i].Doc, objects[i].File, objects[i].Line)
+		if len(refreshed) == 1 {
+			objects[i] = refreshed[0]
+		}
+	}
+	return objects, nil
+}
+
+// applyPatches applies strategic merge and JSON 6902 patches in place
+func (b *kustomizeBuild) applyPatches(dir string, k *kustomization, objects []k8sObject) error {
+	for _, p := range k.PatchesStrategicMerge {
+		content, err := b.readPatch(dir, kustomizePatch{Path: p})
+		if err != nil {
+			return err
+		}
+		if err := applyStrategicMergePatch(content, nil, objects); err != nil {
+			return err
+		}
+	}
+
+	for _, p := range append(append([]kustomizePatch{}, k.PatchesJSON6902...), k.Patches...) {
+		content, err := b.readPatch(dir, p)
+		if err != nil {
+			return err
+		}
+		var ops []map[string]interface{}
+		if yaml.Unmarshal([]byte(content), &ops) == nil && len(ops) > 0 {
+			if p.Target == nil {
+				return errors.New("JSON 6902 patch without target")
+			}
+			for i := range objects {
+				if p.Target.matches(&objects[i]) {
+					if err := applyJSON6902Patch(objects[i].Doc, ops); err != nil {
+						return fmt.Errorf("patch %s: %w", p.Path, err)
+					}
+				}
+			}
+			continue
+		}
+		if err := applyStrategicMergePatch(content, p.Target, objects); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// readPatch returns the content of an inline or file patch
+func (b *kustomizeBuild) readPatch(dir string, p kustomizePatch) (string, error) {
+	if p.Patch != "" {
+		return p.Patch, nil
+	}
+	path := filepath.Join(dir, p.Path)
+	content, ok := b.files[path]
+	if !ok {
+		return "", fmt.Errorf("patch %s not found", path)
+	}
+	b.consumed[path] = true
+	return content, nil
+}
+
+// matches reports whether an object is selected by the target
+func (t *kustomizeTarget) matches(obj *k8sObject) bool {
+	return (t.Kind == "" || t.Kind == obj.Kind) &&
+		(t.Name == "" || t.Name == obj.Name) &&
+		(t.Namespace == "" || t.Namespace == obj.Namespace)
+}
+
+// applyStrategicMergePatch merges every patch document into the objects it
+// targets, matched by the explicit target or by the patch's kind and name
+func applyStrategicMergePatch(content string, target *kustomizeTarget, objects []k8sObject) error {
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var patch map[string]interface{}
+		if err := dec.Decode(&patch); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return err
+		}
+		if patch == nil {
+			continue
+		}
+		t := target
+		if t == nil {
+			kind, _ := patch["kind"].(string)
+			name, _ := nestedMap(patch, "metadata")["name"].(string)
+			t = &kustomizeTarget{Kind: kind, Name: name}
+		}
+		for i := range objects {
+			if t.matches(&objects[i]) {
+				objects[i].Doc = strategicMerge(objects[i].Doc, patch)
+			}
+		}
+	}
+}
+
+// strategicMerge merges patch into base. Lists of maps keyed by "name" (such
+// as containers, env and volumes) are merged element-wise; other lists are
+// replaced. A null value deletes the key.
+func strategicMerge(base, patch map[string]interface{}) map[string]interface{} {
+	out := make(map[string]interface{}, len(base))
+	for k, v := range base {
+		out[k] = v
+	}
+	for k, pv := range patch {
+		if pv == nil {
+			delete(out, k)
+			continue
+		}
+		switch p := pv.(type) {
+		case map[string]interface{}:
+			if b, ok := out[k].(map[string]interface{}); ok {
+				out[k] = strategicMerge(b, p)
+				continue
+			}
+		case []interface{}:
+			if b, ok := out[k].([]interface{}); ok && isNamedList(p) && isNamedList(b) {
+				out[k] = mergeNamedLists(b, p)
+				continue
+			}
+		}
+		out[k] = pv
+	}
+	return out
+}
+
+// isNamedList reports whether every element is a map with a name
+func isNamedList(list []interface{}) bool {
+	for _, item := range list {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		if _, ok := m["name"].(string); !ok {
+			return false
+		}
+	}
+	return len(list) > 0
+}
+
+// mergeNamedLists merges list elements by their name key
+func mergeNamedLists(base, patch []interface{}) []interface{} {
+	out := append([]interface{}{}, base...)
+	for _, item := range patch {
+		p := item.(map[string]interface{})
+		merged := false
+		for i, existing := range out {
+			e := existing.(map[string]interface{})
+			if e["name"] == p["name"] {
+				if p["$patch"] == "delete" {
+					out = append(out[:i], out[i+1:]...)
+				} else {
+					out[i] = strategicMerge(e, p)
+				}
+				merged = true
+				break
+			}
+		}
+		if !merged {
+			out = append(out, p)
+		}
+	}
+	return out
+}
+
+// applyJSON6902Patch applies add, replace and remove operations to a document
+func applyJSON6902Patch(doc map[string]interface{}, ops []map[string]interface{}) error {
+	for _, op := range ops {
+		name, _ := op["op"].(string)
+		path, _ := op["path"].(string)
+		tokens := jsonPointerTokens(path)
+		if len(tokens) == 0 {
+			return fmt.Errorf("invalid path %q", path)
+		}
+		switch name {
+		case "add", "replace", "remove":
+			if _, err := jsonPointerApply(doc, tokens, op["value"], name); err != nil {
+				return fmt.Errorf("%s %s: %w", name, path, err)
+			}
+		case "test", "copy", "move":
+			// Not needed to evaluate readiness; leave the document unchanged
+		default:
+			return fmt.Errorf("unknown op %q", name)
+		}
+	}
+	return nil
+}
+
+// jsonPointerTokens splits and unescapes a JSON pointer
+func jsonPointerTokens(path string) []string {
+	if !strings.HasPrefix(path, "/") {
+		return nil
+	}
+	tokens := strings.Split(path[1:], "/")
+	for i, t := range tokens {
+		tokens[i] = strings.ReplaceAll(strings.ReplaceAll(t, "~1", "/"), "~0", "~")
+	}
+	return tokens
+}
+
+// jsonPointerApply applies op at the path below container and returns the
+// updated container (lists are rebuilt when elements are inserted or removed)
+func jsonPointerApply(container interface{}, tokens []string, value interface{}, op string) (interface{}, error) {
+	token := tokens[0]
+	last := len(tokens) == 1
+
+	switch c := container.(type) {
+	case map[string]interface{}:
+		if last {
+			if op == "remove" {
+				delete(c, token)
+			} else {
+				c[token] = value
+			}
+			return c, nil
+		}
+		next, ok := c[token]
+		if !ok {
+			if op != "add" {
+				return nil, fmt.Errorf("missing key %q", token)
+			}
+			next = map[string]interface{}{}
+		}
+		updated, err := jsonPointerApply(next, tokens[1:], value, op)
+		if err != nil {
+			return nil, err
+		}
+		c[token] = updated
+		return c, nil
+
+	case []interface{}:
+		idx := len(c)
+		if token != "-" {
+			var err error
+			if idx, err = strconv.Atoi(token); err != nil || idx < 0 || idx > len(c) {
+				return nil, fmt.Errorf("invalid index %q", token)
+			}
+		}
+		if !last {
+			if idx >= len(c) {
+				return nil, fmt.Errorf("index %d out of range", idx)
+			}
+			updated, err := jsonPointerApply(c[idx], tokens[1:], value, op)
+			if err != nil {
+				return nil, err
+			}
+			c[idx] = updated
+			return c, nil
+		}
+		switch {
+		case op == "add":
+			out := append(append(append([]interface{}{}, c[:idx]...), value), c[idx:]...)
+			return out, nil
+		case idx >= len(c):
+			return nil, fmt.Errorf("index %d out of range", idx)
+		case op == "replace":
+			c[idx] = value
+			return c, nil
+		default:
+			return append(append([]interface{}{}, c[:idx]...), c[idx+1:]...), nil
+		}
+	}
+	return nil, fmt.Errorf("cannot traverse %q", token)
+}
+
+// applyKustomizeTransformers applies images, names, namespace and labels
+func applyKustomizeTransformers(k *kustomization, objects []k8sObject) {
+	for i := range objects {
+		doc := objects[i].Doc
+		kind, _ := doc["kind"].(string)
+
+		metadata := nestedMap(doc, "metadata")
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+			doc["metadata"] = metadata
+		}
+		if name, ok := metadata["name"].(string); ok {
+			metadata["name"] = k.NamePrefix + name + k.NameSuffix
+		}
+		if k.Namespace != "" && kind != "Namespace" && !strings.HasPrefix(kind, "Cluster") {
+			metadata["namespace"] = k.Namespace
+		}
+		if len(k.CommonLabels) > 0 {
+			setLabels(metadata, k.CommonLabels)
+			if w, ok := objects[i].workload(); ok {
+				if template := podTemplateMetadata(&w); template != nil {
+					setLabels(template, k.CommonLabels)
+				}
+			}
+		}
+
+		if len(k.Images) > 0 {
+			if w, ok := objects[i].workload(); ok {
+				for _, c := range w.AllContainers() {
+					c.Spec["image"] = rewriteImage(c.Image, k.Images)
+				}
+			}
+		}
+	}
+}
+
+// podTemplateMetadata returns the pod template metadata map of a workload
+func podTemplateMetadata(w *k8sWorkload) map[string]interface{} {
+	spec := w.Spec()
+	var template map[string]interface{}
+	switch w.Kind {
+	case "Pod":
+		return nestedMap(w.Doc, "metadata")
+	case "CronJob":
+		template = nestedMap(spec, "jobTemplate", "spec", "template")
+	default:
+		template = nestedMap(spec, "template")
+	}
+	if template == nil {
+		return nil
+	}
+	metadata := nestedMap(template, "metadata")
+	if metadata == nil {
+		metadata = map[string]interface{}{}
+		template["metadata"] = metadata
+	}
+	return metadata
+}
+
+// setLabels adds labels to a metadata map
+func setLabels(metadata map[string]interface{}, labels map[string]string) {
+	existing, ok := metadata["labels"].(map[string]interface{})
+	if !ok {
+		existing = map[string]interface{}{}
+		metadata["labels"] = existing
+	}
+	for k, v := range labels {
+		existing[k] = v
+	}
+}
+
+// rewriteImage applies the first matching images entry to an image reference
+func rewriteImage(image string, rules []kustomizeImage) string {
+	name, tag, digest := splitImageRef(image)
+	for _, rule := range rules {
+		if rule.Name != name {
+			continue
+		}
+		if rule.NewName != "" {
+			name = rule.NewName
+		}
+		if rule.NewTag != "" {
+			tag, digest = rule.NewTag, ""
+		}
+		if rule.Digest != "" {
+			tag, digest = "", rule.Digest
+		}
+		break
+	}
+	switch {
+	case digest != "":
+		return name + "@" + digest
+	case tag != "":
+		return name + ":" + tag
+	}
+	return name
+}
+
+// splitImageRef splits an image reference into name, tag and digest
+func splitImageRef(image string) (name, tag, digest string) {
+	name = image
+	if at := strings.Index(name, "@"); at >= 0 {
+		name, digest = name[:at], name[at+1:]
+	}
+	// A colon after the last slash separates the tag (not a registry port)
+	if colon := strings.LastIndex(name, ":"); colon > strings.LastIndex(name, "/") {
+		name, tag = name[:colon], name[colon+1:]
+	}
+	return name, tag, digest
+}
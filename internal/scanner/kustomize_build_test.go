@@ -0,0 +1,183 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func testKustomizeFiles() map[string]string {
+	return map[string]string{
+		"k8s/base/kustomization.yaml": "resources:\n  - deployment.yaml\n",
+		"k8s/base/deployment.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  replicas: 1
+  template:
+    spec:
+      containers:
+        - name: app
+          image: registry/api:dev
+`,
+		"k8s/overlays/prod/kustomization.yaml": `resources:
+  - ../../base
+namePrefix: prod-
+namespace: prod
+patchesStrategicMerge:
+  - limits.yaml
+patchesJson6902:
+  - target:
+      kind: Deployment
+      name: api
+    path: replicas.yaml
+images:
+  - name: registry/api
+    digest: sha256:abc
+`,
+		"k8s/overlays/prod/limits.yaml": `apiVersion: apps/v1
+kind: Deployment
+metadata:
+  name: api
+spec:
+  template:
+    spec:
+      containers:
+        - name: app
+          resources:
+            limits:
+              memory: 256Mi
+`,
+		"k8s/overlays/prod/replicas.yaml": "- op: replace\n  path: /spec/replicas\n  value: 3\n",
+		"k8s/overlays/staging/kustomization.yaml": `resources:
+  - ../../base
+namespace: staging
+images:
+  - name: registry/api
+    newTag: "1.2.0"
+`,
+	}
+}
+
+func TestKustomizeBuild(t *testing.T) {
+	b := &kustomizeBuild{files: testKustomizeFiles(), consumed: make(map[string]bool)}
+
+	objects, err := b.build("k8s/overlays/prod", make(map[string]bool))
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+	if len(objects) != 1 {
+		t.Fatalf("expected 1 object, got %d", len(objects))
+	}
+	w, ok := objects[0].workload()
+	if !ok {
+		t.Fatal("expected a workload")
+	}
+	if w.ID() != "prod/Deployment/prod-api" {
+		t.Errorf("expected namespace and name prefix to apply, got %q", w.ID())
+	}
+	if w.Replicas != 3 {
+		t.Errorf("expected JSON 6902 patch to set 3 replicas, got %d", w.Replicas)
+	}
+	if !containerHasLimits(w.Containers[0]) {
+		t.Error("expected strategic merge patch to add limits")
+	}
+	if w.Containers[0].Image != "registry/api@sha256:abc" {
+		t.Errorf("expected digest-pinned image, got %q", w.Containers[0].Image)
+	}
+	if w.File != "k8s/base/deployment.yaml" || w.Line != 1 {
+		t.Errorf("expected object to map to its base file, got %s:%d", w.File, w.Line)
+	}
+	if !b.consumed["k8s/base/deployment.yaml"] || !b.consumed["k8s/overlays/prod/limits.yaml"] {
+		t.Errorf("expected base and patch files to be consumed, got %v", b.consumed)
+	}
+
+	// The base is parsed afresh for each overlay, so patches do not leak
+	staging, err := b.build("k8s/overlays/staging", make(map[string]bool))
+	if err != nil {
+		t.Fatalf("build() error = %v", err)
+	}
+	sw, _ := staging[0].workload()
+	if containerHasLimits(sw.Containers[0]) || sw.Containers[0].Image != "registry/api:1.2.0" {
+		t.Errorf("unexpected staging workload %+v", sw.Containers[0])
+	}
+
+	t.Run("Missing resource", func(t *testing.T) {
+		files := map[string]string{"kustomization.yaml": "resources:\n  - missing.yaml\n"}
+		b := &kustomizeBuild{files: files, consumed: make(map[string]bool)}
+		if _, err := b.build(".", make(map[string]bool)); err == nil {
+			t.Error("expected an error for a missing resource")
+		}
+	})
+}
+
+func TestStrategicMerge(t *testing.T) {
+	base := map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "a"},
+			map[string]interface{}{"name": "sidecar", "image": "b"},
+		},
+		"args": []interface{}{"--a"},
+		"drop": "me",
+	}
+	patch := map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "image": "a2"},
+			map[string]interface{}{"name": "sidecar", "$patch": "delete"},
+		},
+		"args": []interface{}{"--b"},
+		"drop": nil,
+	}
+	merged := strategicMerge(base, patch)
+
+	containers := merged["containers"].([]interface{})
+	if len(containers) != 1 || containers[0].(map[string]interface{})["image"] != "a2" {
+		t.Errorf("expected named list merge, got %v", containers)
+	}
+	if args := merged["args"].([]interface{}); len(args) != 1 || args[0] != "--b" {
+		t.Errorf("expected scalar list replacement, got %v", args)
+	}
+	if _, ok := merged["drop"]; ok {
+		t.Error("expected null to delete the key")
+	}
+}
+
+func TestRewriteImage(t *testing.T) {
+	rules := []kustomizeImage{{Name: "localhost:5000/api", NewName: "ghcr.io/org/api", NewTag: "v2"}}
+	tests := map[string]string{
+		"localhost:5000/api":        "ghcr.io/org/api:v2",
+		"localhost:5000/api:v1":     "ghcr.io/org/api:v2",
+		"localhost:5000/other:v1":   "localhost:5000/other:v1",
+		"localhost:5000/api@sha:01": "ghcr.io/org/api:v2",
+	}
+	for image, want := range tests {
+		if got := rewriteImage(image, rules); got != want {
+			t.Errorf("rewriteImage(%q) = %q, want %q", image, got, want)
+		}
+	}
+}
+
+func TestAnalyzeKustomizations(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent:   testKustomizeFiles(),
+		BoolSignals:   make(map[string]bool),
+		StringSignals: make(map[string]string),
+		IntSignals:    make(map[string]int),
+	}
+	for path, content := range signals.FileContent {
+		detectK8sManifests(content, path, signals)
+	}
+	analyzeKustomizations(signals, ScanOptions{Logger: &NoopLogger{}})
+	analyzeK8sManifests(signals, ScanOptions{})
+
+	if got := signals.GetInt("kustomize_overlay_count"); got != 2 {
+		t.Errorf("expected 2 overlays, got %d", got)
+	}
+	// Raw base and patch files are superseded by the overlays
+	if got := signals.GetInt("k8s_workload_count"); got != 2 {
+		t.Errorf("expected one workload per overlay, got %d", got)
+	}
+	limits := signals.GetEvidence("k8s_containers_without_limits")
+	if len(limits) != 1 || limits[0].Context != "overlay k8s/overlays/staging" {
+		t.Errorf("expected only the staging overlay to lack limits, got %+v", limits)
+	}
+}
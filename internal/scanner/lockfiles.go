@@ -0,0 +1,320 @@
+package scanner
+
+import (
+	"encoding/json"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// resolvedPackage is a dependency at the exact version a lockfile, or a
+// manifest that only allows exact versions, resolves it to
+type resolvedPackage struct {
+	Ecosystem string // OSV ecosystem
+	Name      string
+	Version   string
+	File      string
+	Line      int
+}
+
+// depOSVEcosystems map dependency ecosystems to OSV ecosystems
+var depOSVEcosystems = map[string]string{
+	depGo:     osvGo,
+	depNPM:    osvNPM,
+	depPython: osvPyPI,
+	depCargo:  osvCratesIO,
+	depRuby:   osvRubyGems,
+	depMaven:  osvMaven,
+	depGradle: osvMaven,
+}
+
+// isDepLockfile reports whether a path is a lockfile the inventory reads
+func isDepLockfile(relPath string) bool {
+	_, ok := lockfileParsers[filepath.Base(relPath)]
+	return ok
+}
+
+// resolvedPackages returns the packages a lockfile resolves, or the exact
+// versions of a manifest: go.mod requirements, which are the versions
+// minimal version selection picks, pinned requirements files and Maven or
+// Gradle coordinates with exact versions
+func resolvedPackages(content, relPath string) []resolvedPackage {
+	slashed := "/" + filepath.ToSlash(relPath)
+	if strings.Contains(slashed, "/vendor/") || strings.Contains(slashed, "/third_party/") {
+		return nil
+	}
+	var packages []resolvedPackage
+	add := func(ecosystem, name, version string, line int) {
+		if name != "" && version != "" {
+			packages = append(packages, resolvedPackage{Ecosystem: ecosystem, Name: name, Version: version, File: relPath, Line: line})
+		}
+	}
+	if parse, ok := lockfileParsers[filepath.Base(relPath)]; ok {
+		parse(content, add)
+	} else {
+		manifestPinnedPackages(content, relPath, add)
+	}
+	return packages
+}
+
+// lockfileParsers read each lockfile the inventory knows by file name
+var lockfileParsers = map[string]func(content string, add func(ecosystem, name, version string, line int)){
+	"package-lock.json":   packageLockPackages,
+	"npm-shrinkwrap.json": packageLockPackages,
+	"yarn.lock":           yarnLockPackages,
+	"pnpm-lock.yaml":      pnpmLockPackages,
+	"poetry.lock":         tomlLockParser(osvPyPI),
+	"uv.lock":             tomlLockParser(osvPyPI),
+	"pdm.lock":            tomlLockParser(osvPyPI),
+	"Cargo.lock":          tomlLockParser(osvCratesIO),
+	"Pipfile.lock":        pipfileLockPackages,
+	"Gemfile.lock":        gemfileLockPackages,
+	"gradle.lockfile":     gradleLockPackages,
+}
+
+// tomlLockParser returns a parser of [[package]] lockfiles of an ecosystem
+func tomlLockParser(ecosystem string) func(content string, add func(ecosystem, name, version string, line int)) {
+	return func(content string, add func(ecosystem, name, version string, line int)) {
+		tomlLockPackages(content, ecosystem, add)
+	}
+}
+
+// manifestPinnedPackages reads the exact versions of a go.mod, pinned
+// requirements file, pom.xml or Gradle build file
+func manifestPinnedPackages(content, relPath string, add func(ecosystem, name, version string, line int)) {
+	m := &depManifest{Ecosystem: depManifestEcosystem(relPath)}
+	switch {
+	case m.Ecosystem == depGo:
+		parseGoMod(content, m)
+	case m.Ecosystem == depPython && requirementsFile("/"+filepath.ToSlash(relPath)):
+		parseRequirements(content, m)
+	case m.Ecosystem == depMaven:
+		parsePom(content, m)
+	case m.Ecosystem == depGradle:
+		parseGradle(content, m)
+	}
+	for _, dep := range m.Deps {
+		version := strings.TrimLeft(dep.Version, "=")
+		if dep.Kind != depPinned || strings.HasPrefix(version, "$") {
+			continue
+		}
+		if m.Ecosystem == depGo {
+			// OSV versions of Go modules have no v prefix
+			version = strings.TrimPrefix(version, "v")
+		}
+		add(depOSVEcosystems[m.Ecosystem], dep.Name, version, dep.Line)
+	}
+}
+
+// gradleLockPackages reads the group:artifact:version=configurations lines
+// of a gradle.lockfile
+func gradleLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	for i, line := range strings.Split(content, "\n") {
+		coordinates, _, _ := strings.Cut(strings.TrimSpace(line), "=")
+		if parts := strings.Split(coordinates, ":"); len(parts) == 3 && !strings.HasPrefix(line, "#") {
+			add(osvMaven, parts[0]+":"+parts[1], parts[2], i+1)
+		}
+	}
+}
+
+// packageLockPackages reads an npm package-lock.json or shrinkwrap: the
+// packages map of lockfile versions 2 and 3, or the nested dependencies of
+// version 1. Linked workspace packages are skipped.
+func packageLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	type lockEntry struct {
+		Version      string                `json:"version"`
+		Link         bool                  `json:"link"`
+		Dependencies map[string]*lockEntry `json:"dependencies"`
+	}
+	var lock struct {
+		Packages     map[string]*lockEntry `json:"packages"`
+		Dependencies map[string]*lockEntry `json:"dependencies"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return
+	}
+	if len(lock.Packages) > 0 {
+		paths := make([]string, 0, len(lock.Packages))
+		for path := range lock.Packages {
+			paths = append(paths, path)
+		}
+		sort.Strings(paths)
+		for _, path := range paths {
+			entry := lock.Packages[path]
+			i := strings.LastIndex(path, "node_modules/")
+			if i < 0 || entry.Link {
+				continue
+			}
+			add(osvNPM, path[i+len("node_modules/"):], entry.Version, 0)
+		}
+		return
+	}
+	var walk func(deps map[string]*lockEntry)
+	walk = func(deps map[string]*lockEntry) {
+		names := make([]string, 0, len(deps))
+		for name := range deps {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if entry := deps[name]; npmExactPattern.MatchString(entry.Version) {
+				add(osvNPM, name, entry.Version, 0)
+				walk(entry.Dependencies)
+			}
+		}
+	}
+	walk(lock.Dependencies)
+}
+
+// yarnVersionPattern matches the version of a yarn.lock entry, in the
+// classic (version "1.2.3") or berry (version: 1.2.3) format
+var yarnVersionPattern = regexp.MustCompile(`^\s+version:?\s+"?([^"\s]+)"?`)
+
+// yarnLockPackages reads a yarn.lock. Entries start with the specifiers they
+// resolve, such as "lodash@^4.17.0", lodash@~4.17.20:, and workspace, link
+// and portal entries are skipped.
+func yarnLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	name, line := "", 0
+	for i, raw := range strings.Split(content, "\n") {
+		if raw == "" || strings.HasPrefix(raw, "#") {
+			continue
+		}
+		if !strings.HasPrefix(raw, " ") {
+			spec, _, _ := strings.Cut(strings.TrimSuffix(raw, ":"), ",")
+			spec = strings.Trim(strings.TrimSpace(spec), `"`)
+			name, line = "", i+1
+			if at := strings.LastIndex(spec, "@"); at > 0 && !strings.Contains(spec, "@workspace:") &&
+				!strings.Contains(spec, "@link:") && !strings.Contains(spec, "@portal:") && !strings.Contains(spec, "@file:") {
+				name = spec[:at]
+				if npm := strings.Index(name, "@npm:"); npm > 0 {
+					name = name[:npm]
+				}
+			}
+			continue
+		}
+		if m := yarnVersionPattern.FindStringSubmatch(raw); m != nil && name != "" {
+			add(osvNPM, name, m[1], line)
+			name = ""
+		}
+	}
+}
+
+var (
+	// /name@1.2.3(peer@1.0.0) and name@1.2.3 keys of lockfile versions 6 and 9
+	pnpmKeyPattern = regexp.MustCompile(`^/?((?:@[^/@]+/)?[^/@]+)@([^(@]+)(?:\(.*)?$`)
+
+	// /name/1.2.3_peer@1.0.0 keys of lockfile version 5
+	pnpmV5KeyPattern = regexp.MustCompile(`^/?((?:@[^/@]+/)?[^/@]+)/([^/_]+)(?:_.*)?$`)
+)
+
+// pnpmLockPackages reads the packages of a pnpm-lock.yaml. Keys are
+// /name/1.2.3 in lockfile version 5, /name@1.2.3 in version 6 and
+// name@1.2.3 in version 9, with peer dependencies in parentheses or after
+// an underscore.
+func pnpmLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	var root yaml.Node
+	if err := yaml.Unmarshal([]byte(content), &root); err != nil || len(root.Content) == 0 {
+		return
+	}
+	packages := yamlMappingValue(root.Content[0], "packages")
+	if packages == nil || packages.Kind != yaml.MappingNode {
+		return
+	}
+	for i := 0; i+1 < len(packages.Content); i += 2 {
+		key := packages.Content[i]
+		m := pnpmKeyPattern.FindStringSubmatch(key.Value)
+		if m == nil {
+			m = pnpmV5KeyPattern.FindStringSubmatch(key.Value)
+		}
+		if m != nil && npmExactPattern.MatchString(m[2]) {
+			add(osvNPM, m[1], m[2], key.Line)
+		}
+	}
+}
+
+// tomlLockPackages reads the [[package]] tables of Cargo.lock, poetry.lock,
+// uv.lock and pdm.lock. Cargo workspace members, which have no source, are
+// skipped.
+func tomlLockPackages(content, ecosystem string, add func(ecosystem, name, version string, line int)) {
+	var name, version string
+	line := 0
+	sourced := false
+	flush := func() {
+		if ecosystem != osvCratesIO || sourced {
+			add(ecosystem, name, version, line)
+		}
+		name, version, sourced = "", "", false
+	}
+	for i, raw := range strings.Split(content, "\n") {
+		trimmed := strings.TrimSpace(raw)
+		switch {
+		case trimmed == "[[package]]":
+			flush()
+			line = i + 1
+		case strings.HasPrefix(trimmed, "["):
+			if name != "" {
+				flush()
+			}
+		default:
+			key, value, ok := strings.Cut(trimmed, "=")
+			if !ok || line == 0 {
+				continue
+			}
+			value, _ = tomlString(strings.TrimSpace(value))
+			switch strings.TrimSpace(key) {
+			case "name":
+				name = value
+			case "version":
+				version = value
+			case "source":
+				sourced = strings.HasPrefix(value, "registry+")
+			}
+		}
+	}
+	flush()
+}
+
+// pipfileLockPackages reads the default and develop packages of a
+// Pipfile.lock
+func pipfileLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	var lock map[string]map[string]struct {
+		Version string `json:"version"`
+	}
+	if err := json.Unmarshal([]byte(content), &lock); err != nil {
+		return
+	}
+	for _, section := range []string{"default", "develop"} {
+		names := make([]string, 0, len(lock[section]))
+		for name := range lock[section] {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			add(osvPyPI, name, strings.TrimPrefix(lock[section][name].Version, "=="), 0)
+		}
+	}
+}
+
+// gemSpecPattern matches a gem of the specs of a Gemfile.lock section, such
+// as "    nokogiri (1.15.4-x86_64-linux)"
+var gemSpecPattern = regexp.MustCompile(`^ {4}([^\s(]+) \(([^)\s]+)\)$`)
+
+// gemfileLockPackages reads the gems of the GEM section of a Gemfile.lock.
+// Platform suffixes are dropped; gems from git and path sections are not
+// published to RubyGems.
+func gemfileLockPackages(content string, add func(ecosystem, name, version string, line int)) {
+	inGems := false
+	for i, raw := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(raw, " ") {
+			inGems = strings.TrimSpace(raw) == "GEM"
+			continue
+		}
+		if m := gemSpecPattern.FindStringSubmatch(strings.TrimRight(raw, "\r")); m != nil && inGems {
+			version, _, _ := strings.Cut(m[2], "-")
+			add(osvRubyGems, m[1], version, i+1)
+		}
+	}
+}
@@ -0,0 +1,157 @@
+package scanner
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+)
+
+// packageList renders resolved packages as ecosystem name@version:line
+func packageList(packages []resolvedPackage) []string {
+	var list []string
+	for _, p := range packages {
+		list = append(list, fmt.Sprintf("%s %s@%s:%d", p.Ecosystem, p.Name, p.Version, p.Line))
+	}
+	return list
+}
+
+func TestResolvedPackages(t *testing.T) {
+	tests := []struct {
+		path     string
+		content  string
+		expected []string
+	}{
+		{
+			"package-lock.json",
+			`{"lockfileVersion": 3, "packages": {
+  "": {"name": "app"},
+  "node_modules/lodash": {"version": "4.17.20"},
+  "node_modules/a/node_modules/@scope/b": {"version": "1.0.0"},
+  "node_modules/ui": {"resolved": "packages/ui", "link": true}
+}}`,
+			[]string{"npm @scope/b@1.0.0:0", "npm lodash@4.17.20:0"},
+		},
+		{
+			"package-lock.json",
+			`{"lockfileVersion": 1, "dependencies": {"a": {"version": "1.0.0", "dependencies": {"b": {"version": "2.0.0"}}}}}`,
+			[]string{"npm a@1.0.0:0", "npm b@2.0.0:0"},
+		},
+		{
+			"yarn.lock",
+			`# yarn lockfile v1
+
+"@babel/core@^7.0.0", "@babel/core@^7.1.0":
+  version "7.24.0"
+  resolved "https://registry.yarnpkg.com/@babel/core/-/core-7.24.0.tgz"
+
+lodash@^4.17.0:
+  version "4.17.21"
+`,
+			[]string{"npm @babel/core@7.24.0:3", "npm lodash@4.17.21:7"},
+		},
+		{
+			"yarn.lock",
+			`"app@workspace:.":
+  version: 0.0.0-use.local
+
+"react@npm:^18.2.0":
+  version: 18.2.0
+`,
+			[]string{"npm react@18.2.0:4"},
+		},
+		{
+			"pnpm-lock.yaml",
+			`lockfileVersion: '6.0'
+packages:
+  /express@4.18.2:
+    resolution: {integrity: sha512-x}
+  /@types/node@20.11.0(typescript@5.3.3):
+    resolution: {integrity: sha512-y}
+  /debug/2.6.9_supports-color@8.1.1:
+    resolution: {integrity: sha512-z}
+`,
+			[]string{"npm express@4.18.2:3", "npm @types/node@20.11.0:5", "npm debug@2.6.9:7"},
+		},
+		{
+			"Cargo.lock",
+			`version = 3
+
+[[package]]
+name = "app"
+version = "0.1.0"
+
+[[package]]
+name = "serde"
+version = "1.0.196"
+source = "registry+https://github.com/rust-lang/crates.io-index"
+`,
+			[]string{"crates.io serde@1.0.196:7"},
+		},
+		{
+			"poetry.lock",
+			`[[package]]
+name = "Django"
+version = "5.0.2"
+description = "web framework"
+
+[package.dependencies]
+asgiref = ">=3.7.0"
+
+[[package]]
+name = "asgiref"
+version = "3.7.2"
+`,
+			[]string{"PyPI Django@5.0.2:1", "PyPI asgiref@3.7.2:9"},
+		},
+		{
+			"Pipfile.lock",
+			`{"_meta": {}, "default": {"requests": {"version": "==2.31.0"}}, "develop": {"pytest": {"version": "==8.0.0"}}}`,
+			[]string{"PyPI requests@2.31.0:0", "PyPI pytest@8.0.0:0"},
+		},
+		{
+			"Gemfile.lock",
+			`GIT
+  remote: https://github.com/acme/internal.git
+  specs:
+    internal (0.1.0)
+
+GEM
+  remote: https://rubygems.org/
+  specs:
+    nokogiri (1.15.4-x86_64-linux)
+      racc (~> 1.4)
+    rails (7.1.3)
+
+PLATFORMS
+  x86_64-linux
+`,
+			[]string{"RubyGems nokogiri@1.15.4:9", "RubyGems rails@7.1.3:11"},
+		},
+		{
+			"gradle.lockfile",
+			"# This is a Gradle generated file\ncom.google.guava:guava:32.1.3-jre=compileClasspath\nempty=annotationProcessor\n",
+			[]string{"Maven com.google.guava:guava@32.1.3-jre:2"},
+		},
+		{
+			"go.mod",
+			"module example.com/app\n\nrequire golang.org/x/net v0.17.0\n",
+			[]string{"Go golang.org/x/net@0.17.0:3"},
+		},
+		{
+			"requirements.txt",
+			"flask==3.0.2\nrequests>=2\n",
+			[]string{"PyPI flask@3.0.2:1"},
+		},
+		{
+			"vendor/example.com/x/go.mod",
+			"module example.com/x\n\nrequire golang.org/x/net v0.1.0\n",
+			nil,
+		},
+	}
+	for _, tt := range tests {
+		got := packageList(resolvedPackages(tt.content, tt.path))
+		if !slices.Equal(got, tt.expected) {
+			t.Errorf("%s: expected %v, got %v", tt.path, tt.expected, got)
+		}
+	}
+}
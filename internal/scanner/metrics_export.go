@@ -0,0 +1,211 @@
+package scanner
+
+import (
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+// metricSourceExts are the source files searched for metric definitions
+var metricSourceExts = map[string]bool{
+	".go": true, ".py": true, ".js": true, ".mjs": true, ".cjs": true, ".ts": true,
+	".java": true, ".kt": true, ".scala": true, ".rb": true,
+}
+
+var (
+	// Go client: prometheus.CounterOpts{Namespace: "app", Name: "requests_total"}
+	goMetricOptsPattern   = regexp.MustCompile(`\b(Counter|Gauge|Histogram|Summary)Opts\s*\{`)
+	goMetricFieldPattern  = regexp.MustCompile(`\b(Namespace|Subsystem|Name)\s*:\s*"([^"]*)"`)
+	goMetricPrefixPattern = regexp.MustCompile(`\b(?:Namespace|Subsystem)\s*:\s*[^"\s]`)
+
+	// Python client: Counter("requests_total", ...) or Counter(name="...")
+	pythonMetricPattern = regexp.MustCompile(`\b(Counter|Gauge|Histogram|Summary|Info|Enum)\(\s*(?:name\s*=\s*)?["']([a-zA-Z_:][\w:]*)["']`)
+
+	// prom-client: new client.Counter({ name: "requests_total", ... })
+	nodeMetricPattern = regexp.MustCompile(`new\s+(?:\w+\.)?(Counter|Gauge|Histogram|Summary)\(\s*\{[^}]*?\bname\s*:\s*["']([a-zA-Z_:][\w:]*)["']`)
+
+	// Java simpleclient: Counter.build().name("requests_total") or
+	// Counter.build("requests_total", "help")
+	javaMetricPattern = regexp.MustCompile(`\b(Counter|Gauge|Histogram|Summary)\.build\(\s*(?:"([a-zA-Z_:][\w:]*)"|\)[\s.]*name\(\s*"([a-zA-Z_:][\w:]*)")`)
+
+	// Micrometer: Counter.builder("http.requests") or registry.counter("jobs")
+	micrometerMetricPattern = regexp.MustCompile(`\b(?:(Counter|Gauge|Timer|DistributionSummary|LongTaskTimer)\.builder|\.(counter|gauge|timer|summary))\(\s*"([a-zA-Z_][\w.]*)"`)
+
+	// Ruby client: Prometheus::Client::Counter.new(:requests_total, ...)
+	rubyMetricPattern = regexp.MustCompile(`\b(Counter|Gauge|Histogram|Summary)\.new\(\s*:(\w+)`)
+
+	// a status label that lets a request counter count errors
+	metricStatusLabelPattern = regexp.MustCompile(`["':](?:code|status|status_code|http_status|outcome|result)["',\s]`)
+)
+
+// metricDefinition is a metric the repository's code defines
+type metricDefinition struct {
+	Name       string // in the Prometheus form, without namespace when SuffixOnly
+	Type       string // counter, gauge, histogram, summary, timer, info or enum
+	SuffixOnly bool   // the Go namespace or subsystem is not a literal
+	HasStatus  bool   // a status code or outcome label is declared alongside
+	File       string
+	Line       int
+}
+
+// metricDefinitions returns the metrics the repository's code defines with a
+// Prometheus or Micrometer client, ordered by file and line. Micrometer names
+// are converted to the Prometheus form (dots become underscores).
+func metricDefinitions(contents map[string]string) []metricDefinition {
+	var defs []metricDefinition
+	for path, content := range contents {
+		finders := metricDefinitionFinders[strings.ToLower(filepath.Ext(path))]
+		add := func(name, kind string, start int, suffixOnly bool) {
+			// labels are declared within the same statement or block
+			end := len(content)
+			if i := strings.Index(content[start:], "\n\n"); i >= 0 {
+				end = start + i
+			}
+			defs = append(defs, metricDefinition{
+				Name:       name,
+				Type:       strings.ToLower(kind),
+				SuffixOnly: suffixOnly,
+				HasStatus:  metricStatusLabelPattern.MatchString(content[start:end]),
+				File:       path,
+				Line:       strings.Count(content[:start], "\n") + 1,
+			})
+		}
+		for _, find := range finders {
+			find(content, add)
+		}
+	}
+	sort.Slice(defs, func(i, j int) bool {
+		if defs[i].File != defs[j].File {
+			return defs[i].File < defs[j].File
+		}
+		return defs[i].Line < defs[j].Line
+	})
+	return defs
+}
+
+// metricFinder reports each metric a client library defines in a file with
+// its name, type, offset and whether only its suffix is known
+type metricFinder func(content string, add func(name, kind string, start int, suffixOnly bool))
+
+// metricDefinitionFinders are the client libraries searched in each source
+// file extension
+var metricDefinitionFinders = map[string][]metricFinder{
+	".go":    {goMetricDefinitions},
+	".py":    {typedMetricDefinitions(pythonMetricPattern)},
+	".js":    {typedMetricDefinitions(nodeMetricPattern)},
+	".mjs":   {typedMetricDefinitions(nodeMetricPattern)},
+	".cjs":   {typedMetricDefinitions(nodeMetricPattern)},
+	".ts":    {typedMetricDefinitions(nodeMetricPattern)},
+	".rb":    {typedMetricDefinitions(rubyMetricPattern)},
+	".java":  {javaMetricDefinitions, micrometerMetricDefinitions},
+	".kt":    {javaMetricDefinitions, micrometerMetricDefinitions},
+	".scala": {javaMetricDefinitions, micrometerMetricDefinitions},
+}
+
+// typedMetricDefinitions finds definitions with a pattern whose first group
+// is the metric type and second the name
+func typedMetricDefinitions(pattern *regexp.Regexp) metricFinder {
+	return func(content string, add func(name, kind string, start int, suffixOnly bool)) {
+		for _, m := range pattern.FindAllStringSubmatchIndex(content, -1) {
+			add(content[m[4]:m[5]], content[m[2]:m[3]], m[0], false)
+		}
+	}
+}
+
+// goMetricDefinitions finds the metric options of the Go client. The name
+// joins the literal namespace, subsystem and name.
+func goMetricDefinitions(content string, add func(name, kind string, start int, suffixOnly bool)) {
+	for _, m := range goMetricOptsPattern.FindAllStringSubmatchIndex(content, -1) {
+		fields := make(map[string]string)
+		opts := balancedArgs(content, m[1]-1)
+		for _, f := range goMetricFieldPattern.FindAllStringSubmatch(opts, -1) {
+			if _, ok := fields[f[1]]; !ok {
+				fields[f[1]] = f[2]
+			}
+		}
+		if fields["Name"] == "" {
+			continue
+		}
+		var parts []string
+		for _, key := range []string{"Namespace", "Subsystem", "Name"} {
+			if fields[key] != "" {
+				parts = append(parts, fields[key])
+			}
+		}
+		add(strings.Join(parts, "_"), content[m[2]:m[3]], m[0], goMetricPrefixPattern.MatchString(opts))
+	}
+}
+
+// javaMetricDefinitions finds the builders of the Java simpleclient
+func javaMetricDefinitions(content string, add func(name, kind string, start int, suffixOnly bool)) {
+	for _, m := range javaMetricPattern.FindAllStringSubmatchIndex(content, -1) {
+		name := submatch(content, m, 2) + submatch(content, m, 3)
+		add(name, content[m[2]:m[3]], m[0], false)
+	}
+}
+
+// micrometerMetricDefinitions finds Micrometer meters, mapping their types
+// to the closest Prometheus type
+func micrometerMetricDefinitions(content string, add func(name, kind string, start int, suffixOnly bool)) {
+	for _, m := range micrometerMetricPattern.FindAllStringSubmatchIndex(content, -1) {
+		kind := submatch(content, m, 1) + submatch(content, m, 2)
+		switch kind {
+		case "DistributionSummary":
+			kind = "summary"
+		case "LongTaskTimer":
+			kind = "timer"
+		}
+		add(strings.ReplaceAll(content[m[6]:m[7]], ".", "_"), kind, m[0], false)
+	}
+}
+
+// submatch returns the nth group of a FindAllStringSubmatchIndex match, or ""
+// when the group did not participate
+func submatch(content string, m []int, n int) string {
+	if m[2*n] < 0 {
+		return ""
+	}
+	return content[m[2*n]:m[2*n+1]]
+}
+
+// exportedMetricNames returns the names of the metrics the repository's
+// code defines. A name is mapped to true when its Go namespace or subsystem
+// is not a literal, so only its suffix is known.
+func exportedMetricNames(contents map[string]string) map[string]bool {
+	names := make(map[string]bool)
+	for _, def := range metricDefinitions(contents) {
+		names[def.Name] = names[def.Name] || def.SuffixOnly
+	}
+	return names
+}
+
+// metricSeriesSuffixes are appended by clients to the name a metric is
+// defined with
+var metricSeriesSuffixes = []string{"_bucket", "_count", "_sum", "_total", "_created", "_info", "_max", "_seconds"}
+
+// metricExported reports whether a series selected in PromQL belongs to an
+// exported metric. Names whose namespace is unknown match as a suffix.
+func metricExported(series string, exported map[string]bool) bool {
+	candidates := []string{series}
+	for _, suffix := range metricSeriesSuffixes {
+		if base, ok := strings.CutSuffix(series, suffix); ok {
+			candidates = append(candidates, base)
+			// histograms in seconds: request_duration_seconds_bucket
+			if base, ok := strings.CutSuffix(base, "_seconds"); ok {
+				candidates = append(candidates, base)
+			}
+		}
+	}
+	for _, candidate := range candidates {
+		if _, ok := exported[candidate]; ok {
+			return true
+		}
+		for name, suffixOnly := range exported {
+			if suffixOnly && strings.HasSuffix(candidate, "_"+name) {
+				return true
+			}
+		}
+	}
+	return false
+}
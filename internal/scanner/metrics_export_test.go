@@ -0,0 +1,50 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestExportedMetricNames(t *testing.T) {
+	contents := map[string]string{
+		"metrics.go": `var requests = promauto.NewCounterVec(prometheus.CounterOpts{
+	Namespace: "shop",
+	Subsystem: "checkout",
+	Name:      "requests_total",
+}, []string{"code"})
+
+var latency = prometheus.NewHistogram(prometheus.HistogramOpts{
+	Namespace: namespace,
+	Name:      "request_duration_seconds",
+})`,
+		"app.py":          `JOBS = Counter("jobs_processed", "Jobs processed")`,
+		"server.js":       `const lag = new client.Gauge({ name: 'queue_lag', help: 'lag' });`,
+		"Metrics.java":    `Counter.builder("orders.created").register(registry);`,
+		"README.md":       `Counter("not_code", "docs")`,
+		"lib/metrics.rb":  `Prometheus::Client::Counter.new(:logins_total, docstring: "logins")`,
+		"Exporter.kt":     `val c = Counter.build().name("cache_misses").help("misses").register()`,
+		"src/compose.yml": `image: app`,
+	}
+	exported := exportedMetricNames(contents)
+
+	tests := []struct {
+		series string
+		want   bool
+	}{
+		{"shop_checkout_requests_total", true},
+		{"myapp_request_duration_seconds_bucket", true},
+		{"jobs_processed_total", true},
+		{"queue_lag", true},
+		{"orders_created_total", true},
+		{"logins_total", true},
+		{"cache_misses_total", true},
+		{"not_code", false},
+		{"checkout_requests_total", false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.series, func(t *testing.T) {
+			if got := metricExported(tt.series, exported); got != tt.want {
+				t.Errorf("expected %v, got %v (exported %v)", tt.want, got, exported)
+			}
+		})
+	}
+}
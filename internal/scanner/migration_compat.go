@@ -0,0 +1,229 @@
+package scanner
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+)
+
+// migrationCompatibility is the result of replaying a migration history:
+// the operations that break the application version deployed before them,
+// the destructive operations that complete an expand/contract sequence, and
+// evidence for every verified multi-step sequence
+type migrationCompatibility struct {
+	Breaking   map[*migrationOp]string
+	Contracted map[*migrationOp]bool
+	Sequences  []Evidence
+}
+
+// columnState tracks a column added by the history. Indexes are positions
+// in the history, -1 when the step has not happened.
+type columnState struct {
+	added      int
+	backfilled int
+	hasDefault bool
+	sources    []string // values the backfills assigned
+}
+
+// verifyMigrationHistory replays a history in order, assuming each migration
+// ships with a new application version while the previous one is still
+// running. A column added nullable, backfilled and constrained in later
+// migrations, or replaced by a column added and backfilled in earlier
+// migrations before it is dropped, is safe; the same change made in a single
+// migration breaks the previous version.
+func verifyMigrationHistory(h *migrationHistory) migrationCompatibility {
+	r := &historyReplay{
+		history: h,
+		result: migrationCompatibility{
+			Breaking:   make(map[*migrationOp]string),
+			Contracted: make(map[*migrationOp]bool),
+		},
+		columns:    make(map[string]*columnState),
+		expansions: make(map[string][]string),
+	}
+	for i := range h.Migrations {
+		m := &h.Migrations[i]
+		created := createdTables(m)
+		for k := range m.Ops {
+			op := &m.Ops[k]
+			if created[op.Table] && op.Kind != opCreateTable {
+				continue
+			}
+			r.replay(i, op)
+		}
+	}
+	return r.result
+}
+
+// historyReplay is the state of verifyMigrationHistory
+type historyReplay struct {
+	history    *migrationHistory
+	result     migrationCompatibility
+	columns    map[string]*columnState
+	expansions map[string][]string // table -> columns added by the history
+}
+
+// version returns the version of the i-th migration
+func (r *historyReplay) version(i int) string {
+	return r.history.Migrations[i].Version
+}
+
+// sequence records a verified multi-step sequence ending in op
+func (r *historyReplay) sequence(m *migration, op *migrationOp, detail string) {
+	r.result.Sequences = append(r.result.Sequences, Evidence{
+		Subject: migrationTarget(op),
+		File:    m.File,
+		Line:    op.Line,
+		Detail:  detail,
+		Context: m.Tool,
+	})
+}
+
+// replay applies an operation of the i-th migration
+func (r *historyReplay) replay(i int, op *migrationOp) {
+	target := migrationTarget(op)
+	state := r.columns[target]
+	switch op.Kind {
+	case opAddColumn:
+		r.columns[target] = &columnState{added: i, backfilled: -1, hasDefault: op.Default}
+		r.expansions[op.Table] = append(r.expansions[op.Table], op.Column)
+		if op.NotNull && !op.Default {
+			r.result.Breaking[op] = fmt.Sprintf("adds %s as NOT NULL without a default, so inserts from the previous version fail", target)
+		}
+	case opBackfill:
+		if state != nil {
+			state.backfilled = i
+			state.sources = append(state.sources, op.Source)
+		}
+	case opSetNotNull:
+		// columns the history did not add have an unknown past
+		if state != nil {
+			r.setNotNull(i, op, state)
+		}
+	case opDropColumn:
+		r.dropColumn(i, op, state)
+	default:
+		if reason := inPlaceBreak(op, target); reason != "" {
+			r.result.Breaking[op] = reason
+		}
+	}
+}
+
+// setNotNull checks that a column was backfilled, or added with a default,
+// in a migration before the one that constrains it
+func (r *historyReplay) setNotNull(i int, op *migrationOp, state *columnState) {
+	m := &r.history.Migrations[i]
+	target := migrationTarget(op)
+	switch {
+	case state.added == i:
+		r.result.Breaking[op] = fmt.Sprintf("adds and constrains %s in one migration, so inserts from the previous version fail", target)
+	case state.hasDefault:
+		r.sequence(m, op, fmt.Sprintf("added with a default in %s, constrained in %s", r.version(state.added), m.Version))
+	case state.backfilled < 0:
+		r.result.Breaking[op] = fmt.Sprintf("constrains %s without backfilling the rows written before it existed", target)
+	default:
+		r.sequence(m, op, fmt.Sprintf("added in %s, backfilled in %s, constrained in %s", r.version(state.added), r.version(state.backfilled), m.Version))
+	}
+}
+
+// dropColumn checks that a dropped column was replaced in earlier
+// migrations. Columns added by the same migration are dropped safely.
+func (r *historyReplay) dropColumn(i int, op *migrationOp, state *columnState) {
+	m := &r.history.Migrations[i]
+	if replacement := contractedBy(r.columns, r.expansions[op.Table], op, i); replacement != nil {
+		r.result.Contracted[op] = true
+		r.sequence(m, op, fmt.Sprintf("replaced by %s.%s (added in %s, backfilled in %s), dropped in %s",
+			op.Table, replacement.name, r.version(replacement.added), r.version(replacement.backfilled), m.Version))
+		return
+	}
+	if state != nil && state.added == i {
+		return
+	}
+	r.result.Breaking[op] = fmt.Sprintf("drops %s in one step while the previous version may still read or write it", migrationTarget(op))
+}
+
+// inPlaceBreak returns why an operation breaks the previous version no
+// matter what the history did before it, or "" if it does not
+func inPlaceBreak(op *migrationOp, target string) string {
+	switch op.Kind {
+	case opRenameColumn:
+		return fmt.Sprintf("renames %s to %s in place, so the previous version uses a column that no longer exists", target, op.NewName)
+	case opRenameTable:
+		return fmt.Sprintf("renames %s to %s in place, so the previous version uses a table that no longer exists", target, op.NewName)
+	case opDropTable:
+		return fmt.Sprintf("drops %s while the previous version may still query it", target)
+	case opAlterType:
+		return fmt.Sprintf("changes the type of %s in place", target)
+	}
+	return ""
+}
+
+// replacementColumn is a column that took over from a dropped one
+type replacementColumn struct {
+	name       string
+	added      int
+	backfilled int
+}
+
+// contractedBy returns the column that replaced a dropped column: one added
+// to the same table and backfilled from it in migrations before the drop
+func contractedBy(columns map[string]*columnState, added []string, op *migrationOp, index int) *replacementColumn {
+	for _, name := range added {
+		if name == op.Column {
+			continue
+		}
+		state := columns[op.Table+"."+name]
+		if state == nil || state.added >= index || state.backfilled < 0 || state.backfilled >= index {
+			continue
+		}
+		if !readsColumn(state.sources, op.Column) {
+			continue
+		}
+		return &replacementColumn{name: name, added: state.added, backfilled: state.backfilled}
+	}
+	return nil
+}
+
+// readsColumn reports whether a backfilled value refers to a column
+func readsColumn(sources []string, column string) bool {
+	for _, source := range sources {
+		for _, word := range strings.FieldsFunc(source, isNotIdentifier) {
+			if word == column {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isNotIdentifier splits SQL expressions into identifiers
+func isNotIdentifier(r rune) bool {
+	return !unicode.IsLetter(r) && !unicode.IsDigit(r) && r != '_'
+}
+
+// breakingMigrationEvidence reports a migration that breaks the previous
+// application version, at its first breaking operation
+func breakingMigrationEvidence(m *migration, compat *migrationCompatibility) (Evidence, bool) {
+	var reasons []string
+	line := 0
+	for k := range m.Ops {
+		reason, ok := compat.Breaking[&m.Ops[k]]
+		if !ok {
+			continue
+		}
+		if line == 0 {
+			line = m.Ops[k].Line
+		}
+		reasons = append(reasons, reason)
+	}
+	if len(reasons) == 0 {
+		return Evidence{}, false
+	}
+	return Evidence{
+		Subject: m.Version,
+		File:    m.File,
+		Line:    line,
+		Detail:  strings.Join(reasons, "; "),
+		Context: m.Tool,
+	}, true
+}
@@ -0,0 +1,119 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestVerifyMigrationHistory(t *testing.T) {
+	tests := []struct {
+		name      string
+		files     map[string]string
+		breaking  []string
+		sequences []string
+	}{
+		{
+			name: "Expand and contract across migrations",
+			files: map[string]string{
+				"db/migration/V1__add_email.sql":      "ALTER TABLE users ADD COLUMN email TEXT;\n",
+				"db/migration/V2__backfill_email.sql": "UPDATE users SET email = login || '@example.com' WHERE email IS NULL;\n",
+				"db/migration/V3__require_email.sql":  "ALTER TABLE users ALTER COLUMN email SET NOT NULL;\n",
+				"db/migration/V4__add_full_name.sql":  "ALTER TABLE users ADD COLUMN full_name TEXT;\n",
+				"db/migration/V5__copy_name.sql":      "UPDATE users SET full_name = name;\n",
+				"db/migration/V6__drop_name.sql":      "ALTER TABLE users DROP COLUMN name;\n",
+				"db/migration/V7__add_plan.sql":       "ALTER TABLE users ADD COLUMN plan TEXT DEFAULT 'free';\n",
+				"db/migration/V8__require_plan.sql":   "ALTER TABLE users ALTER COLUMN plan SET NOT NULL;\n",
+			},
+			sequences: []string{"users.email", "users.name", "users.plan"},
+		},
+		{
+			name: "Single-step changes",
+			files: map[string]string{
+				"db/migration/V1__plan.sql": `ALTER TABLE accounts ADD COLUMN plan TEXT;
+UPDATE accounts SET plan = 'free';
+ALTER TABLE accounts ALTER COLUMN plan SET NOT NULL;
+`,
+				"db/migration/V2__add_tier.sql":     "ALTER TABLE accounts ADD COLUMN tier TEXT;\n",
+				"db/migration/V3__require_tier.sql": "ALTER TABLE accounts ALTER COLUMN tier SET NOT NULL;\n",
+				// the drop ships with its replacement, and plan was not copied from name
+				"db/migration/V4__display_name.sql": `ALTER TABLE accounts ADD COLUMN display_name TEXT;
+UPDATE accounts SET display_name = name;
+ALTER TABLE accounts DROP COLUMN name;
+`,
+				"db/migration/V5__rename.sql": "ALTER TABLE accounts RENAME COLUMN tier TO level;\n",
+			},
+			breaking: []string{"1", "3", "4", "5"},
+		},
+		{
+			name: "Rails change_column_null with a replacement value",
+			files: map[string]string{
+				"db/migrate/20240101000000_add_region.rb": `class AddRegion < ActiveRecord::Migration[7.1]
+  def change
+    add_column :stores, :region, :string
+  end
+end
+`,
+				"db/migrate/20240102000000_require_region.rb": `class RequireRegion < ActiveRecord::Migration[7.1]
+  def change
+    change_column_null :stores, :region, false, "eu"
+  end
+end
+`,
+			},
+			sequences: []string{"stores.region"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			histories := loadMigrationHistories(tt.files)
+			if len(histories) != 1 {
+				t.Fatalf("expected 1 history, got %d", len(histories))
+			}
+			h := &histories[0]
+			compat := verifyMigrationHistory(h)
+
+			var breaking []string
+			for i := range h.Migrations {
+				if ev, ok := breakingMigrationEvidence(&h.Migrations[i], &compat); ok {
+					breaking = append(breaking, ev.Subject)
+				}
+			}
+			if !slices.Equal(breaking, tt.breaking) {
+				t.Errorf("expected breaking migrations %v, got %v", tt.breaking, breaking)
+			}
+
+			var sequences []string
+			for _, ev := range compat.Sequences {
+				sequences = append(sequences, ev.Subject)
+			}
+			if !slices.Equal(sequences, tt.sequences) {
+				t.Errorf("expected sequences %v, got %+v", tt.sequences, compat.Sequences)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMigrationsExpandContract(t *testing.T) {
+	signals := &RepoSignals{
+		FileContent: map[string]string{
+			"migrations/000001_add_full_name.up.sql": "ALTER TABLE users ADD COLUMN full_name TEXT;\n",
+			"migrations/000002_copy_name.up.sql":     "UPDATE users SET full_name = first_name || ' ' || last_name;\n",
+			"migrations/000003_drop_names.up.sql":    "ALTER TABLE users DROP COLUMN first_name, DROP COLUMN last_name;\n",
+		},
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+	analyzeMigrations(signals, ScanOptions{})
+
+	if !signals.GetBool("backward_compatible_migration_hint") {
+		t.Error("expected backward_compatible_migration_hint")
+	}
+	if signals.GetBool("unsafe_migration_detected") {
+		t.Errorf("expected the contract step not to be high risk, got %+v", signals.GetEvidence("migration_high_risk_operations"))
+	}
+	if got := len(signals.GetEvidence("migration_expand_contract_sequences")); got != 2 {
+		t.Errorf("expected 2 expand/contract sequences, got %d", got)
+	}
+}
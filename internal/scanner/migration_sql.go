@@ -0,0 +1,470 @@
+package scanner
+
+import (
+	"regexp"
+	"strings"
+)
+
+// Schema operations found in migrations
+const (
+	opCreateTable  = "create_table"
+	opDropTable    = "drop_table"
+	opRenameTable  = "rename_table"
+	opTruncate     = "truncate"
+	opAddColumn    = "add_column"
+	opDropColumn   = "drop_column"
+	opRenameColumn = "rename_column"
+	opAlterType    = "alter_type"
+	opSetNotNull   = "set_not_null"
+	opDropNotNull  = "drop_not_null"
+	opCreateIndex  = "create_index"
+	opBackfill     = "backfill"
+)
+
+// migrationOp is one schema or data change of a migration
+type migrationOp struct {
+	Kind       string
+	Table      string
+	Column     string
+	NewName    string // new table or column name of a rename
+	NotNull    bool   // the added column is NOT NULL
+	Default    bool   // the added column has a default
+	Concurrent bool   // the index is built without blocking writes
+	Source     string // the value a backfill assigns
+	Statement  string // the statement, shortened for evidence
+	Line       int
+}
+
+// sqlStatement is a statement of a SQL script and the line it starts on
+type sqlStatement struct {
+	Text string
+	Line int
+}
+
+// splitSQL splits a script into statements on semicolons outside comments,
+// quoted strings and Postgres dollar-quoted bodies. Comments are removed.
+func splitSQL(script string, firstLine int) []sqlStatement {
+	s := &sqlSplitter{script: script, line: firstLine}
+	for i := 0; i < len(script); i++ {
+		c := script[i]
+		switch {
+		case c == '\n':
+			s.line++
+			s.b.WriteByte(c)
+		case strings.HasPrefix(script[i:], "--"):
+			i = s.lineComment(i)
+		case strings.HasPrefix(script[i:], "/*"):
+			i = s.blockComment(i)
+		case c == '\'' || c == '"' || c == '`':
+			i = s.quoted(i)
+		case c == '$':
+			i = s.dollarQuoted(i)
+		case c == ';':
+			s.flush()
+		case c == ' ' || c == '\t' || c == '\r':
+			s.b.WriteByte(c)
+		default:
+			s.mark()
+			s.b.WriteByte(c)
+		}
+	}
+	s.flush()
+	return s.statements
+}
+
+// sqlSplitter is the state of splitSQL. The scanning methods consume the
+// token starting at script[i] and return the index of its last byte.
+type sqlSplitter struct {
+	script     string
+	b          strings.Builder
+	line       int
+	start      int // line the current statement starts on, 0 before its first token
+	statements []sqlStatement
+}
+
+// flush ends the current statement
+func (s *sqlSplitter) flush() {
+	if text := strings.TrimSpace(s.b.String()); text != "" {
+		s.statements = append(s.statements, sqlStatement{Text: text, Line: s.start})
+	}
+	s.b.Reset()
+	s.start = 0
+}
+
+// mark starts the current statement on this line unless it has started
+func (s *sqlSplitter) mark() {
+	if s.start == 0 {
+		s.start = s.line
+	}
+}
+
+// lineComment drops a -- comment, leaving its newline to the caller
+func (s *sqlSplitter) lineComment(i int) int {
+	end := strings.IndexByte(s.script[i:], '\n')
+	if end < 0 {
+		return len(s.script) - 1
+	}
+	return i + end - 1
+}
+
+// blockComment replaces a /* */ comment with a space
+func (s *sqlSplitter) blockComment(i int) int {
+	end := strings.Index(s.script[i+2:], "*/")
+	if end < 0 {
+		return len(s.script) - 1
+	}
+	s.line += strings.Count(s.script[i:i+2+end], "\n")
+	s.b.WriteByte(' ')
+	return i + end + 3
+}
+
+// quoted copies a quoted string or identifier
+func (s *sqlSplitter) quoted(i int) int {
+	s.mark()
+	quote := s.script[i]
+	end := i + 1
+	for end < len(s.script) && s.script[end] != quote {
+		end++
+	}
+	s.line += strings.Count(s.script[i:min(end, len(s.script))], "\n")
+	s.b.WriteString(s.script[i:min(end+1, len(s.script))])
+	return end
+}
+
+// dollarQuoted copies a $tag$ ... $tag$ body, or a lone $
+func (s *sqlSplitter) dollarQuoted(i int) int {
+	s.mark()
+	tag := dollarQuoteTag(s.script[i:])
+	if tag == "" {
+		s.b.WriteByte('$')
+		return i
+	}
+	end := strings.Index(s.script[i+len(tag):], tag)
+	if end < 0 {
+		end = len(s.script) - i - len(tag)
+	}
+	body := s.script[i:min(i+len(tag)+end+len(tag), len(s.script))]
+	s.line += strings.Count(body, "\n")
+	s.b.WriteString(body)
+	return i + len(body) - 1
+}
+
+// dollarQuoteTag returns the $tag$ that opens a dollar-quoted string
+func dollarQuoteTag(s string) string {
+	for i := 1; i < len(s); i++ {
+		c := s[i]
+		if c == '$' {
+			return s[:i+1]
+		}
+		if !(c == '_' || c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || (i > 1 && c >= '0' && c <= '9')) {
+			return ""
+		}
+	}
+	return ""
+}
+
+var (
+	sqlIdent = `[\w."` + "`" + `\[\]]+`
+
+	sqlCreateTablePattern = regexp.MustCompile(`(?is)^create\s+(?:(?:global\s+|local\s+)?(?:temp|temporary|unlogged)\s+)?table\s+(?:if\s+not\s+exists\s+)?(` + sqlIdent + `)`)
+	sqlDropTablePattern   = regexp.MustCompile(`(?is)^drop\s+table\s+(?:if\s+exists\s+)?(` + sqlIdent + `)`)
+	sqlRenameTablePattern = regexp.MustCompile(`(?is)^rename\s+table\s+(` + sqlIdent + `)\s+to\s+(` + sqlIdent + `)`)
+	sqlTruncatePattern    = regexp.MustCompile(`(?is)^truncate\s+(?:table\s+)?(` + sqlIdent + `)`)
+	sqlAlterTablePattern  = regexp.MustCompile(`(?is)^alter\s+table\s+(?:if\s+exists\s+)?(?:only\s+)?(` + sqlIdent + `)\s+(.*)$`)
+	sqlCreateIndexPattern = regexp.MustCompile(`(?is)^create\s+(?:unique\s+)?index\s+(concurrently\s+)?(?:if\s+not\s+exists\s+)?(?:` + sqlIdent + `\s+)?on\s+(?:only\s+)?(` + sqlIdent + `)`)
+	sqlUpdatePattern      = regexp.MustCompile(`(?is)^update\s+(?:only\s+)?(` + sqlIdent + `)\s+(?:as\s+\w+\s+)?set\s+(.*)$`)
+
+	sqlAddColumnPattern    = regexp.MustCompile(`(?is)^add\s+(?:column\s+)?(?:if\s+not\s+exists\s+)?(` + sqlIdent + `)\s+(.*)$`)
+	sqlDropColumnPattern   = regexp.MustCompile(`(?is)^drop\s+(?:column\s+)?(?:if\s+exists\s+)?(` + sqlIdent + `)`)
+	sqlRenameColumnPattern = regexp.MustCompile(`(?is)^rename\s+(?:column\s+)?(` + sqlIdent + `)\s+to\s+(` + sqlIdent + `)`)
+	sqlRenameToPattern     = regexp.MustCompile(`(?is)^rename\s+to\s+(` + sqlIdent + `)`)
+	sqlChangeColumnPattern = regexp.MustCompile(`(?is)^change\s+(?:column\s+)?(` + sqlIdent + `)\s+(` + sqlIdent + `)\s+(.*)$`)
+	sqlModifyColumnPattern = regexp.MustCompile(`(?is)^modify\s+(?:column\s+)?(` + sqlIdent + `)\s+(.*)$`)
+	sqlAlterColumnPattern  = regexp.MustCompile(`(?is)^alter\s+(?:column\s+)?(` + sqlIdent + `)\s+(.*)$`)
+	sqlSetNotNullPattern   = regexp.MustCompile(`(?is)^set\s+not\s+null\b`)
+	sqlDropNotNullPattern  = regexp.MustCompile(`(?is)^drop\s+not\s+null\b`)
+	sqlAlterTypePattern    = regexp.MustCompile(`(?is)^(?:set\s+data\s+)?type\s+`)
+	sqlNotNullPattern      = regexp.MustCompile(`(?i)\bnot\s+null\b`)
+	sqlDefaultPattern      = regexp.MustCompile(`(?i)\bdefault\b|\bgenerated\b|\bserial\b|\bbigserial\b|\bauto_increment\b`)
+	sqlAssignmentPattern   = regexp.MustCompile(`(?i)(?:^|,)\s*(` + sqlIdent + `)\s*=`)
+)
+
+// sqlDropTargets are the non-column objects ALTER TABLE ... DROP removes
+var sqlDropTargets = map[string]bool{"constraint": true, "index": true, "key": true, "primary": true, "foreign": true, "check": true, "default": true, "partition": true}
+
+// sqlAddTargets are the non-column objects ALTER TABLE ... ADD creates
+var sqlAddTargets = map[string]bool{"constraint": true, "index": true, "key": true, "primary": true, "foreign": true, "unique": true, "check": true, "fulltext": true, "spatial": true, "partition": true, "exclude": true}
+
+// parseSQLMigration returns the schema operations of a SQL script
+func parseSQLMigration(script string, firstLine int) []migrationOp {
+	var ops []migrationOp
+	for _, stmt := range splitSQL(script, firstLine) {
+		ops = append(ops, classifySQLStatement(stmt)...)
+	}
+	return ops
+}
+
+// classifySQLStatement returns the operations of one statement
+func classifySQLStatement(stmt sqlStatement) []migrationOp {
+	text := strings.Join(strings.Fields(stmt.Text), " ")
+	keyword, _, _ := strings.Cut(text, " ")
+	classify := sqlStatementKinds[strings.ToLower(keyword)]
+	if classify == nil {
+		return nil
+	}
+	return classify(migrationOp{Statement: shortenStatement(text), Line: stmt.Line}, text)
+}
+
+// sqlStatementKinds classify statements by their first keyword. Handlers
+// get the operation fields every result shares and the normalized text.
+var sqlStatementKinds = map[string]func(base migrationOp, text string) []migrationOp{
+	"create":   sqlCreateOps,
+	"drop":     sqlDropTableOps,
+	"rename":   sqlRenameTableOps,
+	"truncate": sqlTruncateOps,
+	"update":   sqlUpdateOps,
+	"alter":    sqlAlterTableOps,
+}
+
+// sqlOp returns base as an operation of kind on table
+func sqlOp(base migrationOp, kind, table string) migrationOp {
+	base.Kind, base.Table = kind, sqlName(table)
+	return base
+}
+
+// sqlCreateOps classifies CREATE TABLE and CREATE INDEX
+func sqlCreateOps(base migrationOp, text string) []migrationOp {
+	if m := sqlCreateTablePattern.FindStringSubmatch(text); m != nil {
+		return []migrationOp{sqlOp(base, opCreateTable, m[1])}
+	}
+	if m := sqlCreateIndexPattern.FindStringSubmatch(text); m != nil {
+		o := sqlOp(base, opCreateIndex, m[2])
+		o.Concurrent = m[1] != ""
+		return []migrationOp{o}
+	}
+	return nil
+}
+
+// sqlDropTableOps classifies DROP TABLE
+func sqlDropTableOps(base migrationOp, text string) []migrationOp {
+	if m := sqlDropTablePattern.FindStringSubmatch(text); m != nil {
+		return []migrationOp{sqlOp(base, opDropTable, m[1])}
+	}
+	return nil
+}
+
+// sqlRenameTableOps classifies the MySQL RENAME TABLE
+func sqlRenameTableOps(base migrationOp, text string) []migrationOp {
+	if m := sqlRenameTablePattern.FindStringSubmatch(text); m != nil {
+		o := sqlOp(base, opRenameTable, m[1])
+		o.NewName = sqlName(m[2])
+		return []migrationOp{o}
+	}
+	return nil
+}
+
+// sqlTruncateOps classifies TRUNCATE
+func sqlTruncateOps(base migrationOp, text string) []migrationOp {
+	if m := sqlTruncatePattern.FindStringSubmatch(text); m != nil {
+		return []migrationOp{sqlOp(base, opTruncate, m[1])}
+	}
+	return nil
+}
+
+// sqlUpdateOps returns a backfill for each column an UPDATE assigns
+func sqlUpdateOps(base migrationOp, text string) []migrationOp {
+	m := sqlUpdatePattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	var ops []migrationOp
+	assignments, _, _ := strings.Cut(m[2], " WHERE ")
+	assignments, _, _ = strings.Cut(assignments, " where ")
+	matches := sqlAssignmentPattern.FindAllStringSubmatchIndex(assignments, -1)
+	for i, a := range matches {
+		end := len(assignments)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		o := sqlOp(base, opBackfill, m[1])
+		o.Column = sqlName(assignments[a[2]:a[3]])
+		o.Source = strings.ToLower(strings.TrimSpace(assignments[a[1]:end]))
+		ops = append(ops, o)
+	}
+	return ops
+}
+
+// sqlAlterTableOps returns the operations of the actions of an ALTER TABLE
+func sqlAlterTableOps(base migrationOp, text string) []migrationOp {
+	m := sqlAlterTablePattern.FindStringSubmatch(text)
+	if m == nil {
+		return nil
+	}
+	table := sqlOp(base, "", m[1])
+	var ops []migrationOp
+	for _, action := range splitTopLevel(m[2], ',') {
+		action = strings.TrimSpace(action)
+		keyword, _, _ := strings.Cut(action, " ")
+		classify := sqlAlterActions[strings.ToLower(keyword)]
+		if classify == nil {
+			continue
+		}
+		if o, ok := classify(table, action); ok {
+			ops = append(ops, o)
+		}
+	}
+	return ops
+}
+
+// sqlAlterActions classify ALTER TABLE actions by their first keyword.
+// Handlers get the operation on the altered table and the action text.
+var sqlAlterActions = map[string]func(table migrationOp, action string) (migrationOp, bool){
+	"add":    sqlAddColumnOp,
+	"drop":   sqlDropColumnOp,
+	"rename": sqlRenameOp,
+	"change": sqlChangeColumnOp,
+	"modify": sqlModifyColumnOp,
+	"alter":  sqlAlterColumnOp,
+}
+
+// sqlActionTarget returns the lower-cased second word of an action, the
+// kind of object it adds or drops when that is not a column
+func sqlActionTarget(action string) string {
+	if fields := strings.Fields(action); len(fields) > 1 {
+		return strings.ToLower(fields[1])
+	}
+	return ""
+}
+
+// sqlAddColumnOp classifies ADD [COLUMN]
+func sqlAddColumnOp(o migrationOp, action string) (migrationOp, bool) {
+	if sqlAddTargets[sqlActionTarget(action)] {
+		return o, false
+	}
+	c := sqlAddColumnPattern.FindStringSubmatch(action)
+	if c == nil {
+		return o, false
+	}
+	o.Kind, o.Column = opAddColumn, sqlName(c[1])
+	o.NotNull = sqlNotNullPattern.MatchString(c[2])
+	o.Default = sqlDefaultPattern.MatchString(c[2])
+	return o, true
+}
+
+// sqlDropColumnOp classifies DROP [COLUMN]
+func sqlDropColumnOp(o migrationOp, action string) (migrationOp, bool) {
+	if sqlDropTargets[sqlActionTarget(action)] {
+		return o, false
+	}
+	c := sqlDropColumnPattern.FindStringSubmatch(action)
+	if c == nil {
+		return o, false
+	}
+	o.Kind, o.Column = opDropColumn, sqlName(c[1])
+	return o, true
+}
+
+// sqlRenameOp classifies RENAME TO, which renames the table, and RENAME
+// [COLUMN]
+func sqlRenameOp(o migrationOp, action string) (migrationOp, bool) {
+	if c := sqlRenameToPattern.FindStringSubmatch(action); c != nil {
+		o.Kind, o.NewName = opRenameTable, sqlName(c[1])
+		return o, true
+	}
+	if c := sqlRenameColumnPattern.FindStringSubmatch(action); c != nil {
+		o.Kind, o.Column, o.NewName = opRenameColumn, sqlName(c[1]), sqlName(c[2])
+		return o, true
+	}
+	return o, false
+}
+
+// sqlChangeColumnOp classifies the MySQL CHANGE old new type, which renames
+// and retypes a column
+func sqlChangeColumnOp(o migrationOp, action string) (migrationOp, bool) {
+	c := sqlChangeColumnPattern.FindStringSubmatch(action)
+	if c == nil {
+		return o, false
+	}
+	o.Kind = opAlterType
+	if !strings.EqualFold(sqlName(c[1]), sqlName(c[2])) {
+		o.Kind = opRenameColumn
+	}
+	o.Column, o.NewName = sqlName(c[1]), sqlName(c[2])
+	return o, true
+}
+
+// sqlModifyColumnOp classifies the MySQL MODIFY, which retypes a column
+func sqlModifyColumnOp(o migrationOp, action string) (migrationOp, bool) {
+	c := sqlModifyColumnPattern.FindStringSubmatch(action)
+	if c == nil {
+		return o, false
+	}
+	o.Kind, o.Column = opAlterType, sqlName(c[1])
+	return o, true
+}
+
+// sqlAlterColumnOp classifies ALTER [COLUMN] ... SET/DROP NOT NULL and
+// [SET DATA] TYPE
+func sqlAlterColumnOp(o migrationOp, action string) (migrationOp, bool) {
+	c := sqlAlterColumnPattern.FindStringSubmatch(action)
+	if c == nil {
+		return o, false
+	}
+	switch {
+	case sqlSetNotNullPattern.MatchString(c[2]):
+		o.Kind = opSetNotNull
+	case sqlDropNotNullPattern.MatchString(c[2]):
+		o.Kind = opDropNotNull
+	case sqlAlterTypePattern.MatchString(c[2]):
+		o.Kind = opAlterType
+	default:
+		return o, false
+	}
+	o.Column = sqlName(c[1])
+	return o, true
+}
+
+// splitTopLevel splits s on sep outside parentheses and quotes
+func splitTopLevel(s string, sep byte) []string {
+	var parts []string
+	depth, start := 0, 0
+	var quote byte
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '(':
+			depth++
+		case c == ')':
+			depth--
+		case c == sep && depth == 0:
+			parts = append(parts, s[start:i])
+			start = i + 1
+		}
+	}
+	return append(parts, s[start:])
+}
+
+// sqlName normalizes an identifier: quotes and schema qualifiers are
+// removed and the name is lower-cased
+func sqlName(ident string) string {
+	ident = strings.Trim(ident, "\"`[]")
+	if idx := strings.LastIndex(ident, "."); idx >= 0 {
+		ident = ident[idx+1:]
+	}
+	return strings.ToLower(strings.Trim(ident, "\"`[]"))
+}
+
+// shortenStatement bounds a statement for evidence
+func shortenStatement(text string) string {
+	const maxStatement = 120
+	if len(text) <= maxStatement {
+		return text
+	}
+	return text[:maxStatement] + "..."
+}
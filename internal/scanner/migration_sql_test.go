@@ -0,0 +1,66 @@
+package scanner
+
+import (
+	"testing"
+)
+
+func TestSplitSQL(t *testing.T) {
+	script := `-- add email; not a statement
+ALTER TABLE users ADD COLUMN email TEXT;
+
+/* multi-line
+   comment; */
+CREATE FUNCTION touch() RETURNS trigger AS $$
+BEGIN
+  NEW.updated_at = now();
+  RETURN NEW;
+END;
+$$ LANGUAGE plpgsql;
+INSERT INTO notes VALUES ('a; b');`
+
+	statements := splitSQL(script, 1)
+	if len(statements) != 3 {
+		t.Fatalf("expected 3 statements, got %+v", statements)
+	}
+	wantLines := []int{2, 6, 12}
+	for i, line := range wantLines {
+		if statements[i].Line != line {
+			t.Errorf("statement %d: expected line %d, got %d", i, line, statements[i].Line)
+		}
+	}
+}
+
+func TestClassifySQLStatement(t *testing.T) {
+	tests := []struct {
+		sql  string
+		want []migrationOp
+	}{
+		{"DROP TABLE IF EXISTS public.sessions", []migrationOp{{Kind: opDropTable, Table: "sessions"}}},
+		{"ALTER TABLE users DROP COLUMN email, DROP CONSTRAINT users_email_key", []migrationOp{{Kind: opDropColumn, Table: "users", Column: "email"}}},
+		{`ALTER TABLE "users" RENAME COLUMN name TO full_name`, []migrationOp{{Kind: opRenameColumn, Table: "users", Column: "name", NewName: "full_name"}}},
+		{"ALTER TABLE users RENAME TO accounts", []migrationOp{{Kind: opRenameTable, Table: "users", NewName: "accounts"}}},
+		{"ALTER TABLE users ALTER COLUMN age TYPE bigint", []migrationOp{{Kind: opAlterType, Table: "users", Column: "age"}}},
+		{"ALTER TABLE users ALTER COLUMN email SET NOT NULL", []migrationOp{{Kind: opSetNotNull, Table: "users", Column: "email"}}},
+		{"ALTER TABLE users ADD COLUMN plan TEXT NOT NULL", []migrationOp{{Kind: opAddColumn, Table: "users", Column: "plan", NotNull: true}}},
+		{"ALTER TABLE users ADD COLUMN plan TEXT NOT NULL DEFAULT 'free', ADD CONSTRAINT c CHECK (plan <> '')", []migrationOp{{Kind: opAddColumn, Table: "users", Column: "plan", NotNull: true, Default: true}}},
+		{"ALTER TABLE users CHANGE name full_name VARCHAR(255)", []migrationOp{{Kind: opRenameColumn, Table: "users", Column: "name", NewName: "full_name"}}},
+		{"CREATE INDEX idx_users_email ON users (email)", []migrationOp{{Kind: opCreateIndex, Table: "users"}}},
+		{"CREATE UNIQUE INDEX CONCURRENTLY idx ON users (email)", []migrationOp{{Kind: opCreateIndex, Table: "users", Concurrent: true}}},
+		{"UPDATE users SET email_verified = false, plan = 'free' WHERE plan IS NULL", []migrationOp{{Kind: opBackfill, Table: "users", Column: "email_verified", Source: "false"}, {Kind: opBackfill, Table: "users", Column: "plan", Source: "'free'"}}},
+		{"SELECT 1", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.sql, func(t *testing.T) {
+			got := classifySQLStatement(sqlStatement{Text: tt.sql, Line: 1})
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %+v, got %+v", tt.want, got)
+			}
+			for i := range got {
+				got[i].Statement, got[i].Line = "", 0
+				if got[i] != tt.want[i] {
+					t.Errorf("expected %+v, got %+v", tt.want[i], got[i])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,677 @@
+package scanner
+
+import (
+	"encoding/xml"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// Migration tools
+const (
+	migrationFlyway        = "flyway"
+	migrationLiquibase     = "liquibase"
+	migrationGolangMigrate = "golang-migrate"
+	migrationAlembic       = "alembic"
+	migrationRails         = "rails"
+	migrationPrisma        = "prisma"
+)
+
+// migration is one versioned migration (a Liquibase changeSet, an Alembic
+// revision, a migration file for the other tools)
+type migration struct {
+	Tool    string
+	Version string
+	File    string
+	Line    int
+	Ops     []migrationOp
+	parent  string // Alembic down_revision
+}
+
+// migrationHistory is the ordered migrations of one migration directory
+type migrationHistory struct {
+	Tool       string
+	Dir        string
+	Migrations []migration
+}
+
+var (
+	// V2_1__add_email.sql
+	flywayFilePattern = regexp.MustCompile(`^V(\d+(?:[._]\d+)*)__.+\.sql$`)
+
+	// 000002_add_email.up.sql
+	golangMigrateFilePattern = regexp.MustCompile(`^(\d+)_.+\.up\.sql$`)
+
+	// db/migrate/20240101120000_add_email.rb
+	railsFilePattern = regexp.MustCompile(`(?:^|/)db/migrate/(\d+)_\w+\.rb$`)
+
+	// prisma/migrations/20240101120000_add_email/migration.sql
+	prismaFilePattern = regexp.MustCompile(`(?:^|/)migrations/(\d+)_[^/]+/migration\.sql$`)
+
+	alembicRevisionPattern     = regexp.MustCompile(`(?m)^revision\s*(?::\s*\w+\s*)?=\s*['"]([^'"]+)['"]`)
+	alembicDownRevisionPattern = regexp.MustCompile(`(?m)^down_revision\s*(?::[^=]+)?=\s*['"]([^'"]+)['"]`)
+	liquibaseChangeSetPattern  = regexp.MustCompile(`(?m)^--\s*changeset\s+([^\s:]+):(\S+)`)
+)
+
+// loadMigrationHistories finds the migrations of every supported tool and
+// groups them into ordered histories per directory
+func loadMigrationHistories(contents map[string]string) []migrationHistory {
+	paths := make([]string, 0, len(contents))
+	for path := range contents {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+
+	byDir := make(map[string]*migrationHistory)
+	add := func(tool, dir string, migrations ...migration) {
+		key := tool + "\x00" + dir
+		h, ok := byDir[key]
+		if !ok {
+			h = &migrationHistory{Tool: tool, Dir: dir}
+			byDir[key] = h
+		}
+		h.Migrations = append(h.Migrations, migrations...)
+	}
+
+	for _, path := range paths {
+		content := contents[path]
+		slashed := filepath.ToSlash(path)
+		base := filepath.Base(path)
+		dir := filepath.Dir(path)
+
+		switch {
+		case flywayFilePattern.MatchString(base):
+			version := strings.ReplaceAll(flywayFilePattern.FindStringSubmatch(base)[1], "_", ".")
+			add(migrationFlyway, dir, migration{Version: version, File: path, Line: 1, Ops: parseSQLMigration(content, 1)})
+		case golangMigrateFilePattern.MatchString(base):
+			version := golangMigrateFilePattern.FindStringSubmatch(base)[1]
+			add(migrationGolangMigrate, dir, migration{Version: version, File: path, Line: 1, Ops: parseSQLMigration(content, 1)})
+		case prismaFilePattern.MatchString(slashed):
+			version := prismaFilePattern.FindStringSubmatch(slashed)[1]
+			add(migrationPrisma, filepath.Dir(dir), migration{Version: version, File: path, Line: 1, Ops: parseSQLMigration(content, 1)})
+		case railsFilePattern.MatchString(slashed):
+			version := railsFilePattern.FindStringSubmatch(slashed)[1]
+			add(migrationRails, dir, migration{Version: version, File: path, Line: 1, Ops: parseRailsMigration(content)})
+		case filepath.Base(dir) == "versions" && filepath.Ext(base) == ".py" && alembicRevisionPattern.MatchString(content):
+			m := migration{Version: alembicRevisionPattern.FindStringSubmatch(content)[1], File: path, Line: 1, Ops: parseAlembicMigration(content)}
+			if parent := alembicDownRevisionPattern.FindStringSubmatch(content); parent != nil {
+				m.parent = parent[1]
+			}
+			add(migrationAlembic, dir, m)
+		default:
+			if changeSets := parseLiquibaseChangelog(content, path); len(changeSets) > 0 {
+				add(migrationLiquibase, dir, changeSets...)
+			}
+		}
+	}
+
+	keys := make([]string, 0, len(byDir))
+	for key := range byDir {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	histories := make([]migrationHistory, 0, len(keys))
+	for _, key := range keys {
+		h := byDir[key]
+		for i := range h.Migrations {
+			h.Migrations[i].Tool = h.Tool
+		}
+		h.sort()
+		histories = append(histories, *h)
+	}
+	return histories
+}
+
+// sort orders migrations the way the tool applies them
+func (h *migrationHistory) sort() {
+	switch h.Tool {
+	case migrationLiquibase:
+		// changeSets run in changelog order; files are taken in path order
+		sort.SliceStable(h.Migrations, func(i, j int) bool { return h.Migrations[i].File < h.Migrations[j].File })
+	case migrationAlembic:
+		h.Migrations = alembicOrder(h.Migrations)
+	default:
+		sort.SliceStable(h.Migrations, func(i, j int) bool {
+			return compareVersions(h.Migrations[i].Version, h.Migrations[j].Version) < 0
+		})
+	}
+}
+
+// alembicOrder follows the down_revision chain from the base revision.
+// Revisions that are not reachable (branches) are appended in path order.
+func alembicOrder(migrations []migration) []migration {
+	children := make(map[string][]int)
+	for i := range migrations {
+		children[migrations[i].parent] = append(children[migrations[i].parent], i)
+	}
+	ordered := make([]migration, 0, len(migrations))
+	seen := make(map[int]bool)
+	var walk func(parent string)
+	walk = func(parent string) {
+		for _, i := range children[parent] {
+			if seen[i] {
+				continue
+			}
+			seen[i] = true
+			ordered = append(ordered, migrations[i])
+			walk(migrations[i].Version)
+		}
+	}
+	walk("")
+	for i := range migrations {
+		if !seen[i] {
+			ordered = append(ordered, migrations[i])
+		}
+	}
+	return ordered
+}
+
+// compareVersions compares dotted numeric versions such as 2.10 and 2.9
+func compareVersions(a, b string) int {
+	pa, pb := strings.Split(a, "."), strings.Split(b, ".")
+	for i := 0; i < len(pa) || i < len(pb); i++ {
+		var na, nb int
+		if i < len(pa) {
+			na, _ = strconv.Atoi(pa[i])
+		}
+		if i < len(pb) {
+			nb, _ = strconv.Atoi(pb[i])
+		}
+		if na != nb {
+			if na < nb {
+				return -1
+			}
+			return 1
+		}
+	}
+	return 0
+}
+
+var (
+	railsCallPattern       = regexp.MustCompile(`(?m)^[ \t]*(create_table|drop_table|rename_table|add_column|remove_column|rename_column|change_column|change_column_null|add_index|execute)\b[ \t]*\(?[ \t]*(.*)$`)
+	railsSymbolPattern     = regexp.MustCompile(`:(\w+)|["'](\w+)["']`)
+	railsNullFalsePattern  = regexp.MustCompile(`\bnull:\s*false\b`)
+	railsDefaultPattern    = regexp.MustCompile(`\bdefault:`)
+	railsConcurrentPattern = regexp.MustCompile(`algorithm:\s*:concurrently`)
+	railsDownPattern       = regexp.MustCompile(`(?m)^\s*def\s+(?:down|self\.down)\b`)
+	railsHeredocPattern    = regexp.MustCompile(`<<[~-]?['"]?(\w+)['"]?`)
+)
+
+// parseRailsMigration returns the operations of the change or up method of
+// an ActiveRecord migration
+func parseRailsMigration(content string) []migrationOp {
+	if loc := railsDownPattern.FindStringIndex(content); loc != nil {
+		content = content[:loc[0]]
+	}
+	var ops []migrationOp
+	for _, m := range railsCallPattern.FindAllStringSubmatchIndex(content, -1) {
+		call, args := content[m[2]:m[3]], content[m[4]:m[5]]
+		line := strings.Count(content[:m[0]], "\n") + 1
+		if call == "execute" {
+			ops = append(ops, parseSQLMigration(rubyStringArg(content, m[4], args), line)...)
+			continue
+		}
+
+		var names []string
+		for _, s := range railsSymbolPattern.FindAllStringSubmatch(args, 3) {
+			names = append(names, s[1]+s[2])
+		}
+		if len(names) == 0 {
+			continue
+		}
+		op := migrationOp{Table: strings.ToLower(names[0]), Statement: shortenStatement(strings.TrimSpace(call + " " + args)), Line: line}
+		column := func(i int) string {
+			if i < len(names) {
+				return strings.ToLower(names[i])
+			}
+			return ""
+		}
+		switch call {
+		case "create_table":
+			op.Kind = opCreateTable
+		case "drop_table":
+			op.Kind = opDropTable
+		case "rename_table":
+			op.Kind, op.NewName = opRenameTable, column(1)
+		case "add_column":
+			op.Kind, op.Column = opAddColumn, column(1)
+			op.NotNull = railsNullFalsePattern.MatchString(args)
+			op.Default = railsDefaultPattern.MatchString(args)
+		case "remove_column":
+			op.Kind, op.Column = opDropColumn, column(1)
+		case "rename_column":
+			op.Kind, op.Column, op.NewName = opRenameColumn, column(1), column(2)
+		case "change_column":
+			op.Kind, op.Column = opAlterType, column(1)
+		case "change_column_null":
+			op.Kind, op.Column = opDropNotNull, column(1)
+			if strings.Contains(args, "false") {
+				op.Kind = opSetNotNull
+				// a fourth argument replaces existing NULLs before the constraint
+				if len(splitTopLevel(args, ',')) >= 4 {
+					backfill := op
+					backfill.Kind = opBackfill
+					ops = append(ops, backfill)
+				}
+			}
+		case "add_index":
+			op.Kind = opCreateIndex
+			op.Concurrent = railsConcurrentPattern.MatchString(args)
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// rubyStringArg returns the string passed to execute: a quoted string or a heredoc
+func rubyStringArg(content string, offset int, args string) string {
+	if m := railsHeredocPattern.FindStringSubmatch(args); m != nil {
+		body := content[offset+len(args):]
+		if end := regexp.MustCompile(`(?m)^\s*` + m[1] + `\s*$`).FindStringIndex(body); end != nil {
+			return body[:end[0]]
+		}
+		return ""
+	}
+	return unquoteArg(args)
+}
+
+var (
+	alembicCallPattern     = regexp.MustCompile(`\bop\.(create_table|drop_table|rename_table|add_column|drop_column|alter_column|create_index|execute)\(`)
+	alembicStringPattern   = regexp.MustCompile(`['"](\w+)['"]`)
+	alembicColumnPattern   = regexp.MustCompile(`sa\.Column\(\s*['"](\w+)['"]`)
+	alembicUpgradePattern  = regexp.MustCompile(`(?m)^def\s+upgrade\b`)
+	alembicDowngradePatern = regexp.MustCompile(`(?m)^def\s+downgrade\b`)
+	alembicIndexPattern    = regexp.MustCompile(`^\s*(?:op\.f\()?['"][^'"]*['"]\)?\s*,\s*['"](\w+)['"]`)
+	alembicRenamePattern   = regexp.MustCompile(`new_column_name\s*=\s*['"](\w+)['"]`)
+)
+
+// parseAlembicMigration returns the operations of the upgrade function of an
+// Alembic revision
+func parseAlembicMigration(content string) []migrationOp {
+	start := 0
+	if loc := alembicUpgradePattern.FindStringIndex(content); loc != nil {
+		start = loc[0]
+	}
+	end := len(content)
+	if loc := alembicDowngradePatern.FindStringIndex(content[start:]); loc != nil {
+		end = start + loc[0]
+	}
+
+	var ops []migrationOp
+	for _, m := range alembicCallPattern.FindAllStringSubmatchIndex(content[start:end], -1) {
+		offset := start + m[0]
+		call := content[start+m[2] : start+m[3]]
+		args := balancedArgs(content, start+m[1]-1)
+		line := strings.Count(content[:offset], "\n") + 1
+		if call == "execute" {
+			ops = append(ops, parseSQLMigration(unquoteArg(args), line)...)
+			continue
+		}
+
+		op := migrationOp{Statement: shortenStatement(strings.Join(strings.Fields("op."+call+"("+args+")"), " ")), Line: line}
+		strs := alembicStringPattern.FindAllStringSubmatch(args, 2)
+		arg := func(i int) string {
+			if i < len(strs) {
+				return strings.ToLower(strs[i][1])
+			}
+			return ""
+		}
+		op.Table = arg(0)
+		switch call {
+		case "create_table":
+			op.Kind = opCreateTable
+		case "drop_table":
+			op.Kind = opDropTable
+		case "rename_table":
+			op.Kind, op.NewName = opRenameTable, arg(1)
+		case "drop_column":
+			op.Kind, op.Column = opDropColumn, arg(1)
+		case "add_column":
+			op.Kind = opAddColumn
+			if c := alembicColumnPattern.FindStringSubmatch(args); c != nil {
+				op.Column = strings.ToLower(c[1])
+			}
+			op.NotNull = strings.Contains(args, "nullable=False")
+			op.Default = strings.Contains(args, "server_default=")
+		case "create_index":
+			op.Kind = opCreateIndex
+			op.Table = ""
+			if c := alembicIndexPattern.FindStringSubmatch(args); c != nil {
+				op.Table = strings.ToLower(c[1])
+			}
+			op.Concurrent = strings.Contains(args, "postgresql_concurrently=True")
+		case "alter_column":
+			op.Column = arg(1)
+			// One alter_column call can rename, retype and constrain a column
+			var altered []migrationOp
+			if r := alembicRenamePattern.FindStringSubmatch(args); r != nil {
+				o := op
+				o.Kind, o.NewName = opRenameColumn, strings.ToLower(r[1])
+				altered = append(altered, o)
+			}
+			if strings.Contains(args, "type_=") {
+				o := op
+				o.Kind = opAlterType
+				altered = append(altered, o)
+			}
+			if strings.Contains(args, "nullable=False") {
+				o := op
+				o.Kind = opSetNotNull
+				altered = append(altered, o)
+			} else if strings.Contains(args, "nullable=True") {
+				o := op
+				o.Kind = opDropNotNull
+				altered = append(altered, o)
+			}
+			ops = append(ops, altered...)
+			continue
+		}
+		ops = append(ops, op)
+	}
+	return ops
+}
+
+// unquoteArg returns the first string literal of a call's arguments,
+// including Python triple-quoted strings
+func unquoteArg(args string) string {
+	args = strings.TrimSpace(args)
+	args = strings.TrimLeft(args, "rbfuRBFU")
+	for _, quote := range []string{`"""`, `'''`, `"`, `'`} {
+		if strings.HasPrefix(args, quote) {
+			body := args[len(quote):]
+			if end := strings.Index(body, quote); end >= 0 {
+				return body[:end]
+			}
+			return body
+		}
+	}
+	return ""
+}
+
+// parseLiquibaseChangelog returns the changeSets of a Liquibase changelog in
+// XML, YAML or formatted SQL
+func parseLiquibaseChangelog(content, relPath string) []migration {
+	switch strings.ToLower(filepath.Ext(relPath)) {
+	case ".sql":
+		if !strings.Contains(content, "--liquibase formatted sql") {
+			return nil
+		}
+		return parseLiquibaseFormattedSQL(content, relPath)
+	case ExtYAML, ExtYML:
+		if !strings.Contains(content, "databaseChangeLog") {
+			return nil
+		}
+		return parseLiquibaseYAML(content, relPath)
+	case ".xml":
+		if !strings.Contains(content, "databaseChangeLog") {
+			return nil
+		}
+		return parseLiquibaseXML(content, relPath)
+	}
+	return nil
+}
+
+// parseLiquibaseFormattedSQL splits a formatted SQL changelog at its
+// --changeset comments. Rollback comments are not part of the change.
+func parseLiquibaseFormattedSQL(content, relPath string) []migration {
+	var changeSets []migration
+	matches := liquibaseChangeSetPattern.FindAllStringSubmatchIndex(content, -1)
+	for i, m := range matches {
+		end := len(content)
+		if i+1 < len(matches) {
+			end = matches[i+1][0]
+		}
+		line := strings.Count(content[:m[0]], "\n") + 1
+		var body []string
+		for _, l := range strings.Split(content[m[1]:end], "\n") {
+			if strings.HasPrefix(strings.TrimSpace(l), "--rollback") {
+				l = ""
+			}
+			body = append(body, l)
+		}
+		changeSets = append(changeSets, migration{
+			Version: content[m[4]:m[5]],
+			File:    relPath,
+			Line:    line,
+			Ops:     parseSQLMigration(strings.Join(body, "\n"), line),
+		})
+	}
+	return changeSets
+}
+
+// liquibaseChange is a change of a changeSet in any changelog format
+type liquibaseChange struct {
+	Type    string
+	Attrs   map[string]string
+	Columns []map[string]string // column attributes, with constraints flattened
+	SQL     string
+	Line    int
+}
+
+// parseLiquibaseYAML reads the changeSets of a YAML changelog
+func parseLiquibaseYAML(content, relPath string) []migration {
+	var root yaml.Node
+	if yaml.Unmarshal([]byte(content), &root) != nil || len(root.Content) == 0 {
+		return nil
+	}
+	log := yamlMappingValue(root.Content[0], "databaseChangeLog")
+	if log == nil {
+		return nil
+	}
+	var changeSets []migration
+	for _, entry := range log.Content {
+		node := yamlMappingValue(entry, "changeSet")
+		if node == nil {
+			continue
+		}
+		cs := migration{File: relPath, Line: node.Line}
+		if id := yamlMappingValue(node, "id"); id != nil {
+			cs.Version = id.Value
+		}
+		if changes := yamlMappingValue(node, "changes"); changes != nil {
+			for _, changeNode := range changes.Content {
+				if changeNode.Kind != yaml.MappingNode || len(changeNode.Content) < 2 {
+					continue
+				}
+				var spec map[string]interface{}
+				if changeNode.Content[1].Decode(&spec) != nil {
+					continue
+				}
+				change := liquibaseChange{Type: changeNode.Content[0].Value, Attrs: make(map[string]string), Line: changeNode.Line}
+				for key, value := range spec {
+					items, isList := value.([]interface{})
+					if !isList {
+						change.Attrs[key] = yamlScalarString(value)
+						continue
+					}
+					// columns: [{column: {name: ..., constraints: {...}}}]
+					for _, item := range items {
+						if m, ok := item.(map[string]interface{}); ok {
+							change.Columns = append(change.Columns, flattenLiquibaseColumn(nestedMap(m, "column")))
+						}
+					}
+				}
+				change.SQL = change.Attrs["sql"]
+				cs.Ops = append(cs.Ops, change.ops()...)
+			}
+		}
+		changeSets = append(changeSets, cs)
+	}
+	return changeSets
+}
+
+// flattenLiquibaseColumn merges a column's constraints into its attributes
+func flattenLiquibaseColumn(column map[string]interface{}) map[string]string {
+	attrs := make(map[string]string)
+	for key, value := range column {
+		if key == "constraints" {
+			for ck, cv := range nestedMap(column, "constraints") {
+				attrs[ck] = yamlScalarString(cv)
+			}
+			continue
+		}
+		attrs[key] = yamlScalarString(value)
+	}
+	return attrs
+}
+
+// yamlScalarString formats a decoded scalar as text
+func yamlScalarString(v interface{}) string {
+	switch s := v.(type) {
+	case string:
+		return s
+	case bool:
+		return strconv.FormatBool(s)
+	case int:
+		return strconv.Itoa(s)
+	case float64:
+		return strconv.FormatFloat(s, 'f', -1, 64)
+	}
+	return ""
+}
+
+// liquibaseSkippedElements are changeSet children that describe no change
+var liquibaseSkippedElements = map[string]bool{"rollback": true, "preConditions": true, "comment": true, "validCheckSum": true}
+
+// parseLiquibaseXML reads the changeSets of an XML changelog
+func parseLiquibaseXML(content, relPath string) []migration {
+	r := &liquibaseXMLReader{dec: xml.NewDecoder(strings.NewReader(content)), file: relPath}
+	for {
+		tok, err := r.dec.Token()
+		if err != nil {
+			// io.EOF or a malformed document; keep what was read
+			break
+		}
+		switch t := tok.(type) {
+		case xml.StartElement:
+			r.start(t)
+		case xml.CharData:
+			if r.change != nil {
+				r.text.Write(t)
+			}
+		case xml.EndElement:
+			r.end(t)
+		}
+	}
+	return r.changeSets
+}
+
+// liquibaseXMLReader is the state of parseLiquibaseXML
+type liquibaseXMLReader struct {
+	dec        *xml.Decoder
+	file       string
+	changeSets []migration
+	cs         *migration       // the open changeSet
+	change     *liquibaseChange // the open change of cs
+	text       strings.Builder  // character data of change, the SQL of sql changes
+	skip       int              // depth inside rollback and preConditions elements
+}
+
+// line returns the line the decoder is on
+func (r *liquibaseXMLReader) line() int {
+	line, _ := r.dec.InputPos()
+	return line
+}
+
+// start opens a changeSet or a change, or adds a column to the open change
+func (r *liquibaseXMLReader) start(t xml.StartElement) {
+	if r.skip > 0 || (r.change == nil && liquibaseSkippedElements[t.Name.Local]) {
+		r.skip++
+		return
+	}
+	attrs := make(map[string]string, len(t.Attr))
+	for _, a := range t.Attr {
+		attrs[a.Name.Local] = a.Value
+	}
+	switch {
+	case t.Name.Local == "changeSet":
+		r.changeSets = append(r.changeSets, migration{Version: attrs["id"], File: r.file, Line: r.line()})
+		r.cs = &r.changeSets[len(r.changeSets)-1]
+	case r.cs != nil && r.change == nil:
+		r.change = &liquibaseChange{Type: t.Name.Local, Attrs: attrs, Line: r.line()}
+		r.text.Reset()
+	case r.change != nil && t.Name.Local == "column":
+		r.change.Columns = append(r.change.Columns, attrs)
+	case r.change != nil && t.Name.Local == "constraints" && len(r.change.Columns) > 0:
+		for k, v := range attrs {
+			r.change.Columns[len(r.change.Columns)-1][k] = v
+		}
+	}
+}
+
+// end closes the open change, adding its operations to the changeSet, or
+// the changeSet
+func (r *liquibaseXMLReader) end(t xml.EndElement) {
+	if r.skip > 0 {
+		r.skip--
+		return
+	}
+	switch {
+	case r.change != nil && t.Name.Local == r.change.Type:
+		r.change.SQL = r.text.String()
+		r.cs.Ops = append(r.cs.Ops, r.change.ops()...)
+		r.change = nil
+	case t.Name.Local == "changeSet":
+		r.cs = nil
+	}
+}
+
+// ops converts a Liquibase change into schema operations
+func (c *liquibaseChange) ops() []migrationOp {
+	table := strings.ToLower(c.Attrs["tableName"])
+	op := migrationOp{Table: table, Column: strings.ToLower(c.Attrs["columnName"]), Statement: c.Type, Line: c.Line}
+	switch c.Type {
+	case "sql":
+		return parseSQLMigration(c.SQL, c.Line)
+	case "createTable":
+		op.Kind = opCreateTable
+	case "dropTable":
+		op.Kind = opDropTable
+	case "renameTable":
+		op.Kind, op.Table, op.NewName = opRenameTable, strings.ToLower(c.Attrs["oldTableName"]), strings.ToLower(c.Attrs["newTableName"])
+	case "renameColumn":
+		op.Kind, op.Column, op.NewName = opRenameColumn, strings.ToLower(c.Attrs["oldColumnName"]), strings.ToLower(c.Attrs["newColumnName"])
+	case "modifyDataType":
+		op.Kind = opAlterType
+	case "addNotNullConstraint":
+		op.Kind = opSetNotNull
+	case "dropNotNullConstraint":
+		op.Kind = opDropNotNull
+	case "createIndex":
+		op.Kind = opCreateIndex
+	case "dropColumn", "addColumn", "update":
+		var ops []migrationOp
+		columns := c.Columns
+		if op.Column != "" {
+			columns = append(columns, map[string]string{"name": op.Column})
+		}
+		for _, column := range columns {
+			o := op
+			o.Column = strings.ToLower(column["name"])
+			switch c.Type {
+			case "dropColumn":
+				o.Kind = opDropColumn
+			case "update":
+				o.Kind = opBackfill
+				o.Source = strings.ToLower(column["valueComputed"])
+			default:
+				o.Kind = opAddColumn
+				o.NotNull = column["nullable"] == "false" || column["primaryKey"] == "true"
+				for key := range column {
+					if strings.HasPrefix(key, "defaultValue") || key == "autoIncrement" {
+						o.Default = true
+					}
+				}
+			}
+			ops = append(ops, o)
+		}
+		return ops
+	default:
+		return nil
+	}
+	return []migrationOp{op}
+}
@@ -0,0 +1,294 @@
+package scanner
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+)
+
+// Network policy engines
+const (
+	netpolKubernetes = "kubernetes"
+	netpolCilium     = "cilium"
+	netpolCalico     = "calico"
+)
+
+// networkPolicy is a Kubernetes, Cilium or Calico policy normalized to the
+// pods it selects and the traffic directions it isolates
+type networkPolicy struct {
+	Object          *k8sObject
+	Engine          string
+	ClusterWide     bool // applies in every namespace
+	SelectsAll      bool // selects every pod in its scope
+	Ingress         bool // isolates ingress of the pods it selects
+	Egress          bool // isolates egress of the pods it selects
+	AllowAllIngress bool // has a rule that allows all ingress
+	AllowAllEgress  bool // has a rule that allows all egress
+	selects         func(labels map[string]string) bool
+}
+
+// Selects reports whether the policy selects a pod in a namespace
+func (p *networkPolicy) Selects(namespace string, labels map[string]string) bool {
+	if !p.ClusterWide && k8sNamespace(p.Object) != namespace {
+		return false
+	}
+	return p.SelectsAll || p.selects(labels)
+}
+
+// DenyIngress reports whether the policy denies all ingress not otherwise
+// allowed to every pod in its scope
+func (p *networkPolicy) DenyIngress() bool {
+	return p.SelectsAll && p.Ingress && !p.AllowAllIngress
+}
+
+// DenyEgress reports whether the policy denies all egress not otherwise
+// allowed from every pod in its scope
+func (p *networkPolicy) DenyEgress() bool {
+	return p.SelectsAll && p.Egress && !p.AllowAllEgress
+}
+
+// parseNetworkPolicy normalizes a NetworkPolicy, CiliumNetworkPolicy,
+// CiliumClusterwideNetworkPolicy, Calico NetworkPolicy or Calico
+// GlobalNetworkPolicy. Other objects are not policies.
+func parseNetworkPolicy(obj *k8sObject) (networkPolicy, bool) {
+	spec := obj.Spec()
+	calico := strings.Contains(obj.APIVersion, "projectcalico.org")
+	switch {
+	case obj.Kind == "NetworkPolicy" && !calico:
+		return parseK8sNetworkPolicy(obj, spec), spec != nil
+	case obj.Kind == "CiliumNetworkPolicy" || obj.Kind == "CiliumClusterwideNetworkPolicy":
+		return parseCiliumPolicy(obj)
+	case calico && (obj.Kind == "NetworkPolicy" || obj.Kind == "GlobalNetworkPolicy"):
+		return parseCalicoPolicy(obj, spec)
+	}
+	return networkPolicy{}, false
+}
+
+// parseK8sNetworkPolicy normalizes a networking.k8s.io NetworkPolicy. Without
+// policyTypes it isolates ingress, and egress when it has egress rules.
+func parseK8sNetworkPolicy(obj *k8sObject, spec map[string]interface{}) networkPolicy {
+	selector := nestedMap(spec, "podSelector")
+	p := networkPolicy{
+		Object:     obj,
+		Engine:     netpolKubernetes,
+		SelectsAll: len(selector) == 0,
+		selects:    func(labels map[string]string) bool { return selectorMatches(selector, labels) },
+	}
+	ingress, _ := spec["ingress"].([]interface{})
+	egress, hasEgress := spec["egress"].([]interface{})
+	if types := stringList(spec["policyTypes"]); len(types) > 0 {
+		p.Ingress = slices.Contains(types, "Ingress")
+		p.Egress = slices.Contains(types, "Egress")
+	} else {
+		p.Ingress = true
+		p.Egress = hasEgress
+	}
+	// a rule without peers or ports allows everything
+	p.AllowAllIngress = slices.ContainsFunc(ingress, emptyNetworkRule)
+	p.AllowAllEgress = slices.ContainsFunc(egress, emptyNetworkRule)
+	return p
+}
+
+// parseCiliumPolicy normalizes a Cilium policy, merging the rules of specs.
+// Selecting an endpoint in a policy with ingress or egress rules isolates it
+// in that direction; an empty rule ({}) allows nothing.
+func parseCiliumPolicy(obj *k8sObject) (networkPolicy, bool) {
+	var specs []map[string]interface{}
+	if spec := obj.Spec(); spec != nil {
+		specs = append(specs, spec)
+	}
+	list, _ := obj.Doc["specs"].([]interface{})
+	for _, item := range list {
+		if spec, ok := item.(map[string]interface{}); ok {
+			specs = append(specs, spec)
+		}
+	}
+	if len(specs) == 0 {
+		return networkPolicy{}, false
+	}
+
+	p := networkPolicy{
+		Object:      obj,
+		Engine:      netpolCilium,
+		ClusterWide: obj.Kind == "CiliumClusterwideNetworkPolicy",
+	}
+	var selectors []map[string]interface{}
+	for _, spec := range specs {
+		// node policies select hosts, not pods
+		if _, ok := spec["nodeSelector"]; ok {
+			continue
+		}
+		selector := nestedMap(spec, "endpointSelector")
+		if len(selector) == 0 {
+			p.SelectsAll = true
+		}
+		selectors = append(selectors, selector)
+		for _, key := range []string{"ingress", "ingressDeny"} {
+			if _, ok := spec[key]; ok {
+				p.Ingress = true
+			}
+		}
+		for _, key := range []string{"egress", "egressDeny"} {
+			if _, ok := spec[key]; ok {
+				p.Egress = true
+			}
+		}
+		p.AllowAllIngress = p.AllowAllIngress || ciliumAllowsAll(spec["ingress"], "fromEntities")
+		p.AllowAllEgress = p.AllowAllEgress || ciliumAllowsAll(spec["egress"], "toEntities")
+	}
+	if len(selectors) == 0 {
+		return networkPolicy{}, false
+	}
+	p.selects = func(labels map[string]string) bool {
+		return slices.ContainsFunc(selectors, func(selector map[string]interface{}) bool {
+			return selectorMatches(ciliumSelector(selector), labels)
+		})
+	}
+	return p, true
+}
+
+// parseCalicoPolicy normalizes a projectcalico.org NetworkPolicy or
+// GlobalNetworkPolicy. Global policies restricted to some namespaces by a
+// namespaceSelector cannot be resolved without namespace labels and are
+// skipped.
+func parseCalicoPolicy(obj *k8sObject, spec map[string]interface{}) (networkPolicy, bool) {
+	if spec == nil {
+		return networkPolicy{}, false
+	}
+	global := obj.Kind == "GlobalNetworkPolicy"
+	if namespaceSelector, _ := spec["namespaceSelector"].(string); global && !calicoSelectsAll(namespaceSelector) {
+		return networkPolicy{}, false
+	}
+
+	selector, _ := spec["selector"].(string)
+	p := networkPolicy{
+		Object:      obj,
+		Engine:      netpolCalico,
+		ClusterWide: global,
+		SelectsAll:  calicoSelectsAll(selector),
+		selects:     func(labels map[string]string) bool { return calicoSelectorMatches(selector, labels) },
+	}
+	ingress, _ := spec["ingress"].([]interface{})
+	egress, hasEgress := spec["egress"].([]interface{})
+	if types := stringList(spec["types"]); len(types) > 0 {
+		p.Ingress = slices.Contains(types, "Ingress")
+		p.Egress = slices.Contains(types, "Egress")
+	} else {
+		p.Ingress = true
+		p.Egress = hasEgress
+	}
+	p.AllowAllIngress = slices.ContainsFunc(ingress, calicoAllowsAll)
+	p.AllowAllEgress = slices.ContainsFunc(egress, calicoAllowsAll)
+	return p, true
+}
+
+// emptyNetworkRule reports whether a NetworkPolicy rule has no peers and no
+// ports, and so matches all traffic
+func emptyNetworkRule(raw interface{}) bool {
+	rule, ok := raw.(map[string]interface{})
+	return ok && len(rule) == 0
+}
+
+// ciliumAllowsAll reports whether Cilium rules allow traffic from or to the
+// "all" entity
+func ciliumAllowsAll(raw interface{}, entitiesKey string) bool {
+	rules, _ := raw.([]interface{})
+	for _, item := range rules {
+		rule, _ := item.(map[string]interface{})
+		if slices.Contains(stringList(rule[entitiesKey]), "all") {
+			return true
+		}
+	}
+	return false
+}
+
+// ciliumSelector strips the label source prefixes Cilium allows in
+// endpoint selectors, such as k8s:app
+func ciliumSelector(selector map[string]interface{}) map[string]interface{} {
+	matchLabels := stringMap(selector["matchLabels"])
+	if len(matchLabels) == 0 {
+		return selector
+	}
+	stripped := make(map[string]interface{}, len(matchLabels))
+	for key, value := range matchLabels {
+		for _, source := range []string{"k8s:", "any:"} {
+			key = strings.TrimPrefix(key, source)
+		}
+		stripped[key] = value
+	}
+	return map[string]interface{}{"matchLabels": stripped, "matchExpressions": selector["matchExpressions"]}
+}
+
+// calicoAllowsAll reports whether a Calico rule allows all traffic: an Allow
+// action without any match criteria
+func calicoAllowsAll(raw interface{}) bool {
+	rule, ok := raw.(map[string]interface{})
+	if !ok || rule["action"] != "Allow" {
+		return false
+	}
+	for key := range rule {
+		if key != "action" && key != "metadata" {
+			return false
+		}
+	}
+	return true
+}
+
+// calicoSelectsAll reports whether a Calico selector selects every endpoint
+func calicoSelectsAll(selector string) bool {
+	selector = strings.TrimSpace(selector)
+	return selector == "" || selector == "all()"
+}
+
+var (
+	calicoHasPattern   = regexp.MustCompile(`^(!?)\s*has\(\s*([\w./-]+)\s*\)$`)
+	calicoEqualPattern = regexp.MustCompile(`^([\w./-]+)\s*(==|!=)\s*['"]([^'"]*)['"]$`)
+	calicoInPattern    = regexp.MustCompile(`^([\w./-]+)\s+(in|not in)\s*\{([^}]*)\}$`)
+)
+
+// calicoSelectorMatches evaluates a Calico selector against pod labels. Only
+// conjunctions of all(), has(), ==, != and in are understood; any other
+// selector is taken to select nothing.
+func calicoSelectorMatches(selector string, labels map[string]string) bool {
+	if calicoSelectsAll(selector) {
+		return true
+	}
+	if strings.Contains(selector, "||") {
+		return false
+	}
+	for _, term := range strings.Split(selector, "&&") {
+		term = strings.TrimSpace(term)
+		if term == "all()" {
+			continue
+		}
+		if m := calicoHasPattern.FindStringSubmatch(term); m != nil {
+			_, exists := labels[m[2]]
+			if exists == (m[1] == "!") {
+				return false
+			}
+			continue
+		}
+		if m := calicoEqualPattern.FindStringSubmatch(term); m != nil {
+			value, exists := labels[m[1]]
+			if (m[2] == "==") != (exists && value == m[3]) {
+				return false
+			}
+			continue
+		}
+		if m := calicoInPattern.FindStringSubmatch(term); m != nil {
+			value, exists := labels[m[1]]
+			in := false
+			for _, v := range strings.Split(m[3], ",") {
+				if exists && strings.Trim(strings.TrimSpace(v), `'"`) == value {
+					in = true
+				}
+			}
+			if (m[2] == "in") != in {
+				return false
+			}
+			continue
+		}
+		return false
+	}
+	return true
+}
@@ -0,0 +1,171 @@
+package scanner
+
+import "testing"
+
+func TestParseNetworkPolicy(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		ok          bool
+		clusterWide bool
+		denyIngress bool
+		denyEgress  bool
+		selects     bool // selects a pod labelled app=api
+	}{
+		{
+			name: "kubernetes default deny",
+			content: `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: default-deny
+spec:
+  podSelector: {}
+  policyTypes: [Ingress, Egress]
+`,
+			ok: true, denyIngress: true, denyEgress: true, selects: true,
+		},
+		{
+			name: "kubernetes allow all",
+			content: `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: allow-all
+spec:
+  podSelector: {}
+  ingress:
+  - {}
+`,
+			ok: true, selects: true,
+		},
+		{
+			name: "kubernetes selected pods",
+			content: `apiVersion: networking.k8s.io/v1
+kind: NetworkPolicy
+metadata:
+  name: api
+spec:
+  podSelector:
+    matchLabels:
+      app: api
+  ingress:
+  - from:
+    - podSelector:
+        matchLabels:
+          app: web
+`,
+			ok: true, selects: true,
+		},
+		{
+			name: "cilium default deny",
+			content: `apiVersion: cilium.io/v2
+kind: CiliumNetworkPolicy
+metadata:
+  name: default-deny
+spec:
+  endpointSelector: {}
+  ingress:
+  - {}
+`,
+			ok: true, denyIngress: true, selects: true,
+		},
+		{
+			name: "cilium clusterwide prefixed labels",
+			content: `apiVersion: cilium.io/v2
+kind: CiliumClusterwideNetworkPolicy
+metadata:
+  name: api
+specs:
+- endpointSelector:
+    matchLabels:
+      k8s:app: api
+  egress:
+  - toEntities: [all]
+`,
+			ok: true, clusterWide: true, selects: true,
+		},
+		{
+			name: "calico global default deny",
+			content: `apiVersion: projectcalico.org/v3
+kind: GlobalNetworkPolicy
+metadata:
+  name: default-deny
+spec:
+  selector: all()
+  types: [Ingress, Egress]
+`,
+			ok: true, clusterWide: true, denyIngress: true, denyEgress: true, selects: true,
+		},
+		{
+			name: "calico selector",
+			content: `apiVersion: projectcalico.org/v3
+kind: NetworkPolicy
+metadata:
+  name: web
+spec:
+  selector: app == 'web'
+  ingress:
+  - action: Allow
+`,
+			ok: true,
+		},
+		{
+			name: "calico global policy for some namespaces",
+			content: `apiVersion: projectcalico.org/v3
+kind: GlobalNetworkPolicy
+metadata:
+  name: prod
+spec:
+  namespaceSelector: env == 'prod'
+  selector: all()
+`,
+		},
+		{
+			name:    "not a policy",
+			content: "apiVersion: v1\nkind: Service\nmetadata:\n  name: api\nspec: {}\n",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			obj := parseK8sManifests(tt.content, "policy.yaml")[0]
+			p, ok := parseNetworkPolicy(&obj)
+			if ok != tt.ok {
+				t.Fatalf("expected ok %v, got %v", tt.ok, ok)
+			}
+			if !ok {
+				return
+			}
+			if p.ClusterWide != tt.clusterWide || p.DenyIngress() != tt.denyIngress || p.DenyEgress() != tt.denyEgress {
+				t.Errorf("unexpected policy %+v", p)
+			}
+			if got := p.Selects("default", map[string]string{"app": "api"}); got != tt.selects {
+				t.Errorf("expected selects %v, got %v", tt.selects, got)
+			}
+			if !tt.clusterWide && p.Selects("other", map[string]string{"app": "api"}) {
+				t.Error("expected a namespaced policy not to select pods in other namespaces")
+			}
+		})
+	}
+}
+
+func TestCalicoSelectorMatches(t *testing.T) {
+	labels := map[string]string{"app": "api", "tier": "backend"}
+	tests := []struct {
+		selector string
+		expected bool
+	}{
+		{"", true},
+		{"all()", true},
+		{"app == 'api'", true},
+		{`app == "web"`, false},
+		{"app != 'web' && has(tier)", true},
+		{"!has(tier)", false},
+		{"tier in {'frontend', 'backend'}", true},
+		{"tier not in {'backend'}", false},
+		{"app == 'api' || app == 'web'", false},
+	}
+	for _, tt := range tests {
+		if got := calicoSelectorMatches(tt.selector, labels); got != tt.expected {
+			t.Errorf("%q: expected %v, got %v", tt.selector, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,249 @@
+package scanner
+
+import (
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+)
+
+// OSV range types
+const (
+	osvRangeSemver    = "SEMVER"
+	osvRangeEcosystem = "ECOSYSTEM"
+)
+
+// osvAdvisory is an advisory in the OSV schema, reduced to the fields used
+// for matching and reporting
+type osvAdvisory struct {
+	ID               string        `json:"id"`
+	Summary          string        `json:"summary"`
+	Aliases          []string      `json:"aliases"`
+	Modified         time.Time     `json:"modified"`
+	Withdrawn        *time.Time    `json:"withdrawn"`
+	Affected         []osvAffected `json:"affected"`
+	DatabaseSpecific struct {
+		Severity string `json:"severity"`
+	} `json:"database_specific"`
+}
+
+// osvAffected is a package an advisory affects and the affected versions
+type osvAffected struct {
+	Package struct {
+		Ecosystem string `json:"ecosystem"`
+		Name      string `json:"name"`
+	} `json:"package"`
+	Ranges   []osvRange `json:"ranges"`
+	Versions []string   `json:"versions"`
+}
+
+// osvRange is a range of affected versions, bounded by introduced, fixed
+// and last_affected events
+type osvRange struct {
+	Type   string     `json:"type"`
+	Events []osvEvent `json:"events"`
+}
+
+// osvEvent is an event of an affected range. Exactly one field is set.
+type osvEvent struct {
+	Introduced   string `json:"introduced"`
+	Fixed        string `json:"fixed"`
+	LastAffected string `json:"last_affected"`
+}
+
+// version returns the version of the event
+func (e osvEvent) version() string {
+	return e.Introduced + e.Fixed + e.LastAffected
+}
+
+// osvDatabase is an offline snapshot of OSV advisories indexed by package
+type osvDatabase struct {
+	Advisories int
+	Snapshot   time.Time // when the newest advisory was modified
+	packages   map[string][]osvPackageAdvisory
+}
+
+// osvPackageAdvisory is an advisory and what it says about one package
+type osvPackageAdvisory struct {
+	Advisory *osvAdvisory
+	Affected *osvAffected
+}
+
+// osvMatch is an advisory affecting a dependency and the versions that fix it
+type osvMatch struct {
+	Advisory *osvAdvisory
+	Fixed    []string
+}
+
+// pypiNameSeparators are normalized in PyPI package names (PEP 503)
+var pypiNameSeparators = regexp.MustCompile(`[-_.]+`)
+
+// osvPackageKey identifies a package of an ecosystem. PyPI names are
+// normalized; other ecosystems compare names exactly.
+func osvPackageKey(ecosystem, name string) string {
+	if ecosystem == osvPyPI {
+		name = pypiNameSeparators.ReplaceAllString(strings.ToLower(name), "-")
+	}
+	return ecosystem + "|" + name
+}
+
+// loadOSVDatabase loads an OSV JSON export: a directory of advisory files,
+// one per advisory, and the all.zip archives osv.dev publishes per
+// ecosystem. Advisories for ecosystems the scanner does not inventory, and
+// withdrawn advisories, are skipped.
+func loadOSVDatabase(dir string) (*osvDatabase, error) {
+	info, err := os.Stat(dir)
+	if err != nil {
+		return nil, err
+	}
+	if !info.IsDir() {
+		return nil, fmt.Errorf("%s is not a directory", dir)
+	}
+
+	db := &osvDatabase{packages: make(map[string][]osvPackageAdvisory)}
+	err = filepath.WalkDir(dir, func(path string, entry fs.DirEntry, err error) error {
+		if err != nil || entry.IsDir() {
+			return err
+		}
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".json":
+			// #nosec G304 - the database directory is chosen by the user
+			data, err := os.ReadFile(path)
+			if err != nil {
+				return err
+			}
+			db.add(data)
+		case ".zip":
+			return db.addArchive(path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	if db.Advisories == 0 {
+		return nil, fmt.Errorf("no OSV advisories found in %s", dir)
+	}
+	return db, nil
+}
+
+// addArchive adds the advisories of a zip archive
+func (db *osvDatabase) addArchive(path string) error {
+	archive, err := zip.OpenReader(path)
+	if err != nil {
+		return fmt.Errorf("%s: %w", path, err)
+	}
+	defer archive.Close()
+	for _, file := range archive.File {
+		if !strings.EqualFold(filepath.Ext(file.Name), ".json") {
+			continue
+		}
+		r, err := file.Open()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		data, err := io.ReadAll(r)
+		r.Close()
+		if err != nil {
+			return fmt.Errorf("%s: %w", path, err)
+		}
+		db.add(data)
+	}
+	return nil
+}
+
+// add indexes an advisory. Files that are not OSV advisories are ignored.
+func (db *osvDatabase) add(data []byte) {
+	var advisory osvAdvisory
+	if err := json.Unmarshal(data, &advisory); err != nil || advisory.ID == "" || advisory.Withdrawn != nil {
+		return
+	}
+	added := false
+	for i := range advisory.Affected {
+		affected := &advisory.Affected[i]
+		// ecosystems may name a release or repository, as in Maven:https://...
+		ecosystem, _, _ := strings.Cut(affected.Package.Ecosystem, ":")
+		if _, ok := osvVersionComparers[ecosystem]; !ok || affected.Package.Name == "" {
+			continue
+		}
+		key := osvPackageKey(ecosystem, affected.Package.Name)
+		db.packages[key] = append(db.packages[key], osvPackageAdvisory{Advisory: &advisory, Affected: affected})
+		added = true
+	}
+	if !added {
+		return
+	}
+	db.Advisories++
+	if advisory.Modified.After(db.Snapshot) {
+		db.Snapshot = advisory.Modified
+	}
+}
+
+// Match returns the advisories affecting a version of a package
+func (db *osvDatabase) Match(ecosystem, name, version string) []osvMatch {
+	var matches []osvMatch
+	for _, pa := range db.packages[osvPackageKey(ecosystem, name)] {
+		if fixed, ok := osvAffects(pa.Affected, ecosystem, version); ok {
+			matches = append(matches, osvMatch{Advisory: pa.Advisory, Fixed: fixed})
+		}
+	}
+	sort.Slice(matches, func(i, j int) bool { return matches[i].Advisory.ID < matches[j].Advisory.ID })
+	return matches
+}
+
+// osvAffects reports whether a version is affected, either listed
+// explicitly or within a SEMVER or ECOSYSTEM range, and returns the first
+// fixed version after it in each range. Git commit ranges are not
+// evaluated.
+func osvAffects(affected *osvAffected, ecosystem, version string) ([]string, bool) {
+	compare := osvVersionComparers[ecosystem]
+	hit := slices.ContainsFunc(affected.Versions, func(v string) bool { return compare(v, version) == 0 })
+	var fixed []string
+	for _, r := range affected.Ranges {
+		cmp := compare
+		switch r.Type {
+		case osvRangeSemver:
+			cmp = compareSemver
+		case osvRangeEcosystem:
+		default:
+			continue
+		}
+		events := slices.Clone(r.Events)
+		sort.SliceStable(events, func(i, j int) bool {
+			if events[i].Introduced == "0" || events[j].Introduced == "0" {
+				return events[i].Introduced == "0" && events[j].Introduced != "0"
+			}
+			return cmp(events[i].version(), events[j].version()) < 0
+		})
+
+		inRange := false
+		for _, e := range events {
+			c := 1
+			if e.Introduced != "0" {
+				c = cmp(version, e.version())
+			}
+			if c < 0 || e.LastAffected != "" && c == 0 {
+				if inRange && e.Fixed != "" {
+					fixed = append(fixed, e.Fixed)
+				}
+				break
+			}
+			switch {
+			case e.Introduced != "":
+				inRange = true
+			case e.Fixed != "" || e.LastAffected != "":
+				inRange = false
+			}
+		}
+		hit = hit || inRange
+	}
+	return fixed, hit
+}
@@ -0,0 +1,147 @@
+package scanner
+
+import (
+	"archive/zip"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+)
+
+const osvTestAdvisory = `{
+  "id": "GHSA-aaaa-bbbb-cccc",
+  "modified": "2026-09-30T12:00:00Z",
+  "aliases": ["CVE-2026-0001"],
+  "summary": "Prototype pollution",
+  "affected": [{
+    "package": {"ecosystem": "npm", "name": "lodash"},
+    "ranges": [{"type": "SEMVER", "events": [{"introduced": "0"}, {"fixed": "4.17.21"}]}]
+  }],
+  "database_specific": {"severity": "HIGH"}
+}`
+
+// writeOSVExport writes advisories as files of an OSV export directory,
+// and zipped advisories as an all.zip archive
+func writeOSVExport(t *testing.T, files map[string]string, zipped map[string]string) string {
+	t.Helper()
+	dir := t.TempDir()
+	for name, content := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if len(zipped) > 0 {
+		f, err := os.Create(filepath.Join(dir, "all.zip"))
+		if err != nil {
+			t.Fatal(err)
+		}
+		w := zip.NewWriter(f)
+		for name, content := range zipped {
+			entry, err := w.Create(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := entry.Write([]byte(content)); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := w.Close(); err != nil {
+			t.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			t.Fatal(err)
+		}
+	}
+	return dir
+}
+
+func TestLoadOSVDatabase(t *testing.T) {
+	dir := writeOSVExport(t, map[string]string{
+		"npm/GHSA-aaaa-bbbb-cccc.json": osvTestAdvisory,
+		"Debian/DSA-1.json":            `{"id": "DSA-1", "modified": "2026-10-10T00:00:00Z", "affected": [{"package": {"ecosystem": "Debian:12", "name": "openssl"}}]}`,
+		"npm/GHSA-withdrawn.json":      `{"id": "GHSA-withdrawn", "withdrawn": "2026-01-01T00:00:00Z", "affected": [{"package": {"ecosystem": "npm", "name": "lodash"}}]}`,
+		"README.md":                    "not an advisory",
+	}, map[string]string{
+		"PYSEC-2026-1.json": `{
+  "id": "PYSEC-2026-1",
+  "modified": "2026-08-01T00:00:00Z",
+  "affected": [{
+    "package": {"ecosystem": "PyPI", "name": "Django"},
+    "ranges": [{"type": "ECOSYSTEM", "events": [{"introduced": "4.2"}, {"fixed": "4.2.11"}, {"introduced": "5.0"}, {"fixed": "5.0.3"}]}]
+  }]
+}`,
+	})
+
+	db, err := loadOSVDatabase(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Debian and withdrawn advisories are skipped
+	if db.Advisories != 2 {
+		t.Errorf("expected 2 advisories, got %d", db.Advisories)
+	}
+	if got := db.Snapshot.Format("2006-01-02"); got != "2026-09-30" {
+		t.Errorf("expected the snapshot of the newest advisory, got %s", got)
+	}
+
+	matches := db.Match(osvNPM, "lodash", "4.17.20")
+	if len(matches) != 1 || matches[0].Advisory.ID != "GHSA-aaaa-bbbb-cccc" || !slices.Equal(matches[0].Fixed, []string{"4.17.21"}) {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+	if matches := db.Match(osvNPM, "lodash", "4.17.21"); len(matches) != 0 {
+		t.Errorf("expected the fixed version not to match, got %+v", matches)
+	}
+
+	// PyPI names are normalized and versions compared by PEP 440
+	matches = db.Match(osvPyPI, "django", "5.0.2")
+	if len(matches) != 1 || !slices.Equal(matches[0].Fixed, []string{"5.0.3"}) {
+		t.Errorf("unexpected matches %+v", matches)
+	}
+	if matches := db.Match(osvPyPI, "Django", "4.2.11"); len(matches) != 0 {
+		t.Errorf("expected 4.2.11 to be fixed, got %+v", matches)
+	}
+}
+
+func TestLoadOSVDatabaseErrors(t *testing.T) {
+	if _, err := loadOSVDatabase(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("expected an error for a missing directory")
+	}
+	if _, err := loadOSVDatabase(writeOSVExport(t, map[string]string{"notes.json": "{}"}, nil)); err == nil {
+		t.Error("expected an error for a directory without advisories")
+	}
+}
+
+func TestOSVAffects(t *testing.T) {
+	// events out of order, a last_affected bound and an explicit version
+	affected := &osvAffected{
+		Ranges: []osvRange{{
+			Type:   osvRangeEcosystem,
+			Events: []osvEvent{{Introduced: "2.0.0"}, {LastAffected: "2.3.1"}, {Introduced: "1.0.0"}, {Fixed: "1.4.2"}},
+		}},
+		Versions: []string{"0.9.0"},
+	}
+
+	tests := []struct {
+		version  string
+		expected bool
+		fixed    []string
+	}{
+		{"0.9.0", true, nil},
+		{"0.9.5", false, nil},
+		{"1.0.0", true, []string{"1.4.2"}},
+		{"1.4.2", false, nil},
+		{"1.9.0", false, nil},
+		{"2.3.1", true, nil},
+		{"2.3.2", false, nil},
+	}
+	for _, tt := range tests {
+		fixed, ok := osvAffects(affected, osvMaven, tt.version)
+		if ok != tt.expected || !slices.Equal(fixed, tt.fixed) {
+			t.Errorf("%s: expected %v %v, got %v %v", tt.version, tt.expected, tt.fixed, ok, fixed)
+		}
+	}
+}
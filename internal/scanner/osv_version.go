@@ -0,0 +1,310 @@
+package scanner
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// OSV ecosystems of the dependency ecosystems. Gradle resolves Maven
+// artifacts.
+const (
+	osvGo       = "Go"
+	osvNPM      = "npm"
+	osvPyPI     = "PyPI"
+	osvCratesIO = "crates.io"
+	osvRubyGems = "RubyGems"
+	osvMaven    = "Maven"
+)
+
+// osvVersionComparers order the versions of each OSV ecosystem. Each returns
+// a negative number, zero or a positive number as a is older than, the same
+// as or newer than b.
+var osvVersionComparers = map[string]func(a, b string) int{
+	osvGo:       compareSemver,
+	osvNPM:      compareSemver,
+	osvCratesIO: compareSemver,
+	osvPyPI:     comparePEP440,
+	osvRubyGems: compareRubyGems,
+	osvMaven:    compareMaven,
+}
+
+// compareInts orders two integers
+func compareInts(a, b int) int {
+	switch {
+	case a < b:
+		return -1
+	case a > b:
+		return 1
+	}
+	return 0
+}
+
+// atoi parses a version number, treating anything else as 0
+func atoi(s string) int {
+	n, _ := strconv.Atoi(s)
+	return n
+}
+
+// compareSemver orders Semantic Versioning 2.0 versions, which Go modules,
+// npm and crates.io use. A leading v or = is ignored, missing minor and
+// patch numbers are 0, and build metadata does not affect the order.
+func compareSemver(a, b string) int {
+	coreA, preA := splitSemver(a)
+	coreB, preB := splitSemver(b)
+	for i := 0; i < 3; i++ {
+		if c := compareInts(coreA[i], coreB[i]); c != 0 {
+			return c
+		}
+	}
+	switch {
+	case preA == "" && preB == "":
+		return 0
+	case preA == "":
+		return 1
+	case preB == "":
+		return -1
+	}
+
+	// pre-release identifiers: numbers sort numerically and before words
+	idsA, idsB := strings.Split(preA, "."), strings.Split(preB, ".")
+	for i := 0; i < len(idsA) && i < len(idsB); i++ {
+		numA, errA := strconv.Atoi(idsA[i])
+		numB, errB := strconv.Atoi(idsB[i])
+		switch {
+		case errA == nil && errB == nil:
+			if c := compareInts(numA, numB); c != 0 {
+				return c
+			}
+		case errA == nil:
+			return -1
+		case errB == nil:
+			return 1
+		default:
+			if c := strings.Compare(idsA[i], idsB[i]); c != 0 {
+				return c
+			}
+		}
+	}
+	return compareInts(len(idsA), len(idsB))
+}
+
+// splitSemver splits a version into its major, minor and patch numbers and
+// its pre-release
+func splitSemver(v string) ([3]int, string) {
+	v = strings.TrimLeft(strings.TrimSpace(v), "v=")
+	v, _, _ = strings.Cut(v, "+")
+	core, pre, _ := strings.Cut(v, "-")
+	var numbers [3]int
+	for i, part := range strings.SplitN(core, ".", 3) {
+		numbers[i] = atoi(part)
+	}
+	return numbers, pre
+}
+
+// pep440Pattern parses a PEP 440 version into its epoch, release,
+// pre-release, post-release and development release
+var pep440Pattern = regexp.MustCompile(`^v?(?:(\d+)!)?(\d+(?:\.\d+)*)` +
+	`(?:[-_.]?(a|alpha|b|beta|c|rc|pre|preview)[-_.]?(\d*))?` +
+	`(?:-(\d+)|[-_.]?(post|rev|r)[-_.]?(\d*))?` +
+	`(?:[-_.]?(dev)[-_.]?(\d*))?(?:\+[a-z0-9._-]*)?$`)
+
+// pep440Key is a PEP 440 version in comparable form
+type pep440Key struct {
+	Epoch   int
+	Release []int
+	Pre     [2]int // phase (-1 for dev-only releases, 3 for final) and number
+	Post    int    // -1 without a post-release
+	Dev     int    // max int without a development release
+}
+
+// comparePEP440 orders PyPI versions following PEP 440. Versions that do not
+// parse fall back to semver ordering.
+func comparePEP440(a, b string) int {
+	keyA, okA := parsePEP440(a)
+	keyB, okB := parsePEP440(b)
+	if !okA || !okB {
+		return compareSemver(a, b)
+	}
+	if c := compareInts(keyA.Epoch, keyB.Epoch); c != 0 {
+		return c
+	}
+	for i := 0; i < len(keyA.Release) || i < len(keyB.Release); i++ {
+		var x, y int
+		if i < len(keyA.Release) {
+			x = keyA.Release[i]
+		}
+		if i < len(keyB.Release) {
+			y = keyB.Release[i]
+		}
+		if c := compareInts(x, y); c != 0 {
+			return c
+		}
+	}
+	for _, pair := range [][2]int{
+		{keyA.Pre[0], keyB.Pre[0]}, {keyA.Pre[1], keyB.Pre[1]}, {keyA.Post, keyB.Post}, {keyA.Dev, keyB.Dev},
+	} {
+		if c := compareInts(pair[0], pair[1]); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// parsePEP440 parses a PyPI version
+func parsePEP440(v string) (pep440Key, bool) {
+	m := pep440Pattern.FindStringSubmatch(strings.ToLower(strings.TrimSpace(v)))
+	if m == nil {
+		return pep440Key{}, false
+	}
+	key := pep440Key{Epoch: atoi(m[1]), Pre: [2]int{3, 0}, Post: -1, Dev: int(^uint(0) >> 1)}
+	for _, part := range strings.Split(m[2], ".") {
+		key.Release = append(key.Release, atoi(part))
+	}
+	switch m[3] {
+	case "a", "alpha":
+		key.Pre = [2]int{0, atoi(m[4])}
+	case "b", "beta":
+		key.Pre = [2]int{1, atoi(m[4])}
+	case "c", "rc", "pre", "preview":
+		key.Pre = [2]int{2, atoi(m[4])}
+	}
+	if m[5] != "" || m[6] != "" {
+		key.Post = atoi(m[5] + m[7])
+	}
+	if m[8] != "" {
+		key.Dev = atoi(m[9])
+		if m[3] == "" && key.Post < 0 {
+			// 1.0.dev1 comes before 1.0a1
+			key.Pre = [2]int{-1, 0}
+		}
+	}
+	return key, true
+}
+
+// versionSegmentPattern splits a version into runs of digits and letters
+var versionSegmentPattern = regexp.MustCompile(`\d+|[a-z]+`)
+
+// compareRubyGems orders RubyGems versions like Gem::Version: segments are
+// compared in turn, missing segments are 0 and a segment with letters marks
+// a pre-release that sorts before any number
+func compareRubyGems(a, b string) int {
+	segsA := versionSegmentPattern.FindAllString(strings.ToLower(a), -1)
+	segsB := versionSegmentPattern.FindAllString(strings.ToLower(b), -1)
+	for i := 0; i < len(segsA) || i < len(segsB); i++ {
+		x, y := "0", "0"
+		if i < len(segsA) {
+			x = segsA[i]
+		}
+		if i < len(segsB) {
+			y = segsB[i]
+		}
+		numX, errX := strconv.Atoi(x)
+		numY, errY := strconv.Atoi(y)
+		var c int
+		switch {
+		case errX == nil && errY == nil:
+			c = compareInts(numX, numY)
+		case errX == nil:
+			c = 1
+		case errY == nil:
+			c = -1
+		default:
+			c = strings.Compare(x, y)
+		}
+		if c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mavenQualifiers rank the well-known Maven qualifiers. Releases rank 5;
+// unknown qualifiers sort after all of them.
+var mavenQualifiers = map[string]int{
+	"alpha": 0, "a": 0,
+	"beta": 1, "b": 1,
+	"milestone": 2, "m": 2,
+	"rc": 3, "cr": 3,
+	"snapshot": 4,
+	"":         5, "ga": 5, "final": 5, "release": 5,
+	"sp": 6,
+}
+
+// mavenItem is a number or qualifier of a Maven version
+type mavenItem struct {
+	Number    int
+	Qualifier string
+	IsNumber  bool
+}
+
+// compare orders two items; numbers sort after qualifiers
+func (x mavenItem) compare(y mavenItem) int {
+	switch {
+	case x.IsNumber && y.IsNumber:
+		return compareInts(x.Number, y.Number)
+	case x.IsNumber:
+		return 1
+	case y.IsNumber:
+		return -1
+	}
+	rankX, knownX := mavenQualifiers[x.Qualifier]
+	rankY, knownY := mavenQualifiers[y.Qualifier]
+	switch {
+	case knownX && knownY:
+		return compareInts(rankX, rankY)
+	case knownX:
+		return -1
+	case knownY:
+		return 1
+	}
+	return strings.Compare(x.Qualifier, y.Qualifier)
+}
+
+// compareMaven approximates Maven's ComparableVersion: a version is split
+// into numbers and qualifiers at dots, hyphens and changes between digits
+// and letters, trailing zeros and release qualifiers are dropped, and
+// pre-release qualifiers such as alpha, rc and SNAPSHOT sort before the
+// release
+func compareMaven(a, b string) int {
+	itemsA, itemsB := mavenItems(a), mavenItems(b)
+	zero := mavenItem{IsNumber: true}
+	for i := 0; i < len(itemsA) || i < len(itemsB); i++ {
+		x, y := zero, zero
+		if i < len(itemsA) {
+			x = itemsA[i]
+		} else if !itemsB[i].IsNumber {
+			x = mavenItem{}
+		}
+		if i < len(itemsB) {
+			y = itemsB[i]
+		} else if !itemsA[i].IsNumber {
+			y = mavenItem{}
+		}
+		if c := x.compare(y); c != 0 {
+			return c
+		}
+	}
+	return 0
+}
+
+// mavenItems splits a Maven version into items, dropping trailing zeros
+// and release qualifiers
+func mavenItems(v string) []mavenItem {
+	var items []mavenItem
+	for _, seg := range versionSegmentPattern.FindAllString(strings.ToLower(v), -1) {
+		if n, err := strconv.Atoi(seg); err == nil {
+			items = append(items, mavenItem{Number: n, IsNumber: true})
+		} else {
+			items = append(items, mavenItem{Qualifier: seg})
+		}
+	}
+	for len(items) > 0 {
+		last := items[len(items)-1]
+		if rank, known := mavenQualifiers[last.Qualifier]; last.IsNumber && last.Number != 0 || !last.IsNumber && (!known || rank != 5) {
+			break
+		}
+		items = items[:len(items)-1]
+	}
+	return items
+}
@@ -0,0 +1,40 @@
+package scanner
+
+import "testing"
+
+func TestOSVVersionComparers(t *testing.T) {
+	tests := []struct {
+		ecosystem string
+		a, b      string
+		expected  int
+	}{
+		{osvGo, "v1.2.3", "1.2.3", 0},
+		{osvGo, "0.0.0-20230101000000-abcdef123456", "0.1.0", -1},
+		{osvNPM, "1.10.0", "1.9.9", 1},
+		{osvNPM, "2.0.0-rc.1", "2.0.0", -1},
+		{osvNPM, "2.0.0-alpha.10", "2.0.0-alpha.9", 1},
+		{osvNPM, "2.0.0-alpha", "2.0.0-alpha.1", -1},
+		{osvCratesIO, "1.0.0+build", "1.0.0", 0},
+		{osvPyPI, "2.0", "2.0.0", 0},
+		{osvPyPI, "1.0.dev1", "1.0a1", -1},
+		{osvPyPI, "1.0rc1", "1.0", -1},
+		{osvPyPI, "1.0.post1", "1.0", 1},
+		{osvPyPI, "1!0.5", "2.0", 1},
+		{osvPyPI, "1.10", "1.9", 1},
+		{osvRubyGems, "1.0.0.pre", "1.0.0", -1},
+		{osvRubyGems, "1.10", "1.9.1", 1},
+		{osvRubyGems, "7.1", "7.1.0", 0},
+		{osvMaven, "1.0", "1.0.0", 0},
+		{osvMaven, "1.0-alpha-1", "1.0", -1},
+		{osvMaven, "1.0-SNAPSHOT", "1.0-rc1", 1},
+		{osvMaven, "1.0.RELEASE", "1.0", 0},
+		{osvMaven, "2.16.1", "2.9", 1},
+		{osvMaven, "1.0-sp1", "1.0", 1},
+	}
+	for _, tt := range tests {
+		got := osvVersionComparers[tt.ecosystem](tt.a, tt.b)
+		if got != tt.expected {
+			t.Errorf("%s %s vs %s: expected %d, got %d", tt.ecosystem, tt.a, tt.b, tt.expected, got)
+		}
+	}
+}
@@ -0,0 +1,284 @@
+package scanner
+
+import (
+	"errors"
+	"io"
+	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// OpenTelemetry setup kinds
+const (
+	otelTracerProvider = "tracer provider"
+	otelTraceExporter  = "trace exporter"
+	otelMeterProvider  = "meter provider"
+	otelMetricExporter = "metric exporter"
+	otelPropagation    = "propagation"
+	otelAgent          = "auto-instrumentation agent"
+)
+
+// otelSetup is one OpenTelemetry SDK call, or an agent that instruments a
+// process without code changes
+type otelSetup struct {
+	Kind     string
+	Language string
+	Detail   string // the matched call, e.g. sdktrace.NewTracerProvider(
+	File     string
+	Line     int
+}
+
+// otelPattern recognizes one kind of setup call in one language
+type otelPattern struct {
+	kind    string
+	pattern *regexp.Regexp
+}
+
+// otelLanguage groups the setup patterns of an SDK. A file is only searched
+// when it references the SDK's package.
+type otelLanguage struct {
+	name     string
+	exts     []string
+	marker   string
+	patterns []otelPattern
+}
+
+var otelLanguages = []otelLanguage{
+	{
+		name:   "go",
+		exts:   []string{".go"},
+		marker: "go.opentelemetry.io/",
+		patterns: []otelPattern{
+			{otelTracerProvider, regexp.MustCompile(`\b\w*trace\.NewTracerProvider\(`)},
+			{otelTraceExporter, regexp.MustCompile(`\b(?:otlptrace(?:grpc|http)?|stdouttrace|jaeger|zipkin)\.New\w*\(`)},
+			{otelMeterProvider, regexp.MustCompile(`\b\w*metric\.NewMeterProvider\(`)},
+			{otelMetricExporter, regexp.MustCompile(`\b(?:otlpmetric(?:grpc|http)|stdoutmetric)\.New\w*\(|\b\w*metric\.NewPeriodicReader\(`)},
+			{otelPropagation, regexp.MustCompile(`\botel\.SetTextMapPropagator\(|\bpropagation\.TraceContext\{|\b(?:otelhttp|otelgrpc|otelgin|otelmux|otelecho|otelfiber|otelchi)\.(?:New\w*|Middleware|\w+Interceptor)\(`)},
+		},
+	},
+	{
+		name:   "python",
+		exts:   []string{".py"},
+		marker: "opentelemetry",
+		patterns: []otelPattern{
+			{otelTracerProvider, regexp.MustCompile(`\bTracerProvider\(`)},
+			{otelTraceExporter, regexp.MustCompile(`\b(?:OTLPSpanExporter|ConsoleSpanExporter|JaegerExporter|ZipkinExporter)\(`)},
+			{otelMeterProvider, regexp.MustCompile(`\bMeterProvider\(`)},
+			{otelMetricExporter, regexp.MustCompile(`\b(?:OTLPMetricExporter|ConsoleMetricExporter|PrometheusMetricReader)\(`)},
+			{otelPropagation, regexp.MustCompile(`\bset_global_textmap\(|\b\w+Instrumentor\(\)\.instrument(?:_app)?\(|\bOpenTelemetryMiddleware\(`)},
+		},
+	},
+	{
+		name:   "node",
+		exts:   []string{".js", ".mjs", ".cjs", ".ts"},
+		marker: "@opentelemetry/",
+		patterns: []otelPattern{
+			// NodeSDK exports over OTLP unless OTEL_TRACES_EXPORTER says otherwise
+			{otelTracerProvider, regexp.MustCompile(`new\s+(?:NodeTracerProvider|WebTracerProvider|BasicTracerProvider|NodeSDK)\(`)},
+			{otelTraceExporter, regexp.MustCompile(`new\s+(?:OTLPTraceExporter|JaegerExporter|ZipkinExporter|ConsoleSpanExporter|NodeSDK)\(`)},
+			{otelMeterProvider, regexp.MustCompile(`new\s+MeterProvider\(|\bmetricReader\s*:`)},
+			{otelMetricExporter, regexp.MustCompile(`new\s+(?:OTLPMetricExporter|PrometheusExporter|ConsoleMetricExporter)\(`)},
+			{otelPropagation, regexp.MustCompile(`\bpropagation\.setGlobalPropagator\(|new\s+W3CTraceContextPropagator\(|\bgetNodeAutoInstrumentations\(|new\s+(?:Http|Express|Grpc|Fastify|Koa|Undici)Instrumentation\(`)},
+		},
+	},
+	{
+		name:   "java",
+		exts:   []string{".java", ".kt"},
+		marker: "io.opentelemetry",
+		patterns: []otelPattern{
+			// autoconfigure reads exporters and propagators from OTEL_* settings
+			{otelTracerProvider, regexp.MustCompile(`\bSdkTracerProvider\.builder\(|\bAutoConfiguredOpenTelemetrySdk\.`)},
+			{otelTraceExporter, regexp.MustCompile(`\b(?:Otlp(?:Grpc|Http)SpanExporter|LoggingSpanExporter|ZipkinSpanExporter|JaegerGrpcSpanExporter)\.|\bAutoConfiguredOpenTelemetrySdk\.`)},
+			{otelMeterProvider, regexp.MustCompile(`\bSdkMeterProvider\.builder\(|\bAutoConfiguredOpenTelemetrySdk\.`)},
+			{otelMetricExporter, regexp.MustCompile(`\b(?:Otlp(?:Grpc|Http)MetricExporter|LoggingMetricExporter|PrometheusHttpServer)\.|\bAutoConfiguredOpenTelemetrySdk\.`)},
+			{otelPropagation, regexp.MustCompile(`\bW3CTraceContextPropagator\.getInstance\(|\bContextPropagators\.create\(|\bAutoConfiguredOpenTelemetrySdk\.|\b\w+Telemetry\.(?:create|builder)\(`)},
+		},
+	},
+}
+
+// otelAgentPattern matches agents that set up tracing, metrics and
+// propagation from the command line: the Java agent, the Python
+// opentelemetry-instrument wrapper and the Node register hook
+var otelAgentPattern = regexp.MustCompile(`-javaagent:\S*opentelemetry-javaagent[\w.-]*\.jar|\bopentelemetry-instrument\s|@opentelemetry/auto-instrumentations-node/register`)
+
+// parseOTelSetup returns the OpenTelemetry setup calls and agents in a file
+func parseOTelSetup(content, relPath string) []otelSetup {
+	var setups []otelSetup
+	add := func(kind, language string, m []int) {
+		setups = append(setups, otelSetup{
+			Kind:     kind,
+			Language: language,
+			Detail:   strings.TrimSpace(content[m[0]:m[1]]),
+			File:     relPath,
+			Line:     strings.Count(content[:m[0]], "\n") + 1,
+		})
+	}
+
+	ext := strings.ToLower(filepath.Ext(relPath))
+	for _, lang := range otelLanguages {
+		if !slices.Contains(lang.exts, ext) || !strings.Contains(content, lang.marker) {
+			continue
+		}
+		for _, p := range lang.patterns {
+			for _, m := range p.pattern.FindAllStringIndex(content, -1) {
+				add(p.kind, lang.name, m)
+			}
+		}
+	}
+	for _, m := range otelAgentPattern.FindAllStringIndex(content, -1) {
+		language := "node"
+		switch {
+		case strings.Contains(content[m[0]:m[1]], "javaagent"):
+			language = "java"
+		case strings.Contains(content[m[0]:m[1]], "opentelemetry-instrument"):
+			language = "python"
+		}
+		add(otelAgent, language, m)
+	}
+	return setups
+}
+
+// otelPipeline is a pipeline of an OpenTelemetry Collector configuration
+type otelPipeline struct {
+	Name      string // traces, metrics/prometheus, ...
+	Receivers []string
+	Exporters []string
+	Problems  []string
+	File      string
+	Line      int
+}
+
+// Signal returns the telemetry type of a pipeline: traces, metrics or logs
+func (p *otelPipeline) Signal() string {
+	signal, _, _ := strings.Cut(p.Name, "/")
+	return signal
+}
+
+// otelDebugExporters print telemetry instead of shipping it
+var otelDebugExporters = map[string]bool{"debug": true, "logging": true, "file": true, "nop": true}
+
+// parseOTelCollectorConfig returns the pipelines of an OpenTelemetry
+// Collector configuration, given as a plain config file or as the config of
+// an OpenTelemetryCollector resource
+func parseOTelCollectorConfig(content, relPath string) []otelPipeline {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if ext != ExtYAML && ext != ExtYML {
+		return nil
+	}
+	if !strings.Contains(content, "pipelines:") && !strings.Contains(content, "OpenTelemetryCollector") {
+		return nil
+	}
+
+	var pipelines []otelPipeline
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return pipelines
+			}
+			break
+		}
+		if len(node.Content) == 0 {
+			continue
+		}
+		config := node.Content[0]
+		if yamlNodeString(yamlMappingValue(config, "kind")) == "OpenTelemetryCollector" {
+			config = yamlMappingValue(yamlMappingValue(config, "spec"), "config")
+			if config != nil && config.Kind == yaml.ScalarNode {
+				// the v1alpha1 operator API takes the config as a string
+				var embedded yaml.Node
+				if yaml.Unmarshal([]byte(config.Value), &embedded) != nil || len(embedded.Content) == 0 {
+					continue
+				}
+				line := config.Line
+				config = embedded.Content[0]
+				pipelines = append(pipelines, otelCollectorPipelines(config, relPath, line)...)
+				continue
+			}
+		}
+		pipelines = append(pipelines, otelCollectorPipelines(config, relPath, 0)...)
+	}
+	return pipelines
+}
+
+// otelCollectorPipelines reads service.pipelines and checks each pipeline
+// against the receivers and exporters the config defines. Lines are those of
+// the pipeline keys, or line when the config is embedded in a string.
+func otelCollectorPipelines(config *yaml.Node, relPath string, line int) []otelPipeline {
+	receivers := yamlMappingValue(config, "receivers")
+	exporters := yamlMappingValue(config, "exporters")
+	pipelines := yamlMappingValue(yamlMappingValue(config, "service"), "pipelines")
+	if receivers == nil || exporters == nil || pipelines == nil || pipelines.Kind != yaml.MappingNode {
+		return nil
+	}
+
+	var result []otelPipeline
+	for i := 0; i+1 < len(pipelines.Content); i += 2 {
+		spec := pipelines.Content[i+1]
+		p := otelPipeline{
+			Name:      pipelines.Content[i].Value,
+			Receivers: yamlStringList(yamlMappingValue(spec, "receivers")),
+			Exporters: yamlStringList(yamlMappingValue(spec, "exporters")),
+			File:      relPath,
+			Line:      pipelines.Content[i].Line,
+		}
+		if line > 0 {
+			p.Line = line
+		}
+		switch p.Signal() {
+		case "traces", "metrics", "logs":
+		default:
+			continue
+		}
+
+		var undefined []string
+		for _, name := range p.Receivers {
+			if yamlMappingValue(receivers, name) == nil {
+				undefined = append(undefined, "receiver "+name)
+			}
+		}
+		shipping := 0
+		for _, name := range p.Exporters {
+			if yamlMappingValue(exporters, name) == nil {
+				undefined = append(undefined, "exporter "+name)
+			}
+			kind, _, _ := strings.Cut(name, "/")
+			if !otelDebugExporters[kind] {
+				shipping++
+			}
+		}
+		sort.Strings(undefined)
+		if len(undefined) > 0 {
+			p.Problems = append(p.Problems, "uses undefined "+strings.Join(undefined, ", "))
+		}
+		switch {
+		case len(p.Receivers) == 0:
+			p.Problems = append(p.Problems, "has no receivers")
+		case len(p.Exporters) == 0:
+			p.Problems = append(p.Problems, "has no exporters")
+		case shipping == 0:
+			p.Problems = append(p.Problems, "only exports to "+strings.Join(p.Exporters, ", "))
+		}
+		result = append(result, p)
+	}
+	return result
+}
+
+// yamlStringList returns the scalar items of a YAML sequence
+func yamlStringList(node *yaml.Node) []string {
+	if node == nil || node.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var items []string
+	for _, item := range node.Content {
+		if value := yamlNodeString(item); value != "" {
+			items = append(items, value)
+		}
+	}
+	return items
+}
@@ -0,0 +1,138 @@
+package scanner
+
+import (
+	"testing"
+)
+
+var testOTelFiles = map[string]string{
+	"cmd/api/telemetry.go": `package main
+
+import (
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func setup() {
+	exporter, _ := otlptracegrpc.New(ctx)
+	tp := sdktrace.NewTracerProvider(sdktrace.WithBatcher(exporter))
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	http.Handle("/", otelhttp.NewHandler(mux, "api"))
+}
+`,
+	"worker/tracing.py": `from opentelemetry.sdk.trace import TracerProvider
+from opentelemetry.sdk.metrics import MeterProvider
+
+provider = TracerProvider()
+meter_provider = MeterProvider(metric_readers=[])
+`,
+	"web/instrumentation.ts": `import { NodeSDK } from '@opentelemetry/sdk-node';
+import { getNodeAutoInstrumentations } from '@opentelemetry/auto-instrumentations-node';
+
+const sdk = new NodeSDK({ instrumentations: [getNodeAutoInstrumentations()] });
+`,
+	"billing/Dockerfile": `FROM eclipse-temurin:21
+ENTRYPOINT ["java", "-javaagent:/otel/opentelemetry-javaagent.jar", "-jar", "app.jar"]
+`,
+	"web/package.json": `{"dependencies": {"@opentelemetry/sdk-node": "0.52.0"}}`,
+	"deploy/otel-collector.yaml": `receivers:
+  otlp:
+    protocols:
+      grpc: {}
+processors:
+  batch: {}
+exporters:
+  otlp/tempo:
+    endpoint: tempo:4317
+  debug: {}
+service:
+  pipelines:
+    traces:
+      receivers: [otlp]
+      processors: [batch]
+      exporters: [otlp/tempo]
+    metrics:
+      receivers: [otlp, prometheus]
+      exporters: [debug]
+`,
+	"deploy/collector-cr.yaml": `apiVersion: opentelemetry.io/v1alpha1
+kind: OpenTelemetryCollector
+metadata:
+  name: gateway
+spec:
+  config: |
+    receivers:
+      otlp: {}
+    exporters:
+      otlphttp: {}
+    service:
+      pipelines:
+        logs:
+          receivers: [otlp]
+`,
+}
+
+func TestParseOTelSetup(t *testing.T) {
+	tests := []struct {
+		file string
+		want []otelSetup
+	}{
+		{"cmd/api/telemetry.go", []otelSetup{
+			{Kind: otelTracerProvider, Language: "go", Detail: "sdktrace.NewTracerProvider(", Line: 13},
+			{Kind: otelTraceExporter, Language: "go", Detail: "otlptracegrpc.New(", Line: 12},
+			{Kind: otelPropagation, Language: "go", Detail: "otel.SetTextMapPropagator(", Line: 15},
+			{Kind: otelPropagation, Language: "go", Detail: "propagation.TraceContext{", Line: 15},
+			{Kind: otelPropagation, Language: "go", Detail: "otelhttp.NewHandler(", Line: 16},
+		}},
+		{"worker/tracing.py", []otelSetup{
+			{Kind: otelTracerProvider, Language: "python", Detail: "TracerProvider(", Line: 4},
+			{Kind: otelMeterProvider, Language: "python", Detail: "MeterProvider(", Line: 5},
+		}},
+		{"web/instrumentation.ts", []otelSetup{
+			{Kind: otelTracerProvider, Language: "node", Detail: "new NodeSDK(", Line: 4},
+			{Kind: otelTraceExporter, Language: "node", Detail: "new NodeSDK(", Line: 4},
+			{Kind: otelPropagation, Language: "node", Detail: "getNodeAutoInstrumentations(", Line: 4},
+		}},
+		{"billing/Dockerfile", []otelSetup{
+			{Kind: otelAgent, Language: "java", Detail: "-javaagent:/otel/opentelemetry-javaagent.jar", Line: 2},
+		}},
+		{"web/package.json", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := parseOTelSetup(testOTelFiles[tt.file], tt.file)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d setups, got %+v", len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				want.File = tt.file
+				if got[i] != want {
+					t.Errorf("expected %+v, got %+v", want, got[i])
+				}
+			}
+		})
+	}
+}
+
+func TestParseOTelCollectorConfig(t *testing.T) {
+	got := parseOTelCollectorConfig(testOTelFiles["deploy/otel-collector.yaml"], "deploy/otel-collector.yaml")
+	if len(got) != 2 {
+		t.Fatalf("expected 2 pipelines, got %+v", got)
+	}
+	if got[0].Name != "traces" || got[0].Line != 13 || len(got[0].Problems) != 0 {
+		t.Errorf("unexpected traces pipeline %+v", got[0])
+	}
+	want := []string{"uses undefined receiver prometheus", "only exports to debug"}
+	if got[1].Name != "metrics" || len(got[1].Problems) != 2 || got[1].Problems[0] != want[0] || got[1].Problems[1] != want[1] {
+		t.Errorf("unexpected metrics pipeline %+v", got[1])
+	}
+
+	embedded := parseOTelCollectorConfig(testOTelFiles["deploy/collector-cr.yaml"], "deploy/collector-cr.yaml")
+	if len(embedded) != 1 || embedded[0].Signal() != "logs" || embedded[0].Line != 6 ||
+		len(embedded[0].Problems) != 1 || embedded[0].Problems[0] != "has no exporters" {
+		t.Errorf("unexpected embedded pipelines %+v", embedded)
+	}
+}
@@ -0,0 +1,243 @@
+package scanner
+
+import (
+	"fmt"
+	"path/filepath"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// promRule is an alerting or recording rule from a Prometheus rules file or
+// a PrometheusRule resource
+type promRule struct {
+	Alert       string
+	Record      string
+	Expr        string
+	For         string
+	Labels      map[string]string
+	Annotations map[string]string
+	Group       string
+	File        string
+	Line        int
+	Source      string // "rules file" or the PrometheusRule namespace/name
+}
+
+// Name returns the alert or recorded series name
+func (r *promRule) Name() string {
+	if r.Alert != "" {
+		return r.Alert
+	}
+	return r.Record
+}
+
+// promRuleGroups decodes the groups of a rules file or PrometheusRule spec.
+// Rule lines are looked up in content from the line the document starts on.
+func promRuleGroups(raw interface{}, content, file, source string, line int) []promRule {
+	groups, ok := raw.([]interface{})
+	if !ok {
+		return nil
+	}
+	lines := strings.Split(content, "\n")
+	var rules []promRule
+	for _, g := range groups {
+		group, ok := g.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		groupName, _ := group["name"].(string)
+		list, _ := group["rules"].([]interface{})
+		for _, item := range list {
+			spec, ok := item.(map[string]interface{})
+			if !ok {
+				continue
+			}
+			r := promRule{
+				Alert:       yamlScalarString(spec["alert"]),
+				Record:      yamlScalarString(spec["record"]),
+				Expr:        yamlScalarString(spec["expr"]),
+				For:         yamlScalarString(spec["for"]),
+				Labels:      stringMap(spec["labels"]),
+				Annotations: stringMap(spec["annotations"]),
+				Group:       groupName,
+				File:        file,
+				Source:      source,
+			}
+			if r.Name() == "" {
+				continue
+			}
+			key := "record"
+			if r.Alert != "" {
+				key = "alert"
+			}
+			r.Line = promRuleLine(lines, line, key, r.Name())
+			if r.Line > 0 {
+				// the next rule of the same name starts after this one
+				line = r.Line + 1
+			} else {
+				r.Line = line
+			}
+			rules = append(rules, r)
+		}
+	}
+	return rules
+}
+
+// promRuleLine returns the line of "alert: <name>" or "record: <name>" at or
+// after a line, or 0 when the content does not contain it (as for rendered
+// manifests)
+func promRuleLine(lines []string, from int, key, name string) int {
+	for i := max(from-1, 0); i < len(lines); i++ {
+		text := strings.TrimPrefix(strings.TrimSpace(lines[i]), "- ")
+		value, ok := strings.CutPrefix(strings.TrimSpace(text), key+":")
+		if ok && strings.Trim(strings.TrimSpace(value), `"'`) == name {
+			return i + 1
+		}
+	}
+	return 0
+}
+
+// parsePromRulesFile returns the rules of a plain Prometheus rules file:
+// a YAML document with a top-level groups list and no Kubernetes kind
+func parsePromRulesFile(content, relPath string) []promRule {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if ext != ExtYAML && ext != ExtYML && ext != ".rules" {
+		return nil
+	}
+	if !strings.Contains(content, "groups:") {
+		return nil
+	}
+	var doc map[string]interface{}
+	if err := yaml.Unmarshal([]byte(content), &doc); err != nil || doc == nil {
+		return nil
+	}
+	if _, ok := doc["kind"]; ok {
+		return nil
+	}
+	return promRuleGroups(doc["groups"], content, relPath, "rules file", 1)
+}
+
+// promRulesFromManifest returns the rules of a PrometheusRule resource
+func promRulesFromManifest(obj *k8sObject, content string) []promRule {
+	source := fmt.Sprintf("PrometheusRule %s", obj.Name)
+	if obj.Namespace != "" {
+		source = fmt.Sprintf("PrometheusRule %s/%s", obj.Namespace, obj.Name)
+	}
+	return promRuleGroups(obj.Spec()["groups"], content, obj.File, source, obj.Line)
+}
+
+// promQLGroupingKeywords take a parenthesized label list, not series
+var promQLGroupingKeywords = map[string]bool{
+	"by": true, "without": true, "on": true, "ignoring": true, "group_left": true, "group_right": true,
+}
+
+// promQLKeywords are operators, literals and aggregations (which may be
+// followed by "by" instead of a parenthesis) that look like identifiers
+var promQLKeywords = map[string]bool{
+	"and": true, "or": true, "unless": true, "bool": true, "offset": true, "inf": true, "nan": true,
+	"sum": true, "min": true, "max": true, "avg": true, "group": true, "stddev": true, "stdvar": true,
+	"count": true, "count_values": true, "bottomk": true, "topk": true, "quantile": true, "limitk": true,
+}
+
+// promQLMetrics returns the metric names a PromQL expression selects, in
+// order of first use. Label matchers, strings, ranges, functions and the
+// label lists of grouping modifiers are skipped.
+func promQLMetrics(expr string) []string {
+	var metrics []string
+	seen := make(map[string]bool)
+	for i := 0; i < len(expr); i++ {
+		c := expr[i]
+		switch {
+		case c == '"' || c == '\'' || c == '`':
+			i = promQLStringEnd(expr, i)
+		case c == '{':
+			i = promQLGroupEnd(expr, i, '{', '}')
+		case c == '[':
+			i = promQLGroupEnd(expr, i, '[', ']')
+		case c >= '0' && c <= '9' || c == '.':
+			i = promQLNumberEnd(expr, i)
+		case promQLIdentByte(c, true):
+			var metric string
+			metric, i = promQLIdentifier(expr, i)
+			if metric != "" && !seen[metric] {
+				seen[metric] = true
+				metrics = append(metrics, metric)
+			}
+		}
+	}
+	return metrics
+}
+
+// promQLIdentByte reports whether c can appear in a metric name or keyword,
+// at its start if first is set
+func promQLIdentByte(c byte, first bool) bool {
+	return c == '_' || c == ':' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (!first && c >= '0' && c <= '9')
+}
+
+// promQLStringEnd returns the index of the quote closing the string that
+// starts at i
+func promQLStringEnd(expr string, i int) int {
+	quote := expr[i]
+	for i++; i < len(expr) && expr[i] != quote; i++ {
+		if expr[i] == '\\' {
+			i++
+		}
+	}
+	return i
+}
+
+// promQLGroupEnd returns the index of the close bracket matching the open
+// bracket at i, skipping strings inside it
+func promQLGroupEnd(expr string, i int, open, close byte) int {
+	depth := 0
+	for ; i < len(expr); i++ {
+		switch c := expr[i]; {
+		case c == '"' || c == '\'' || c == '`':
+			i = promQLStringEnd(expr, i)
+		case c == open:
+			depth++
+		case c == close:
+			depth--
+			if depth == 0 {
+				return i
+			}
+		}
+	}
+	return i
+}
+
+// promQLNumberEnd returns the index of the last byte of the number that
+// starts at i, including forms such as 1e3 and 0x1f
+func promQLNumberEnd(expr string, i int) int {
+	for i+1 < len(expr) && (promQLIdentByte(expr[i+1], false) || expr[i+1] == '.') {
+		i++
+	}
+	return i
+}
+
+// promQLIdentifier reads the identifier that starts at i and returns it if
+// it names a metric, with the index of the last byte consumed. Functions,
+// aggregations, operators and grouping modifiers with their label lists
+// return "".
+func promQLIdentifier(expr string, i int) (metric string, end int) {
+	start := i
+	for i+1 < len(expr) && promQLIdentByte(expr[i+1], false) {
+		i++
+	}
+	word := expr[start : i+1]
+	next := i + 1
+	for next < len(expr) && (expr[next] == ' ' || expr[next] == '\t' || expr[next] == '\n') {
+		next++
+	}
+	followedByParen := next < len(expr) && expr[next] == '('
+	switch {
+	case promQLGroupingKeywords[strings.ToLower(word)]:
+		if followedByParen {
+			return "", promQLGroupEnd(expr, next, '(', ')')
+		}
+		return "", i
+	case followedByParen, promQLKeywords[strings.ToLower(word)]:
+		return "", i
+	}
+	return word, i
+}
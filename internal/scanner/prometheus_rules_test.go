@@ -0,0 +1,59 @@
+package scanner
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestPromQLMetrics(t *testing.T) {
+	tests := []struct {
+		expr string
+		want []string
+	}{
+		{`up{job="api"} == 0`, []string{"up"}},
+		{`sum by (code) (rate(http_requests_total{code=~"5.."}[5m])) / sum(rate(http_requests_total[5m])) > 0.05`, []string{"http_requests_total"}},
+		{`histogram_quantile(0.99, sum(rate(request_duration_seconds_bucket[5m])) by (le)) > 1.5e0`, []string{"request_duration_seconds_bucket"}},
+		{`slo:sli_error:ratio_rate1h{service="api"} > (14.4 * 0.001) and slo:sli_error:ratio_rate5m > (14.4 * 0.001)`, []string{"slo:sli_error:ratio_rate1h", "slo:sli_error:ratio_rate5m"}},
+		{`queue_depth offset 1h > on(instance) group_left(team) queue_capacity`, []string{"queue_depth", "queue_capacity"}},
+		{`label_replace(jobs_failed, "dst", "$1", "src", "(.*)") unless absent(jobs_failed)`, []string{"jobs_failed"}},
+		{`vector(1)`, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.expr, func(t *testing.T) {
+			if got := promQLMetrics(tt.expr); !slices.Equal(got, tt.want) {
+				t.Errorf("expected %v, got %v", tt.want, got)
+			}
+		})
+	}
+}
+
+func TestParsePromRulesFile(t *testing.T) {
+	content := `groups:
+  - name: api
+    rules:
+      - record: job:http_errors:rate5m
+        expr: sum(rate(http_requests_total{code=~"5.."}[5m]))
+      - alert: HighErrorRate
+        expr: job:http_errors:rate5m > 1
+        for: 10m
+        labels:
+          severity: page
+        annotations:
+          summary: "{{ $labels.job }} is failing"
+`
+	rules := parsePromRulesFile(content, "monitoring/api.rules.yml")
+	if len(rules) != 2 {
+		t.Fatalf("expected 2 rules, got %+v", rules)
+	}
+	if rules[0].Record != "job:http_errors:rate5m" || rules[0].Line != 4 {
+		t.Errorf("unexpected recording rule %+v", rules[0])
+	}
+	alert := rules[1]
+	if alert.Alert != "HighErrorRate" || alert.Line != 6 || alert.For != "10m" || alert.Labels["severity"] != "page" || alert.Group != "api" {
+		t.Errorf("unexpected alert %+v", alert)
+	}
+
+	if rules := parsePromRulesFile("groups:\n  - admins\n", "users.yaml"); rules != nil {
+		t.Errorf("expected no rules, got %+v", rules)
+	}
+}
@@ -1,6 +1,28 @@
 package scanner
 
-import "sync"
+import (
+	"fmt"
+	"sort"
+	"sync"
+)
+
+// Evidence locates one concrete occurrence behind a signal, such as a
+// container without limits or a risky migration statement
+type Evidence struct {
+	Subject string `json:"subject"`
+	File    string `json:"file,omitempty"`
+	Line    int    `json:"line,omitempty"`
+	Detail  string `json:"detail,omitempty"`
+	Context string `json:"context,omitempty"` // e.g. the Helm chart or kustomize overlay
+}
+
+// Location formats the file and line of the evidence
+func (e Evidence) Location() string {
+	if e.Line > 0 {
+		return fmt.Sprintf("%s:%d", e.File, e.Line)
+	}
+	return e.File
+}
 
 // RepoSignals holds scanned information
 type RepoSignals struct {
@@ -12,6 +34,10 @@ type RepoSignals struct {
 	StringSignals   map[string]string
 	IntSignals      map[string]int
 	DetectedRegions map[string]bool
+	Evidence        map[string][]Evidence // concrete occurrences behind a signal
+
+	k8sManifests       map[string][]k8sObject     // parsed Kubernetes objects by source
+	environmentRegions map[string]map[string]bool // regions only one environment deploys to
 }
 
 func (s *RepoSignals) SetFile(path string) {
@@ -50,6 +76,48 @@ func (s *RepoSignals) SetRegion(region string) {
 	s.DetectedRegions[region] = true
 }
 
+// SetEnvironmentRegion records a region that only one deployment environment
+// uses, such as the region of a Terraform variable file or a Pulumi stack
+func (s *RepoSignals) SetEnvironmentRegion(env, region string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.environmentRegions == nil {
+		s.environmentRegions = make(map[string]map[string]bool)
+	}
+	if s.environmentRegions[env] == nil {
+		s.environmentRegions[env] = make(map[string]bool)
+	}
+	s.environmentRegions[env][region] = true
+}
+
+// AddEvidence appends an occurrence to the evidence list of a signal
+func (s *RepoSignals) AddEvidence(key string, ev Evidence) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.Evidence == nil {
+		s.Evidence = make(map[string][]Evidence)
+	}
+	s.Evidence[key] = append(s.Evidence[key], ev)
+}
+
+// addK8sManifests records the Kubernetes objects decoded from a source
+func (s *RepoSignals) addK8sManifests(source string, objects []k8sObject) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.k8sManifests == nil {
+		s.k8sManifests = make(map[string][]k8sObject)
+	}
+	s.k8sManifests[source] = objects
+}
+
+// removeK8sManifests drops the objects of a source that another stage,
+// such as Helm rendering, evaluates instead
+func (s *RepoSignals) removeK8sManifests(source string) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	delete(s.k8sManifests, source)
+}
+
 func (s *RepoSignals) GetBool(key string) bool {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
@@ -102,10 +170,49 @@ func (s *RepoSignals) GetContent(path string) (string, bool) {
 	return content, ok
 }
 
+// GetRegionCount returns the number of regions a single deployment spans:
+// the shared regions plus those of the environment using the most
 func (s *RepoSignals) GetRegionCount() int {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
-	return len(s.DetectedRegions)
+	count := len(s.DetectedRegions)
+	for _, regions := range s.environmentRegions {
+		n := len(s.DetectedRegions)
+		for region := range regions {
+			if !s.DetectedRegions[region] {
+				n++
+			}
+		}
+		count = max(count, n)
+	}
+	return count
+}
+
+// GetEvidence returns the evidence of a signal ordered by location
+func (s *RepoSignals) GetEvidence(key string) []Evidence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	if len(s.Evidence[key]) == 0 {
+		return nil
+	}
+	res := make([]Evidence, len(s.Evidence[key]))
+	copy(res, s.Evidence[key])
+	sortEvidence(res)
+	return res
+}
+
+// GetEvidenceMap returns a copy of all evidence ordered by location
+func (s *RepoSignals) GetEvidenceMap() map[string][]Evidence {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make(map[string][]Evidence, len(s.Evidence))
+	for k, v := range s.Evidence {
+		list := make([]Evidence, len(v))
+		copy(list, v)
+		sortEvidence(list)
+		res[k] = list
+	}
+	return res
 }
 
 func (s *RepoSignals) GetFiles() map[string]bool {
@@ -128,3 +235,30 @@ func (s *RepoSignals) GetFileContentMap() map[string]string {
 	}
 	return res
 }
+
+// getK8sManifests returns a copy of the parsed Kubernetes objects by source
+func (s *RepoSignals) getK8sManifests() map[string][]k8sObject {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	res := make(map[string][]k8sObject, len(s.k8sManifests))
+	for k, v := range s.k8sManifests {
+		res[k] = v
+	}
+	return res
+}
+
+// sortEvidence orders evidence by file, line and subject for stable reports
+func sortEvidence(list []Evidence) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].File != list[j].File {
+			return list[i].File < list[j].File
+		}
+		if list[i].Line != list[j].Line {
+			return list[i].Line < list[j].Line
+		}
+		if list[i].Subject != list[j].Subject {
+			return list[i].Subject < list[j].Subject
+		}
+		return list[i].Context < list[j].Context
+	})
+}
@@ -20,6 +20,7 @@ func TestRepoSignals(t *testing.T) {
 	t.Run("StringSignals", func(t *testing.T) { testSignalsString(t, s) })
 	t.Run("IntSignals", func(t *testing.T) { testSignalsInt(t, s) })
 	t.Run("Regions", func(t *testing.T) { testSignalsRegions(t, s) })
+	t.Run("Evidence", func(t *testing.T) { testSignalsEvidence(t, s) })
 }
 
 func testSignalsFiles(t *testing.T, s *RepoSignals) {
@@ -118,6 +119,26 @@ func testSignalsRegions(t *testing.T, s *RepoSignals) {
 	}
 }
 
+func testSignalsEvidence(t *testing.T, s *RepoSignals) {
+	s.AddEvidence("test_evidence", Evidence{Subject: "b", File: "z.yaml", Line: 3})
+	s.AddEvidence("test_evidence", Evidence{Subject: "a", File: "a.yaml", Line: 10})
+	got := s.GetEvidence("test_evidence")
+	if len(got) != 2 || got[0].Subject != "a" || got[1].Subject != "b" {
+		t.Errorf("expected evidence ordered by location, got %+v", got)
+	}
+	if got[0].Location() != "a.yaml:10" {
+		t.Errorf("expected location a.yaml:10, got %q", got[0].Location())
+	}
+	if s.GetEvidence("nonexistent") != nil {
+		t.Errorf("expected no evidence for unknown signal")
+	}
+	// Verify copy
+	got[0].Subject = "hacked"
+	if s.GetEvidenceMap()["test_evidence"][0].Subject != "a" {
+		t.Errorf("GetEvidence should return a copy, not a reference")
+	}
+}
+
 func TestRepoSignalsConcurrency(t *testing.T) {
 	s := &RepoSignals{
 		Files:           make(map[string]bool),
@@ -148,3 +169,13 @@ func TestRepoSignalsConcurrency(t *testing.T) {
 	<-done
 	<-done
 }
+
+// newFileSignals returns empty signals over the given file contents
+func newFileSignals(files map[string]string) *RepoSignals {
+	return &RepoSignals{
+		FileContent:   files,
+		BoolSignals:   make(map[string]bool),
+		IntSignals:    make(map[string]int),
+		StringSignals: make(map[string]string),
+	}
+}
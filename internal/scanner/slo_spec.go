@@ -0,0 +1,305 @@
+package scanner
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+)
+
+// SLO specification formats
+const (
+	sloFormatSloth   = "sloth"
+	sloFormatPyrra   = "pyrra"
+	sloFormatOpenSLO = "openslo"
+)
+
+// slothDefaultWindow is the SLO period Sloth uses unless told otherwise
+const slothDefaultWindow = "30d"
+
+// sloSpec is one service-level objective from a Sloth, Pyrra or OpenSLO
+// document
+type sloSpec struct {
+	Format    string
+	Name      string
+	Service   string
+	Query     string  // the SLI query, or a reference to a separate SLI
+	Objective float64 // target in percent, e.g. 99.9
+	HasTarget bool
+	Window    string // e.g. 30d; empty when the document sets none
+	File      string
+	Line      int
+}
+
+var (
+	// Prometheus durations such as 30d or 1h30m
+	sloDurationPattern     = regexp.MustCompile(`^(?:\d+(?:ms|[smhdwy]))+$`)
+	sloDurationPartPattern = regexp.MustCompile(`(\d+)(ms|[smhdwy])`)
+)
+
+// parseSLOSpecs returns the objectives of every Sloth, Pyrra and OpenSLO
+// document in a YAML file
+func parseSLOSpecs(content, relPath string) []sloSpec {
+	ext := strings.ToLower(filepath.Ext(relPath))
+	if ext != ExtYAML && ext != ExtYML {
+		return nil
+	}
+	if !strings.Contains(content, "slos:") && !strings.Contains(content, "ServiceLevelObjective") && !strings.Contains(content, "openslo") {
+		return nil
+	}
+
+	var specs []sloSpec
+	dec := yaml.NewDecoder(strings.NewReader(content))
+	for {
+		var node yaml.Node
+		if err := dec.Decode(&node); err != nil {
+			if !errors.Is(err, io.EOF) {
+				return specs
+			}
+			break
+		}
+		if len(node.Content) == 0 || node.Content[0].Kind != yaml.MappingNode {
+			continue
+		}
+		doc := node.Content[0]
+		apiVersion := yamlNodeString(yamlMappingValue(doc, "apiVersion"))
+		kind := yamlNodeString(yamlMappingValue(doc, "kind"))
+		switch {
+		case yamlNodeString(yamlMappingValue(doc, "version")) == "prometheus/v1":
+			specs = append(specs, parseSlothSLOs(doc, relPath)...)
+		case strings.HasPrefix(apiVersion, "sloth.slok.dev/") && kind == "PrometheusServiceLevel":
+			specs = append(specs, parseSlothSLOs(yamlMappingValue(doc, "spec"), relPath)...)
+		case strings.HasPrefix(apiVersion, "pyrra.dev/") && kind == "ServiceLevelObjective":
+			specs = append(specs, parsePyrraSLO(doc, relPath))
+		case strings.HasPrefix(apiVersion, "openslo/") && kind == "SLO":
+			specs = append(specs, parseOpenSLO(doc, relPath)...)
+		}
+	}
+	return specs
+}
+
+// parseSlothSLOs reads a Sloth prometheus/v1 spec or PrometheusServiceLevel
+// spec. Sloth has no per-SLO window; it uses a 30 day period by default.
+func parseSlothSLOs(spec *yaml.Node, relPath string) []sloSpec {
+	service := yamlNodeString(yamlMappingValue(spec, "service"))
+	slos := yamlMappingValue(spec, "slos")
+	if slos == nil || slos.Kind != yaml.SequenceNode {
+		return nil
+	}
+	var specs []sloSpec
+	for _, item := range slos.Content {
+		s := sloSpec{
+			Format:  sloFormatSloth,
+			Name:    yamlNodeString(yamlMappingValue(item, "name")),
+			Service: service,
+			Window:  slothDefaultWindow,
+			File:    relPath,
+			Line:    item.Line,
+		}
+		s.Objective, s.HasTarget = sloNumber(yamlMappingValue(item, "objective"))
+
+		sli := yamlMappingValue(item, "sli")
+		if events := yamlMappingValue(sli, "events"); events != nil {
+			s.Query = yamlNodeString(yamlMappingValue(events, "error_query"))
+		} else if raw := yamlMappingValue(sli, "raw"); raw != nil {
+			s.Query = yamlNodeString(yamlMappingValue(raw, "error_ratio_query"))
+		} else if plugin := yamlMappingValue(sli, "plugin"); plugin != nil {
+			s.Query = "plugin " + yamlNodeString(yamlMappingValue(plugin, "id"))
+		}
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// parsePyrraSLO reads a Pyrra ServiceLevelObjective, whose target is a
+// percentage string
+func parsePyrraSLO(doc *yaml.Node, relPath string) sloSpec {
+	metadata := yamlMappingValue(doc, "metadata")
+	spec := yamlMappingValue(doc, "spec")
+	s := sloSpec{
+		Format: sloFormatPyrra,
+		Name:   yamlNodeString(yamlMappingValue(metadata, "name")),
+		Window: yamlNodeString(yamlMappingValue(spec, "window")),
+		File:   relPath,
+		Line:   doc.Line,
+	}
+	s.Service = yamlNodeString(yamlMappingValue(yamlMappingValue(metadata, "labels"), "app.kubernetes.io/name"))
+	if s.Service == "" {
+		s.Service = yamlNodeString(yamlMappingValue(metadata, "namespace"))
+	}
+	s.Objective, s.HasTarget = sloNumber(yamlMappingValue(spec, "target"))
+
+	indicator := yamlMappingValue(spec, "indicator")
+	for _, kind := range []string{"ratio", "latency", "latencyNative", "bool_gauge"} {
+		ind := yamlMappingValue(indicator, kind)
+		if ind == nil {
+			continue
+		}
+		for _, key := range []string{"errors", "success", "total"} {
+			if metric := yamlNodeString(yamlMappingValue(yamlMappingValue(ind, key), "metric")); metric != "" {
+				s.Query = metric
+				break
+			}
+		}
+		if s.Query == "" {
+			s.Query = yamlNodeString(yamlMappingValue(ind, "metric"))
+		}
+		break
+	}
+	return s
+}
+
+// parseOpenSLO reads an OpenSLO SLO. Each objective becomes a spec; targets
+// are fractions (target: 0.999) or percentages (targetPercent: 99.9).
+func parseOpenSLO(doc *yaml.Node, relPath string) []sloSpec {
+	metadata := yamlMappingValue(doc, "metadata")
+	spec := yamlMappingValue(doc, "spec")
+	base := sloSpec{
+		Format:  sloFormatOpenSLO,
+		Name:    yamlNodeString(yamlMappingValue(metadata, "name")),
+		Service: yamlNodeString(yamlMappingValue(spec, "service")),
+		File:    relPath,
+		Line:    doc.Line,
+	}
+
+	windows := yamlMappingValue(spec, "timeWindow")
+	if windows == nil {
+		windows = yamlMappingValue(spec, "timeWindows") // v1alpha
+	}
+	if windows != nil && windows.Kind == yaml.SequenceNode && len(windows.Content) > 0 {
+		window := windows.Content[0]
+		base.Window = yamlNodeString(yamlMappingValue(window, "duration"))
+		if base.Window == "" {
+			// v1alpha: unit: Day, count: 28
+			count := yamlNodeString(yamlMappingValue(window, "count"))
+			if unit := strings.ToLower(yamlNodeString(yamlMappingValue(window, "unit"))); count != "" && unit != "" {
+				base.Window = count + unit[:1]
+			}
+		}
+	}
+
+	if ref := yamlNodeString(yamlMappingValue(spec, "indicatorRef")); ref != "" {
+		base.Query = "indicatorRef " + ref
+	} else if indicator := yamlMappingValue(yamlMappingValue(spec, "indicator"), "spec"); indicator != nil {
+		base.Query = openSLOQuery(indicator)
+	}
+
+	objectives := yamlMappingValue(spec, "objectives")
+	if objectives == nil || objectives.Kind != yaml.SequenceNode || len(objectives.Content) == 0 {
+		return []sloSpec{base}
+	}
+	var specs []sloSpec
+	for _, objective := range objectives.Content {
+		s := base
+		s.Line = objective.Line
+		if name := yamlNodeString(yamlMappingValue(objective, "displayName")); name != "" {
+			s.Name = base.Name + "/" + name
+		}
+		if target, ok := sloNumber(yamlMappingValue(objective, "target")); ok {
+			s.Objective, s.HasTarget = math.Round(target*100*1e6)/1e6, true
+		} else {
+			s.Objective, s.HasTarget = sloNumber(yamlMappingValue(objective, "targetPercent"))
+		}
+		specs = append(specs, s)
+	}
+	return specs
+}
+
+// openSLOQuery returns the first query of an OpenSLO SLI: the good or bad
+// metric of a ratio, or the threshold metric
+func openSLOQuery(indicator *yaml.Node) string {
+	var find func(node *yaml.Node, depth int) string
+	find = func(node *yaml.Node, depth int) string {
+		if node == nil || depth > 6 {
+			return ""
+		}
+		if node.Kind == yaml.MappingNode {
+			for i := 0; i+1 < len(node.Content); i += 2 {
+				key := node.Content[i].Value
+				if (key == "query" || key == "promql") && node.Content[i+1].Kind == yaml.ScalarNode {
+					return node.Content[i+1].Value
+				}
+			}
+		}
+		for _, child := range node.Content {
+			if q := find(child, depth+1); q != "" {
+				return q
+			}
+		}
+		return ""
+	}
+	return find(indicator, 0)
+}
+
+// sloNumber reads a number or numeric string such as "99.9" or "99.9%"
+func sloNumber(node *yaml.Node) (float64, bool) {
+	value := strings.TrimSuffix(strings.TrimSpace(yamlNodeString(node)), "%")
+	if value == "" {
+		return 0, false
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	return n, err == nil
+}
+
+// validate returns why an objective cannot be used, or "" when it can
+func (s *sloSpec) validate() string {
+	switch {
+	case !s.HasTarget:
+		return "no objective"
+	case s.Objective >= 100:
+		return fmt.Sprintf("objective %s%% leaves no error budget", formatPercent(s.Objective))
+	case s.Objective <= 0:
+		return fmt.Sprintf("objective %s%% is not a target", formatPercent(s.Objective))
+	case s.Window == "":
+		return "no time window"
+	case s.Query == "":
+		return "no SLI query"
+	}
+	if _, err := parseSLOWindow(s.Window); err != nil {
+		return err.Error()
+	}
+	return ""
+}
+
+// ErrorBudget returns the share of the window that may fail, as a fraction
+func (s *sloSpec) ErrorBudget() float64 {
+	return (100 - s.Objective) / 100
+}
+
+// ErrorBudgetDuration returns how long the service may fail per window
+func (s *sloSpec) ErrorBudgetDuration() time.Duration {
+	window, _ := parseSLOWindow(s.Window)
+	return time.Duration(float64(window) * s.ErrorBudget())
+}
+
+// parseSLOWindow parses a Prometheus duration such as 4w or 30d
+func parseSLOWindow(window string) (time.Duration, error) {
+	if !sloDurationPattern.MatchString(window) {
+		return 0, fmt.Errorf("window %q is not a duration", window)
+	}
+	units := map[string]time.Duration{
+		"ms": time.Millisecond, "s": time.Second, "m": time.Minute, "h": time.Hour,
+		"d": 24 * time.Hour, "w": 7 * 24 * time.Hour, "y": 365 * 24 * time.Hour,
+	}
+	var total time.Duration
+	for _, part := range sloDurationPartPattern.FindAllStringSubmatch(window, -1) {
+		n, _ := strconv.Atoi(part[1])
+		total += time.Duration(n) * units[part[2]]
+	}
+	if total <= 0 {
+		return 0, fmt.Errorf("window %q is empty", window)
+	}
+	return total, nil
+}
+
+// formatPercent formats an objective without trailing zeros
+func formatPercent(p float64) string {
+	return strconv.FormatFloat(p, 'f', -1, 64)
+}
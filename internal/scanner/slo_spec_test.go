@@ -0,0 +1,125 @@
+package scanner
+
+import (
+	"testing"
+	"time"
+)
+
+var testSLOFiles = map[string]string{
+	"slos/sloth.yaml": `version: "prometheus/v1"
+service: "checkout"
+slos:
+  - name: "requests-availability"
+    objective: 99.9
+    sli:
+      events:
+        error_query: sum(rate(http_requests_total{code=~"5.."}[{{.window}}]))
+        total_query: sum(rate(http_requests_total[{{.window}}]))
+  - name: "perfect"
+    objective: 100
+    sli:
+      raw:
+        error_ratio_query: job:errors:ratio
+`,
+	"slos/pyrra.yaml": `apiVersion: pyrra.dev/v1alpha1
+kind: ServiceLevelObjective
+metadata:
+  name: api-errors
+  namespace: shop
+spec:
+  target: "99.5"
+  window: 4w
+  indicator:
+    ratio:
+      errors:
+        metric: http_requests_total{code=~"5.."}
+      total:
+        metric: http_requests_total
+---
+apiVersion: pyrra.dev/v1alpha1
+kind: ServiceLevelObjective
+metadata:
+  name: no-window
+spec:
+  target: "99"
+  indicator:
+    bool_gauge:
+      metric: probe_success
+`,
+	"slos/openslo.yaml": `apiVersion: openslo/v1
+kind: SLO
+metadata:
+  name: search-latency
+spec:
+  service: search
+  indicatorRef: search-latency-sli
+  timeWindow:
+    - duration: 28d
+      isRolling: true
+  objectives:
+    - displayName: fast
+      target: 0.999
+`,
+	"docs/slo.md": "Our SLO is 99.9% availability.\n",
+}
+
+func TestParseSLOSpecs(t *testing.T) {
+	tests := []struct {
+		file string
+		want []sloSpec
+	}{
+		{"slos/sloth.yaml", []sloSpec{
+			{Format: sloFormatSloth, Name: "requests-availability", Service: "checkout", Objective: 99.9, Window: "30d", Line: 4},
+			{Format: sloFormatSloth, Name: "perfect", Service: "checkout", Objective: 100, Window: "30d", Line: 10},
+		}},
+		{"slos/pyrra.yaml", []sloSpec{
+			{Format: sloFormatPyrra, Name: "api-errors", Service: "shop", Objective: 99.5, Window: "4w", Line: 1},
+			{Format: sloFormatPyrra, Name: "no-window", Objective: 99, Line: 16},
+		}},
+		{"slos/openslo.yaml", []sloSpec{
+			{Format: sloFormatOpenSLO, Name: "search-latency/fast", Service: "search", Objective: 99.9, Window: "28d", Line: 12},
+		}},
+		{"docs/slo.md", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			got := parseSLOSpecs(testSLOFiles[tt.file], tt.file)
+			if len(got) != len(tt.want) {
+				t.Fatalf("expected %d objectives, got %+v", len(tt.want), got)
+			}
+			for i, want := range tt.want {
+				g := got[i]
+				if g.Format != want.Format || g.Name != want.Name || g.Service != want.Service ||
+					g.Objective != want.Objective || g.Window != want.Window || g.Line != want.Line || g.Query == "" {
+					t.Errorf("expected %+v, got %+v", want, g)
+				}
+			}
+		})
+	}
+}
+
+func TestSLOSpecValidate(t *testing.T) {
+	tests := []struct {
+		name   string
+		spec   sloSpec
+		want   string
+		budget time.Duration
+	}{
+		{"valid", sloSpec{Objective: 99.9, HasTarget: true, Window: "30d", Query: "q"}, "", 43*time.Minute + 12*time.Second},
+		{"perfect", sloSpec{Objective: 100, HasTarget: true, Window: "30d", Query: "q"}, "objective 100% leaves no error budget", 0},
+		{"no target", sloSpec{Window: "30d", Query: "q"}, "no objective", 0},
+		{"no window", sloSpec{Objective: 99, HasTarget: true, Query: "q"}, "no time window", 0},
+		{"bad window", sloSpec{Objective: 99, HasTarget: true, Window: "monthly", Query: "q"}, `window "monthly" is not a duration`, 0},
+		{"no query", sloSpec{Objective: 99, HasTarget: true, Window: "7d"}, "no SLI query", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.spec.validate(); got != tt.want {
+				t.Errorf("expected %q, got %q", tt.want, got)
+			}
+			if tt.want == "" && tt.spec.ErrorBudgetDuration().Round(time.Second) != tt.budget {
+				t.Errorf("expected budget %s, got %s", tt.budget, tt.spec.ErrorBudgetDuration())
+			}
+		})
+	}
+}
@@ -0,0 +1,309 @@
+package scanner
+
+import (
+	"path/filepath"
+	"reflect"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// terraformBlock is a provider, resource or module block with its location
+type terraformBlock struct {
+	Type string // resource type, provider name or "module"
+	Name string // resource or module name ("" for providers)
+	File string
+	Line int
+	Body *hclBody
+}
+
+// terraformModule is a Terraform root or child module: the .tf files of one
+// directory, with variable values resolved from defaults and .tfvars files
+type terraformModule struct {
+	Dir       string
+	Providers []terraformBlock
+	Resources []terraformBlock
+	Modules   []terraformBlock
+	Variables map[string][]interface{} // values of var.<name>, one per variable file set
+	VarSets   []terraformVarSet        // alternative -var-file environments, if any
+	Locals    map[string]interface{}
+	Errors    []Evidence
+}
+
+// terraformVarSet is the variable values of one environment: the module
+// applied with a single -var-file on top of the automatically loaded files
+type terraformVarSet struct {
+	Name   string // variable file name without extension, e.g. "prod"
+	Values map[string]interface{}
+}
+
+// isTerraformFile reports whether a path is Terraform configuration or a
+// variable file. Modules downloaded into .terraform are not part of the repo.
+func isTerraformFile(relPath string) bool {
+	if strings.Contains(filepath.ToSlash(relPath), ".terraform/") {
+		return false
+	}
+	ext := filepath.Ext(relPath)
+	return ext == ".tf" || ext == ".tfvars"
+}
+
+// loadTerraformModules parses the Terraform files of a repository by directory
+func loadTerraformModules(contents map[string]string) []*terraformModule {
+	byDir := make(map[string][]string)
+	for path := range contents {
+		if isTerraformFile(path) {
+			byDir[filepath.Dir(path)] = append(byDir[filepath.Dir(path)], path)
+		}
+	}
+
+	dirs := make([]string, 0, len(byDir))
+	for dir := range byDir {
+		dirs = append(dirs, dir)
+	}
+	sort.Strings(dirs)
+
+	var modules []*terraformModule
+	for _, dir := range dirs {
+		paths := byDir[dir]
+		sort.Strings(paths)
+		m := &terraformModule{Dir: dir, Variables: map[string][]interface{}{}, Locals: map[string]interface{}{}}
+
+		var autoFiles, varFiles []*hclBody
+		var varNames []string
+		for _, path := range paths {
+			body, err := parseHCL(contents[path])
+			if err != nil {
+				m.Errors = append(m.Errors, Evidence{Subject: path, File: path, Detail: err.Error()})
+				continue
+			}
+			switch {
+			case filepath.Base(path) == "terraform.tfvars":
+				// loaded before any *.auto.tfvars
+				autoFiles = append([]*hclBody{body}, autoFiles...)
+			case strings.HasSuffix(path, ".auto.tfvars"):
+				autoFiles = append(autoFiles, body)
+			case filepath.Ext(path) == ".tfvars":
+				varFiles = append(varFiles, body)
+				varNames = append(varNames, strings.TrimSuffix(filepath.Base(path), ".tfvars"))
+			default:
+				m.addConfig(body, path)
+			}
+		}
+		m.applyVarFiles(autoFiles, varFiles, varNames)
+		if len(m.Providers)+len(m.Resources)+len(m.Modules) > 0 || len(autoFiles)+len(varFiles) > 0 {
+			modules = append(modules, m)
+		}
+	}
+	return modules
+}
+
+// addConfig records the blocks of a parsed .tf file
+func (m *terraformModule) addConfig(body *hclBody, path string) {
+	for _, block := range body.Blocks {
+		switch block.Type {
+		case "provider":
+			m.Providers = append(m.Providers, terraformBlock{Type: block.label(0), File: path, Line: block.Line, Body: block.Body})
+		case "resource":
+			m.Resources = append(m.Resources, terraformBlock{Type: block.label(0), Name: block.label(1), File: path, Line: block.Line, Body: block.Body})
+		case "module":
+			m.Modules = append(m.Modules, terraformBlock{Type: "module", Name: block.label(0), File: path, Line: block.Line, Body: block.Body})
+		case "variable":
+			if def, ok := block.Body.Attributes["default"]; ok {
+				m.Variables[block.label(0)] = []interface{}{def}
+			}
+		case "locals":
+			for name, value := range block.Body.Attributes {
+				m.Locals[name] = value
+			}
+		}
+	}
+}
+
+// applyVarFiles resolves the variables of the module once per variable file
+// set. Terraform always loads terraform.tfvars and *.auto.tfvars; every other
+// .tfvars file is an alternative passed with -var-file, typically one per
+// environment. Within a set later files override earlier ones and any file
+// overrides the default, so a default only remains a candidate for the sets
+// that leave the variable unset. Called with the defaults in m.Variables.
+// The values of each -var-file set are also kept in m.VarSets.
+func (m *terraformModule) applyVarFiles(autoFiles, varFiles []*hclBody, varNames []string) {
+	sets := [][]*hclBody{autoFiles}
+	if len(varFiles) > 0 {
+		sets = sets[:0]
+		for _, body := range varFiles {
+			sets = append(sets, append(slices.Clone(autoFiles), body))
+		}
+	}
+
+	names := make(map[string]bool, len(m.Variables))
+	for name := range m.Variables {
+		names[name] = true
+	}
+	for _, body := range append(slices.Clone(autoFiles), varFiles...) {
+		for name := range body.Attributes {
+			names[name] = true
+		}
+	}
+
+	if len(varFiles) > 0 {
+		for _, name := range varNames {
+			m.VarSets = append(m.VarSets, terraformVarSet{Name: name, Values: map[string]interface{}{}})
+		}
+	}
+	resolved := make(map[string][]interface{}, len(names))
+	for name := range names {
+		for i, set := range sets {
+			value, ok := varFileValue(name, m.Variables[name], set)
+			if !ok {
+				continue
+			}
+			if len(m.VarSets) > 0 {
+				m.VarSets[i].Values[name] = value
+			}
+			if !slices.ContainsFunc(resolved[name], func(v interface{}) bool { return reflect.DeepEqual(v, value) }) {
+				resolved[name] = append(resolved[name], value)
+			}
+		}
+	}
+	m.Variables = resolved
+}
+
+// withVarSet returns the module as applied with one variable file set, so
+// that every variable resolves to the value of that environment
+func (m *terraformModule) withVarSet(set terraformVarSet) *terraformModule {
+	env := *m
+	env.Variables = make(map[string][]interface{}, len(set.Values))
+	for name, value := range set.Values {
+		env.Variables[name] = []interface{}{value}
+	}
+	return &env
+}
+
+// varFileValue returns the value a variable file set gives a variable: the
+// last file setting it, or the default
+func varFileValue(name string, defaults []interface{}, set []*hclBody) (interface{}, bool) {
+	for i := len(set) - 1; i >= 0; i-- {
+		if value, ok := set[i].Attributes[name]; ok {
+			return value, true
+		}
+	}
+	if len(defaults) > 0 {
+		return defaults[0], true
+	}
+	return nil, false
+}
+
+// resources returns the resources of the given types
+func (m *terraformModule) resources(types ...string) []terraformBlock {
+	var res []terraformBlock
+	for _, r := range m.Resources {
+		for _, t := range types {
+			if r.Type == t {
+				res = append(res, r)
+			}
+		}
+	}
+	return res
+}
+
+// resolve returns the possible literal values of an attribute value,
+// following var.* and local.* references. Unknown values resolve to nothing.
+func (m *terraformModule) resolve(value interface{}) []interface{} {
+	return m.resolveDepth(value, 0)
+}
+
+func (m *terraformModule) resolveDepth(value interface{}, depth int) []interface{} {
+	if depth > 8 {
+		return nil
+	}
+	ref := ""
+	switch v := value.(type) {
+	case hclExpr:
+		ref = string(v)
+	case string:
+		// "${var.region}" is the legacy spelling of var.region
+		if strings.HasPrefix(v, "${") && strings.HasSuffix(v, "}") && strings.Count(v, "${") == 1 {
+			ref = strings.TrimSpace(v[2 : len(v)-1])
+		} else if strings.Contains(v, "${") {
+			return nil
+		} else {
+			return []interface{}{v}
+		}
+	default:
+		return []interface{}{v}
+	}
+
+	var candidates []interface{}
+	switch {
+	case strings.HasPrefix(ref, "var."):
+		candidates = m.Variables[strings.TrimPrefix(ref, "var.")]
+	case strings.HasPrefix(ref, "local."):
+		if local, ok := m.Locals[strings.TrimPrefix(ref, "local.")]; ok {
+			candidates = []interface{}{local}
+		}
+	}
+	var res []interface{}
+	for _, c := range candidates {
+		res = append(res, m.resolveDepth(c, depth+1)...)
+	}
+	return res
+}
+
+// resolveStrings returns the possible string values of an attribute
+func (m *terraformModule) resolveStrings(value interface{}) []string {
+	var res []string
+	for _, v := range m.resolve(value) {
+		if s, ok := v.(string); ok {
+			res = append(res, s)
+		}
+	}
+	return res
+}
+
+// attrBool returns the value of a boolean attribute when every candidate
+// agrees, falling back to def when the attribute is unset
+func (m *terraformModule) attrBool(b *terraformBlock, name string, def bool) (val, known bool) {
+	raw, ok := b.Body.Attributes[name]
+	if !ok {
+		return def, true
+	}
+	values := m.resolve(raw)
+	if len(values) == 0 {
+		return false, false
+	}
+	for i, v := range values {
+		bv, ok := v.(bool)
+		if !ok || (i > 0 && bv != val) {
+			return false, false
+		}
+		val = bv
+	}
+	return val, true
+}
+
+// attrMinNumber returns the smallest candidate of a numeric attribute
+func (m *terraformModule) attrMinNumber(b *terraformBlock, name string) (val float64, set, known bool) {
+	raw, ok := b.Body.Attributes[name]
+	if !ok {
+		return 0, false, true
+	}
+	values := m.resolve(raw)
+	for i, v := range values {
+		f, ok := v.(float64)
+		if !ok {
+			return 0, true, false
+		}
+		if i == 0 || f < val {
+			val = f
+		}
+	}
+	return val, true, len(values) > 0
+}
+
+// address returns the Terraform address of a resource or module block
+func (b *terraformBlock) address() string {
+	if b.Type == "module" {
+		return "module." + b.Name
+	}
+	return b.Type + "." + b.Name
+}